@@ -13,7 +13,10 @@ func SetupMux(cfg *APIConfig) http.Handler {
 	// middleware
 	mdAuth := cfg.middlewareAuthenticate
 	mdClear := cfg.middlewareCheckClearance
+	mdNoKeys := cfg.middlewareDenyAPIKeys
+	mdAdmin := cfg.middlewareRequireAdmin
 	mdValidateTxn := cfg.middlewareValidateTxn
+	mdAudit := cfg.middlewareAudit
 
 	// REGISTER API HANDLERS
 	// ======================
@@ -31,6 +34,10 @@ func SetupMux(cfg *APIConfig) http.Handler {
 	if err != nil {
 		panic(err)
 	}
+	wellKnown, err := reg.NewBuilder(".well-known")
+	if err != nil {
+		panic(err)
+	}
 
 	// Admin & State
 	r.Handle(
@@ -45,28 +52,44 @@ func SetupMux(cfg *APIConfig) http.Handler {
 		admin.Build().Get().Add("users").Add("count"),
 		cfg.handleGetTotalUserCount,
 	)
+	r.Handle(
+		admin.Build().Get().Add("lockouts"),
+		mdAuth(mdNoKeys(mdAdmin(cfg.handleGetLockouts))),
+	)
+	r.Handle(
+		admin.Build().Delete().Add("lockouts"),
+		mdAuth(mdNoKeys(mdAdmin(cfg.handleClearLockouts))),
+	)
 	r.Handle(
 		api.Build().Get().Add("healthz"),
 		cfg.handleReadiness,
 	)
 
 	// User authentication
+	r.Handle(
+		wellKnown.Build().Get().Add("jwks.json"),
+		cfg.handleGetJWKS,
+	)
 	r.Handle(
 		api.Build().Post().Add("users"),
 		cfg.handleCreateUser,
 	)
 	r.Handle(
 		api.Build().Delete().Add("users"),
-		mdAuth(cfg.handleDeleteUser),
+		mdAuth(mdNoKeys(cfg.handleDeleteUser)),
 	)
 	r.Handle(
 		api.Build().Put().Add("users"),
-		mdAuth(cfg.handleUpdateUserCredentials),
+		mdAuth(mdNoKeys(cfg.handleUpdateUserCredentials)),
 	)
 	r.Handle(
 		api.Build().Post().Add("login"),
 		cfg.handleLoginUser,
 	)
+	r.Handle(
+		api.Build().Post().Add("login").Add("2fa"),
+		cfg.handleLoginTwoFactor,
+	)
 	r.Handle(
 		api.Build().Post().Add("refresh"),
 		cfg.handleCheckRefreshToken,
@@ -75,26 +98,94 @@ func SetupMux(cfg *APIConfig) http.Handler {
 		api.Build().Post().Add("revoke"),
 		cfg.handleRevokeRefreshToken,
 	)
+	// OpenID Connect
+	r.Handle(
+		api.Build().Get().Add("login").Add("oidc"),
+		cfg.handleStartOIDCLogin,
+	)
+	r.Handle(
+		api.Build().Get().Add("login").Add("oidc").Add("callback"),
+		cfg.handleOIDCCallback,
+	)
+	r.Handle(
+		api.Build().Post().Add("users").Add("oidc"),
+		mdAuth(mdNoKeys(cfg.handleLinkOIDCIdentity)),
+	)
+	// Two-factor authentication
+	r.Handle(
+		api.Build().Post().Add("users").Add("2fa"),
+		mdAuth(mdNoKeys(cfg.handleEnrollTwoFactor)),
+	)
+	r.Handle(
+		api.Build().Post().Add("users").Add("2fa").Add("confirm"),
+		mdAuth(mdNoKeys(cfg.handleConfirmTwoFactor)),
+	)
+	r.Handle(
+		api.Build().Delete().Add("users").Add("2fa"),
+		mdAuth(mdNoKeys(cfg.handleDisableTwoFactor)),
+	)
+	// API keys
+	r.Handle(
+		api.Build().Post().APIKey().Col(),
+		mdAuth(mdNoKeys(cfg.handleCreateAPIKey)),
+	)
+	r.Handle(
+		api.Build().Get().APIKey().Col(),
+		mdAuth(mdNoKeys(cfg.handleGetAPIKeys)),
+	)
+	r.Handle(
+		api.Build().Delete().APIKey(),
+		mdAuth(mdNoKeys(cfg.handleRevokeAPIKey)),
+	)
+	// Sessions
+	r.Handle(
+		api.Build().Get().Session().Col(),
+		mdAuth(mdNoKeys(cfg.handleGetSessions)),
+	)
+	r.Handle(
+		api.Build().Delete().Session().Col(),
+		mdAuth(mdNoKeys(cfg.handleRevokeOtherSessions)),
+	)
+	r.Handle(
+		api.Build().Delete().Session(),
+		mdAuth(mdNoKeys(cfg.handleRevokeSession)),
+	)
 	// Budgets
 	r.Handle(
 		api.Build().Post().Budget().Col(),
-		mdAuth(cfg.handleCreateBudget),
+		mdAuth(mdNoKeys(mdAudit(auditBudget, auditCreate, cfg.handleCreateBudget))),
 	)
 	r.Handle(
 		api.Build().Post().Budget().Member().Col(),
-		mdAuth(mdClear(MANAGER, cfg.handleAddBudgetMemberWithRole)),
+		mdAuth(mdClear(MANAGER, cfg.handleAddBudgetMember)),
+	)
+	r.Handle(
+		api.Build().Patch().Budget().Member(),
+		mdAuth(mdClear(MANAGER, mdAudit(auditMember, auditUpdate, cfg.handleSetBudgetMemberRole))),
 	)
 	r.Handle(
 		api.Build().Put().Budget(),
-		mdAuth(mdClear(MANAGER, cfg.handleUpdateBudget)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditBudget, auditUpdate, cfg.handleUpdateBudget))),
 	)
 	r.Handle(
 		api.Build().Delete().Budget(),
-		mdAuth(mdClear(ADMIN, cfg.handleDeleteBudget)),
+		mdAuth(mdClear(ADMIN, mdAudit(auditBudget, auditDelete, cfg.handleDeleteBudget))),
 	)
 	r.Handle(
 		api.Build().Delete().Budget().Member(),
-		mdAuth(mdClear(MANAGER, cfg.handleRemoveBudgetMember)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditMember, auditDelete, cfg.handleRemoveBudgetMember))),
+	)
+	r.Handle(
+		api.Build().Put().Budget().Member().Add("restrictions"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditMemberRestriction, auditUpdate, cfg.handleSetMemberRestrictions))),
+	)
+	r.Handle(
+		api.Build().Get().Budget().Member().Add("restrictions"),
+		mdAuth(mdClear(VIEWER, cfg.handleGetMemberRestrictions)),
+	)
+	r.Handle(
+		api.Build().Delete().Budget().Member().Add("restrictions"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditMemberRestriction, auditDelete, cfg.handleDeleteMemberRestrictions))),
 	)
 	r.Handle(
 		api.Build().Get().Budget().Col(),
@@ -108,10 +199,87 @@ func SetupMux(cfg *APIConfig) http.Handler {
 		api.Build().Get().Budget().Add("capital"),
 		mdAuth(mdClear(VIEWER, cfg.handleGetBudgetCapital)),
 	)
+	r.Handle(
+		api.Build().Put().Budget().Add("overspending-rule"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditBudget, auditUpdate, cfg.handleSetBudgetOverspendingRule))),
+	)
+	// Invitations
+	r.Handle(
+		api.Build().Post().Budget().Invitation().Col(),
+		mdAuth(mdClear(MANAGER, mdAudit(auditInvitation, auditCreate, cfg.handleCreateBudgetInvitation))),
+	)
+	r.Handle(
+		api.Build().Get().Budget().Invitation().Col(),
+		mdAuth(mdClear(MANAGER, cfg.handleGetBudgetInvitations)),
+	)
+	r.Handle(
+		api.Build().Delete().Budget().Invitation(),
+		mdAuth(mdClear(MANAGER, mdAudit(auditInvitation, auditDelete, cfg.handleRevokeBudgetInvitation))),
+	)
+	r.Handle(
+		api.Build().Get().Add("users").Invitation().Col(),
+		mdAuth(mdNoKeys(cfg.handleGetUserInvitations)),
+	)
+	r.Handle(
+		api.Build().Post().Add("users").Invitation().Add("accept"),
+		mdAuth(mdNoKeys(mdAudit(auditMember, auditCreate, cfg.handleAcceptInvitation))),
+	)
+	r.Handle(
+		api.Build().Post().Add("users").Invitation().Add("decline"),
+		mdAuth(mdNoKeys(cfg.handleDeclineInvitation)),
+	)
+	r.Handle(
+		api.Build().Post().Add("users").Invitation().Col().Add("redeem"),
+		mdAuth(mdNoKeys(mdAudit(auditMember, auditCreate, cfg.handleRedeemInvitationLink))),
+	)
+	// Ownership transfers
+	r.Handle(
+		api.Build().Post().Budget().Add("ownership-transfer"),
+		mdAuth(mdNoKeys(mdClear(ADMIN, mdAudit(auditOwnershipTransfer, auditCreate, cfg.handleNominateBudgetOwner)))),
+	)
+	r.Handle(
+		api.Build().Delete().Budget().Add("ownership-transfer"),
+		mdAuth(mdNoKeys(mdClear(ADMIN, mdAudit(auditOwnershipTransfer, auditDelete, cfg.handleCancelOwnershipTransfer)))),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Add("ownership-transfer").Add("accept"),
+		mdAuth(mdNoKeys(mdClear(VIEWER, mdAudit(auditBudget, auditUpdate, cfg.handleAcceptOwnershipTransfer)))),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Add("ownership-transfer").Add("decline"),
+		mdAuth(mdNoKeys(mdClear(VIEWER, mdAudit(auditOwnershipTransfer, auditDelete, cfg.handleDeclineOwnershipTransfer)))),
+	)
+	r.Handle(
+		api.Build().Get().Add("users").Add("ownership-transfers"),
+		mdAuth(mdNoKeys(cfg.handleGetUserOwnershipTransfers)),
+	)
+	// Audit
+	r.Handle(
+		api.Build().Get().Budget().Add("audit"),
+		mdAuth(mdClear(MANAGER, cfg.handleGetAuditLog)),
+	)
+	// Export & Import
+	r.Handle(
+		api.Build().Get().Budget().Add("export"),
+		mdAuth(mdClear(MANAGER, cfg.handleExportBudget)),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Col().Add("import"),
+		mdAuth(mdNoKeys(cfg.handleImportBudget)),
+	)
+	// Templates & Cloning
+	r.Handle(
+		api.Build().Get().Budget().Col().Add("templates"),
+		mdAuth(cfg.handleGetBudgetTemplates),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Add("clone"),
+		mdAuth(mdNoKeys(mdClear(MANAGER, cfg.handleCloneBudget))),
+	)
 	// Groups
 	r.Handle(
 		api.Build().Post().Budget().Group().Col(),
-		mdAuth(mdClear(MANAGER, cfg.handleCreateGroup)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditGroup, auditCreate, cfg.handleCreateGroup))),
 	)
 	r.Handle(
 		api.Build().Get().Budget().Group().Col(),
@@ -123,16 +291,16 @@ func SetupMux(cfg *APIConfig) http.Handler {
 	)
 	r.Handle(
 		api.Build().Put().Budget().Group(),
-		mdAuth(mdClear(MANAGER, cfg.handleUpdateGroup)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditGroup, auditUpdate, cfg.handleUpdateGroup))),
 	)
 	r.Handle(
 		api.Build().Delete().Budget().Group(),
-		mdAuth(mdClear(MANAGER, cfg.handleDeleteGroup)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditGroup, auditDelete, cfg.handleDeleteGroup))),
 	)
 	// Categories
 	r.Handle(
 		api.Build().Post().Budget().Category().Col(),
-		mdAuth(mdClear(MANAGER, cfg.handleCreateCategory)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditCategory, auditCreate, cfg.handleCreateCategory))),
 	)
 	r.Handle(
 		api.Build().Get().Budget().Category().Col(),
@@ -144,16 +312,32 @@ func SetupMux(cfg *APIConfig) http.Handler {
 	)
 	r.Handle(
 		api.Build().Put().Budget().Category(),
-		mdAuth(mdClear(MANAGER, cfg.handleUpdateCategory)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditCategory, auditUpdate, cfg.handleUpdateCategory))),
 	)
 	r.Handle(
 		api.Build().Delete().Budget().Category(),
-		mdAuth(mdClear(MANAGER, cfg.handleDeleteCategory)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditCategory, auditDelete, cfg.handleDeleteCategory))),
+	)
+	r.Handle(
+		api.Build().Put().Budget().Category().Add("goal"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditCategoryGoal, auditUpdate, cfg.handleUpsertCategoryGoal))),
+	)
+	r.Handle(
+		api.Build().Get().Budget().Category().Add("goal"),
+		mdAuth(mdClear(VIEWER, cfg.handleGetCategoryGoal)),
+	)
+	r.Handle(
+		api.Build().Delete().Budget().Category().Add("goal"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditCategoryGoal, auditDelete, cfg.handleDeleteCategoryGoal))),
+	)
+	r.Handle(
+		api.Build().Put().Budget().Category().Add("overspending-rule"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditCategory, auditUpdate, cfg.handleSetCategoryOverspendingRule))),
 	)
 	// Payees
 	r.Handle(
 		api.Build().Post().Budget().Payee().Col(),
-		mdAuth(mdClear(CONTRIBUTOR, cfg.handleCreatePayee)),
+		mdAuth(mdClear(CONTRIBUTOR, mdAudit(auditPayee, auditCreate, cfg.handleCreatePayee))),
 	)
 	r.Handle(
 		api.Build().Get().Budget().Payee().Col(),
@@ -165,16 +349,16 @@ func SetupMux(cfg *APIConfig) http.Handler {
 	)
 	r.Handle(
 		api.Build().Put().Budget().Payee(),
-		mdAuth(mdClear(MANAGER, cfg.handleUpdatePayee)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditPayee, auditUpdate, cfg.handleUpdatePayee))),
 	)
 	r.Handle(
 		api.Build().Delete().Budget().Payee(),
-		mdAuth(mdClear(CONTRIBUTOR, cfg.handleDeletePayee)),
+		mdAuth(mdClear(CONTRIBUTOR, mdAudit(auditPayee, auditDelete, cfg.handleDeletePayee))),
 	)
 	// Accounts
 	r.Handle(
 		api.Build().Post().Budget().Account().Col(),
-		mdAuth(mdClear(CONTRIBUTOR, cfg.handleAddAccount)),
+		mdAuth(mdClear(CONTRIBUTOR, mdAudit(auditAccount, auditCreate, cfg.handleAddAccount))),
 	)
 	r.Handle(
 		api.Build().Get().Budget().Account().Col(),
@@ -190,20 +374,62 @@ func SetupMux(cfg *APIConfig) http.Handler {
 	)
 	r.Handle(
 		api.Build().Put().Budget().Account(),
-		mdAuth(mdClear(MANAGER, cfg.handleUpdateAccount)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditAccount, auditUpdate, cfg.handleUpdateAccount))),
 	)
 	r.Handle(
 		api.Build().Patch().Budget().Account(),
-		mdAuth(mdClear(MANAGER, cfg.handleRestoreAccount)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditAccount, auditUpdate, cfg.handleRestoreAccount))),
 	)
 	r.Handle(
 		api.Build().Delete().Budget().Account(),
-		mdAuth(mdClear(MANAGER, cfg.handleDeleteAccount)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditAccount, auditDelete, cfg.handleDeleteAccount))),
+	)
+	// Reconciliation
+	r.Handle(
+		api.Build().Post().Budget().Account().Add("reconcile"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditReconciliation, auditCreate, cfg.handleReconcileAccount))),
+	)
+	r.Handle(
+		api.Build().Get().Budget().Account().Add("reconciliations"),
+		mdAuth(mdClear(VIEWER, cfg.handleGetReconciliations)),
+	)
+	// Importing
+	r.Handle(
+		api.Build().Put().Budget().Account().Add("import-profile"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditImportProfile, auditUpdate, cfg.handleUpsertImportProfile))),
+	)
+	r.Handle(
+		api.Build().Get().Budget().Account().Add("import-profile"),
+		mdAuth(mdClear(VIEWER, cfg.handleGetImportProfile)),
+	)
+	r.Handle(
+		api.Build().Delete().Budget().Account().Add("import-profile"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditImportProfile, auditDelete, cfg.handleDeleteImportProfile))),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Account().Add("import").Add("csv"),
+		mdAuth(mdClear(CONTRIBUTOR, mdAudit(auditImport, auditCreate, cfg.handleImportCSV))),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Account().Add("import").Add("ofx"),
+		mdAuth(mdClear(CONTRIBUTOR, mdAudit(auditImport, auditCreate, cfg.handleImportOFX))),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Add("import").Add("qif"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditImport, auditCreate, cfg.handleImportQIF))),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Add("import").Add("ynab"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditImport, auditCreate, cfg.handleImportYNAB))),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Add("import").Add("actual"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditImport, auditCreate, cfg.handleImportActual))),
 	)
 	// Transactions
 	r.Handle(
 		api.Build().Post().Budget().Transaction().Col(),
-		mdAuth(mdClear(CONTRIBUTOR, mdValidateTxn(cfg.handleLogTransaction))),
+		mdAuth(mdClear(CONTRIBUTOR, mdAudit(auditTransaction, auditCreate, mdValidateTxn(cfg.handleLogTransaction)))),
 	)
 	r.Handle(
 		api.Build().Get().Budget().Transaction().Col(),
@@ -227,21 +453,62 @@ func SetupMux(cfg *APIConfig) http.Handler {
 	)
 	r.Handle(
 		api.Build().Put().Budget().Transaction(),
-		mdAuth(mdClear(MANAGER, mdValidateTxn(cfg.handleUpdateTransaction))),
+		mdAuth(mdClear(MANAGER, mdAudit(auditTransaction, auditUpdate, mdValidateTxn(cfg.handleUpdateTransaction)))),
 	)
 	r.Handle(
 		api.Build().Delete().Budget().Transaction(),
-		mdAuth(mdClear(MANAGER, cfg.handleDeleteTransaction)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditTransaction, auditDelete, cfg.handleDeleteTransaction))),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Transaction().Add("undo"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditTransaction, auditUpdate, cfg.handleUndoTransactionUpdate))),
+	)
+	r.Handle(
+		api.Build().Get().Budget().Transaction().Col().Add("trash"),
+		mdAuth(mdClear(VIEWER, cfg.handleGetTrashedTransactions)),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Transaction().Add("restore"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditTransaction, auditRestore, cfg.handleRestoreTransaction))),
+	)
+	// Recurring Transactions
+	r.Handle(
+		api.Build().Post().Budget().Recurring().Col(),
+		mdAuth(mdClear(MANAGER, mdAudit(auditRecurring, auditCreate, cfg.handleCreateRecurringTransaction))),
+	)
+	r.Handle(
+		api.Build().Get().Budget().Recurring().Col(),
+		mdAuth(mdClear(VIEWER, cfg.handleGetRecurringTransactions)),
+	)
+	r.Handle(
+		api.Build().Get().Budget().Recurring().Col().Add("upcoming"),
+		mdAuth(mdClear(VIEWER, cfg.handleGetUpcomingTransactions)),
+	)
+	r.Handle(
+		api.Build().Get().Budget().Recurring(),
+		mdAuth(mdClear(VIEWER, cfg.handleGetRecurringTransaction)),
+	)
+	r.Handle(
+		api.Build().Put().Budget().Recurring(),
+		mdAuth(mdClear(MANAGER, mdAudit(auditRecurring, auditUpdate, cfg.handleUpdateRecurringTransaction))),
+	)
+	r.Handle(
+		api.Build().Delete().Budget().Recurring(),
+		mdAuth(mdClear(MANAGER, mdAudit(auditRecurring, auditDelete, cfg.handleDeleteRecurringTransaction))),
 	)
 
 	// Dollar Assignment
 	r.Handle(
 		api.Build().Post().Budget().Month().Category().Col(),
-		mdAuth(mdClear(MANAGER, cfg.handleAssignAmountToCategory)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditAssignment, auditUpdate, cfg.handleAssignAmountToCategory))),
 	)
 	r.Handle(
 		api.Build().Put().Budget().Month().Category().Col(),
-		mdAuth(mdClear(MANAGER, cfg.handleAssignAmountToCategory)),
+		mdAuth(mdClear(MANAGER, mdAudit(auditAssignment, auditUpdate, cfg.handleAssignAmountToCategory))),
+	)
+	r.Handle(
+		api.Build().Post().Budget().Month().Add("auto-assign"),
+		mdAuth(mdClear(MANAGER, mdAudit(auditAssignment, auditUpdate, cfg.handleAutoAssign))),
 	)
 	// Reporting
 	r.Handle(
@@ -1,11 +1,46 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 
 	db "github.com/YouWantToPinch/pincher-api/internal/database"
 )
 
+// paymentGroupName is the name of the group to which the payment
+// categories of credit card accounts belong.
+const paymentGroupName = "Credit Card Payments"
+
+// createPaymentCategory creates the payment category of a credit card
+// account, named after the account, and links the two. Money set aside
+// in the category is what is owed on the card.
+func createPaymentCategory(ctx context.Context, q *db.Queries, dbAccount *db.Account, created *CreatedResources) error {
+	groupID, err := ensureGroup(ctx, q, dbAccount.BudgetID, paymentGroupName, created)
+	if err != nil {
+		return err
+	}
+	dbCategory, err := q.CreateCategory(ctx, db.CreateCategoryParams{
+		BudgetID: dbAccount.BudgetID,
+		GroupID:  &groupID,
+		Name:     dbAccount.Name,
+		Notes:    "Payments toward credit card account " + dbAccount.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create payment category '%s': %w", dbAccount.Name, err)
+	}
+	created.Categories = append(created.Categories, dbCategory.Name)
+	err = q.SetAccountPaymentCategory(ctx, db.SetAccountPaymentCategoryParams{
+		ID:                dbAccount.ID,
+		PaymentCategoryID: &dbCategory.ID,
+	})
+	if err != nil {
+		return err
+	}
+	dbAccount.PaymentCategoryID = &dbCategory.ID
+	return nil
+}
+
 func (cfg *APIConfig) handleAddAccount(w http.ResponseWriter, r *http.Request) {
 	type rqSchema struct {
 		AccountType string `json:"account_type"`
@@ -23,30 +58,58 @@ func (cfg *APIConfig) handleAddAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if rqPayload.AccountType != "ON_BUDGET" && rqPayload.AccountType != "OFF_BUDGET" {
+	switch rqPayload.AccountType {
+	case "ON_BUDGET", "OFF_BUDGET", "CREDIT_CARD":
+	default:
 		respondWithError(w, http.StatusBadRequest, "invalid account type", nil)
 		return
 	}
 
 	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
 
-	dbAccount, err := cfg.db.AddAccount(r.Context(), db.AddAccountParams{
-		BudgetID:    pathBudgetID,
-		AccountType: rqPayload.AccountType,
-		Name:        rqPayload.Name,
-		Notes:       rqPayload.Notes,
-	})
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "could not create account", err)
-		return
+	var dbAccount db.Account
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		dbAccount, err = q.AddAccount(r.Context(), db.AddAccountParams{
+			BudgetID:    pathBudgetID,
+			AccountType: rqPayload.AccountType,
+			Name:        rqPayload.Name,
+			Notes:       rqPayload.Notes,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not create account", err)
+			return
+		}
+
+		if dbAccount.AccountType == "CREDIT_CARD" {
+			if err := createPaymentCategory(r.Context(), q, &dbAccount, &CreatedResources{}); err != nil {
+				respondWithError(w, http.StatusConflict, "could not create payment category", err)
+				return
+			}
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
 	}
 
 	rspPayload := Account{
-		ID:          dbAccount.ID,
-		CreatedAt:   dbAccount.CreatedAt,
-		UpdatedAt:   dbAccount.UpdatedAt,
-		AccountType: dbAccount.AccountType,
-		IsDeleted:   dbAccount.IsDeleted,
+		ID:                dbAccount.ID,
+		CreatedAt:         dbAccount.CreatedAt,
+		UpdatedAt:         dbAccount.UpdatedAt,
+		AccountType:       dbAccount.AccountType,
+		IsDeleted:         dbAccount.IsDeleted,
+		PaymentCategoryID: dbAccount.PaymentCategoryID,
 		Meta: Meta{
 			Name:  dbAccount.Name,
 			Notes: dbAccount.Notes,
@@ -64,20 +127,28 @@ func (cfg *APIConfig) handleGetAccounts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+
 	viewDeleted := r.URL.Query().Has("deleted")
 
 	var accounts []Account
 	for _, account := range dbAccounts {
-		if account.IsDeleted != viewDeleted {
+		if account.IsDeleted != viewDeleted || !permitsAccount(restriction, account.ID) {
 			continue
 		}
 		accounts = append(accounts, Account{
-			ID:          account.ID,
-			CreatedAt:   account.CreatedAt,
-			UpdatedAt:   account.UpdatedAt,
-			BudgetID:    account.BudgetID,
-			AccountType: account.AccountType,
-			IsDeleted:   account.IsDeleted,
+			ID:                account.ID,
+			CreatedAt:         account.CreatedAt,
+			UpdatedAt:         account.UpdatedAt,
+			BudgetID:          account.BudgetID,
+			AccountType:       account.AccountType,
+			IsDeleted:         account.IsDeleted,
+			PaymentCategoryID: account.PaymentCategoryID,
 			Meta: Meta{
 				Name:  account.Name,
 				Notes: account.Notes,
@@ -110,12 +181,13 @@ func (cfg *APIConfig) handleGetAccount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rspPayload := Account{
-		ID:          dbAccount.ID,
-		CreatedAt:   dbAccount.CreatedAt,
-		UpdatedAt:   dbAccount.UpdatedAt,
-		BudgetID:    dbAccount.BudgetID,
-		AccountType: dbAccount.AccountType,
-		IsDeleted:   dbAccount.IsDeleted,
+		ID:                dbAccount.ID,
+		CreatedAt:         dbAccount.CreatedAt,
+		UpdatedAt:         dbAccount.UpdatedAt,
+		BudgetID:          dbAccount.BudgetID,
+		AccountType:       dbAccount.AccountType,
+		IsDeleted:         dbAccount.IsDeleted,
+		PaymentCategoryID: dbAccount.PaymentCategoryID,
 		Meta: Meta{
 			Name:  dbAccount.Name,
 			Notes: dbAccount.Notes,
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/YouWantToPinch/pincher-api/internal/auth"
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// apiKeyPrefixLength is the number of leading characters of an API key
+// which are kept in the clear, so that users may tell their keys apart.
+const apiKeyPrefixLength = len(auth.APIKeyPrefix) + 6
+
+// handleCreateAPIKey creates a personal API key for the user, scoped to the
+// given budgets and limited to the given role within each of them. The key
+// itself is only ever returned here; only its hash is kept.
+func (cfg *APIConfig) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		Name      string      `json:"name"`
+		BudgetIDs []uuid.UUID `json:"budget_ids"`
+		MaxRole   string      `json:"max_role"`
+		// ExpiresAt is optional; keys otherwise never expire.
+		// It is a time string in the custom format "2006-01-02" (YYYY-MM-DD)
+		ExpiresAt string `json:"expires_at"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if rqPayload.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "name not provided", nil)
+		return
+	}
+	if len(rqPayload.BudgetIDs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "no budgets provided", nil)
+		return
+	}
+	maxRole, err := BMRFromString(rqPayload.MaxRole)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	var expiresAt pgtype.Timestamp
+	if rqPayload.ExpiresAt != "" {
+		expiryDate, err := parseDate(rqPayload.ExpiresAt)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "", err)
+			return
+		}
+		if !expiryDate.After(time.Now().UTC()) {
+			respondWithError(w, http.StatusBadRequest, "expiry date must be in the future", nil)
+			return
+		}
+		expiresAt = pgtype.Timestamp{Time: expiryDate, Valid: true}
+	}
+
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	budgetIDs := slices.Clone(rqPayload.BudgetIDs)
+	slices.SortFunc(budgetIDs, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	budgetIDs = slices.Compact(budgetIDs)
+	for _, budgetID := range budgetIDs {
+		_, err := cfg.db.GetBudgetMemberRole(r.Context(), db.GetBudgetMemberRoleParams{
+			BudgetID: budgetID,
+			UserID:   validatedUserID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusForbidden, fmt.Sprintf("user not found as member of budget %s", budgetID), err)
+			return
+		}
+	}
+
+	apiKey, err := auth.MakeAPIKey()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create API key", err)
+		return
+	}
+
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		dbAPIKey, err := q.CreateAPIKey(r.Context(), db.CreateAPIKeyParams{
+			UserID:    validatedUserID,
+			Name:      rqPayload.Name,
+			KeyPrefix: apiKey[:apiKeyPrefixLength],
+			HashedKey: auth.HashAPIKey(apiKey),
+			MaxRole:   maxRole.String(),
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not save API key", err)
+			return
+		}
+
+		for _, budgetID := range budgetIDs {
+			err := q.AddAPIKeyBudget(r.Context(), db.AddAPIKeyBudgetParams{
+				ApiKeyID: dbAPIKey.ID,
+				BudgetID: budgetID,
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not grant API key access to budget", err)
+				return
+			}
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+
+		rspPayload := apiKeyFromDB(&dbAPIKey, budgetIDs)
+		rspPayload.Key = apiKey
+		respondWithJSON(w, http.StatusCreated, rspPayload)
+	}
+}
+
+func (cfg *APIConfig) handleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	dbAPIKeys, err := cfg.db.GetAPIKeysByUserID(r.Context(), validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve API keys", err)
+		return
+	}
+
+	apiKeys := []APIKey{}
+	for _, dbAPIKey := range dbAPIKeys {
+		budgetIDs, err := cfg.db.GetAPIKeyBudgetIDs(r.Context(), dbAPIKey.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not retrieve API key budgets", err)
+			return
+		}
+		apiKeys = append(apiKeys, apiKeyFromDB(&dbAPIKey, budgetIDs))
+	}
+
+	type rspSchema struct {
+		APIKeys []APIKey `json:"data"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{APIKeys: apiKeys})
+}
+
+func (cfg *APIConfig) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	pathAPIKeyID, err := parseUUIDFromPath("api_key_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	revoked, err := cfg.db.RevokeAPIKey(r.Context(), db.RevokeAPIKeyParams{
+		ID:     pathAPIKeyID,
+		UserID: validatedUserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not revoke API key", err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "no active API key found", nil)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+func apiKeyFromDB(dbAPIKey *db.ApiKey, budgetIDs []uuid.UUID) APIKey {
+	if budgetIDs == nil {
+		budgetIDs = []uuid.UUID{}
+	}
+	return APIKey{
+		CreatedAt:  dbAPIKey.CreatedAt,
+		ID:         dbAPIKey.ID,
+		Name:       dbAPIKey.Name,
+		KeyPrefix:  dbAPIKey.KeyPrefix,
+		MaxRole:    dbAPIKey.MaxRole,
+		BudgetIDs:  budgetIDs,
+		ExpiresAt:  timeFromPGTimestamp(dbAPIKey.ExpiresAt),
+		LastUsedAt: timeFromPGTimestamp(dbAPIKey.LastUsedAt),
+		RevokedAt:  timeFromPGTimestamp(dbAPIKey.RevokedAt),
+	}
+}
+
+// timeFromPGTimestamp returns the time of a nullable timestamp,
+// or nil where it is null.
+func timeFromPGTimestamp(ts pgtype.Timestamp) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
@@ -44,7 +44,7 @@ func (cfg *APIConfig) handleAssignAmountToCategory(w http.ResponseWriter, r *htt
 	if usingDBTxn {
 		// USE DB TRANSACTION
 		{
-			tx, err = cfg.Pool.Begin(r.Context())
+			tx, err = cfg.beginTx(r.Context())
 			if err != nil {
 				respondWithError(w, http.StatusInternalServerError, "", err)
 				return
@@ -150,11 +150,21 @@ func (cfg *APIConfig) handleGetMonthReport(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	readyToAssign, err := cfg.db.GetReadyToAssign(r.Context(), db.GetReadyToAssignParams{
+		BudgetID: pathBudgetID,
+		MonthID:  parsedMonthID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not calculate money ready to assign for month specified", err)
+		return
+	}
+
 	rspPayload := MonthReport{
-		MonthID:  monthReport.Month,
-		Assigned: monthReport.Assigned,
-		Activity: monthReport.Activity,
-		Balance:  monthReport.Balance,
+		MonthID:       monthReport.Month,
+		Assigned:      monthReport.Assigned,
+		Activity:      monthReport.Activity,
+		Balance:       monthReport.Balance,
+		ReadyToAssign: readyToAssign,
 	}
 
 	respondWithJSON(w, http.StatusOK, rspPayload)
@@ -181,13 +191,21 @@ func (cfg *APIConfig) handleGetMonthCategories(w http.ResponseWriter, r *http.Re
 	var categoryReports []CategoryReport
 	for _, report := range dbCategoryReports {
 		categoryReports = append(categoryReports, CategoryReport{
-			MonthID:    report.Month,
-			CategoryID: report.CategoryID,
-			GroupID:    report.GroupID,
-			Name:       report.CategoryName,
-			Assigned:   report.Assigned,
-			Activity:   report.Activity,
-			Balance:    report.Balance,
+			MonthID:     report.Month,
+			CategoryID:  report.CategoryID,
+			GroupID:     report.GroupID,
+			Name:        report.CategoryName,
+			Assigned:    report.Assigned,
+			Activity:    report.Activity,
+			Balance:     report.Balance,
+			Needed:      report.Needed,
+			Underfunded: report.Underfunded,
+			Goal: goalProgressFromReport(report.GoalType, report.GoalTargetAmount, report.GoalTargetDate,
+				report.Needed, report.Underfunded, report.Balance),
+			OverspendingRule: report.OverspendingRule,
+			IsOverspent:      report.Overspent > 0,
+			Overspent:        report.Overspent,
+			CreditOverspent:  report.CreditOverspent,
 		})
 	}
 
@@ -227,13 +245,21 @@ func (cfg *APIConfig) handleGetMonthCategoryReport(w http.ResponseWriter, r *htt
 	}
 
 	rspPayload := CategoryReport{
-		MonthID:    dbCategoryReport.Month,
-		CategoryID: dbCategoryReport.CategoryID,
-		GroupID:    dbCategoryReport.GroupID,
-		Name:       dbCategoryReport.CategoryName,
-		Assigned:   dbCategoryReport.Assigned,
-		Activity:   dbCategoryReport.Activity,
-		Balance:    dbCategoryReport.Balance,
+		MonthID:     dbCategoryReport.Month,
+		CategoryID:  dbCategoryReport.CategoryID,
+		GroupID:     dbCategoryReport.GroupID,
+		Name:        dbCategoryReport.CategoryName,
+		Assigned:    dbCategoryReport.Assigned,
+		Activity:    dbCategoryReport.Activity,
+		Balance:     dbCategoryReport.Balance,
+		Needed:      dbCategoryReport.Needed,
+		Underfunded: dbCategoryReport.Underfunded,
+		Goal: goalProgressFromReport(dbCategoryReport.GoalType, dbCategoryReport.GoalTargetAmount, dbCategoryReport.GoalTargetDate,
+			dbCategoryReport.Needed, dbCategoryReport.Underfunded, dbCategoryReport.Balance),
+		OverspendingRule: dbCategoryReport.OverspendingRule,
+		IsOverspent:      dbCategoryReport.Overspent > 0,
+		Overspent:        dbCategoryReport.Overspent,
+		CreditOverspent:  dbCategoryReport.CreditOverspent,
 	}
 	respondWithJSON(w, http.StatusOK, rspPayload)
 }
@@ -313,3 +339,137 @@ func (cfg *APIConfig) handleGetMonthGroupReport(w http.ResponseWriter, r *http.R
 	}
 	respondWithJSON(w, http.StatusOK, rspPayload)
 }
+
+// handleAutoAssign assigns money to every category of the budget at once
+// for the given month, according to the strategy requested. Changes are
+// previewed without being made where a dry run is requested; otherwise,
+// they are all made together, or not at all.
+func (cfg *APIConfig) handleAutoAssign(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		Strategy string `json:"strategy"`
+		// Months is only used by the AVERAGE_SPENT strategy.
+		Months int `json:"months"`
+		// Categories optionally limits the categories assigned to, by name.
+		Categories []string `json:"categories"`
+		DryRun     bool     `json:"dry_run"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	rule := &autoAssignRule{
+		strategy: rqPayload.Strategy,
+		months:   rqPayload.Months,
+	}
+	if err := validateAutoAssignRule(rule); err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	parsedMonthID, err := parseDateFromPath("month_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+	monthStart := time.Date(parsedMonthID.Year(), parsedMonthID.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	report := AutoAssignReport{
+		MonthID:  monthStart,
+		Strategy: rule.strategy,
+		DryRun:   rqPayload.DryRun,
+	}
+
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		dbCategoryReports, err := q.GetMonthCategoryReports(r.Context(), db.GetMonthCategoryReportsParams{
+			MonthID:  monthStart,
+			BudgetID: pathBudgetID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not generate category reports for month specified", err)
+			return
+		}
+
+		wanted := map[string]bool{}
+		for _, name := range rqPayload.Categories {
+			wanted[name] = true
+		}
+		var current []categoryMonth
+		for _, categoryReport := range dbCategoryReports {
+			if len(wanted) > 0 && !wanted[categoryReport.CategoryName] {
+				continue
+			}
+			delete(wanted, categoryReport.CategoryName)
+			current = append(current, categoryMonth{
+				categoryID:  categoryReport.CategoryID,
+				name:        categoryReport.CategoryName,
+				assigned:    categoryReport.Assigned,
+				activity:    categoryReport.Activity,
+				underfunded: categoryReport.Underfunded,
+			})
+		}
+		for name := range wanted {
+			respondWithError(w, http.StatusBadRequest, "could not get category by given name: "+name, nil)
+			return
+		}
+
+		var history []categoryMonth
+		if n := rule.historyMonths(); n > 0 {
+			dbHistory, err := q.GetCategoryReportsBetween(r.Context(), db.GetCategoryReportsBetweenParams{
+				BudgetID:  pathBudgetID,
+				StartDate: monthStart.AddDate(0, -n, 0),
+				EndDate:   monthStart.AddDate(0, 0, -1),
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not generate category reports for previous months", err)
+				return
+			}
+			for _, categoryReport := range dbHistory {
+				history = append(history, categoryMonth{
+					categoryID: categoryReport.CategoryID,
+					name:       categoryReport.CategoryName,
+					assigned:   categoryReport.Assigned,
+					activity:   categoryReport.Activity,
+				})
+			}
+		}
+
+		report.Changes = planAutoAssign(rule, current, history)
+		for _, change := range report.Changes {
+			report.TotalChange += change.Change
+		}
+
+		if !rqPayload.DryRun {
+			for _, change := range report.Changes {
+				if _, err := q.AssignAmountToCategory(r.Context(), db.AssignAmountToCategoryParams{
+					MonthID:    monthStart,
+					CategoryID: change.CategoryID,
+					Amount:     change.Change,
+				}); err != nil {
+					respondWithError(w, http.StatusInternalServerError, "could not assign amount to category for month specified", err)
+					return
+				}
+			}
+			if err := tx.Commit(r.Context()); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "", err)
+				return
+			}
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
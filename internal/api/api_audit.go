@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultAuditEntries is how many audit entries are returned, where
+	// no limit is given; maxAuditEntries is the most returned at once.
+	defaultAuditEntries = 100
+	maxAuditEntries     = 1000
+)
+
+// recordAudit adds an entry to the audit log, within the same transaction
+// as the change it records, so that neither is kept without the other.
+func recordAudit(ctx context.Context, q *db.Queries, params db.CreateAuditEntryParams, before, after any) error {
+	var err error
+	if params.Before, err = marshalAuditState(before); err != nil {
+		return fmt.Errorf("could not encode audit state: %w", err)
+	}
+	if params.After, err = marshalAuditState(after); err != nil {
+		return fmt.Errorf("could not encode audit state: %w", err)
+	}
+	return q.CreateAuditEntry(ctx, params)
+}
+
+// recordBudgetCreated records the creation of a budget by a route other
+// than the one creating empty budgets, which is audited as it is handled.
+func recordBudgetCreated(ctx context.Context, q *db.Queries, actorID, budgetID uuid.UUID) error {
+	after, err := auditBudget.snapshot(ctx, q, budgetID, budgetID.String())
+	if err != nil {
+		return fmt.Errorf("could not read resource for audit: %w", err)
+	}
+	return recordAudit(ctx, q, db.CreateAuditEntryParams{
+		BudgetID:     &budgetID,
+		ActorID:      &actorID,
+		ResourceType: auditBudget.kind,
+		ResourceID:   budgetID.String(),
+		Action:       auditCreate,
+	}, nil, after)
+}
+
+// handleGetAuditLog returns the latest changes made to the budget, newest
+// first, optionally filtered by resource, by the user who made them, and
+// by the dates they were made between.
+func (cfg *APIConfig) handleGetAuditLog(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	parsedStartDate, err := parseDateFromQuery("start_date", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+	parsedEndDate, err := parseDateFromQuery("end_date", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	parsedActorID := uuid.Nil
+	if userID := r.URL.Query().Get("user_id"); userID != "" {
+		parsedActorID, err = uuid.Parse(userID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid query parameter value 'user_id'", err)
+			return
+		}
+	}
+
+	limit := defaultAuditEntries
+	if limitVal := r.URL.Query().Get("limit"); limitVal != "" {
+		limit, err = strconv.Atoi(limitVal)
+		if err != nil || limit < 1 || limit > maxAuditEntries {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxAuditEntries), nil)
+			return
+		}
+	}
+
+	dbEntries, err := cfg.db.GetAuditEntries(r.Context(), db.GetAuditEntriesParams{
+		BudgetID:     pathBudgetID,
+		ResourceType: r.URL.Query().Get("resource_type"),
+		ResourceID:   r.URL.Query().Get("resource_id"),
+		ActorID:      parsedActorID,
+		StartDate:    parsedStartDate,
+		EndDate:      parsedEndDate,
+		MaxEntries:   int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve audit log", err)
+		return
+	}
+
+	entries := []AuditEntry{}
+	for _, dbEntry := range dbEntries {
+		entries = append(entries, AuditEntry{
+			ID:           dbEntry.ID,
+			CreatedAt:    dbEntry.CreatedAt,
+			ActorID:      dbEntry.ActorID,
+			ResourceType: dbEntry.ResourceType,
+			ResourceID:   dbEntry.ResourceID,
+			Action:       dbEntry.Action,
+			Before:       json.RawMessage(dbEntry.Before),
+			After:        json.RawMessage(dbEntry.After),
+		})
+	}
+
+	type rspSchema struct {
+		Entries []AuditEntry `json:"data"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{Entries: entries})
+}
@@ -1,16 +1,26 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/YouWantToPinch/pincher-api/internal/auth"
 	db "github.com/YouWantToPinch/pincher-api/internal/database"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+// refreshTokenLifetime is how long a refresh token may be used, if not rotated.
+const refreshTokenLifetime = time.Hour * 24 * 30
+
+// challengeTokenLifetime is how long a user has to complete
+// the second step of a two-step login.
+const challengeTokenLifetime = time.Minute * 5
+
 func (cfg *APIConfig) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 	type rqSchema struct {
 		Password string `json:"password"`
@@ -28,15 +38,73 @@ func (cfg *APIConfig) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	throttleKeys := []throttleKey{
+		{scope: throttleUsername, subject: rqPayload.Username},
+		{scope: throttleIP, subject: clientIP(r)},
+	}
+	if !cfg.checkThrottles(w, r, throttleKeys...) {
+		return
+	}
+
 	dbUser, err := cfg.db.GetUserByUsername(r.Context(), rqPayload.Username)
+	if err == nil {
+		var match bool
+		match, err = auth.CheckPasswordHash(rqPayload.Password, dbUser.HashedPassword)
+		if err == nil && !match {
+			err = errors.New("password mismatch")
+		}
+	}
 	if err != nil {
+		if err := cfg.recordFailedAttempts(r.Context(), throttleKeys...); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record failed attempt", err)
+			return
+		}
 		respondWithError(w, http.StatusUnauthorized, "incorrect username or password", err)
 		return
 	}
 
-	match, err := auth.CheckPasswordHash(rqPayload.Password, dbUser.HashedPassword)
-	if err != nil || !match {
-		respondWithError(w, http.StatusUnauthorized, "incorrect username or password", err)
+	cfg.completeLogin(w, r, &dbUser)
+}
+
+// completeLogin signs in a user who has proven who they are, unless they
+// have enabled two-factor authentication, in which case they are issued
+// a challenge token with which to provide a code first.
+func (cfg *APIConfig) completeLogin(w http.ResponseWriter, r *http.Request, dbUser *db.User) {
+	twoFactor, err := cfg.db.GetUserTwoFactor(r.Context(), dbUser.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "could not check two-factor authentication", err)
+		return
+	}
+	if err == nil && twoFactor.EnabledAt.Valid {
+		challengeToken, err := auth.MakeChallengeJWT(dbUser.ID, cfg.jwtKeys, challengeTokenLifetime)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not create challenge token", err)
+			return
+		}
+		type rspSchema struct {
+			TwoFactorRequired bool   `json:"two_factor_required"`
+			ChallengeToken    string `json:"challenge_token"`
+		}
+		respondWithJSON(w, http.StatusOK, rspSchema{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		})
+		return
+	}
+
+	cfg.startSession(w, r, dbUser)
+}
+
+// startSession signs the user in, issuing a refresh token which begins
+// a new session, along with an access token on its behalf. Failed login
+// attempts made against the user are forgotten.
+func (cfg *APIConfig) startSession(w http.ResponseWriter, r *http.Request, dbUser *db.User) {
+	_, err := cfg.db.ClearLoginThrottle(r.Context(), db.ClearLoginThrottleParams{
+		Scope:   throttleUsername,
+		Subject: dbUser.Username,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not clear failed attempts", err)
 		return
 	}
 
@@ -48,7 +116,7 @@ func (cfg *APIConfig) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 
 	// DB TRANSACTION BLOCK
 	{
-		tx, err := cfg.Pool.Begin(r.Context())
+		tx, err := cfg.beginTx(r.Context())
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "", err)
 			return
@@ -57,22 +125,25 @@ func (cfg *APIConfig) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 
 		q := cfg.db.WithTx(tx)
 
-		_, err = q.CreateRefreshToken(r.Context(), db.CreateRefreshTokenParams{
+		dbToken, err := q.CreateRefreshToken(r.Context(), db.CreateRefreshTokenParams{
 			Token:     refreshToken,
 			UserID:    dbUser.ID,
-			ExpiresAt: time.Now().UTC().UTC().Add(time.Hour * 24 * 30),
+			ExpiresAt: time.Now().UTC().Add(refreshTokenLifetime),
+			FamilyID:  uuid.New(),
+			UserAgent: r.UserAgent(),
+			IpAddress: clientIP(r),
 		})
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "could not save refresh token", err)
 			return
 		}
 
-		accessToken, err := auth.MakeJWT(dbUser.ID, jwt.SigningMethodHS256, cfg.jwtSecret, time.Hour)
+		accessToken, err := auth.MakeJWT(dbUser.ID, dbToken.FamilyID, cfg.jwtKeys, time.Hour)
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "could not create new access token", err)
 			return
 		}
-		rspPayload := cfg.makeAuthPayload(w, r, &dbUser, accessToken, refreshToken)
+		rspPayload := cfg.makeAuthPayload(w, r, dbUser, accessToken, refreshToken)
 
 		if err := tx.Commit(r.Context()); err != nil {
 			respondWithError(w, http.StatusInternalServerError, "", err)
@@ -82,6 +153,11 @@ func (cfg *APIConfig) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleCheckRefreshToken exchanges a refresh token for a new access token.
+// Refresh tokens are rotated: the token presented is revoked, and a new one
+// in the same family is issued in its place. Should a revoked token ever be
+// presented again, it is assumed to have been stolen, and every token in its
+// family is revoked.
 func (cfg *APIConfig) handleCheckRefreshToken(w http.ResponseWriter, r *http.Request) {
 	refreshToken, err := FindRefreshToken(r)
 	if err != nil {
@@ -89,20 +165,88 @@ func (cfg *APIConfig) handleCheckRefreshToken(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	dbUser, err := cfg.db.GetUserByRefreshToken(r.Context(), refreshToken)
+	newRefreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "could not get user by refresh token", err)
+		respondWithError(w, http.StatusInternalServerError, "could not create refresh token", err)
 		return
 	}
 
-	accessToken, err := auth.MakeJWT(dbUser.ID, jwt.SigningMethodHS256, cfg.jwtSecret, time.Hour)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "could not validate access token", err)
-		return
-	}
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		dbToken, err := q.GetRefreshTokenForUpdate(r.Context(), refreshToken)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "could not get refresh token", err)
+			return
+		}
+
+		if dbToken.RevokedAt.Valid {
+			revoked, err := q.RevokeRefreshTokenFamily(r.Context(), dbToken.FamilyID)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not revoke refresh token family", err)
+				return
+			}
+			if err := tx.Commit(r.Context()); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "", err)
+				return
+			}
+			slog.Warn("revoked refresh token reused; token family revoked",
+				slog.String("user_id", dbToken.UserID.String()),
+				slog.String("family_id", dbToken.FamilyID.String()),
+				slog.Int64("revoked", revoked))
+			respondWithError(w, http.StatusUnauthorized, "refresh token has been revoked", nil)
+			return
+		}
+		if !dbToken.ExpiresAt.After(time.Now().UTC()) {
+			respondWithError(w, http.StatusUnauthorized, "refresh token has expired", nil)
+			return
+		}
+
+		if err := q.RedeemRefreshToken(r.Context(), refreshToken); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not revoke refresh token", err)
+			return
+		}
+		_, err = q.CreateRefreshToken(r.Context(), db.CreateRefreshTokenParams{
+			Token:     newRefreshToken,
+			UserID:    dbToken.UserID,
+			ExpiresAt: time.Now().UTC().Add(refreshTokenLifetime),
+			FamilyID:  dbToken.FamilyID,
+			UserAgent: r.UserAgent(),
+			IpAddress: clientIP(r),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not save refresh token", err)
+			return
+		}
+
+		dbUser, err := q.GetUserByRefreshToken(r.Context(), newRefreshToken)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "could not get user by refresh token", err)
+			return
+		}
+
+		accessToken, err := auth.MakeJWT(dbUser.ID, dbToken.FamilyID, cfg.jwtKeys, time.Hour)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "could not validate access token", err)
+			return
+		}
+
+		rspPayload := cfg.makeAuthPayload(w, r, &dbUser, accessToken, newRefreshToken)
 
-	rspPayload := cfg.makeAuthPayload(w, r, &dbUser, accessToken, refreshToken)
-	respondWithJSON(w, http.StatusOK, rspPayload)
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, rspPayload)
+	}
 }
 
 func (cfg *APIConfig) handleRevokeRefreshToken(w http.ResponseWriter, r *http.Request) {
@@ -182,12 +326,21 @@ func (cfg *APIConfig) makeAuthPayload(w http.ResponseWriter, r *http.Request, db
 				RefreshToken: refreshToken,
 			}
 		}
-	} else {
+	} else if forBrowser || !contains("/refresh") {
 		return struct {
 			Token string `json:"token"`
 		}{
 			Token: accessToken,
 		}
+	} else {
+		// refresh tokens are rotated, so the new one must be returned
+		return struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refresh_token"`
+		}{
+			Token:        accessToken,
+			RefreshToken: refreshToken,
+		}
 	}
 }
 
@@ -228,3 +381,13 @@ func GetRefreshTokenFromCookie(r *http.Request) (string, error) {
 	}
 	return cookie.Value, nil
 }
+
+// handleGetJWKS publishes the public part of every key by which access
+// tokens are verified, so that other services may verify them too.
+func (cfg *APIConfig) handleGetJWKS(w http.ResponseWriter, r *http.Request) {
+	type rspSchema struct {
+		Keys []auth.JWK `json:"keys"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{Keys: cfg.jwtKeys.JWKS()})
+}
@@ -0,0 +1,529 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+// exportPageSize is how many transactions are read at a time during export.
+const exportPageSize = 500
+
+// maxBudgetImportBytes limits the size of budget exports uploaded for import.
+const maxBudgetImportBytes = 256 << 20
+
+// handleExportBudget streams the whole budget as a versioned JSON document,
+// from which it may be recreated by import. The budget is read as of a
+// single snapshot, so that the document is consistent throughout.
+func (cfg *APIConfig) handleExportBudget(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+	if restriction != nil {
+		respondWithError(w, http.StatusForbidden, "restricted members may not export the budget", nil)
+		return
+	}
+
+	tx, err := cfg.Pool.BeginTx(r.Context(), pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	q := cfg.db.WithTx(tx)
+
+	structure, errMsg, err := readBudgetStructure(r.Context(), q, pathBudgetID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, errMsg, err)
+		return
+	}
+	dbAssignments, err := q.GetBudgetAssignments(r.Context(), pathBudgetID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get assignments", err)
+		return
+	}
+	structure.setAssignments(dbAssignments)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="budget-%s.json"`, pathBudgetID))
+	w.WriteHeader(http.StatusOK)
+
+	ew := newExportWriter(w, http.NewResponseController(w).Flush)
+	ew.writeHeader(BudgetExportHeader{
+		Format:     budgetExportFormat,
+		Version:    budgetExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Budget:     structure.budget,
+	})
+	writeExportSection(ew, "members", structure.members)
+	writeExportSection(ew, "groups", structure.groups)
+	writeExportSection(ew, "categories", structure.categories)
+	writeExportSection(ew, "accounts", structure.accounts)
+	writeExportSection(ew, "payees", structure.payees)
+	writeExportSection(ew, "assignments", structure.assignments)
+
+	// transactions are written a page at a time, so that the budget
+	// need never be held in memory all at once
+	ew.beginSection("transactions")
+	afterID := uuid.Nil
+	for {
+		page, err := q.GetTransactionsForExport(r.Context(), db.GetTransactionsForExportParams{
+			BudgetID: pathBudgetID,
+			AfterID:  afterID,
+			PageSize: exportPageSize,
+		})
+		if err != nil {
+			// the response is underway, so the document is left unfinished
+			slog.Error("could not export transactions: "+err.Error(), slog.String("budget_id", pathBudgetID.String()))
+			return
+		}
+		for _, row := range page {
+			txn, err := exportTxnFromRow(&row)
+			if err != nil {
+				slog.Error("could not export transaction: "+err.Error(), slog.String("transaction_id", row.ID.String()))
+				return
+			}
+			ew.writeEntry(txn)
+		}
+		ew.sendBuffered()
+		if len(page) < exportPageSize {
+			break
+		}
+		afterID = page[len(page)-1].ID
+	}
+	ew.endSection()
+
+	if err := ew.close(); err != nil {
+		slog.Error("could not write budget export: "+err.Error(), slog.String("budget_id", pathBudgetID.String()))
+	}
+}
+
+func exportTxnFromRow(row *db.GetTransactionsForExportRow) (ExportTransaction, error) {
+	txn := ExportTransaction{
+		ID:              row.ID,
+		AccountID:       row.AccountID,
+		TransactionType: row.TransactionType,
+		TransactionDate: row.TransactionDate,
+		PayeeID:         row.PayeeID,
+		LoggerName:      row.LoggerName.String,
+		Notes:           row.Notes,
+		Cleared:         row.Cleared,
+		Reconciled:      row.Reconciled,
+		Splits:          []ExportSplit{},
+	}
+	if row.TransferredToID != nil {
+		txn.LinkedTransactionID = row.TransferredToID
+	} else {
+		txn.LinkedTransactionID = row.TransferredFromID
+	}
+	if err := json.Unmarshal(row.Splits, &txn.Splits); err != nil {
+		return txn, err
+	}
+	return txn, nil
+}
+
+// handleImportBudget recreates a budget from an export, streamed as the
+// request body, under new IDs. The user importing it becomes its ADMIN.
+// Members exported with it who have accounts on this server are invited
+// to it with their former roles; all others are reported as skipped.
+// Every transaction is attributed to the importer, as no one else has
+// agreed to have the budget imported.
+func (cfg *APIConfig) handleImportBudget(w http.ResponseWriter, r *http.Request) {
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBudgetImportBytes)
+	defer r.Body.Close()
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		importer := newDBBudgetImporter(r.Context(), q, dbUser)
+		err = readBudgetExport(r.Body, importer)
+		if err == nil {
+			err = importer.finish()
+		}
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			switch {
+			case errors.As(err, &maxBytesErr):
+				respondWithError(w, http.StatusRequestEntityTooLarge, "budget export is too large", err)
+			case errors.Is(err, errInvalidExport):
+				respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			default:
+				respondWithError(w, http.StatusInternalServerError, "could not import budget", err)
+			}
+			return
+		}
+
+		if err := recordBudgetCreated(r.Context(), q, validatedUserID, importer.budget.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record audit entry", err)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+
+		type rspSchema struct {
+			Budget
+			Invited        []string `json:"invited"`
+			SkippedMembers []string `json:"skipped_members"`
+		}
+
+		respondWithJSON(w, http.StatusCreated, rspSchema{
+			Budget: Budget{
+				ID:               importer.budget.ID,
+				CreatedAt:        importer.budget.CreatedAt,
+				UpdatedAt:        importer.budget.UpdatedAt,
+				AdminID:          importer.budget.AdminID,
+				OverspendingRule: importer.budget.OverspendingRule,
+				Meta: Meta{
+					Name:  importer.budget.Name,
+					Notes: importer.budget.Notes,
+				},
+			},
+			Invited:        importer.invited,
+			SkippedMembers: importer.skipped,
+		})
+	}
+}
+
+// dbBudgetImporter recreates an exported budget in the database, giving
+// every resource a new ID and remapping the references between them.
+type dbBudgetImporter struct {
+	ctx      context.Context
+	q        *db.Queries
+	importer db.User
+	budget   db.Budget
+
+	groupIDs    map[uuid.UUID]uuid.UUID
+	categoryIDs map[uuid.UUID]uuid.UUID
+	accountIDs  map[uuid.UUID]uuid.UUID
+	payeeIDs    map[uuid.UUID]uuid.UUID
+	// pendingTransfers holds one leg of each transfer read so far,
+	// keyed by the old ID of the other leg, which is yet to be read.
+	pendingTransfers map[uuid.UUID]*db.Transaction
+
+	invited []string
+	skipped []string
+}
+
+func newDBBudgetImporter(ctx context.Context, q *db.Queries, importer db.User) *dbBudgetImporter {
+	return &dbBudgetImporter{
+		ctx:              ctx,
+		q:                q,
+		importer:         importer,
+		groupIDs:         map[uuid.UUID]uuid.UUID{},
+		categoryIDs:      map[uuid.UUID]uuid.UUID{},
+		accountIDs:       map[uuid.UUID]uuid.UUID{},
+		payeeIDs:         map[uuid.UUID]uuid.UUID{},
+		pendingTransfers: map[uuid.UUID]*db.Transaction{},
+		invited:          []string{},
+		skipped:          []string{},
+	}
+}
+
+func (imp *dbBudgetImporter) importBudget(budget ExportBudget) error {
+	if budget.Name == "" {
+		return invalidExportErr("budget name not provided")
+	}
+	if err := validateOverspendingRule(&budget.OverspendingRule, false); err != nil {
+		return invalidExportErr("%v", err)
+	}
+
+	dbBudget, err := imp.q.CreateBudget(imp.ctx, db.CreateBudgetParams{
+		AdminID: imp.importer.ID,
+		Name:    budget.Name,
+		Notes:   budget.Notes,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create budget: %w", err)
+	}
+	if _, err = imp.q.AssignBudgetMemberWithRole(imp.ctx, db.AssignBudgetMemberWithRoleParams{
+		BudgetID:   dbBudget.ID,
+		UserID:     imp.importer.ID,
+		MemberRole: ADMIN.String(),
+	}); err != nil {
+		return fmt.Errorf("could not assign ADMIN to new budget: %w", err)
+	}
+	if err = imp.q.SetBudgetOverspendingRule(imp.ctx, db.SetBudgetOverspendingRuleParams{
+		ID:               dbBudget.ID,
+		OverspendingRule: budget.OverspendingRule,
+	}); err != nil {
+		return fmt.Errorf("could not set overspending rule: %w", err)
+	}
+	dbBudget.OverspendingRule = budget.OverspendingRule
+	imp.budget = dbBudget
+	return nil
+}
+
+// importMember invites an exported member to the new budget, where they
+// have an account on this server. As the importer becomes the ADMIN, the
+// former ADMIN is invited as a MANAGER.
+func (imp *dbBudgetImporter) importMember(member ExportMember) error {
+	memberRole, err := BMRFromString(member.MemberRole)
+	if err != nil {
+		return invalidExportErr("%v", err)
+	}
+	if member.Username == imp.importer.Username {
+		return nil
+	}
+
+	dbUser, err := imp.q.GetUserByUsername(imp.ctx, member.Username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			imp.skipped = append(imp.skipped, member.Username)
+			return nil
+		}
+		return fmt.Errorf("could not get user: %w", err)
+	}
+
+	if memberRole == ADMIN {
+		memberRole = MANAGER
+	}
+	if _, err = imp.q.CreateBudgetInvitation(imp.ctx, db.CreateBudgetInvitationParams{
+		BudgetID:   imp.budget.ID,
+		InviterID:  &imp.importer.ID,
+		InviteeID:  &dbUser.ID,
+		MemberRole: memberRole.String(),
+		ExpiresAt:  time.Now().UTC().Add(invitationLifetime),
+	}); err != nil {
+		return fmt.Errorf("could not invite member: %w", err)
+	}
+	imp.invited = append(imp.invited, member.Username)
+	return nil
+}
+
+func (imp *dbBudgetImporter) importGroup(group ExportGroup) error {
+	dbGroup, err := imp.q.CreateGroup(imp.ctx, db.CreateGroupParams{
+		BudgetID: imp.budget.ID,
+		Name:     group.Name,
+		Notes:    group.Notes,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create group: %w", err)
+	}
+	imp.groupIDs[group.ID] = dbGroup.ID
+	return nil
+}
+
+func (imp *dbBudgetImporter) importCategory(category ExportCategory) error {
+	var groupID *uuid.UUID
+	if category.GroupID != nil {
+		newGroupID, ok := imp.groupIDs[*category.GroupID]
+		if !ok {
+			return invalidExportErr("category %s refers to unknown group %s", category.ID, *category.GroupID)
+		}
+		groupID = &newGroupID
+	}
+	if err := validateOverspendingRule(&category.OverspendingRule, true); err != nil {
+		return invalidExportErr("%v", err)
+	}
+
+	dbCategory, err := imp.q.CreateCategory(imp.ctx, db.CreateCategoryParams{
+		BudgetID: imp.budget.ID,
+		GroupID:  groupID,
+		Name:     category.Name,
+		Notes:    category.Notes,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create category: %w", err)
+	}
+	if category.OverspendingRule != overspendingBudget {
+		if err = imp.q.SetCategoryOverspendingRule(imp.ctx, db.SetCategoryOverspendingRuleParams{
+			ID:               dbCategory.ID,
+			OverspendingRule: category.OverspendingRule,
+		}); err != nil {
+			return fmt.Errorf("could not set overspending rule: %w", err)
+		}
+	}
+	imp.categoryIDs[category.ID] = dbCategory.ID
+	return nil
+}
+
+func (imp *dbBudgetImporter) importAccount(account ExportAccount) error {
+	switch account.AccountType {
+	case "ON_BUDGET", "OFF_BUDGET", "CREDIT_CARD":
+	default:
+		return invalidExportErr("account %s has invalid type %s", account.ID, account.AccountType)
+	}
+
+	dbAccount, err := imp.q.AddAccount(imp.ctx, db.AddAccountParams{
+		BudgetID:    imp.budget.ID,
+		AccountType: account.AccountType,
+		Name:        account.Name,
+		Notes:       account.Notes,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create account: %w", err)
+	}
+	if account.PaymentCategoryID != nil {
+		categoryID, ok := imp.categoryIDs[*account.PaymentCategoryID]
+		if !ok {
+			return invalidExportErr("account %s refers to unknown payment category %s", account.ID, *account.PaymentCategoryID)
+		}
+		if err = imp.q.SetAccountPaymentCategory(imp.ctx, db.SetAccountPaymentCategoryParams{
+			ID:                dbAccount.ID,
+			PaymentCategoryID: &categoryID,
+		}); err != nil {
+			return fmt.Errorf("could not set payment category: %w", err)
+		}
+	}
+	if account.IsDeleted {
+		if err = imp.q.DeleteAccountSoft(imp.ctx, dbAccount.ID); err != nil {
+			return fmt.Errorf("could not delete account: %w", err)
+		}
+	}
+	imp.accountIDs[account.ID] = dbAccount.ID
+	return nil
+}
+
+func (imp *dbBudgetImporter) importPayee(payee ExportPayee) error {
+	dbPayee, err := imp.q.CreatePayee(imp.ctx, db.CreatePayeeParams{
+		BudgetID: imp.budget.ID,
+		Name:     payee.Name,
+		Notes:    payee.Notes,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create payee: %w", err)
+	}
+	imp.payeeIDs[payee.ID] = dbPayee.ID
+	return nil
+}
+
+func (imp *dbBudgetImporter) importAssignment(assignment ExportAssignment) error {
+	categoryID, ok := imp.categoryIDs[assignment.CategoryID]
+	if !ok {
+		return invalidExportErr("assignment refers to unknown category %s", assignment.CategoryID)
+	}
+	if _, err := imp.q.AssignAmountToCategory(imp.ctx, db.AssignAmountToCategoryParams{
+		MonthID:    assignment.Month,
+		CategoryID: categoryID,
+		Amount:     assignment.Assigned,
+	}); err != nil {
+		return fmt.Errorf("could not assign amount to category: %w", err)
+	}
+	return nil
+}
+
+// importTransaction recreates a transaction and its splits, logged by the
+// importer. Each leg of a transfer is linked to the other once both have
+// been read.
+func (imp *dbBudgetImporter) importTransaction(txn ExportTransaction) error {
+	switch txn.TransactionType {
+	case "DEPOSIT", "WITHDRAWAL", "TRANSFER_TO", "TRANSFER_FROM":
+	default:
+		return invalidExportErr("transaction %s has invalid type %s", txn.ID, txn.TransactionType)
+	}
+	if checkIsTransfer(txn.TransactionType) != (txn.LinkedTransactionID != nil) {
+		return invalidExportErr("transaction %s must be linked if and only if it is a transfer", txn.ID)
+	}
+	if txn.Reconciled && !txn.Cleared {
+		return invalidExportErr("transaction %s is reconciled, but not cleared", txn.ID)
+	}
+	accountID, ok := imp.accountIDs[txn.AccountID]
+	if !ok {
+		return invalidExportErr("transaction %s refers to unknown account %s", txn.ID, txn.AccountID)
+	}
+	payeeID := uuid.Nil
+	if txn.PayeeID != uuid.Nil {
+		if payeeID, ok = imp.payeeIDs[txn.PayeeID]; !ok {
+			return invalidExportErr("transaction %s refers to unknown payee %s", txn.ID, txn.PayeeID)
+		}
+	}
+	dbTxn, err := imp.q.ImportTransaction(imp.ctx, db.ImportTransactionParams{
+		BudgetID:        imp.budget.ID,
+		LoggerID:        imp.importer.ID,
+		AccountID:       accountID,
+		TransactionType: txn.TransactionType,
+		TransactionDate: txn.TransactionDate,
+		PayeeID:         payeeID,
+		Notes:           txn.Notes,
+		Cleared:         txn.Cleared,
+		Reconciled:      txn.Reconciled,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create transaction: %w", err)
+	}
+
+	for _, split := range txn.Splits {
+		var categoryID *uuid.UUID
+		if split.CategoryID != nil {
+			newCategoryID, ok := imp.categoryIDs[*split.CategoryID]
+			if !ok {
+				return invalidExportErr("transaction %s refers to unknown category %s", txn.ID, *split.CategoryID)
+			}
+			categoryID = &newCategoryID
+		}
+		if err = imp.q.ImportTransactionSplit(imp.ctx, db.ImportTransactionSplitParams{
+			TransactionID: dbTxn.ID,
+			CategoryID:    categoryID,
+			Amount:        split.Amount,
+		}); err != nil {
+			return fmt.Errorf("could not create transaction split: %w", err)
+		}
+	}
+
+	if txn.LinkedTransactionID == nil {
+		return nil
+	}
+	linkedTxn, ok := imp.pendingTransfers[txn.ID]
+	if !ok {
+		imp.pendingTransfers[*txn.LinkedTransactionID] = &dbTxn
+		return nil
+	}
+	delete(imp.pendingTransfers, txn.ID)
+
+	toPtr, fromPtr, err := getOrderedTransferIDs(&dbTxn, linkedTxn)
+	if err != nil {
+		return invalidExportErr("transaction %s: %v", txn.ID, err)
+	}
+	if _, err = imp.q.LogAccountTransfer(imp.ctx, db.LogAccountTransferParams{
+		FromTransactionID: fromPtr.ID,
+		ToTransactionID:   toPtr.ID,
+	}); err != nil {
+		return fmt.Errorf("could not link transfer transactions: %w", err)
+	}
+	return nil
+}
+
+// finish reports whether the import is complete, once all has been read.
+func (imp *dbBudgetImporter) finish() error {
+	for missingID := range imp.pendingTransfers {
+		return invalidExportErr("transfer transaction %s not found", missingID)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/jackc/pgx/v5"
+)
+
+// handleNominateBudgetOwner nominates another member of the budget to take
+// it over from its admin. Ownership only moves once the nominee accepts;
+// a budget has at most one pending nomination, which a new one replaces.
+func (cfg *APIConfig) handleNominateBudgetOwner(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	type rqSchema struct {
+		UserName string `json:"username"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	nominee, err := cfg.db.GetUserByUsername(r.Context(), rqPayload.UserName)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user does not exist", err)
+		return
+	}
+	if nominee.ID == validatedUserID {
+		respondWithError(w, http.StatusBadRequest, "user is already admin of this budget", nil)
+		return
+	}
+
+	_, err = cfg.db.GetBudgetMemberRole(r.Context(), db.GetBudgetMemberRoleParams{
+		BudgetID: pathBudgetID,
+		UserID:   nominee.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "user is not a member of this budget", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not check budget membership", err)
+		return
+	}
+
+	dbOwnershipTransfer, err := cfg.db.UpsertOwnershipTransfer(r.Context(), db.UpsertOwnershipTransferParams{
+		BudgetID:   pathBudgetID,
+		FromUserID: validatedUserID,
+		ToUserID:   nominee.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not save ownership transfer", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, ownershipTransferFromDB(&dbOwnershipTransfer))
+}
+
+func (cfg *APIConfig) handleCancelOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	deleted, err := cfg.db.DeleteOwnershipTransfer(r.Context(), pathBudgetID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not cancel ownership transfer", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "no pending ownership transfer found", nil)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+// handleGetUserOwnershipTransfers returns every budget the user has been
+// nominated to take over.
+func (cfg *APIConfig) handleGetUserOwnershipTransfers(w http.ResponseWriter, r *http.Request) {
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	dbOwnershipTransfers, err := cfg.db.GetOwnershipTransfersToUser(r.Context(), validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve ownership transfers", err)
+		return
+	}
+
+	ownershipTransfers := []OwnershipTransfer{}
+	for _, dbOwnershipTransfer := range dbOwnershipTransfers {
+		ownershipTransfers = append(ownershipTransfers, OwnershipTransfer{
+			CreatedAt:  dbOwnershipTransfer.CreatedAt,
+			BudgetID:   dbOwnershipTransfer.BudgetID,
+			BudgetName: dbOwnershipTransfer.BudgetName,
+			FromUserID: dbOwnershipTransfer.FromUserID,
+			ToUserID:   dbOwnershipTransfer.ToUserID,
+		})
+	}
+
+	type rspSchema struct {
+		OwnershipTransfers []OwnershipTransfer `json:"data"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{OwnershipTransfers: ownershipTransfers})
+}
+
+// handleAcceptOwnershipTransfer makes the nominee admin of the budget, and
+// its former admin a manager of it. The admin of the budget and the roles of
+// both members change together, or not at all.
+func (cfg *APIConfig) handleAcceptOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		dbOwnershipTransfer, err := q.ConsumeOwnershipTransfer(r.Context(), db.ConsumeOwnershipTransferParams{
+			BudgetID: pathBudgetID,
+			ToUserID: validatedUserID,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "no pending ownership transfer found", nil)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "could not get ownership transfer", err)
+			return
+		}
+
+		updated, err := q.SetBudgetAdmin(r.Context(), db.SetBudgetAdminParams{
+			NewAdminID:    validatedUserID,
+			BudgetID:      pathBudgetID,
+			FormerAdminID: dbOwnershipTransfer.FromUserID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not change budget admin", err)
+			return
+		}
+		if updated == 0 {
+			respondWithError(w, http.StatusConflict, "budget admin has changed since the nomination", nil)
+			return
+		}
+
+		// both the nominee and the former admin must still be members
+		updated, err = q.SwapBudgetAdminRole(r.Context(), db.SwapBudgetAdminRoleParams{
+			NewAdminID:    validatedUserID,
+			BudgetID:      pathBudgetID,
+			FormerAdminID: dbOwnershipTransfer.FromUserID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not change member roles", err)
+			return
+		}
+		if updated != 2 {
+			respondWithError(w, http.StatusConflict, "budget membership has changed since the nomination", nil)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, BudgetMembership{
+			BudgetID:   pathBudgetID,
+			UserID:     validatedUserID,
+			MemberRole: ADMIN,
+		})
+	}
+}
+
+func (cfg *APIConfig) handleDeclineOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	_, err := cfg.db.ConsumeOwnershipTransfer(r.Context(), db.ConsumeOwnershipTransferParams{
+		BudgetID: pathBudgetID,
+		ToUserID: validatedUserID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "no pending ownership transfer found", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not decline ownership transfer", err)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+func ownershipTransferFromDB(dbOwnershipTransfer *db.BudgetOwnershipTransfer) OwnershipTransfer {
+	return OwnershipTransfer{
+		CreatedAt:  dbOwnershipTransfer.CreatedAt,
+		BudgetID:   dbOwnershipTransfer.BudgetID,
+		FromUserID: dbOwnershipTransfer.FromUserID,
+		ToUserID:   dbOwnershipTransfer.ToUserID,
+	}
+}
@@ -1,10 +1,14 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
 	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 func (cfg *APIConfig) handleCreateBudget(w http.ResponseWriter, r *http.Request) {
@@ -12,6 +16,8 @@ func (cfg *APIConfig) handleCreateBudget(w http.ResponseWriter, r *http.Request)
 
 	type rqSchema struct {
 		Meta
+		// Template optionally names a starter template to create the budget from.
+		Template string `json:"template"`
 	}
 
 	rqPayload, err := decodePayload[rqSchema](r)
@@ -25,9 +31,18 @@ func (cfg *APIConfig) handleCreateBudget(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	var template *BudgetTemplate
+	if rqPayload.Template != "" {
+		template, err = getBudgetTemplate(rqPayload.Template)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "could not get budget template by given name", err)
+			return
+		}
+	}
+
 	// DB TRANSACTION BLOCK
 	{
-		tx, err := cfg.Pool.Begin(r.Context())
+		tx, err := cfg.beginTx(r.Context())
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "", err)
 			return
@@ -55,11 +70,18 @@ func (cfg *APIConfig) handleCreateBudget(w http.ResponseWriter, r *http.Request)
 			respondWithError(w, http.StatusInternalServerError, "could not assign ADMIN to new budget", err)
 			return
 		}
+		if template != nil {
+			if errMsg, err := pgxApplyBudgetTemplate(q, r.Context(), dbBudget.ID, template); err != nil {
+				respondWithError(w, http.StatusInternalServerError, errMsg, err)
+				return
+			}
+		}
 		rspPayload := Budget{
-			ID:        dbBudget.ID,
-			CreatedAt: dbBudget.CreatedAt,
-			UpdatedAt: dbBudget.UpdatedAt,
-			AdminID:   dbBudget.AdminID,
+			ID:               dbBudget.ID,
+			CreatedAt:        dbBudget.CreatedAt,
+			UpdatedAt:        dbBudget.UpdatedAt,
+			AdminID:          dbBudget.AdminID,
+			OverspendingRule: dbBudget.OverspendingRule,
 			Meta: Meta{
 				Name:  dbBudget.Name,
 				Notes: dbBudget.Notes,
@@ -84,10 +106,11 @@ func (cfg *APIConfig) handleGetBudget(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rspPayload := Budget{
-		ID:        dbBudget.ID,
-		CreatedAt: dbBudget.CreatedAt,
-		UpdatedAt: dbBudget.UpdatedAt,
-		AdminID:   dbBudget.AdminID,
+		ID:               dbBudget.ID,
+		CreatedAt:        dbBudget.CreatedAt,
+		UpdatedAt:        dbBudget.UpdatedAt,
+		AdminID:          dbBudget.AdminID,
+		OverspendingRule: dbBudget.OverspendingRule,
 		Meta: Meta{
 			Name:  dbBudget.Name,
 			Notes: dbBudget.Notes,
@@ -115,13 +138,28 @@ func (cfg *APIConfig) handleGetUserBudgets(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// API keys only see the budgets they were granted access to
+	var keyBudgetIDs []uuid.UUID
+	apiKey := getContextAPIKey(r.Context())
+	if apiKey != nil {
+		keyBudgetIDs, err = cfg.db.GetAPIKeyBudgetIDs(r.Context(), apiKey.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not retrieve API key budgets", err)
+			return
+		}
+	}
+
 	var budgets []Budget
 	for _, dbBudget := range dbBudgets {
+		if apiKey != nil && !slices.Contains(keyBudgetIDs, dbBudget.ID) {
+			continue
+		}
 		addBudget := Budget{
-			ID:        dbBudget.ID,
-			CreatedAt: dbBudget.CreatedAt,
-			UpdatedAt: dbBudget.UpdatedAt,
-			AdminID:   dbBudget.AdminID,
+			ID:               dbBudget.ID,
+			CreatedAt:        dbBudget.CreatedAt,
+			UpdatedAt:        dbBudget.UpdatedAt,
+			AdminID:          dbBudget.AdminID,
+			OverspendingRule: dbBudget.OverspendingRule,
 			Meta: Meta{
 				Name:  dbBudget.Name,
 				Notes: dbBudget.Notes,
@@ -165,11 +203,23 @@ func (cfg *APIConfig) handleGetBudgetCapital(w http.ResponseWriter, r *http.Requ
 	respondWithJSON(w, http.StatusOK, rspPayload)
 }
 
-func (cfg *APIConfig) handleAddBudgetMemberWithRole(w http.ResponseWriter, r *http.Request) {
+// handleAddBudgetMember answers the route once used to add members directly.
+// Users only ever join a budget by accepting an invitation.
+func (cfg *APIConfig) handleAddBudgetMember(w http.ResponseWriter, r *http.Request) {
+	respondWithError(w, http.StatusGone, "members may no longer be added directly; invite them with POST /api/budgets/{budget_id}/invitations, and change their roles with PATCH /api/budgets/{budget_id}/members/{user_id}", nil)
+}
+
+// handleSetBudgetMemberRole changes the role of an existing member of the budget.
+func (cfg *APIConfig) handleSetBudgetMemberRole(w http.ResponseWriter, r *http.Request) {
 	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
 
+	pathUserID, err := parseUUIDFromPath("user_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
 	type rqSchema struct {
-		UserName   string `json:"username"`
 		MemberRole string `json:"member_role"`
 	}
 
@@ -179,29 +229,27 @@ func (cfg *APIConfig) handleAddBudgetMemberWithRole(w http.ResponseWriter, r *ht
 		return
 	}
 
-	user, err := cfg.db.GetUserByUsername(r.Context(), rqPayload.UserName)
-	if err != nil {
-		respondWithError(w, http.StatusNotFound, "user does not exist", err)
-		return
-	}
-
 	newMemberRole, err := BMRFromString(rqPayload.MemberRole)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "failure interpreting role", err)
 		return
 	}
 	if newMemberRole == ADMIN {
-		respondWithError(w, http.StatusBadRequest, "could not add admin to budget (only one is permitted)", nil)
+		respondWithError(w, http.StatusBadRequest, "could not make member admin of budget (only one is permitted)", nil)
 		return
 	}
 
-	dbBudgetMembership, err := cfg.db.AssignBudgetMemberWithRole(r.Context(), db.AssignBudgetMemberWithRoleParams{
+	dbBudgetMembership, err := cfg.db.UpdateBudgetMemberRole(r.Context(), db.UpdateBudgetMemberRoleParams{
 		BudgetID:   pathBudgetID,
-		UserID:     user.ID,
+		UserID:     pathUserID,
 		MemberRole: newMemberRole.String(),
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "could not assign new member to budget", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "user is not a member of this budget whose role may be changed; invite them instead", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not change member role", err)
 		return
 	}
 
@@ -211,7 +259,7 @@ func (cfg *APIConfig) handleAddBudgetMemberWithRole(w http.ResponseWriter, r *ht
 		MemberRole: newMemberRole,
 	}
 
-	respondWithJSON(w, http.StatusCreated, rspPayload)
+	respondWithJSON(w, http.StatusOK, rspPayload)
 }
 
 func (cfg *APIConfig) handleRemoveBudgetMember(w http.ResponseWriter, r *http.Request) {
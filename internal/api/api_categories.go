@@ -55,11 +55,12 @@ func (cfg *APIConfig) handleCreateCategory(w http.ResponseWriter, r *http.Reques
 	}
 
 	rspPayload := Category{
-		ID:        dbCategory.ID,
-		CreatedAt: dbCategory.CreatedAt,
-		UpdatedAt: dbCategory.UpdatedAt,
-		BudgetID:  dbCategory.BudgetID,
-		GroupID:   dbCategory.GroupID,
+		ID:               dbCategory.ID,
+		CreatedAt:        dbCategory.CreatedAt,
+		UpdatedAt:        dbCategory.UpdatedAt,
+		BudgetID:         dbCategory.BudgetID,
+		OverspendingRule: dbCategory.OverspendingRule,
+		GroupID:          dbCategory.GroupID,
 		Meta: Meta{
 			Name:  dbCategory.Name,
 			Notes: dbCategory.Notes,
@@ -100,11 +101,12 @@ func (cfg *APIConfig) handleGetCategories(w http.ResponseWriter, r *http.Request
 	var respCategories []Category
 	for _, category := range categories {
 		respCategories = append(respCategories, Category{
-			ID:        category.ID,
-			CreatedAt: category.CreatedAt,
-			UpdatedAt: category.UpdatedAt,
-			BudgetID:  category.BudgetID,
-			GroupID:   category.GroupID,
+			ID:               category.ID,
+			CreatedAt:        category.CreatedAt,
+			UpdatedAt:        category.UpdatedAt,
+			BudgetID:         category.BudgetID,
+			OverspendingRule: category.OverspendingRule,
+			GroupID:          category.GroupID,
 			Meta: Meta{
 				Name:  category.Name,
 				Notes: category.Notes,
@@ -137,10 +139,11 @@ func (cfg *APIConfig) handleGetCategory(w http.ResponseWriter, r *http.Request)
 	}
 
 	rspPayload := Category{
-		ID:        dbCategory.ID,
-		CreatedAt: dbCategory.CreatedAt,
-		UpdatedAt: dbCategory.UpdatedAt,
-		BudgetID:  dbCategory.BudgetID,
+		ID:               dbCategory.ID,
+		CreatedAt:        dbCategory.CreatedAt,
+		UpdatedAt:        dbCategory.UpdatedAt,
+		BudgetID:         dbCategory.BudgetID,
+		OverspendingRule: dbCategory.OverspendingRule,
 		Meta: Meta{
 			Name:  dbCategory.Name,
 			Notes: dbCategory.Notes,
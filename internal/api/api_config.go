@@ -3,20 +3,29 @@ package api
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/joho/godotenv"
 	"github.com/pressly/goose/v3"
 
+	"github.com/YouWantToPinch/pincher-api/internal/auth"
 	db "github.com/YouWantToPinch/pincher-api/internal/database"
 )
 
+// defaultJWTKeysDir is where JWT signing keys are kept,
+// where JWT_KEYS_DIR is not set.
+const defaultJWTKeysDir = "keys"
+
 type DBConfig struct {
 	DBUser     string
 	DBPassword string
@@ -26,14 +35,29 @@ type DBConfig struct {
 }
 
 type APIConfig struct {
-	db        *db.Queries
-	Pool      *pgxpool.Pool
-	dbURL     string
-	platform  string
-	jwtSecret string
-	logger    *slog.Logger
-	origins   map[string]struct{}
-	// apiKeys  *map[string]string
+	db       *db.Queries
+	Pool     *pgxpool.Pool
+	dbURL    string
+	platform string
+	// jwtKeys sign and verify JWTs
+	jwtKeys *auth.KeySet
+	logger  *slog.Logger
+	origins map[string]struct{}
+	// schedulerInterval determines how often background jobs are run
+	schedulerInterval time.Duration
+	// trashRetention determines how long deleted transactions, and the prior
+	// versions of updated ones, are kept before being purged
+	trashRetention time.Duration
+	// loginThrottle determines how failed logins are slowed and locked out
+	loginThrottle throttlePolicy
+	// signupThrottle limits how many users may be created from one address
+	signupThrottle throttlePolicy
+	// admins are the users permitted to administer the server
+	admins map[uuid.UUID]struct{}
+	// oidc signs users in through an OpenID Connect provider, if configured
+	oidc *auth.OIDCProvider
+	// oidcAutoProvision allows users to be created for unlinked identities
+	oidcAutoProvision bool
 }
 
 func (cfg *APIConfig) Init(envPath string) error {
@@ -65,7 +89,103 @@ func (cfg *APIConfig) Init(envPath string) error {
 		}
 	}
 
-	cfg.jwtSecret = os.Getenv("JWT_SECRET")
+	keysDir := os.Getenv("JWT_KEYS_DIR")
+	if keysDir == "" {
+		keysDir = defaultJWTKeysDir
+	}
+	jwtKeys, err := auth.LoadKeySet(keysDir, os.Getenv("JWT_SIGNING_KEY"))
+	if err != nil {
+		return fmt.Errorf("could not load JWT keys: %w", err)
+	}
+	// tokens signed with the shared secret used before
+	// signing keys remain valid until they expire
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		jwtKeys.AcceptLegacySecret(secret)
+	}
+	cfg.jwtKeys = jwtKeys
+
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		clientID := os.Getenv("OIDC_CLIENT_ID")
+		redirectURL := os.Getenv("OIDC_REDIRECT_URL")
+		if clientID == "" || redirectURL == "" {
+			return errors.New("OIDC_ISSUER requires OIDC_CLIENT_ID and OIDC_REDIRECT_URL")
+		}
+		cfg.oidc = auth.NewOIDCProvider(issuer, clientID, os.Getenv("OIDC_CLIENT_SECRET"), redirectURL)
+		cfg.oidcAutoProvision = os.Getenv("OIDC_AUTO_PROVISION") == "true"
+	}
+
+	cfg.schedulerInterval = defaultSchedulerInterval
+	if intervalVal := os.Getenv("SCHEDULER_INTERVAL"); intervalVal != "" {
+		interval, err := time.ParseDuration(intervalVal)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid SCHEDULER_INTERVAL: %s", intervalVal)
+		}
+		cfg.schedulerInterval = interval
+	}
+
+	cfg.trashRetention = defaultTrashRetention
+	if daysVal := os.Getenv("TRASH_RETENTION_DAYS"); daysVal != "" {
+		days, err := strconv.Atoi(daysVal)
+		if err != nil || days <= 0 {
+			return fmt.Errorf("invalid TRASH_RETENTION_DAYS: %s", daysVal)
+		}
+		cfg.trashRetention = time.Duration(days) * 24 * time.Hour
+	}
+
+	cfg.loginThrottle = defaultThrottlePolicy
+	for name, count := range map[string]*int{
+		"LOGIN_FREE_ATTEMPTS":    &cfg.loginThrottle.freeAttempts,
+		"LOGIN_LOCKOUT_ATTEMPTS": &cfg.loginThrottle.lockoutAttempts,
+	} {
+		if countVal := os.Getenv(name); countVal != "" {
+			parsed, err := strconv.Atoi(countVal)
+			if err != nil || parsed < 0 {
+				return fmt.Errorf("invalid %s: %s", name, countVal)
+			}
+			*count = parsed
+		}
+	}
+	for name, duration := range map[string]*time.Duration{
+		"LOGIN_BACKOFF_BASE":     &cfg.loginThrottle.baseDelay,
+		"LOGIN_BACKOFF_MAX":      &cfg.loginThrottle.maxDelay,
+		"LOGIN_LOCKOUT_DURATION": &cfg.loginThrottle.lockoutDuration,
+	} {
+		if durationVal := os.Getenv(name); durationVal != "" {
+			parsed, err := time.ParseDuration(durationVal)
+			if err != nil || parsed <= 0 {
+				return fmt.Errorf("invalid %s: %s", name, durationVal)
+			}
+			*duration = parsed
+		}
+	}
+
+	cfg.signupThrottle = defaultSignupPolicy
+	if limitVal := os.Getenv("SIGNUP_LIMIT"); limitVal != "" {
+		limit, err := strconv.Atoi(limitVal)
+		if err != nil || limit <= 0 {
+			return fmt.Errorf("invalid SIGNUP_LIMIT: %s", limitVal)
+		}
+		cfg.signupThrottle = signupPolicy(limit, cfg.signupThrottle.lockoutDuration)
+	}
+	if windowVal := os.Getenv("SIGNUP_WINDOW"); windowVal != "" {
+		window, err := time.ParseDuration(windowVal)
+		if err != nil || window <= 0 {
+			return fmt.Errorf("invalid SIGNUP_WINDOW: %s", windowVal)
+		}
+		cfg.signupThrottle = signupPolicy(cfg.signupThrottle.lockoutAttempts, window)
+	}
+
+	cfg.admins = map[uuid.UUID]struct{}{}
+	for idVal := range strings.SplitSeq(os.Getenv("ADMIN_USER_IDS"), ",") {
+		if idVal = strings.TrimSpace(idVal); idVal == "" {
+			continue
+		}
+		adminID, err := uuid.Parse(idVal)
+		if err != nil {
+			return fmt.Errorf("invalid ADMIN_USER_IDS: %s", idVal)
+		}
+		cfg.admins[adminID] = struct{}{}
+	}
 
 	altDBUrl := os.Getenv("DB_URL")
 	if len(altDBUrl) > 0 {
@@ -195,5 +315,5 @@ func (cfg *APIConfig) ConnectToDB(fs embed.FS, migrationsDir string) {
 	}
 	cfg.Pool = pool
 
-	cfg.db = db.New(pool)
+	cfg.db = db.New(&auditedPool{Pool: pool})
 }
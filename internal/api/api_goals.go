@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+func (cfg *APIConfig) handleUpsertCategoryGoal(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		GoalType     string `json:"goal_type"`
+		TargetAmount int64  `json:"target_amount"`
+		// TargetDate is only used by TARGET_BALANCE_BY_DATE goals,
+		// and is a time string in the custom format "2006-01-02" (YYYY-MM-DD)
+		TargetDate string `json:"target_date"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	goal := &categoryGoal{
+		goalType:     rqPayload.GoalType,
+		targetAmount: rqPayload.TargetAmount,
+	}
+	if rqPayload.TargetDate != "" {
+		targetDate, err := parseDate(rqPayload.TargetDate)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "", err)
+			return
+		}
+		goal.targetDate = &targetDate
+	}
+	if err := validateCategoryGoal(goal); err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	dbCategory := cfg.getBudgetCategory(w, r)
+	if dbCategory == nil {
+		return
+	}
+
+	dbGoal, err := cfg.db.UpsertCategoryGoal(r.Context(), db.UpsertCategoryGoalParams{
+		CategoryID:   dbCategory.ID,
+		GoalType:     goal.goalType,
+		TargetAmount: goal.targetAmount,
+		TargetDate:   goal.targetDate,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not save category goal", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, categoryGoalFromDB(&dbGoal))
+}
+
+func (cfg *APIConfig) handleGetCategoryGoal(w http.ResponseWriter, r *http.Request) {
+	dbCategory := cfg.getBudgetCategory(w, r)
+	if dbCategory == nil {
+		return
+	}
+
+	dbGoal, err := cfg.db.GetCategoryGoal(r.Context(), dbCategory.ID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get category goal", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, categoryGoalFromDB(&dbGoal))
+}
+
+func (cfg *APIConfig) handleDeleteCategoryGoal(w http.ResponseWriter, r *http.Request) {
+	dbCategory := cfg.getBudgetCategory(w, r)
+	if dbCategory == nil {
+		return
+	}
+
+	if err := cfg.db.DeleteCategoryGoal(r.Context(), dbCategory.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not delete category goal", err)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+// getBudgetCategory returns the category at the path of a request,
+// after ensuring that it belongs to the budget.
+// Where it does not, a response is written, and nil is returned.
+func (cfg *APIConfig) getBudgetCategory(w http.ResponseWriter, r *http.Request) *db.Category {
+	pathCategoryID, err := parseUUIDFromPath("category_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return nil
+	}
+	dbCategory, err := cfg.db.GetCategoryByID(r.Context(), pathCategoryID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get category", err)
+		return nil
+	}
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	if pathBudgetID != dbCategory.BudgetID {
+		respondWithCode(w, http.StatusForbidden)
+		return nil
+	}
+	return &dbCategory
+}
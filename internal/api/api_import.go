@@ -0,0 +1,327 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"unicode/utf8"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/YouWantToPinch/pincher-api/internal/ingest"
+)
+
+func (cfg *APIConfig) handleUpsertImportProfile(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		HasHeader       bool   `json:"has_header"`
+		Delimiter       string `json:"delimiter"`
+		SkipRows        int32  `json:"skip_rows"`
+		DateColumn      string `json:"date_column"`
+		DateFormat      string `json:"date_format"`
+		AmountColumn    string `json:"amount_column"`
+		DebitColumn     string `json:"debit_column"`
+		CreditColumn    string `json:"credit_column"`
+		PayeeColumn     string `json:"payee_column"`
+		MemoColumn      string `json:"memo_column"`
+		CategoryColumn  string `json:"category_column"`
+		DefaultCategory string `json:"default_category"`
+		SignConvention  string `json:"sign_convention"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if rqPayload.Delimiter == "" {
+		rqPayload.Delimiter = ","
+	}
+	if rqPayload.DateFormat == "" {
+		rqPayload.DateFormat = "YYYY-MM-DD"
+	}
+	if rqPayload.SignConvention == "" {
+		rqPayload.SignConvention = ingest.DepositsPositive
+	}
+	if utf8.RuneCountInString(rqPayload.Delimiter) != 1 {
+		respondWithError(w, http.StatusBadRequest, "delimiter must be a single character", nil)
+		return
+	}
+
+	dbAccount, _ := cfg.getImportAccount(w, r)
+	if dbAccount == nil {
+		return
+	}
+
+	delimiter, _ := utf8.DecodeRuneInString(rqPayload.Delimiter)
+	mapping := ingest.CSVMapping{
+		HasHeader:      rqPayload.HasHeader,
+		Delimiter:      delimiter,
+		SkipRows:       int(rqPayload.SkipRows),
+		DateColumn:     rqPayload.DateColumn,
+		DateFormat:     rqPayload.DateFormat,
+		AmountColumn:   rqPayload.AmountColumn,
+		DebitColumn:    rqPayload.DebitColumn,
+		CreditColumn:   rqPayload.CreditColumn,
+		PayeeColumn:    rqPayload.PayeeColumn,
+		MemoColumn:     rqPayload.MemoColumn,
+		CategoryColumn: rqPayload.CategoryColumn,
+		SignConvention: rqPayload.SignConvention,
+	}
+	if err := mapping.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	if rqPayload.DefaultCategory != "" {
+		pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+		if _, err := cfg.db.GetBudgetCategoryIDByName(r.Context(), db.GetBudgetCategoryIDByNameParams{
+			CategoryName: rqPayload.DefaultCategory,
+			BudgetID:     pathBudgetID,
+		}); err != nil {
+			respondWithError(w, http.StatusBadRequest, "could not get default category by given name", err)
+			return
+		}
+	}
+
+	params := db.UpsertImportProfileParams{
+		AccountID:       dbAccount.ID,
+		HasHeader:       rqPayload.HasHeader,
+		Delimiter:       rqPayload.Delimiter,
+		SkipRows:        rqPayload.SkipRows,
+		DateColumn:      rqPayload.DateColumn,
+		DateFormat:      rqPayload.DateFormat,
+		AmountColumn:    rqPayload.AmountColumn,
+		DebitColumn:     rqPayload.DebitColumn,
+		CreditColumn:    rqPayload.CreditColumn,
+		PayeeColumn:     rqPayload.PayeeColumn,
+		MemoColumn:      rqPayload.MemoColumn,
+		CategoryColumn:  rqPayload.CategoryColumn,
+		DefaultCategory: rqPayload.DefaultCategory,
+		SignConvention:  rqPayload.SignConvention,
+	}
+
+	dbProfile, err := cfg.db.UpsertImportProfile(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not save import profile", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, importProfileFromDB(&dbProfile))
+}
+
+func (cfg *APIConfig) handleGetImportProfile(w http.ResponseWriter, r *http.Request) {
+	dbAccount, _ := cfg.getImportAccount(w, r)
+	if dbAccount == nil {
+		return
+	}
+
+	dbProfile, err := cfg.db.GetImportProfileByAccountID(r.Context(), dbAccount.ID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get import profile", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, importProfileFromDB(&dbProfile))
+}
+
+func (cfg *APIConfig) handleDeleteImportProfile(w http.ResponseWriter, r *http.Request) {
+	dbAccount, _ := cfg.getImportAccount(w, r)
+	if dbAccount == nil {
+		return
+	}
+
+	if err := cfg.db.DeleteImportProfile(r.Context(), dbAccount.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not delete import profile", err)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+// handleImportCSV logs every row of an uploaded CSV file to an account,
+// according to the import profile saved for that account.
+func (cfg *APIConfig) handleImportCSV(w http.ResponseWriter, r *http.Request) {
+	dbAccount, restriction := cfg.getImportAccount(w, r)
+	if dbAccount == nil {
+		return
+	}
+
+	dbProfile, err := cfg.db.GetImportProfileByAccountID(r.Context(), dbAccount.ID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "no import profile saved for account", err)
+		return
+	}
+
+	data, err := readImportUpload(w, r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	records, err := ingest.ParseCSV(bytes.NewReader(data), csvMappingFromProfile(&dbProfile))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "could not parse CSV file", err)
+		return
+	}
+
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	// DB TRANSACTION BLOCK
+	var report ImportReport
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		importer := newTxnImporter(tx, cfg.db, pathBudgetID, validatedUserID, restriction, dbAccount, dbProfile.DefaultCategory)
+		for _, record := range records {
+			if err := importer.importRecord(r.Context(), record); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not complete import", err)
+				return
+			}
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		report = importer.report
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// handleImportOFX logs the transactions of an uploaded OFX (or QFX) statement
+// to an account. Transactions already imported, as identified by their FITID,
+// are skipped. Where the file holds statements for several accounts,
+// the 'ofx_account_id' query parameter selects which is imported.
+func (cfg *APIConfig) handleImportOFX(w http.ResponseWriter, r *http.Request) {
+	dbAccount, restriction := cfg.getImportAccount(w, r)
+	if dbAccount == nil {
+		return
+	}
+
+	data, err := readImportUpload(w, r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	statements, err := ingest.ParseOFX(bytes.NewReader(data))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "could not parse OFX file", err)
+		return
+	}
+
+	var statement *ingest.Statement
+	ofxAccountID := r.URL.Query().Get("ofx_account_id")
+	for i := range statements {
+		if ofxAccountID == "" || statements[i].AccountID == ofxAccountID {
+			if statement != nil {
+				respondWithError(w, http.StatusBadRequest, "file contains several statements; specify one with the ofx_account_id query parameter", nil)
+				return
+			}
+			statement = &statements[i]
+		}
+	}
+	if statement == nil {
+		respondWithError(w, http.StatusBadRequest, "no matching statement found in OFX file", nil)
+		return
+	}
+
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	defaultCategory := r.URL.Query().Get("default_category")
+	if defaultCategory != "" {
+		if _, err := cfg.db.GetBudgetCategoryIDByName(r.Context(), db.GetBudgetCategoryIDByNameParams{
+			CategoryName: defaultCategory,
+			BudgetID:     pathBudgetID,
+		}); err != nil {
+			respondWithError(w, http.StatusBadRequest, "could not get default category by given name", err)
+			return
+		}
+	} else if dbProfile, err := cfg.db.GetImportProfileByAccountID(r.Context(), dbAccount.ID); err == nil {
+		defaultCategory = dbProfile.DefaultCategory
+	}
+
+	// DB TRANSACTION BLOCK
+	var report OFXImportReport
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		importer := newTxnImporter(tx, cfg.db, pathBudgetID, validatedUserID, restriction, dbAccount, defaultCategory)
+		for _, record := range statement.Records {
+			if err := importer.importRecord(r.Context(), record); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not complete import", err)
+				return
+			}
+		}
+		capital, err := importer.q.GetBudgetAccountCapital(r.Context(), dbAccount.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not calculate budget account capital", err)
+			return
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+
+		report.ImportReport = importer.report
+		report.AccountCapital = capital
+		if statement.LedgerBalance != nil {
+			difference := statement.LedgerBalance.Amount - capital
+			report.LedgerBalance = &statement.LedgerBalance.Amount
+			report.LedgerBalanceDate = &statement.LedgerBalance.AsOf
+			report.Difference = &difference
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+func csvMappingFromProfile(dbProfile *db.ImportProfile) ingest.CSVMapping {
+	delimiter, _ := utf8.DecodeRuneInString(dbProfile.Delimiter)
+	return ingest.CSVMapping{
+		HasHeader:      dbProfile.HasHeader,
+		Delimiter:      delimiter,
+		SkipRows:       int(dbProfile.SkipRows),
+		DateColumn:     dbProfile.DateColumn,
+		DateFormat:     dbProfile.DateFormat,
+		AmountColumn:   dbProfile.AmountColumn,
+		DebitColumn:    dbProfile.DebitColumn,
+		CreditColumn:   dbProfile.CreditColumn,
+		PayeeColumn:    dbProfile.PayeeColumn,
+		MemoColumn:     dbProfile.MemoColumn,
+		CategoryColumn: dbProfile.CategoryColumn,
+		SignConvention: dbProfile.SignConvention,
+	}
+}
+
+func importProfileFromDB(dbProfile *db.ImportProfile) ImportProfile {
+	return ImportProfile{
+		AccountID:       dbProfile.AccountID,
+		CreatedAt:       dbProfile.CreatedAt,
+		UpdatedAt:       dbProfile.UpdatedAt,
+		HasHeader:       dbProfile.HasHeader,
+		Delimiter:       dbProfile.Delimiter,
+		SkipRows:        dbProfile.SkipRows,
+		DateColumn:      dbProfile.DateColumn,
+		DateFormat:      dbProfile.DateFormat,
+		AmountColumn:    dbProfile.AmountColumn,
+		DebitColumn:     dbProfile.DebitColumn,
+		CreditColumn:    dbProfile.CreditColumn,
+		PayeeColumn:     dbProfile.PayeeColumn,
+		MemoColumn:      dbProfile.MemoColumn,
+		CategoryColumn:  dbProfile.CategoryColumn,
+		DefaultCategory: dbProfile.DefaultCategory,
+		SignConvention:  dbProfile.SignConvention,
+	}
+}
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/YouWantToPinch/pincher-api/internal/ingest"
+)
+
+// handleImportYNAB imports the register and budget exported by YNAB into a
+// budget. Either may be uploaded alone, as the 'register' and 'budget'
+// fields of a multipart form; or both within the zip archive YNAB exports.
+func (cfg *APIConfig) handleImportYNAB(w http.ResponseWriter, r *http.Request) {
+	opts := ingest.YNABOptions{
+		DateFormat: r.URL.Query().Get("date_format"),
+	}
+	if opts.DateFormat != "" {
+		if _, err := ingest.DateLayout(opts.DateFormat); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+
+	registerData, budgetData, err := readYNABUpload(w, r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	file := &ingest.QIFFile{}
+	if registerData != nil {
+		file, err = ingest.ParseYNABRegister(bytes.NewReader(registerData), opts)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "could not parse YNAB register", err)
+			return
+		}
+	}
+	var assignments []ingest.YNABAssignment
+	if budgetData != nil {
+		assignments, err = ingest.ParseYNABBudget(bytes.NewReader(budgetData))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "could not parse YNAB budget", err)
+			return
+		}
+		ingest.MarkYNABCreditCards(file, assignments)
+	}
+
+	cfg.importBudgetRegister(w, r, file, assignments)
+}
+
+// handleImportActual imports the transactions exported by Actual Budget
+// as CSV into a budget.
+func (cfg *APIConfig) handleImportActual(w http.ResponseWriter, r *http.Request) {
+	opts := ingest.ActualOptions{}
+	if incomeCategories := r.URL.Query().Get("income_categories"); incomeCategories != "" {
+		for _, name := range strings.Split(incomeCategories, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				opts.IncomeCategories = append(opts.IncomeCategories, name)
+			}
+		}
+	}
+
+	data, err := readImportUpload(w, r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	file, err := ingest.ParseActualCSV(bytes.NewReader(data), opts)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "could not parse Actual Budget export", err)
+		return
+	}
+
+	cfg.importBudgetRegister(w, r, file, nil)
+}
+
+// readYNABUpload returns the register and budget uploaded for a YNAB import,
+// either of which may be nil, though not both. An archive uploaded in their
+// place, as the body or the 'file' field of a form, is unpacked, and any
+// other file is taken to be a register.
+func readYNABUpload(w http.ResponseWriter, r *http.Request) (register, budget []byte, err error) {
+	var data []byte
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+		defer r.Body.Close()
+		if register, err = readFormFile(r, "register"); err != nil {
+			return nil, nil, err
+		}
+		if budget, err = readFormFile(r, "budget"); err != nil {
+			return nil, nil, err
+		}
+		if register != nil || budget != nil {
+			return register, budget, nil
+		}
+		// an archive may be uploaded as the file of any other import
+		if data, err = readFormFile(r, "file"); err != nil {
+			return nil, nil, err
+		}
+		if data == nil {
+			return nil, nil, fmt.Errorf("no register or budget file")
+		}
+	} else if data, err = readImportUpload(w, r); err != nil {
+		return nil, nil, err
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return ingest.ReadYNABExport(data)
+	}
+	return data, nil, nil
+}
+
+// readFormFile returns the contents of the named file of a multipart form,
+// or nil where the form has no such file.
+func readFormFile(r *http.Request, name string) ([]byte, error) {
+	file, _, err := r.FormFile(name)
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read uploaded file: %w", err)
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// importBudgetRegister imports the accounts and transactions read from the
+// export of another budgeting tool as those of a QIF file would be, followed
+// by any assignments, and responds with a report reconciling the balance of
+// each account affected.
+func (cfg *APIConfig) importBudgetRegister(w http.ResponseWriter, r *http.Request, file *ingest.QIFFile, assignments []ingest.YNABAssignment) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+
+	// DB TRANSACTION BLOCK
+	var report BudgetImportReport
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		before, err := getAccountBalances(r.Context(), q, pathBudgetID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not calculate budget account capital", err)
+			return
+		}
+
+		importer := newQIFImporter(tx, cfg.db, pathBudgetID, validatedUserID, restriction, "")
+		if err := importer.importFile(r.Context(), file); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not complete import", err)
+			return
+		}
+		assignmentReport, err := importAssignments(r.Context(), q, pathBudgetID, assignments, &importer.report.CreatedResources)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not complete import", err)
+			return
+		}
+
+		after, err := getAccountBalances(r.Context(), q, pathBudgetID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not calculate budget account capital", err)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		report = BudgetImportReport{
+			QIFImportReport: importer.report,
+			Assignments:     assignmentReport,
+			Reconciliation:  reconcileImport(file, before, after),
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// importAssignments sets the amount assigned to each category for each month
+// to that read from an imported budget, creating categories and their groups
+// where necessary. Payment categories are not created, as they belong to
+// credit card accounts. Errors returned are not specific to an assignment,
+// and should abort the import.
+func importAssignments(ctx context.Context, q *db.Queries, budgetID uuid.UUID, assignments []ingest.YNABAssignment, created *CreatedResources) (AssignmentImportReport, error) {
+	report := AssignmentImportReport{
+		Rows: []ImportRowReport{},
+	}
+	reject := func(line int, reason string) {
+		report.Rejected++
+		report.Rows = append(report.Rows, ImportRowReport{
+			Line:   line,
+			Status: importRejected,
+			Reason: reason,
+		})
+	}
+
+	for _, assignment := range assignments {
+		if assignment.Err != nil {
+			reject(assignment.Line, assignment.Err.Error())
+			continue
+		}
+		name := truncateName(assignment.Category, 50)
+
+		var categoryID uuid.UUID
+		var err error
+		if assignment.Group == ingest.YNABPaymentGroup {
+			categoryID, err = q.GetBudgetCategoryIDByName(ctx, db.GetBudgetCategoryIDByNameParams{
+				CategoryName: name,
+				BudgetID:     budgetID,
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				reject(assignment.Line, fmt.Sprintf("no credit card account named '%s'", name))
+				continue
+			}
+		} else {
+			categoryID, err = ensureCategory(ctx, q, budgetID, truncateName(assignment.Group, 50), name, created)
+		}
+		if err != nil {
+			return report, fmt.Errorf("could not get category '%s': %w", name, err)
+		}
+
+		month := time.Date(assignment.Month.Year(), assignment.Month.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if err := q.DeleteMonthAssignmentForCat(ctx, db.DeleteMonthAssignmentForCatParams{
+			Month:      month,
+			CategoryID: categoryID,
+		}); err != nil {
+			return report, err
+		}
+		if assignment.Assigned != 0 {
+			if _, err := q.AssignAmountToCategory(ctx, db.AssignAmountToCategoryParams{
+				MonthID:    month,
+				CategoryID: categoryID,
+				Amount:     assignment.Assigned,
+			}); err != nil {
+				return report, err
+			}
+		}
+		report.Accepted++
+	}
+	return report, nil
+}
+
+// accountBalance is the capital of an account at some point during an import.
+type accountBalance struct {
+	account db.Account
+	capital int64
+}
+
+func getAccountBalances(ctx context.Context, q *db.Queries, budgetID uuid.UUID) ([]accountBalance, error) {
+	dbAccounts, err := q.GetAccountsFromBudget(ctx, budgetID)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]accountBalance, 0, len(dbAccounts))
+	for _, dbAccount := range dbAccounts {
+		capital, err := q.GetBudgetAccountCapital(ctx, dbAccount.ID)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, accountBalance{account: dbAccount, capital: capital})
+	}
+	return balances, nil
+}
+
+// reconcileImport compares the balances of accounts before and after an
+// import, for each account listed by the file or otherwise affected by it.
+// Where the file lists an account, the change in its balance is compared
+// against the total of the transactions listed for it; any difference is
+// left by those rejected, or skipped as duplicates.
+func reconcileImport(file *ingest.QIFFile, before, after []accountBalance) []AccountReconciliation {
+	fileTotals := map[string]int64{}
+	for _, account := range file.Accounts {
+		var total int64
+		for _, txn := range account.Transactions {
+			if txn.Err == nil {
+				total += txn.Amount
+			}
+		}
+		fileTotals[truncateName(account.Name, 50)] += total
+	}
+	capitalBefore := map[uuid.UUID]int64{}
+	for _, balance := range before {
+		capitalBefore[balance.account.ID] = balance.capital
+	}
+
+	reconciliation := []AccountReconciliation{}
+	for _, balance := range after {
+		fileTotal, listed := fileTotals[balance.account.Name]
+		previous := capitalBefore[balance.account.ID]
+		if !listed && previous == balance.capital {
+			continue
+		}
+		entry := AccountReconciliation{
+			AccountID:     balance.account.ID,
+			Name:          balance.account.Name,
+			BalanceBefore: previous,
+			BalanceAfter:  balance.capital,
+		}
+		if listed {
+			difference := fileTotal - (balance.capital - previous)
+			entry.FileTotal = &fileTotal
+			entry.Difference = &difference
+		}
+		reconciliation = append(reconciliation, entry)
+	}
+	return reconciliation
+}
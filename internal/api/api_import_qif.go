@@ -0,0 +1,348 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/YouWantToPinch/pincher-api/internal/ingest"
+)
+
+// handleImportQIF imports the accounts, categories and transactions of an
+// uploaded QIF file into a budget. Accounts, payees, groups and categories
+// named by the file are created where they do not yet exist.
+func (cfg *APIConfig) handleImportQIF(w http.ResponseWriter, r *http.Request) {
+	opts := ingest.QIFOptions{}
+	switch r.URL.Query().Get("date_order") {
+	case "", "MDY":
+	case "DMY":
+		opts.DayFirst = true
+	default:
+		respondWithError(w, http.StatusBadRequest, "date_order must be either MDY or DMY", nil)
+		return
+	}
+
+	data, err := readImportUpload(w, r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	file, err := ingest.ParseQIF(bytes.NewReader(data), opts)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "could not parse QIF file", err)
+		return
+	}
+	for _, account := range file.Accounts {
+		if account.Name == "" {
+			account.Name = r.URL.Query().Get("account_name")
+			if account.Name == "" {
+				respondWithError(w, http.StatusBadRequest, "file does not name its account; specify one with the account_name query parameter", nil)
+				return
+			}
+		}
+	}
+
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	defaultCategory := r.URL.Query().Get("default_category")
+	if defaultCategory != "" {
+		if _, err := cfg.db.GetBudgetCategoryIDByName(r.Context(), db.GetBudgetCategoryIDByNameParams{
+			CategoryName: defaultCategory,
+			BudgetID:     pathBudgetID,
+		}); err != nil {
+			respondWithError(w, http.StatusBadRequest, "could not get default category by given name", err)
+			return
+		}
+	}
+
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+
+	// DB TRANSACTION BLOCK
+	var report QIFImportReport
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		importer := newQIFImporter(tx, cfg.db, pathBudgetID, validatedUserID, restriction, defaultCategory)
+		if err := importer.importFile(r.Context(), file); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not complete import", err)
+			return
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		report = importer.report
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// qifImporter imports a QIF file into a budget, logging the transactions
+// of each account with a txnImporter of its own.
+type qifImporter struct {
+	tx              pgx.Tx
+	q               *db.Queries
+	budgetID        uuid.UUID
+	loggerID        uuid.UUID
+	restriction     *db.MemberRestriction
+	defaultCategory string
+	// incomeCategories names the categories marked as income by the file.
+	// Money deposited to them is left to be assigned, rather than
+	// being assigned to a category of the same name.
+	incomeCategories map[string]bool
+	accounts         map[string]*db.Account
+	importers        map[string]*txnImporter
+	// transfers counts the transfers logged by this import by the counterpart
+	// the file is expected to list for the other account, so that the
+	// counterpart is not logged a second time.
+	transfers map[string]int
+	report    QIFImportReport
+}
+
+func newQIFImporter(tx pgx.Tx, q *db.Queries, budgetID, loggerID uuid.UUID, restriction *db.MemberRestriction, defaultCategory string) *qifImporter {
+	return &qifImporter{
+		tx:               tx,
+		q:                q.WithTx(tx),
+		budgetID:         budgetID,
+		loggerID:         loggerID,
+		restriction:      restriction,
+		defaultCategory:  defaultCategory,
+		incomeCategories: map[string]bool{},
+		accounts:         map[string]*db.Account{},
+		importers:        map[string]*txnImporter{},
+		transfers:        map[string]int{},
+		report: QIFImportReport{
+			Accounts: []ImportReport{},
+		},
+	}
+}
+
+func (qi *qifImporter) importFile(ctx context.Context, file *ingest.QIFFile) error {
+	dbAccounts, err := qi.q.GetAccountsFromBudget(ctx, qi.budgetID)
+	if err != nil {
+		return err
+	}
+	for _, dbAccount := range dbAccounts {
+		qi.accounts[dbAccount.Name] = &dbAccount
+	}
+
+	for _, category := range file.Categories {
+		if category.Income {
+			qi.incomeCategories[category.Name] = true
+			continue
+		}
+		group, name := ingest.SplitQIFCategory(category.Name)
+		if _, err := ensureCategory(ctx, qi.q, qi.budgetID, truncateName(group, 50), truncateName(name, 50), &qi.report.CreatedResources); err != nil {
+			return fmt.Errorf("could not create category '%s': %w", category.Name, err)
+		}
+	}
+
+	// accounts listed by the file are created before any that are
+	// only named by transfers, so that they are given the right type
+	for _, account := range file.Accounts {
+		if _, err := qi.ensureAccount(ctx, account.Name, account.Type, account.Description); err != nil {
+			return err
+		}
+	}
+	for _, account := range file.Accounts {
+		for _, txn := range account.Transactions {
+			transfers := []string{txn.Transfer}
+			for _, split := range txn.Splits {
+				transfers = append(transfers, split.Transfer)
+			}
+			for _, transfer := range transfers {
+				if transfer == "" {
+					continue
+				}
+				if _, err := qi.ensureAccount(ctx, transfer, "", ""); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, account := range file.Accounts {
+		dbAccount := qi.accounts[truncateName(account.Name, 50)]
+		qi.importers[dbAccount.Name] = newTxnImporter(qi.tx, qi.q, qi.budgetID, qi.loggerID, qi.restriction, dbAccount, qi.defaultCategory)
+	}
+	for _, account := range file.Accounts {
+		importer := qi.importers[truncateName(account.Name, 50)]
+		for _, txn := range account.Transactions {
+			if err := qi.importTxn(ctx, importer, txn); err != nil {
+				return err
+			}
+		}
+		qi.report.Accounts = append(qi.report.Accounts, importer.report)
+	}
+	return nil
+}
+
+// ensureAccount returns the budget account with the given name, creating it
+// where it does not yet exist. Asset, liability and investment accounts are
+// created off-budget, and credit card accounts as such; all others are
+// created on-budget.
+func (qi *qifImporter) ensureAccount(ctx context.Context, name, qifType, description string) (*db.Account, error) {
+	name = truncateName(name, 50)
+	if dbAccount, ok := qi.accounts[name]; ok {
+		return dbAccount, nil
+	}
+	accountType := "ON_BUDGET"
+	switch qifType {
+	case ingest.QIFAsset, ingest.QIFLiability, ingest.QIFInvestment:
+		accountType = "OFF_BUDGET"
+	case ingest.QIFCreditCard:
+		accountType = "CREDIT_CARD"
+	}
+	dbAccount, err := qi.q.AddAccount(ctx, db.AddAccountParams{
+		BudgetID:    qi.budgetID,
+		AccountType: accountType,
+		Name:        name,
+		Notes:       description,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create account '%s': %w", name, err)
+	}
+	if accountType == "CREDIT_CARD" {
+		if err := createPaymentCategory(ctx, qi.q, &dbAccount, &qi.report.CreatedResources); err != nil {
+			return nil, err
+		}
+	}
+	qi.accounts[name] = &dbAccount
+	qi.report.CreatedResources.Accounts = append(qi.report.CreatedResources.Accounts, name)
+	return &dbAccount, nil
+}
+
+// categoryKey returns the key under which an amount for the given QIF
+// category is logged, creating the category where necessary.
+func (qi *qifImporter) categoryKey(ctx context.Context, account *db.Account, category string, amount int64) (string, error) {
+	if account.AccountType == "OFF_BUDGET" || (amount > 0 && qi.incomeCategories[category]) {
+		return "UNCATEGORIZED", nil
+	}
+	if category == "" {
+		if qi.defaultCategory != "" {
+			return qi.defaultCategory, nil
+		}
+		return "UNCATEGORIZED", nil
+	}
+	group, name := ingest.SplitQIFCategory(category)
+	name = truncateName(name, 50)
+	if _, err := ensureCategory(ctx, qi.q, qi.budgetID, truncateName(group, 50), name, &qi.report.CreatedResources); err != nil {
+		return "", fmt.Errorf("could not create category '%s': %w", category, err)
+	}
+	return name, nil
+}
+
+// importTxn logs a QIF transaction to the account of the given importer.
+// Split transactions are logged as up to two transactions, one for money
+// entering the account and one for money leaving it, along with a transfer
+// for each split that transfers money to or from another account.
+func (qi *qifImporter) importTxn(ctx context.Context, importer *txnImporter, txn ingest.QIFTransaction) error {
+	if txn.Err != nil {
+		importer.reject(txn.Line, txn.Err.Error())
+		return nil
+	}
+	account := importer.account
+	if account.IsDeleted {
+		importer.reject(txn.Line, "cannot import transactions to a deleted account")
+		return nil
+	}
+
+	splits := txn.Splits
+	if len(splits) == 0 {
+		splits = []ingest.QIFSplit{{Category: txn.Category, Transfer: txn.Transfer, Amount: txn.Amount}}
+	}
+	deposits, withdrawals := map[string]int64{}, map[string]int64{}
+	var transfers []ingest.QIFSplit
+	for _, split := range splits {
+		split.Transfer = truncateName(split.Transfer, 50)
+		// opening balances are written as transfers from the account itself
+		if split.Transfer != "" && split.Transfer != account.Name {
+			transfers = append(transfers, split)
+			continue
+		}
+		key, err := qi.categoryKey(ctx, account, split.Category, split.Amount)
+		if err != nil {
+			return err
+		}
+		switch {
+		case split.Amount > 0:
+			deposits[key] += split.Amount
+		case split.Amount < 0:
+			withdrawals[key] += split.Amount
+		}
+	}
+
+	txnDate := txn.Date.Format("2006-01-02")
+	payee := truncateName(txn.Payee, 50)
+	if payee == "" {
+		payee = "Unknown Payee"
+	}
+	for _, amounts := range []map[string]int64{deposits, withdrawals} {
+		if len(amounts) == 0 {
+			continue
+		}
+		if _, err := importer.importTxn(ctx, txn.Line, "", UpsertTransactionRqSchema{
+			AccountName:     account.Name,
+			TransactionDate: txnDate,
+			PayeeName:       payee,
+			Notes:           txn.Memo,
+			Cleared:         txn.Cleared,
+			Amounts:         amounts,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, split := range transfers {
+		if key := transferKey(account.Name, split.Transfer, txnDate, split.Amount); qi.transfers[key] > 0 {
+			qi.transfers[key]--
+			importer.duplicate(txn.Line)
+			continue
+		}
+		notes := split.Memo
+		if notes == "" {
+			notes = txn.Memo
+		}
+		logged, err := importer.importTxn(ctx, txn.Line, "", UpsertTransactionRqSchema{
+			AccountName:         account.Name,
+			TransferAccountName: split.Transfer,
+			TransactionDate:     txnDate,
+			Notes:               notes,
+			Cleared:             txn.Cleared,
+			Amounts:             map[string]int64{"TRANSFER": split.Amount},
+		})
+		if err != nil {
+			return err
+		}
+		if logged == nil {
+			continue
+		}
+		qi.transfers[transferKey(split.Transfer, account.Name, txnDate, -split.Amount)]++
+		if other, ok := qi.importers[split.Transfer]; ok {
+			// the counterpart logged to the other account
+			// is not a duplicate of anything it imports
+			other.logged[duplicateKey(uuid.Nil, txnDate, -split.Amount)]++
+		}
+	}
+	return nil
+}
+
+func transferKey(accountName, transferAccountName, txnDate string, amount int64) string {
+	return fmt.Sprintf("%s|%s|%s|%d", accountName, transferAccountName, txnDate, amount)
+}
@@ -2,14 +2,19 @@ package api
 
 import (
 	"embed"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/YouWantToPinch/pincher-api/internal/auth"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -102,6 +107,14 @@ func (c *APITestClient) GetJSONFieldAsString(field string) (string, error) {
 	return "", fmt.Errorf("field retrieved from response was not of type string")
 }
 
+// JoinBudget has a manager invite a user to a budget with the given role,
+// and the user accept.
+func (c *APITestClient) JoinBudget(managerToken, budgetID, username, memberToken, memberRole string) {
+	c.Request(c.InviteMemberToBudget(managerToken, budgetID, username, memberRole), http.StatusCreated)
+	invitationID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.AcceptInvitation(memberToken, invitationID), http.StatusCreated)
+}
+
 func (c *APITestClient) GetJSONFieldAsInt64(field string) (int64, error) {
 	fieldRetrieved, err := c.GetJSONField(field)
 	if err != nil {
@@ -127,10 +140,16 @@ func (c *APITestClient) GetJSONFieldAsInt64(field string) (int64, error) {
 // initialize a Postgres testcontainer and
 // return an APITestClient for testing
 func doServerSetup(t *testing.T) *http.Server {
+	return &http.Server{Handler: SetupMux(doConfigSetup(t))}
+}
+
+// doConfigSetup returns the configuration of a server connected to a fresh
+// database, for tests which must run its background jobs themselves.
+func doConfigSetup(t *testing.T) *APIConfig {
 	pgdb := SetupPostgres(t)
 
 	t.Setenv("MIGRATE_ON_START", "true")
-	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_KEYS_DIR", t.TempDir())
 	t.Setenv("PLATFORM", "test")
 	slog.Error("DB_URL: " + pgdb.URI)
 	t.Setenv("DB_URL", pgdb.URI)
@@ -149,7 +168,7 @@ func doServerSetup(t *testing.T) *http.Server {
 		cfg.Pool.Close()
 	})
 
-	return &http.Server{Handler: SetupMux(cfg)}
+	return cfg
 }
 
 // Check CRUD for each resource in as much isolation as possible.
@@ -376,6 +395,7 @@ func Test_BuildOrgDoAuthChecks(t *testing.T) {
 	c.Request(c.LoginUser(username2, password2), http.StatusOK)
 	jwt2, _ := c.GetJSONFieldAsString("token")
 	c.Request(c.LoginUser(username3, password3), http.StatusOK)
+	jwt3, _ := c.GetJSONFieldAsString("token")
 	c.Request(c.LoginUser(username4, password4), http.StatusOK)
 	jwt4, _ := c.GetJSONFieldAsString("token")
 
@@ -385,11 +405,11 @@ func Test_BuildOrgDoAuthChecks(t *testing.T) {
 	c.Request(c.CreateBudget(jwt1, "Personal", "user1's budget for personal finance."), http.StatusCreated)
 
 	// Try adding user2 as ADMIN using user4 (not in budget), then as MANAGER. Both should fail.
-	c.Request(c.AssignMemberToBudget(jwt4, budget1ID, username2, roleAdmin), http.StatusForbidden)
-	c.Request(c.AssignMemberToBudget(jwt4, budget1ID, username2, roleManager), http.StatusForbidden)
+	c.Request(c.InviteMemberToBudget(jwt4, budget1ID, username2, roleAdmin), http.StatusForbidden)
+	c.Request(c.InviteMemberToBudget(jwt4, budget1ID, username2, roleManager), http.StatusForbidden)
 
 	// Add user4 to Webflyx Org as user1 ADMIN, with role: VIEWER
-	c.Request(c.AssignMemberToBudget(jwt1, budget1ID, username4, roleViewer), http.StatusCreated)
+	c.JoinBudget(jwt1, budget1ID, username4, jwt4, roleViewer)
 
 	// user4 should be assigned to only 1 budget
 	c.Request(c.GetUserBudgets(jwt4), http.StatusOK)
@@ -397,11 +417,11 @@ func Test_BuildOrgDoAuthChecks(t *testing.T) {
 	assert.Len(t, gotBudgets.([]any), 1)
 
 	// As user4, try adding user2 as a MANAGER. Should fail auth check.
-	c.Request(c.AssignMemberToBudget(jwt4, budget1ID, username2, roleManager), http.StatusForbidden)
+	c.Request(c.InviteMemberToBudget(jwt4, budget1ID, username2, roleManager), http.StatusForbidden)
 
 	// As user1 ADMIN, add user2 and user3 as MANAGER and CONTRIBUTOR, respectively.
-	c.Request(c.AssignMemberToBudget(jwt1, budget1ID, username2, roleManager), http.StatusCreated)
-	c.Request(c.AssignMemberToBudget(jwt1, budget1ID, username3, roleContributor), http.StatusCreated)
+	c.JoinBudget(jwt1, budget1ID, username2, jwt2, roleManager)
+	c.JoinBudget(jwt1, budget1ID, username3, jwt3, roleContributor)
 
 	// Attemc.deletion of Webflyx Org budget as user2. Should fail; only admin can do it.
 	c.Request(c.DeleteUserBudget(jwt2, budget1ID), http.StatusForbidden)
@@ -461,9 +481,9 @@ func Test_BuildOrgLogTransaction(t *testing.T) {
 	// user1 ADMIN: Creating Webflyx Org budget & assigning u2, u3, u4 as MANAGER, CONTRIBUTOR, VIEWER.
 	c.Request(c.CreateBudget(jwt1, "Webflyx Org", "For budgeting Webflyx Org financial resources and tracking expenses."), http.StatusCreated)
 	budgetID, _ := c.GetJSONFieldAsString("id")
-	c.Request(c.AssignMemberToBudget(jwt1, budgetID, username2, roleManager), http.StatusCreated)
-	c.Request(c.AssignMemberToBudget(jwt1, budgetID, username3, roleContributor), http.StatusCreated)
-	c.Request(c.AssignMemberToBudget(jwt1, budgetID, username4, roleViewer), http.StatusCreated)
+	c.JoinBudget(jwt1, budgetID, username2, jwt2, roleManager)
+	c.JoinBudget(jwt1, budgetID, username3, jwt3, roleContributor)
+	c.JoinBudget(jwt1, budgetID, username4, jwt4, roleViewer)
 
 	// user2 MANAGER: Adding account, groups, & categories.
 	c.Request(c.CreateBudgetAccount(jwt2, budgetID, "ON_BUDGET", "Saved Org Funds", "Represents a bank account holding business capital."), http.StatusCreated)
@@ -659,6 +679,9 @@ func Test_CategoryMoneyAssignment(t *testing.T) {
 	c.Request(c.GetMonthReport(jwt1, budget1ID, dateOctober), http.StatusOK)
 	budgetTotalBalance, _ := c.GetJSONFieldAsInt64("balance")
 	assert.Equal(t, int64(1000), (budgetTotalCapital - budgetTotalBalance))
+	c.Request(c.GetMonthReport(jwt1, budget1ID, dateOctober), http.StatusOK)
+	readyToAssign, _ := c.GetJSONFieldAsInt64("ready_to_assign")
+	assert.Equal(t, int64(1000), readyToAssign)
 
 	// NOVEMBER 2025 ACTIVITIES
 	// deposit NO more money into the checking account.
@@ -685,4 +708,1888 @@ func Test_CategoryMoneyAssignment(t *testing.T) {
 	c.Request(c.GetMonthReport(jwt1, budget1ID, dateNovember), http.StatusOK)
 	budgetTotalBalance, _ = c.GetJSONFieldAsInt64("balance")
 	assert.Equal(t, int64(-1000), (budgetTotalCapital - budgetTotalBalance))
+	c.Request(c.GetMonthReport(jwt1, budget1ID, dateNovember), http.StatusOK)
+	readyToAssign, _ = c.GetJSONFieldAsInt64("ready_to_assign")
+	assert.Equal(t, int64(-1000), readyToAssign)
+}
+
+// Import a CSV bank export to an account according to its saved import profile.
+// Then, ensure that re-importing an overlapping export does not duplicate any transactions,
+// while rows legitimately repeated within one export are kept.
+func Test_ImportCSV(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Personal Budget", "For personal accounting (user1)."), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	accountID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateGroup(jwt1, budgetID, "Spending", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Spending", "Groceries", ""), http.StatusCreated)
+
+	// importing without a saved profile is rejected
+	c.Request(c.ImportFile(jwt1, budgetID, accountID, "csv", "Date,Description,Amount\n"), http.StatusBadRequest)
+
+	c.Request(c.SaveImportProfile(jwt1, budgetID, accountID, map[string]any{
+		"has_header":       true,
+		"date_column":      "Date",
+		"date_format":      "MM/DD/YYYY",
+		"amount_column":    "Amount",
+		"payee_column":     "Description",
+		"default_category": "Groceries",
+	}), http.StatusOK)
+
+	export := "Date,Description,Amount\n" +
+		"09/01/2025,Employer,\"1,000.00\"\n" +
+		"09/02/2025,Corner Market,-12.50\n" +
+		"09/02/2025,Corner Market,-12.50\n" +
+		"09/31/2025,Corner Market,-5.00\n"
+	c.Request(c.ImportFile(jwt1, budgetID, accountID, "csv", export), http.StatusOK)
+	accepted, _ := c.GetJSONFieldAsInt64("accepted")
+	assert.Equal(t, int64(3), accepted)
+
+	c.Request(c.ImportFile(jwt1, budgetID, accountID, "csv", export+"09/03/2025,Corner Market,-7.25\n"), http.StatusOK)
+	accepted, _ = c.GetJSONFieldAsInt64("accepted")
+	assert.Equal(t, int64(1), accepted)
+	c.Request(c.ImportFile(jwt1, budgetID, accountID, "csv", export), http.StatusOK)
+	duplicates, _ := c.GetJSONFieldAsInt64("duplicates")
+	assert.Equal(t, int64(3), duplicates)
+	rejected, _ := c.GetJSONFieldAsInt64("rejected")
+	assert.Equal(t, int64(1), rejected)
+
+	c.Request(c.GetBudgetCapital(jwt1, budgetID, accountID), http.StatusOK)
+	capital, _ := c.GetJSONFieldAsInt64("capital")
+	assert.Equal(t, int64(100000-1250-1250-725), capital)
+}
+
+func Test_ImportOFX(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Personal Budget", "For personal accounting (user1)."), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	accountID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateGroup(jwt1, budgetID, "Spending", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Spending", "Groceries", ""), http.StatusCreated)
+
+	statement := func(transactions, balance string) string {
+		return "OFXHEADER:100\nDATA:OFXSGML\nVERSION:102\n\n" +
+			"<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>USD\n" +
+			"<BANKACCTFROM><ACCTID>000111222</BANKACCTFROM>\n" +
+			"<BANKTRANLIST>" + transactions + "</BANKTRANLIST>\n" +
+			"<LEDGERBAL><BALAMT>" + balance + "<DTASOF>20250905</LEDGERBAL>\n" +
+			"</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>\n"
+	}
+	paycheck := "<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20250901<TRNAMT>1000.00<FITID>A1<NAME>Employer</STMTTRN>\n"
+	groceries := "<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20250902<TRNAMT>-12.50<FITID>A2<NAME>Corner Market</STMTTRN>\n"
+	moreGroceries := "<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20250902<TRNAMT>-12.50<FITID>A3<NAME>Corner Market</STMTTRN>\n"
+
+	c.Request(c.ImportFile(jwt1, budgetID, accountID, "ofx?default_category=Groceries",
+		statement(paycheck+groceries, "987.50")), http.StatusOK)
+	accepted, _ := c.GetJSONFieldAsInt64("accepted")
+	assert.Equal(t, int64(2), accepted)
+	difference, _ := c.GetJSONFieldAsInt64("difference")
+	assert.Equal(t, int64(0), difference)
+
+	// an overlapping statement only imports transactions not seen before,
+	// even where they match the date, payee and amount of another
+	c.Request(c.ImportFile(jwt1, budgetID, accountID, "ofx?default_category=Groceries",
+		statement(paycheck+groceries+moreGroceries, "975.00")), http.StatusOK)
+	accepted, _ = c.GetJSONFieldAsInt64("accepted")
+	assert.Equal(t, int64(1), accepted)
+	duplicates, _ := c.GetJSONFieldAsInt64("duplicates")
+	assert.Equal(t, int64(2), duplicates)
+	capital, _ := c.GetJSONFieldAsInt64("account_capital")
+	assert.Equal(t, int64(100000-1250-1250), capital)
+	ledgerBalance, _ := c.GetJSONFieldAsInt64("ledger_balance")
+	assert.Equal(t, int64(97500), ledgerBalance)
+
+	// transactions with an ID are imported alongside those logged by hand,
+	// and only skipped once imported under that ID
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", "2025-09-03", "Cafe", "", false, map[string]int64{"Groceries": -450}), http.StatusCreated)
+	coffee := "<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20250903<TRNAMT>-4.50<FITID>A4<NAME>Cafe</STMTTRN>\n"
+	c.Request(c.ImportFile(jwt1, budgetID, accountID, "ofx?default_category=Groceries",
+		statement(coffee, "966.00")), http.StatusOK)
+	accepted, _ = c.GetJSONFieldAsInt64("accepted")
+	assert.Equal(t, int64(1), accepted)
+	c.Request(c.ImportFile(jwt1, budgetID, accountID, "ofx?default_category=Groceries",
+		statement(coffee, "966.00")), http.StatusOK)
+	duplicates, _ = c.GetJSONFieldAsInt64("duplicates")
+	assert.Equal(t, int64(1), duplicates)
+
+	c.Request(c.ImportFile(jwt1, budgetID, accountID, "ofx", "not an OFX file"), http.StatusBadRequest)
+}
+
+func Test_ImportQIF(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Personal Budget", "For personal accounting (user1)."), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+
+	export := "!Type:Cat\nNSalary\nI\n^\nNFood:Groceries\nE\n^\n" +
+		"!Account\nNChecking\nTBank\n^\n!Type:Bank\n" +
+		"D9/1'25\nT1,000.00\nPEmployer\nLSalary\n^\n" +
+		"D9/2'25\nT-60.00\nPCorner Market\nSFood:Groceries\n$-40.00\nS[Savings]\n$-20.00\n^\n" +
+		"D9/3'25\nT-100.00\nL[Savings]\n^\n" +
+		"!Account\nNSavings\nTBank\n^\n!Type:Bank\n" +
+		"D9/3'25\nT100.00\nL[Checking]\n^\n"
+
+	var report QIFImportReport
+	c.Request(c.ImportBudgetFile(jwt1, budgetID, "qif", export), http.StatusOK)
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&report))
+	assert.ElementsMatch(t, []string{"Checking", "Savings"}, report.CreatedResources.Accounts)
+	assert.ElementsMatch(t, []string{"Food"}, report.CreatedResources.Groups)
+	assert.ElementsMatch(t, []string{"Groceries"}, report.CreatedResources.Categories)
+	require.Len(t, report.Accounts, 2)
+	assert.Equal(t, 4, report.Accounts[0].Accepted)
+	// the counterpart of the transfer from checking is not logged again
+	assert.Equal(t, 0, report.Accounts[1].Accepted)
+	assert.Equal(t, 1, report.Accounts[1].Duplicates)
+
+	c.Request(c.GetBudgetCapital(jwt1, budgetID, report.Accounts[0].AccountID.String()), http.StatusOK)
+	capital, _ := c.GetJSONFieldAsInt64("capital")
+	assert.Equal(t, int64(100000-6000-10000), capital)
+	c.Request(c.GetBudgetCapital(jwt1, budgetID, report.Accounts[1].AccountID.String()), http.StatusOK)
+	capital, _ = c.GetJSONFieldAsInt64("capital")
+	assert.Equal(t, int64(2000+10000), capital)
+
+	// importing the same file again logs nothing
+	c.Request(c.ImportBudgetFile(jwt1, budgetID, "qif", export), http.StatusOK)
+	report = QIFImportReport{}
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&report))
+	assert.Empty(t, report.CreatedResources.Accounts)
+	assert.Equal(t, 0, report.Accounts[0].Accepted+report.Accounts[1].Accepted)
+
+	c.Request(c.ImportBudgetFile(jwt1, budgetID, "qif", "!Type:Bank\nD9/1'25\nT5.00\nPGift\n^\n"), http.StatusBadRequest)
+}
+
+func Test_ReconcileAccount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Personal Budget", "For personal accounting (user1)."), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	accountID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateGroup(jwt1, budgetID, "Spending", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Spending", "Groceries", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Employer", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Corner Market", ""), http.StatusCreated)
+
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", "2025-09-01", "Employer", "", true, map[string]int64{"UNCATEGORIZED": 100000}), http.StatusCreated)
+	paycheckID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", "2025-09-02", "Corner Market", "", true, map[string]int64{"Groceries": -2500}), http.StatusCreated)
+	// neither uncleared transactions, nor those after the statement date, count toward the cleared balance
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", "2025-09-03", "Corner Market", "", false, map[string]int64{"Groceries": -1000}), http.StatusCreated)
+	unclearedID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", "2025-10-01", "Corner Market", "", true, map[string]int64{"Groceries": -1000}), http.StatusCreated)
+
+	// a mismatched statement is reported, but not reconciled
+	c.Request(c.ReconcileAccount(jwt1, budgetID, accountID, "2025-09-30", 98000, false), http.StatusOK)
+	difference, _ := c.GetJSONFieldAsInt64("difference")
+	assert.Equal(t, int64(98000-97500), difference)
+	c.Request(c.UpdateTransaction(jwt1, budgetID, paycheckID, "Checking", "", "2025-09-01", "Employer", "Pay", true, map[string]int64{"UNCATEGORIZED": 100000}), http.StatusNoContent)
+
+	// a matching statement locks the transactions reconciled
+	c.Request(c.ReconcileAccount(jwt1, budgetID, accountID, "2025-09-30", 97500, false), http.StatusOK)
+	locked, _ := c.GetJSONFieldAsInt64("transactions_locked")
+	assert.Equal(t, int64(2), locked)
+	c.Request(c.UpdateTransaction(jwt1, budgetID, paycheckID, "Checking", "", "2025-09-01", "Employer", "", true, map[string]int64{"UNCATEGORIZED": 100000}), http.StatusConflict)
+	c.Request(c.DeleteTransaction(jwt1, budgetID, paycheckID), http.StatusConflict)
+	// nor may cleared transactions be dated within the period reconciled
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", "2025-09-15", "Corner Market", "", true, map[string]int64{"Groceries": -500}), http.StatusConflict)
+	c.Request(c.UpdateTransaction(jwt1, budgetID, unclearedID, "Checking", "", "2025-09-03", "Corner Market", "", true, map[string]int64{"Groceries": -1000}), http.StatusConflict)
+	c.Request(c.DeleteTransaction(jwt1, budgetID, unclearedID), http.StatusNoContent)
+
+	// a mismatched statement may be reconciled with an adjustment
+	c.Request(c.ReconcileAccount(jwt1, budgetID, accountID, "2025-10-31", 96700, true), http.StatusOK)
+	difference, _ = c.GetJSONFieldAsInt64("difference")
+	assert.Equal(t, int64(96700-96500), difference)
+	adjustmentID, _ := c.GetJSONFieldAsString("reconciliation.adjustment_transaction_id")
+	assert.NotEmpty(t, adjustmentID)
+
+	c.Request(c.GetBudgetCapital(jwt1, budgetID, accountID), http.StatusOK)
+	capital, _ := c.GetJSONFieldAsInt64("capital")
+	assert.Equal(t, int64(96700), capital)
+
+	// only statements reconciled are recorded in the audit log
+	c.Request(c.GetAuditLog(jwt1, budgetID, "?resource_type=RECONCILIATION"), http.StatusOK)
+	entries, _ := c.GetJSONField("data")
+	assert.Len(t, entries.([]any), 2)
+}
+
+// Set a goal of each type on budget categories, then ensure that month reports
+// include the amount needed toward each goal, and how much of it is underfunded.
+func Test_CategoryGoals(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Personal Budget", "For personal accounting (user1)."), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Employer", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Corner Market", ""), http.StatusCreated)
+
+	c.Request(c.CreateCategory(jwt1, budgetID, "", "Rent", ""), http.StatusCreated)
+	rentID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateCategory(jwt1, budgetID, "", "Vacation", ""), http.StatusCreated)
+	vacationID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateCategory(jwt1, budgetID, "", "Groceries", ""), http.StatusCreated)
+	groceriesID, _ := c.GetJSONFieldAsString("id")
+
+	c.Request(c.SetCategoryGoal(jwt1, budgetID, rentID, "MONTHLY_FUNDING", 100000, ""), http.StatusOK)
+	c.Request(c.SetCategoryGoal(jwt1, budgetID, vacationID, "TARGET_BALANCE_BY_DATE", 30000, "2025-12-01"), http.StatusOK)
+	c.Request(c.SetCategoryGoal(jwt1, budgetID, groceriesID, "MONTHLY_SPENDING", 40000, ""), http.StatusOK)
+	c.Request(c.SetCategoryGoal(jwt1, budgetID, groceriesID, "TARGET_BALANCE_BY_DATE", 40000, ""), http.StatusBadRequest)
+
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Employer", "", true, map[string]int64{"UNCATEGORIZED": 200000}), http.StatusCreated)
+	c.Request(c.AssignMoneyToCategory(jwt1, budgetID, dateSeptember, "Rent", 40000), http.StatusOK)
+	c.Request(c.AssignMoneyToCategory(jwt1, budgetID, dateSeptember, "Vacation", 10000), http.StatusOK)
+	c.Request(c.AssignMoneyToCategory(jwt1, budgetID, dateSeptember, "Groceries", 40000), http.StatusOK)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Corner Market", "", true, map[string]int64{"Groceries": -15000}), http.StatusCreated)
+
+	checkGoal := func(monthID, categoryID string, wantNeeded, wantUnderfunded int64) {
+		c.Request(c.GetMonthCategoryReport(jwt1, budgetID, monthID, categoryID), http.StatusOK)
+		needed, _ := c.GetJSONFieldAsInt64("needed")
+		assert.Equal(t, wantNeeded, needed)
+		c.Request(c.GetMonthCategoryReport(jwt1, budgetID, monthID, categoryID), http.StatusOK)
+		underfunded, _ := c.GetJSONFieldAsInt64("underfunded")
+		assert.Equal(t, wantUnderfunded, underfunded)
+	}
+
+	// SEPTEMBER: 4 months remain to save for the vacation, this one included
+	checkGoal(dateSeptember, rentID, 100000, 60000)
+	checkGoal(dateSeptember, vacationID, 7500, 0)
+	checkGoal(dateSeptember, groceriesID, 40000, 0)
+
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateSeptember, rentID), http.StatusOK)
+	percentComplete, _ := c.GetJSONFieldAsInt64("goal.percent_complete")
+	assert.Equal(t, int64(40), percentComplete)
+
+	// OCTOBER: what is left of the groceries is only refilled up to the target
+	checkGoal(dateOctober, rentID, 100000, 100000)
+	checkGoal(dateOctober, vacationID, 6667, 6667)
+	checkGoal(dateOctober, groceriesID, 15000, 15000)
+}
+
+// Assign money to every category at once by several strategies, ensuring
+// that dry runs only preview their changes.
+func Test_AutoAssign(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Personal Budget", "For personal accounting (user1)."), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Employer", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Corner Market", ""), http.StatusCreated)
+
+	c.Request(c.CreateCategory(jwt1, budgetID, "", "Rent", ""), http.StatusCreated)
+	rentID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateCategory(jwt1, budgetID, "", "Groceries", ""), http.StatusCreated)
+	groceriesID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.SetCategoryGoal(jwt1, budgetID, rentID, "MONTHLY_FUNDING", 100000, ""), http.StatusOK)
+
+	// SEPTEMBER: fund the rent goal, and spend on groceries
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Employer", "", true, map[string]int64{"UNCATEGORIZED": 300000}), http.StatusCreated)
+	c.Request(c.AutoAssign(jwt1, budgetID, dateSeptember, "FUND_UNDERFUNDED", 0, true), http.StatusOK)
+	totalChange, _ := c.GetJSONFieldAsInt64("total_change")
+	assert.Equal(t, int64(100000), totalChange)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateSeptember, rentID), http.StatusOK)
+	assigned, _ := c.GetJSONFieldAsInt64("assigned")
+	assert.Equal(t, int64(0), assigned)
+
+	c.Request(c.AutoAssign(jwt1, budgetID, dateSeptember, "FUND_UNDERFUNDED", 0, false), http.StatusOK)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateSeptember, rentID), http.StatusOK)
+	assigned, _ = c.GetJSONFieldAsInt64("assigned")
+	assert.Equal(t, int64(100000), assigned)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Corner Market", "", true, map[string]int64{"Groceries": -32000}), http.StatusCreated)
+
+	// OCTOBER: repeat what was assigned, then cover last month's spending
+	c.Request(c.AutoAssign(jwt1, budgetID, dateOctober, "LAST_MONTH_ASSIGNED", 0, false), http.StatusOK)
+	c.Request(c.AutoAssign(jwt1, budgetID, dateOctober, "LAST_MONTH_SPENT", 0, false), http.StatusOK)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateOctober, groceriesID), http.StatusOK)
+	assigned, _ = c.GetJSONFieldAsInt64("assigned")
+	assert.Equal(t, int64(32000), assigned)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateOctober, rentID), http.StatusOK)
+	assigned, _ = c.GetJSONFieldAsInt64("assigned")
+	assert.Equal(t, int64(100000), assigned)
+
+	// NOVEMBER: average two months of grocery spending, then undo everything
+	c.Request(c.AutoAssign(jwt1, budgetID, dateNovember, "AVERAGE_SPENT", 2, false), http.StatusOK)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateNovember, groceriesID), http.StatusOK)
+	assigned, _ = c.GetJSONFieldAsInt64("assigned")
+	assert.Equal(t, int64(16000), assigned)
+
+	c.Request(c.AutoAssign(jwt1, budgetID, dateNovember, "RESET", 0, false), http.StatusOK)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateNovember, groceriesID), http.StatusOK)
+	assigned, _ = c.GetJSONFieldAsInt64("assigned")
+	assert.Equal(t, int64(0), assigned)
+
+	c.Request(c.AutoAssign(jwt1, budgetID, dateNovember, "DOUBLE_IT", 0, false), http.StatusBadRequest)
+}
+
+func Test_CreditCardAccounts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Personal Budget", "For personal accounting (user1)."), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "CREDIT_CARD", "Visa", "Reflects my credit card."), http.StatusCreated)
+	paymentCategoryID, _ := c.GetJSONFieldAsString("payment_category_id")
+	assert.NotEmpty(t, paymentCategoryID)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "LOAN", "Mortgage", ""), http.StatusBadRequest)
+
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Employer", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Corner Market", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "", "Groceries", ""), http.StatusCreated)
+	groceriesID, _ := c.GetJSONFieldAsString("id")
+
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Employer", "", true, map[string]int64{"UNCATEGORIZED": 100000}), http.StatusCreated)
+	c.Request(c.AssignMoneyToCategory(jwt1, budgetID, dateSeptember, "Groceries", 20000), http.StatusOK)
+
+	// spending on the card moves money from groceries into the payment category
+	c.Request(c.LogTransaction(jwt1, budgetID, "Visa", "", dateSeptember, "Corner Market", "", true, map[string]int64{"Groceries": -15000}), http.StatusCreated)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateSeptember, groceriesID), http.StatusOK)
+	balance, _ := c.GetJSONFieldAsInt64("balance")
+	assert.Equal(t, int64(5000), balance)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateSeptember, paymentCategoryID), http.StatusOK)
+	balance, _ = c.GetJSONFieldAsInt64("balance")
+	assert.Equal(t, int64(15000), balance)
+
+	// paying the card from checking draws the payment category down
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "Visa", dateSeptember, "TRANSFER", "Pay off credit card", true, map[string]int64{"TRANSFER": -10000}), http.StatusCreated)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateSeptember, paymentCategoryID), http.StatusOK)
+	balance, _ = c.GetJSONFieldAsInt64("balance")
+	assert.Equal(t, int64(5000), balance)
+
+	c.Request(c.GetMonthReport(jwt1, budgetID, dateSeptember), http.StatusOK)
+	readyToAssign, _ := c.GetJSONFieldAsInt64("ready_to_assign")
+	assert.Equal(t, int64(80000), readyToAssign)
+}
+
+func Test_OverspendingRules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Personal Budget", "For personal accounting (user1)."), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.SetBudgetOverspendingRule(jwt1, budgetID, "BUDGET"), http.StatusBadRequest)
+	c.Request(c.SetBudgetOverspendingRule(jwt1, budgetID, "RESET"), http.StatusNoContent)
+
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "CREDIT_CARD", "Visa", ""), http.StatusCreated)
+	paymentCategoryID, _ := c.GetJSONFieldAsString("payment_category_id")
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Employer", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Corner Market", ""), http.StatusCreated)
+
+	c.Request(c.CreateCategory(jwt1, budgetID, "", "Groceries", ""), http.StatusCreated)
+	groceriesID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateCategory(jwt1, budgetID, "", "Dining", ""), http.StatusCreated)
+	diningID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateCategory(jwt1, budgetID, "", "Gifts", ""), http.StatusCreated)
+	giftsID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.SetCategoryOverspendingRule(jwt1, budgetID, diningID, "CARRY"), http.StatusNoContent)
+
+	// SEPTEMBER: overspend in cash, and on the card
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Employer", "", true, map[string]int64{"UNCATEGORIZED": 100000}), http.StatusCreated)
+	c.Request(c.AssignMoneyToCategory(jwt1, budgetID, dateSeptember, "Groceries", 10000), http.StatusOK)
+	c.Request(c.AssignMoneyToCategory(jwt1, budgetID, dateSeptember, "Gifts", 1000), http.StatusOK)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Corner Market", "", true, map[string]int64{"Groceries": -15000}), http.StatusCreated)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Corner Market", "", true, map[string]int64{"Dining": -2000}), http.StatusCreated)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Visa", "", dateSeptember, "Corner Market", "", true, map[string]int64{"Gifts": -3000}), http.StatusCreated)
+
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateSeptember, groceriesID), http.StatusOK)
+	isOverspent, _ := c.GetJSONField("is_overspent")
+	assert.Equal(t, true, isOverspent)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateSeptember, groceriesID), http.StatusOK)
+	overspent, _ := c.GetJSONFieldAsInt64("overspent")
+	assert.Equal(t, int64(5000), overspent)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateSeptember, giftsID), http.StatusOK)
+	creditOverspent, _ := c.GetJSONFieldAsInt64("credit_overspent")
+	assert.Equal(t, int64(2000), creditOverspent)
+	// only what was covered by the category moves into the payment category
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateSeptember, paymentCategoryID), http.StatusOK)
+	balance, _ := c.GetJSONFieldAsInt64("balance")
+	assert.Equal(t, int64(1000), balance)
+	c.Request(c.GetMonthReport(jwt1, budgetID, dateSeptember), http.StatusOK)
+	readyToAssign, _ := c.GetJSONFieldAsInt64("ready_to_assign")
+	assert.Equal(t, int64(89000), readyToAssign)
+
+	// OCTOBER: reset categories start from zero, at the cost of cash overspending
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateOctober, groceriesID), http.StatusOK)
+	balance, _ = c.GetJSONFieldAsInt64("balance")
+	assert.Equal(t, int64(0), balance)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateOctober, giftsID), http.StatusOK)
+	balance, _ = c.GetJSONFieldAsInt64("balance")
+	assert.Equal(t, int64(0), balance)
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateOctober, diningID), http.StatusOK)
+	balance, _ = c.GetJSONFieldAsInt64("balance")
+	assert.Equal(t, int64(-2000), balance)
+	c.Request(c.GetMonthReport(jwt1, budgetID, dateOctober), http.StatusOK)
+	readyToAssign, _ = c.GetJSONFieldAsInt64("ready_to_assign")
+	assert.Equal(t, int64(84000), readyToAssign)
+}
+
+// Overspend in cash from a RESET category of a budget which otherwise carries
+// overspending, then ensure that the overspending is taken once from the money
+// ready to assign the month after, and never again.
+func Test_ResetOverspendingLowersReadyToAssign(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Personal Budget", "For personal accounting (user1)."), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Employer", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Corner Market", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "", "Groceries", ""), http.StatusCreated)
+	groceriesID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.SetCategoryOverspendingRule(jwt1, budgetID, groceriesID, "RESET"), http.StatusNoContent)
+
+	readyToAssignIn := func(month string) int64 {
+		c.Request(c.GetMonthReport(jwt1, budgetID, month), http.StatusOK)
+		readyToAssign, _ := c.GetJSONFieldAsInt64("ready_to_assign")
+		return readyToAssign
+	}
+
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Employer", "", true, map[string]int64{"UNCATEGORIZED": 50000}), http.StatusCreated)
+	c.Request(c.AssignMoneyToCategory(jwt1, budgetID, dateSeptember, "Groceries", 10000), http.StatusOK)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Corner Market", "", true, map[string]int64{"Groceries": -12500}), http.StatusCreated)
+	assert.Equal(t, int64(40000), readyToAssignIn(dateSeptember))
+
+	c.Request(c.GetMonthCategoryReport(jwt1, budgetID, dateOctober, groceriesID), http.StatusOK)
+	balance, _ := c.GetJSONFieldAsInt64("balance")
+	assert.Equal(t, int64(0), balance)
+	assert.Equal(t, int64(40000-2500), readyToAssignIn(dateOctober))
+	assert.Equal(t, int64(40000-2500), readyToAssignIn(dateNovember))
+}
+
+func Test_APIKeys(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Personal Budget", "For personal accounting (user1)."), http.StatusCreated)
+	budgetID1, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudget(jwt1, "Side Business", "For business accounting (user1)."), http.StatusCreated)
+	budgetID2, _ := c.GetJSONFieldAsString("id")
+
+	c.Request(c.CreateAPIKey(jwt1, "Reporting Script", []string{budgetID1}, "OWNER", ""), http.StatusBadRequest)
+	c.Request(c.CreateAPIKey(jwt1, "Reporting Script", []string{budgetID1}, roleViewer, "2000-01-01"), http.StatusBadRequest)
+	c.Request(c.CreateAPIKey(jwt1, "Reporting Script", []string{budgetID1}, roleViewer, ""), http.StatusCreated)
+	apiKey, _ := c.GetJSONFieldAsString("key")
+	c.Request(c.GetAPIKeys(jwt1), http.StatusOK)
+	apiKeys, _ := c.GetJSONField("data")
+	assert.Len(t, apiKeys.([]any), 1)
+	apiKeyID, _ := apiKeys.([]any)[0].(map[string]any)["id"].(string)
+	assert.Nil(t, apiKeys.([]any)[0].(map[string]any)["key"])
+
+	// the key reaches only its budget, and only as a viewer
+	c.Request(WithAPIKey(c.GetBudgetAccounts("", budgetID1), apiKey), http.StatusOK)
+	c.Request(WithAPIKey(c.GetBudgetAccounts("", budgetID2), apiKey), http.StatusForbidden)
+	c.Request(WithAPIKey(c.CreateCategory("", budgetID1, "", "Groceries", ""), apiKey), http.StatusForbidden)
+	c.Request(WithAPIKey(c.GetUserBudgets(""), apiKey), http.StatusOK)
+	gotBudgets, _ := c.GetJSONField("data")
+	assert.Len(t, gotBudgets.([]any), 1)
+
+	// keys may not manage the user, nor mint more keys
+	c.Request(WithAPIKey(c.CreateAPIKey("", "Another Script", []string{budgetID1}, roleAdmin, ""), apiKey), http.StatusForbidden)
+	c.Request(WithAPIKey(c.CreateBudget("", "Key Budget", ""), apiKey), http.StatusForbidden)
+
+	c.Request(c.GetAPIKeys(jwt1), http.StatusOK)
+	apiKeys, _ = c.GetJSONField("data")
+	assert.NotNil(t, apiKeys.([]any)[0].(map[string]any)["last_used_at"])
+
+	c.Request(c.RevokeAPIKey(jwt1, apiKeyID), http.StatusNoContent)
+	c.Request(c.RevokeAPIKey(jwt1, apiKeyID), http.StatusNotFound)
+	c.Request(WithAPIKey(c.GetBudgetAccounts("", budgetID1), apiKey), http.StatusUnauthorized)
+}
+
+func Test_RefreshTokenRotation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	refreshToken1, _ := c.GetJSONFieldAsString("refresh_token")
+
+	// each refresh hands back a new refresh token
+	c.Request(c.RefreshToken(refreshToken1), http.StatusOK)
+	refreshToken2, _ := c.GetJSONFieldAsString("refresh_token")
+	assert.NotEmpty(t, refreshToken2)
+	assert.NotEqual(t, refreshToken1, refreshToken2)
+
+	// reusing a rotated token revokes its whole family
+	c.Request(c.RefreshToken(refreshToken1), http.StatusUnauthorized)
+	c.Request(c.RefreshToken(refreshToken2), http.StatusUnauthorized)
+
+	// the same holds when the token travels by cookie
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	refreshToken3, _ := c.GetJSONFieldAsString("refresh_token")
+	c.Request(c.RefreshTokenByCookie(refreshToken3), http.StatusOK)
+	var refreshToken4 string
+	for _, cookie := range c.W.Result().Cookies() {
+		if cookie.Name == "refresh_token" {
+			refreshToken4 = cookie.Value
+		}
+	}
+	assert.NotEmpty(t, refreshToken4)
+	assert.NotEqual(t, refreshToken3, refreshToken4)
+
+	c.Request(c.RefreshTokenByCookie(refreshToken4), http.StatusOK)
+	c.Request(c.RefreshTokenByCookie(refreshToken3), http.StatusUnauthorized)
+	c.Request(c.RefreshTokenByCookie(refreshToken4), http.StatusUnauthorized)
+}
+
+func Test_Sessions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+
+	req := c.LoginUser(username1, password1)
+	req.Header.Set("User-Agent", "laptop")
+	c.Request(req, http.StatusOK)
+	jwtLaptop, _ := c.GetJSONFieldAsString("token")
+
+	req = c.LoginUser(username1, password1)
+	req.Header.Set("User-Agent", "phone")
+	c.Request(req, http.StatusOK)
+	refreshPhone, _ := c.GetJSONFieldAsString("refresh_token")
+	jwtPhone, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.GetSessions(jwtLaptop), http.StatusOK)
+	sessions, _ := c.GetJSONField("data")
+	assert.Len(t, sessions.([]any), 2)
+	var phoneSessionID string
+	for _, s := range sessions.([]any) {
+		session := s.(map[string]any)
+		assert.NotEmpty(t, session["ip_address"])
+		if session["user_agent"] == "laptop" {
+			assert.Equal(t, true, session["current"])
+		} else {
+			assert.Equal(t, false, session["current"])
+			phoneSessionID = session["id"].(string)
+		}
+	}
+
+	// revoking a session signs its device out
+	c.Request(c.RevokeSession(jwtLaptop, phoneSessionID), http.StatusNoContent)
+	c.Request(c.RevokeSession(jwtLaptop, phoneSessionID), http.StatusNotFound)
+	c.Request(c.RefreshToken(refreshPhone), http.StatusUnauthorized)
+	c.Request(c.GetSessions(jwtPhone), http.StatusUnauthorized)
+
+	// as does revoking all other sessions
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	refreshOther, _ := c.GetJSONFieldAsString("refresh_token")
+	c.Request(c.RevokeOtherSessions(jwtLaptop), http.StatusNoContent)
+	c.Request(c.RefreshToken(refreshOther), http.StatusUnauthorized)
+	c.Request(c.GetSessions(jwtLaptop), http.StatusOK)
+	sessions, _ = c.GetJSONField("data")
+	assert.Len(t, sessions.([]any), 1)
+
+	// as does changing the password
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	refreshOther, _ = c.GetJSONFieldAsString("refresh_token")
+	jwtOther, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.UpdateUser(jwtLaptop, username1, password2), http.StatusNoContent)
+	c.Request(c.RefreshToken(refreshOther), http.StatusUnauthorized)
+	c.Request(c.GetSessions(jwtOther), http.StatusUnauthorized)
+	c.Request(c.GetSessions(jwtLaptop), http.StatusOK)
+	sessions, _ = c.GetJSONField("data")
+	assert.Len(t, sessions.([]any), 1)
+}
+
+func Test_TwoFactorAuth(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	cfg := doConfigSetup(t)
+	c := APITestClient{Mux: SetupMux(cfg), testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.EnrollTwoFactor(jwt1), http.StatusCreated)
+	uri, _ := c.GetJSONFieldAsString("otpauth_uri")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/"))
+	// enrolling again replaces the pending secret
+	c.Request(c.EnrollTwoFactor(jwt1), http.StatusCreated)
+	secret, _ := c.GetJSONFieldAsString("secret")
+
+	step := auth.TOTPStep(time.Now())
+	code, _ := auth.TOTPCode(secret, step)
+	c.Request(c.ConfirmTwoFactor(jwt1, "000000"), http.StatusBadRequest)
+	c.Request(c.ConfirmTwoFactor(jwt1, code), http.StatusOK)
+	recoveryCodes, _ := c.GetJSONField("recovery_codes")
+	assert.Len(t, recoveryCodes.([]any), 10)
+	c.Request(c.EnrollTwoFactor(jwt1), http.StatusConflict)
+
+	// logging in now takes two steps
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	challengeToken, _ := c.GetJSONFieldAsString("challenge_token")
+	assert.NotEmpty(t, challengeToken)
+	c.Request(c.GetUserBudgets(challengeToken), http.StatusUnauthorized)
+
+	// a code, once used, is not accepted again
+	c.Request(c.LoginUserTwoFactor(challengeToken, code), http.StatusUnauthorized)
+	nextCode, _ := auth.TOTPCode(secret, step+1)
+	c.Request(c.LoginUserTwoFactor(challengeToken, nextCode), http.StatusOK)
+	jwt2, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.GetUserBudgets(jwt2), http.StatusOK)
+
+	// recovery codes may stand in for a code, once each
+	recoveryCode := recoveryCodes.([]any)[0].(string)
+	c.Request(c.LoginUserTwoFactor(challengeToken, recoveryCode), http.StatusOK)
+	c.Request(c.LoginUserTwoFactor(challengeToken, recoveryCode), http.StatusUnauthorized)
+
+	// the password is guarded as at login when disabling
+	for range defaultThrottlePolicy.freeAttempts + 1 {
+		c.Request(c.DisableTwoFactor(jwt2, password2), http.StatusUnauthorized)
+	}
+	c.Request(c.DisableTwoFactor(jwt2, password1), http.StatusTooManyRequests)
+	_, err := cfg.Pool.Exec(t.Context(), "DELETE FROM login_throttles")
+	require.NoError(t, err)
+
+	c.Request(c.DisableTwoFactor(jwt2, password1), http.StatusNoContent)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt3, _ := c.GetJSONFieldAsString("token")
+	assert.NotEmpty(t, jwt3)
+
+	// an enrollment never confirmed is not enabled, and so not disabled
+	c.Request(c.EnrollTwoFactor(jwt3), http.StatusCreated)
+	c.Request(c.DisableTwoFactor(jwt3, password1), http.StatusNotFound)
+}
+
+func Test_LoginThrottling(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	t.Setenv("LOGIN_FREE_ATTEMPTS", "1")
+	t.Setenv("LOGIN_BACKOFF_BASE", "1h")
+	t.Setenv("LOGIN_LOCKOUT_ATTEMPTS", "3")
+	t.Setenv("LOGIN_LOCKOUT_DURATION", "2h")
+	t.Setenv("SIGNUP_LIMIT", "2")
+	cfg := doConfigSetup(t)
+	c := APITestClient{Mux: SetupMux(cfg), testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	userID1, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	// only those configured to administer the server may manage lockouts
+	c.Request(c.GetLockouts(jwt1), http.StatusForbidden)
+	cfg.admins[uuid.MustParse(userID1)] = struct{}{}
+
+	// past the free attempts, even the right password must wait
+	c.Request(c.LoginUser(username1, password2), http.StatusUnauthorized)
+	c.Request(c.LoginUser(username1, password2), http.StatusUnauthorized)
+	c.Request(c.LoginUser(username1, password1), http.StatusTooManyRequests)
+	retryAfter, err := strconv.Atoi(c.W.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.Greater(t, retryAfter, 3500)
+
+	c.Request(c.GetLockouts(jwt1), http.StatusOK)
+	lockouts, _ := c.GetJSONField("data")
+	assert.Len(t, lockouts.([]any), 2)
+
+	c.Request(c.ClearLockouts(jwt1, "USERNAME", username2), http.StatusNotFound)
+	c.Request(c.ClearLockouts(jwt1, "", ""), http.StatusNoContent)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+
+	// signups are limited by client address, apart from failed logins
+	c.Request(c.CreateUser(username2, password2), http.StatusCreated)
+	c.Request(c.CreateUser(username3, password3), http.StatusCreated)
+	c.Request(c.CreateUser(username4, password4), http.StatusTooManyRequests)
+	c.Request(c.ClearLockouts(jwt1, "SIGNUP", "192.0.2.1"), http.StatusNoContent)
+	c.Request(c.CreateUser(username4, password4), http.StatusCreated)
+}
+
+func Test_JWKS(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.GetJWKS(), http.StatusOK)
+	keys, _ := c.GetJSONField("keys")
+	require.Len(t, keys.([]any), 1)
+	jwk := keys.([]any)[0].(map[string]any)
+	assert.Equal(t, "OKP", jwk["kty"])
+	assert.Equal(t, "EdDSA", jwk["alg"])
+
+	// access tokens name the key that signed them
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+	header, err := base64.RawURLEncoding.DecodeString(strings.Split(jwt1, ".")[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(header), fmt.Sprintf(`"kid":"%s"`, jwk["kid"]))
+}
+
+func Test_OIDCLogin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	issuer := newStubIssuer(t)
+	t.Setenv("OIDC_ISSUER", issuer.URL)
+	t.Setenv("OIDC_CLIENT_ID", "pincher")
+	t.Setenv("OIDC_REDIRECT_URL", "http://localhost:5173/callback")
+	cfg := doConfigSetup(t)
+	c := APITestClient{Mux: SetupMux(cfg), testState: t}
+
+	signIn := func(req *http.Request, subject string) (code, state, binding string) {
+		c.Request(req, http.StatusOK)
+		authURL, err := c.GetJSONFieldAsString("authorization_url")
+		require.NoError(t, err)
+		for _, cookie := range c.W.Result().Cookies() {
+			if cookie.Name == "oidc_login" {
+				binding = cookie.Value
+			}
+		}
+		require.NotEmpty(t, binding)
+		code, state = issuer.signIn(t, authURL, subject, username1)
+		return code, state, binding
+	}
+
+	// identities linked to no user are refused, without auto-provisioning
+	code, state, binding := signIn(c.StartOIDCLogin(), "subject-1")
+	c.Request(c.CompleteOIDCLogin(code, state, binding), http.StatusForbidden)
+	// each login may only be completed once
+	c.Request(c.CompleteOIDCLogin(code, state, binding), http.StatusUnauthorized)
+
+	// a signed-in user links the identity to themself
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+	code, state, binding = signIn(c.LinkOIDCIdentity(jwt1), "subject-1")
+	c.Request(c.CompleteOIDCLogin(code, state, binding), http.StatusCreated)
+	code, state, binding = signIn(c.LinkOIDCIdentity(jwt1), "subject-1")
+	c.Request(c.CompleteOIDCLogin(code, state, binding), http.StatusConflict)
+
+	// the link is recorded, though in the log of no budget
+	var linked int
+	err := cfg.Pool.QueryRow(t.Context(),
+		"SELECT COUNT(*) FROM audit_log WHERE budget_id IS NULL AND resource_type = 'USER_IDENTITY' AND action = 'CREATE'",
+	).Scan(&linked)
+	require.NoError(t, err)
+	assert.Equal(t, 1, linked)
+
+	// a login may only be completed by the browser that began it,
+	// lest another be signed in as, or linked to, someone else
+	code, state, binding = signIn(c.LinkOIDCIdentity(jwt1), "subject-2")
+	_, _, otherBinding := signIn(c.StartOIDCLogin(), "subject-2")
+	c.Request(c.CompleteOIDCLogin(code, state, ""), http.StatusUnauthorized)
+	c.Request(c.CompleteOIDCLogin(code, state, otherBinding), http.StatusUnauthorized)
+	c.Request(c.CompleteOIDCLogin(code, state, binding), http.StatusCreated)
+
+	// and may then sign in through the provider, as with a password
+	code, state, binding = signIn(c.StartOIDCLogin(), "subject-1")
+	c.Request(c.CompleteOIDCLogin(code, state, binding), http.StatusOK)
+	refreshToken, _ := c.GetJSONFieldAsString("refresh_token")
+	c.Request(c.RefreshToken(refreshToken), http.StatusOK)
+	jwt2, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.GetSessions(jwt2), http.StatusOK)
+	sessions, _ := c.GetJSONField("data")
+	assert.Len(t, sessions.([]any), 2)
+
+	// a code is worthless without the verifier of its own login
+	codeA, _, _ := signIn(c.StartOIDCLogin(), "subject-1")
+	_, stateB, bindingB := signIn(c.StartOIDCLogin(), "subject-1")
+	c.Request(c.CompleteOIDCLogin(codeA, stateB, bindingB), http.StatusUnauthorized)
+	c.Request(c.CompleteOIDCLogin("", stateB, bindingB), http.StatusBadRequest)
+}
+
+func Test_OIDCAutoProvisioning(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	issuer := newStubIssuer(t)
+	t.Setenv("OIDC_ISSUER", issuer.URL)
+	t.Setenv("OIDC_CLIENT_ID", "pincher")
+	t.Setenv("OIDC_REDIRECT_URL", "http://localhost:5173/callback")
+	t.Setenv("OIDC_AUTO_PROVISION", "true")
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	signIn := func(subject, username string) (code, state, binding string) {
+		c.Request(c.StartOIDCLogin(), http.StatusOK)
+		authURL, err := c.GetJSONFieldAsString("authorization_url")
+		require.NoError(t, err)
+		for _, cookie := range c.W.Result().Cookies() {
+			if cookie.Name == "oidc_login" {
+				binding = cookie.Value
+			}
+		}
+		code, state = issuer.signIn(t, authURL, subject, username)
+		return code, state, binding
+	}
+
+	// a user is created for a new identity, and found by it again after
+	var userIDs []string
+	for range 2 {
+		code, state, binding := signIn("subject-1", username1)
+		c.Request(c.CompleteOIDCLogin(code, state, binding), http.StatusOK)
+		userID, _ := c.GetJSONFieldAsString("id")
+		userIDs = append(userIDs, userID)
+	}
+	assert.NotEmpty(t, userIDs[0])
+	assert.Equal(t, userIDs[0], userIDs[1])
+
+	// no user is created over one of the same name
+	code, state, binding := signIn("subject-2", username1)
+	c.Request(c.CompleteOIDCLogin(code, state, binding), http.StatusConflict)
+	c.Request(c.LoginUser(username1, password1), http.StatusUnauthorized)
+}
+
+func Test_BudgetInvitations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	userID1, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateUser(username2, password2), http.StatusCreated)
+	userID2, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateUser(username3, password3), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.LoginUser(username2, password2), http.StatusOK)
+	jwt2, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.LoginUser(username3, password3), http.StatusOK)
+	jwt3, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Household", ""), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+
+	// no user joins a budget without accepting an invitation
+	c.Request(c.AddBudgetMember(jwt1, budgetID, username2, roleViewer), http.StatusGone)
+	c.Request(c.SetBudgetMemberRole(jwt1, budgetID, userID2, roleViewer), http.StatusNotFound)
+	c.Request(c.InviteMemberToBudget(jwt1, budgetID, username2, roleAdmin), http.StatusBadRequest)
+	c.Request(c.InviteMemberToBudget(jwt1, budgetID, username1, roleViewer), http.StatusConflict)
+	c.Request(c.InviteMemberToBudget(jwt1, budgetID, username2, roleViewer), http.StatusCreated)
+	c.Request(c.GetUserBudgets(jwt2), http.StatusOK)
+	var noBudgets struct {
+		Data []Budget `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&noBudgets))
+	assert.Len(t, noBudgets.Data, 0)
+
+	// inviting again amends the pending invitation
+	c.Request(c.InviteMemberToBudget(jwt1, budgetID, username2, roleContributor), http.StatusCreated)
+	c.Request(c.GetUserInvitations(jwt2), http.StatusOK)
+	invitations, _ := c.GetJSONField("data")
+	require.Len(t, invitations.([]any), 1)
+	invitation := invitations.([]any)[0].(map[string]any)
+	assert.Equal(t, "Household", invitation["budget_name"])
+	assert.Equal(t, roleContributor, invitation["member_role"])
+	invitationID := invitation["id"].(string)
+
+	// only the invitee may answer an invitation, and only once
+	c.Request(c.AcceptInvitation(jwt3, invitationID), http.StatusNotFound)
+	c.Request(c.AcceptInvitation(jwt2, invitationID), http.StatusCreated)
+	c.Request(c.AcceptInvitation(jwt2, invitationID), http.StatusNotFound)
+	c.Request(c.GetUserBudgets(jwt2), http.StatusOK)
+	gotBudgets, _ := c.GetJSONField("data")
+	assert.Len(t, gotBudgets.([]any), 1)
+	c.Request(c.SetBudgetMemberRole(jwt1, budgetID, userID2, roleManager), http.StatusOK)
+	c.Request(c.SetBudgetMemberRole(jwt2, budgetID, userID1, roleViewer), http.StatusNotFound)
+
+	// declined and revoked invitations are gone
+	c.Request(c.InviteMemberToBudget(jwt2, budgetID, username3, roleViewer), http.StatusCreated)
+	invitationID, _ = c.GetJSONFieldAsString("id")
+	c.Request(c.DeclineInvitation(jwt3, invitationID), http.StatusNoContent)
+	c.Request(c.AcceptInvitation(jwt3, invitationID), http.StatusNotFound)
+	c.Request(c.InviteMemberToBudget(jwt2, budgetID, username3, roleViewer), http.StatusCreated)
+	invitationID, _ = c.GetJSONFieldAsString("id")
+	c.Request(c.GetBudgetInvitations(jwt1, budgetID), http.StatusOK)
+	invitations, _ = c.GetJSONField("data")
+	assert.Len(t, invitations.([]any), 1)
+	c.Request(c.RevokeBudgetInvitation(jwt1, budgetID, invitationID), http.StatusNoContent)
+	c.Request(c.RevokeBudgetInvitation(jwt1, budgetID, invitationID), http.StatusNotFound)
+	c.Request(c.AcceptInvitation(jwt3, invitationID), http.StatusNotFound)
+
+	// a link invitation may be redeemed once, by anyone
+	c.Request(c.CreateInvitationLink(jwt1, budgetID, roleViewer), http.StatusCreated)
+	invitationToken, _ := c.GetJSONFieldAsString("token")
+	require.NotEmpty(t, invitationToken)
+	c.Request(c.RedeemInvitationLink(jwt2, invitationToken), http.StatusConflict)
+	c.Request(c.RedeemInvitationLink(jwt3, invitationToken), http.StatusCreated)
+	c.Request(c.RedeemInvitationLink(jwt3, invitationToken), http.StatusNotFound)
+	c.Request(c.GetUserBudgets(jwt3), http.StatusOK)
+	gotBudgets, _ = c.GetJSONField("data")
+	assert.Len(t, gotBudgets.([]any), 1)
+}
+
+func Test_BudgetOwnershipTransfer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	userID1, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateUser(username2, password2), http.StatusCreated)
+	userID2, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateUser(username3, password3), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.LoginUser(username2, password2), http.StatusOK)
+	jwt2, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Household", ""), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.JoinBudget(jwt1, budgetID, username2, jwt2, roleViewer)
+
+	// only members other than the admin may be nominated, and only by the admin
+	c.Request(c.NominateBudgetOwner(jwt1, budgetID, username1), http.StatusBadRequest)
+	c.Request(c.NominateBudgetOwner(jwt1, budgetID, username3), http.StatusNotFound)
+	c.Request(c.NominateBudgetOwner(jwt2, budgetID, username2), http.StatusForbidden)
+
+	// the sole admin of a shared budget may not delete their account
+	c.Request(c.DeleteUser(jwt1, username1, password1), http.StatusConflict)
+
+	// a nomination may be cancelled, or declined
+	c.Request(c.NominateBudgetOwner(jwt1, budgetID, username2), http.StatusCreated)
+	c.Request(c.CancelOwnershipTransfer(jwt1, budgetID), http.StatusNoContent)
+	c.Request(c.AcceptOwnershipTransfer(jwt2, budgetID), http.StatusNotFound)
+	c.Request(c.NominateBudgetOwner(jwt1, budgetID, username2), http.StatusCreated)
+	c.Request(c.DeclineOwnershipTransfer(jwt2, budgetID), http.StatusNoContent)
+	c.Request(c.CancelOwnershipTransfer(jwt1, budgetID), http.StatusNotFound)
+
+	// ownership moves once the nominee accepts
+	c.Request(c.NominateBudgetOwner(jwt1, budgetID, username2), http.StatusCreated)
+	c.Request(c.GetUserOwnershipTransfers(jwt2), http.StatusOK)
+	transfers, _ := c.GetJSONField("data")
+	require.Len(t, transfers.([]any), 1)
+	assert.Equal(t, "Household", transfers.([]any)[0].(map[string]any)["budget_name"])
+	c.Request(c.AcceptOwnershipTransfer(jwt2, budgetID), http.StatusOK)
+	c.Request(c.AcceptOwnershipTransfer(jwt2, budgetID), http.StatusNotFound)
+	c.Request(c.GetUserOwnershipTransfers(jwt2), http.StatusOK)
+	transfers, _ = c.GetJSONField("data")
+	assert.Empty(t, transfers)
+
+	// the former admin stays on as a manager
+	c.Request(c.NominateBudgetOwner(jwt1, budgetID, username2), http.StatusForbidden)
+	c.Request(c.SetBudgetMemberRole(jwt1, budgetID, userID2, roleViewer), http.StatusNotFound)
+	c.Request(c.SetBudgetMemberRole(jwt2, budgetID, userID1, roleViewer), http.StatusOK)
+	c.Request(c.DeleteUser(jwt1, username1, password1), http.StatusNoContent)
+	c.Request(c.DeleteUser(jwt2, username2, password2), http.StatusNoContent)
+}
+
+func Test_MemberRestrictions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.CreateUser(username2, password2), http.StatusCreated)
+	userID2, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.LoginUser(username2, password2), http.StatusOK)
+	jwt2, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Business", ""), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.JoinBudget(jwt1, budgetID, username2, jwt2, roleContributor)
+
+	c.Request(c.CreateGroup(jwt1, budgetID, "Expenses", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Expenses", "Fuel", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Expenses", "Meals", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Company Card", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Gas Station", ""), http.StatusCreated)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Gas Station", "", true, map[string]int64{"Meals": -2500}), http.StatusCreated)
+	checkingTxnID, _ := c.GetJSONFieldAsString("id")
+
+	// only others than the admin and oneself may be restricted
+	c.Request(c.SetMemberRestrictions(jwt1, budgetID, userID2, map[string]any{}), http.StatusBadRequest)
+	c.Request(c.SetMemberRestrictions(jwt2, budgetID, userID2, map[string]any{"max_transaction_amount": 0}), http.StatusForbidden)
+	c.Request(c.SetMemberRestrictions(jwt1, budgetID, userID2, map[string]any{"account_names": []string{"Savings"}}), http.StatusBadRequest)
+	c.Request(c.SetMemberRestrictions(jwt1, budgetID, userID2, map[string]any{
+		"account_names":          []string{"Company Card"},
+		"category_names":         []string{"Fuel"},
+		"max_transaction_amount": 5000,
+	}), http.StatusOK)
+	c.Request(c.GetMemberRestrictions(jwt2, budgetID, userID2), http.StatusOK)
+	maxAmount, _ := c.GetJSONField("max_transaction_amount")
+	assert.EqualValues(t, 5000, maxAmount)
+	c.Request(c.DeleteMemberRestrictions(jwt2, budgetID, userID2), http.StatusForbidden)
+
+	// transactions are only logged within the restrictions
+	c.Request(c.LogTransaction(jwt2, budgetID, "Checking", "", dateSeptember, "Gas Station", "", true, map[string]int64{"Fuel": -4000}), http.StatusForbidden)
+	c.Request(c.LogTransaction(jwt2, budgetID, "Company Card", "", dateSeptember, "Gas Station", "", true, map[string]int64{"Meals": -4000}), http.StatusForbidden)
+	c.Request(c.LogTransaction(jwt2, budgetID, "Company Card", "", dateSeptember, "Gas Station", "", true, map[string]int64{"Fuel": -6000}), http.StatusForbidden)
+	c.Request(c.LogTransaction(jwt2, budgetID, "Company Card", "", dateSeptember, "Gas Station", "", true, map[string]int64{"Fuel": -4000}), http.StatusCreated)
+
+	// accounts out of reach are hidden, along with their transactions
+	c.Request(c.GetBudgetAccounts(jwt2, budgetID), http.StatusOK)
+	accounts, _ := c.GetJSONField("data")
+	assert.Len(t, accounts.([]any), 1)
+	c.Request(c.GetTransactions(jwt2, budgetID, "", "", "", "", ""), http.StatusOK)
+	transactions, _ := c.GetJSONField("data")
+	assert.Len(t, transactions.([]any), 1)
+	c.Request(c.GetTransaction(jwt2, budgetID, checkingTxnID), http.StatusNotFound)
+	c.Request(c.GetTransactions(jwt1, budgetID, "", "", "", "", ""), http.StatusOK)
+	transactions, _ = c.GetJSONField("data")
+	assert.Len(t, transactions.([]any), 2)
+
+	// lifting the restrictions leaves the member with their role alone
+	c.Request(c.DeleteMemberRestrictions(jwt1, budgetID, userID2), http.StatusNoContent)
+	c.Request(c.GetMemberRestrictions(jwt1, budgetID, userID2), http.StatusNotFound)
+	c.Request(c.GetTransaction(jwt2, budgetID, checkingTxnID), http.StatusOK)
+	c.Request(c.GetTransactions(jwt2, budgetID, "", "", "", "", ""), http.StatusOK)
+	transactions, _ = c.GetJSONField("data")
+	assert.Len(t, transactions.([]any), 2)
+}
+
+// Members are held to their restrictions on every path by which transactions
+// are logged for them: imports, recurring transactions and reconciliation.
+func Test_MemberRestrictionsBeyondTransactions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	cfg := doConfigSetup(t)
+	c := APITestClient{Mux: SetupMux(cfg), testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.CreateUser(username2, password2), http.StatusCreated)
+	userID2, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.LoginUser(username2, password2), http.StatusOK)
+	jwt2, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Business", ""), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.JoinBudget(jwt1, budgetID, username2, jwt2, roleManager)
+
+	c.Request(c.CreateGroup(jwt1, budgetID, "Expenses", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Expenses", "Fuel", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Expenses", "Meals", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	checkingID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Company Card", ""), http.StatusCreated)
+	cardID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Gas Station", ""), http.StatusCreated)
+
+	restrict := func(maxAmount int64) {
+		c.Request(c.SetMemberRestrictions(jwt1, budgetID, userID2, map[string]any{
+			"account_names":          []string{"Company Card"},
+			"category_names":         []string{"Fuel"},
+			"max_transaction_amount": maxAmount,
+		}), http.StatusOK)
+	}
+	restrict(5000)
+
+	countTransactions := func() int {
+		c.Request(c.GetTransactions(jwt1, budgetID, "", "", "", "", ""), http.StatusOK)
+		transactions, _ := c.GetJSONField("data")
+		return len(transactions.([]any))
+	}
+
+	// imports to accounts out of reach are refused,
+	// and rows beyond the restrictions rejected
+	profile := map[string]any{
+		"has_header":       true,
+		"date_column":      "Date",
+		"date_format":      "MM/DD/YYYY",
+		"amount_column":    "Amount",
+		"payee_column":     "Description",
+		"default_category": "Fuel",
+	}
+	c.Request(c.SaveImportProfile(jwt1, budgetID, checkingID, profile), http.StatusOK)
+	c.Request(c.SaveImportProfile(jwt1, budgetID, cardID, profile), http.StatusOK)
+	export := "Date,Description,Amount\n" +
+		"09/01/2025,Gas Station,-40.00\n" +
+		"09/02/2025,Gas Station,-60.00\n"
+	c.Request(c.ImportFile(jwt2, budgetID, checkingID, "csv", export), http.StatusNotFound)
+	c.Request(c.ImportFile(jwt2, budgetID, cardID, "csv", export), http.StatusOK)
+	accepted, _ := c.GetJSONFieldAsInt64("accepted")
+	assert.Equal(t, int64(1), accepted)
+	rejected, _ := c.GetJSONFieldAsInt64("rejected")
+	assert.Equal(t, int64(1), rejected)
+
+	statement := "OFXHEADER:100\nDATA:OFXSGML\nVERSION:102\n\n" +
+		"<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>USD\n" +
+		"<BANKACCTFROM><ACCTID>000111222</BANKACCTFROM>\n" +
+		"<BANKTRANLIST><STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20250903<TRNAMT>-10.00<FITID>B1<NAME>Gas Station</STMTTRN>\n</BANKTRANLIST>\n" +
+		"<LEDGERBAL><BALAMT>-10.00<DTASOF>20250905</LEDGERBAL>\n" +
+		"</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>\n"
+	c.Request(c.ImportFile(jwt2, budgetID, checkingID, "ofx", statement), http.StatusNotFound)
+	c.Request(c.ImportFile(jwt2, budgetID, cardID, "ofx?default_category=Meals", statement), http.StatusOK)
+	accepted, _ = c.GetJSONFieldAsInt64("accepted")
+	assert.Equal(t, int64(0), accepted)
+	rejected, _ = c.GetJSONFieldAsInt64("rejected")
+	assert.Equal(t, int64(1), rejected)
+
+	qif := "!Account\nNChecking\nTBank\n^\n!Type:Bank\n" +
+		"D9/5'25\nT-10.00\nPGas Station\nLExpenses:Fuel\n^\n" +
+		"!Account\nNCompany Card\nTBank\n^\n!Type:Bank\n" +
+		"D9/5'25\nT-10.00\nPGas Station\nLExpenses:Fuel\n^\n"
+	var report QIFImportReport
+	c.Request(c.ImportBudgetFile(jwt2, budgetID, "qif", qif), http.StatusOK)
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&report))
+	require.Len(t, report.Accounts, 2)
+	assert.Equal(t, 1, report.Accounts[0].Rejected)
+	assert.Equal(t, 1, report.Accounts[1].Accepted)
+
+	actual := "Account,Date,Payee,Notes,Category,Amount,Split_Amount,Cleared\n" +
+		"Checking,2025-09-06,Gas Station,,Fuel,-10.00,0,false\n" +
+		"Company Card,2025-09-06,Gas Station,,Meals,-10.00,0,false\n"
+	var budgetReport BudgetImportReport
+	c.Request(c.ImportBudgetFile(jwt2, budgetID, "actual", actual), http.StatusOK)
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&budgetReport))
+	require.Len(t, budgetReport.Accounts, 2)
+	for _, account := range budgetReport.Accounts {
+		assert.Equal(t, 0, account.Accepted)
+		assert.Equal(t, 1, account.Rejected)
+	}
+	assert.Equal(t, 2, countTransactions())
+
+	// recurring transactions are only scheduled within the restrictions
+	c.Request(c.CreateRecurringTransaction(jwt2, budgetID, "Checking", "2025-09-10", "Gas Station", "MONTHLY", map[string]int64{"Fuel": -4000}), http.StatusForbidden)
+	c.Request(c.CreateRecurringTransaction(jwt2, budgetID, "Company Card", "2025-09-10", "Gas Station", "MONTHLY", map[string]int64{"Fuel": -6000}), http.StatusForbidden)
+	c.Request(c.CreateRecurringTransaction(jwt2, budgetID, "Company Card", "2025-09-10", "Gas Station", "MONTHLY", map[string]int64{"Fuel": -4000}), http.StatusCreated)
+	recurringID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.UpdateRecurringTransaction(jwt2, budgetID, recurringID, "Company Card", "2025-09-10", "Gas Station", "MONTHLY", map[string]int64{"Meals": -4000}), http.StatusForbidden)
+
+	// and are not posted once beyond any restrictions placed since
+	restrict(3000)
+	cfg.postDueRecurringTxns(t.Context(), time.Date(2025, time.September, 20, 0, 0, 0, 0, time.UTC))
+	var recurring RecurringTransaction
+	c.Request(c.GetRecurringTransaction(jwt1, budgetID, recurringID), http.StatusOK)
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&recurring))
+	assert.Equal(t, int32(1), recurring.FailureCount)
+	assert.Contains(t, recurring.LastError, "greater than")
+	assert.Equal(t, 2, countTransactions())
+
+	// accounts out of reach may not be reconciled, nor adjustments
+	// logged beyond the restrictions
+	c.Request(c.ReconcileAccount(jwt2, budgetID, checkingID, "2025-09-30", 0, false), http.StatusNotFound)
+	c.Request(c.ReconcileAccount(jwt2, budgetID, cardID, "2025-09-30", -10000, true), http.StatusForbidden)
+	assert.Equal(t, 2, countTransactions())
+}
+
+func Test_AuditLog(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	cfg := doConfigSetup(t)
+	c := APITestClient{Mux: SetupMux(cfg), testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	userID1, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateUser(username2, password2), http.StatusCreated)
+	userID2, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.LoginUser(username2, password2), http.StatusOK)
+	jwt2, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Household", ""), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.JoinBudget(jwt1, budgetID, username2, jwt2, roleManager)
+
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Grocer", ""), http.StatusCreated)
+	payeeID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.UpdatePayee(jwt2, budgetID, payeeID, "Greengrocer", ""), http.StatusNoContent)
+	// failed changes are not recorded
+	c.Request(c.UpdatePayee(jwt2, budgetID, uuid.NewString(), "Butcher", ""), http.StatusConflict)
+
+	// each change is recorded with who made it, and the state before and after
+	c.Request(c.GetAuditLog(jwt1, budgetID, "?resource_type=PAYEE"), http.StatusOK)
+	entries, _ := c.GetJSONField("data")
+	require.Len(t, entries.([]any), 2)
+	update := entries.([]any)[0].(map[string]any)
+	assert.Equal(t, "UPDATE", update["action"])
+	assert.Equal(t, payeeID, update["resource_id"])
+	assert.Equal(t, userID2, update["actor_id"])
+	assert.Equal(t, "Grocer", update["before"].(map[string]any)["Name"])
+	assert.Equal(t, "Greengrocer", update["after"].(map[string]any)["Name"])
+	create := entries.([]any)[1].(map[string]any)
+	assert.Equal(t, "CREATE", create["action"])
+	assert.Equal(t, userID1, create["actor_id"])
+	assert.Nil(t, create["before"])
+
+	// the log may be filtered by user, and is only for managers and above
+	c.Request(c.GetAuditLog(jwt1, budgetID, "?user_id="+userID2), http.StatusOK)
+	entries, _ = c.GetJSONField("data")
+	for _, entry := range entries.([]any) {
+		assert.Equal(t, userID2, entry.(map[string]any)["actor_id"])
+	}
+	c.Request(c.GetAuditLog(jwt1, budgetID, "?user_id=nobody"), http.StatusBadRequest)
+	c.Request(c.SetBudgetMemberRole(jwt1, budgetID, userID2, roleViewer), http.StatusOK)
+	c.Request(c.GetAuditLog(jwt2, budgetID, ""), http.StatusForbidden)
+	c.Request(c.GetAuditLog(jwt1, budgetID, "?resource_type=MEMBER&resource_id="+userID2), http.StatusOK)
+	entries, _ = c.GetJSONField("data")
+	require.NotEmpty(t, entries.([]any))
+	roleChange := entries.([]any)[0].(map[string]any)
+	assert.Equal(t, roleManager, roleChange["before"].(map[string]any)["member_role"])
+	assert.Equal(t, roleViewer, roleChange["after"].(map[string]any)["member_role"])
+
+	// a change is only kept along with its entry
+	_, err := cfg.Pool.Exec(t.Context(), "ALTER TABLE audit_log ADD CONSTRAINT no_entries CHECK (false) NOT VALID")
+	require.NoError(t, err)
+	c.Request(c.UpdatePayee(jwt1, budgetID, payeeID, "Butcher", ""), http.StatusInternalServerError)
+	_, err = cfg.Pool.Exec(t.Context(), "ALTER TABLE audit_log DROP CONSTRAINT no_entries")
+	require.NoError(t, err)
+	c.Request(c.GetBudgetPayees(jwt1, budgetID), http.StatusOK)
+	payees, _ := c.GetJSONField("data")
+	for _, payee := range payees.([]any) {
+		assert.NotEqual(t, "Butcher", payee.(map[string]any)["name"])
+	}
+
+	// invitations declined are recorded, as those revoked
+	c.Request(c.CreateUser(username3, password3), http.StatusCreated)
+	userID3, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.LoginUser(username3, password3), http.StatusOK)
+	jwt3, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.InviteMemberToBudget(jwt1, budgetID, username3, roleViewer), http.StatusCreated)
+	invitationID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.DeclineInvitation(jwt3, invitationID), http.StatusNoContent)
+	c.Request(c.GetAuditLog(jwt1, budgetID, "?resource_type=INVITATION&resource_id="+invitationID), http.StatusOK)
+	entries, _ = c.GetJSONField("data")
+	require.Len(t, entries.([]any), 2)
+	decline := entries.([]any)[0].(map[string]any)
+	assert.Equal(t, "DELETE", decline["action"])
+	assert.Equal(t, userID3, decline["actor_id"])
+	assert.NotNil(t, decline["before"])
+
+	// as are transactions posted on schedule, and purged from the trash,
+	// neither of which are made by anyone
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	c.Request(c.CreateGroup(jwt1, budgetID, "Spending", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Spending", "Groceries", ""), http.StatusCreated)
+	c.Request(c.CreateRecurringTransaction(jwt1, budgetID, "Checking", "2025-09-10", "Greengrocer", "MONTHLY", map[string]int64{"Groceries": -1000}), http.StatusCreated)
+	cfg.postDueRecurringTxns(t.Context(), time.Date(2025, time.September, 20, 0, 0, 0, 0, time.UTC))
+	c.Request(c.GetAuditLog(jwt1, budgetID, "?resource_type=TRANSACTION"), http.StatusOK)
+	entries, _ = c.GetJSONField("data")
+	require.Len(t, entries.([]any), 1)
+	posting := entries.([]any)[0].(map[string]any)
+	assert.Equal(t, "CREATE", posting["action"])
+	assert.Nil(t, posting["actor_id"])
+	assert.NotNil(t, posting["after"])
+
+	txnID := posting["resource_id"].(string)
+	c.Request(c.DeleteTransaction(jwt1, budgetID, txnID), http.StatusNoContent)
+	cfg.purgeExpiredTxnHistory(t.Context(), time.Now().Add(365*24*time.Hour))
+	c.Request(c.GetAuditLog(jwt1, budgetID, "?resource_type=TRANSACTION&resource_id="+txnID), http.StatusOK)
+	entries, _ = c.GetJSONField("data")
+	require.Len(t, entries.([]any), 3)
+	purge := entries.([]any)[0].(map[string]any)
+	assert.Equal(t, "PURGE", purge["action"])
+	assert.Nil(t, purge["actor_id"])
+	assert.NotNil(t, purge["before"])
+	assert.Nil(t, purge["after"])
+}
+
+func Test_TransactionTrashAndUndo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Household", ""), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	checkingID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Savings", ""), http.StatusCreated)
+	savingsID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateGroup(jwt1, budgetID, "Spending", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Spending", "Groceries", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Grocer", ""), http.StatusCreated)
+
+	capitalOf := func(accountID string) int64 {
+		c.Request(c.GetBudgetCapital(jwt1, budgetID, accountID), http.StatusOK)
+		capital, _ := c.GetJSONFieldAsInt64("capital")
+		return capital
+	}
+
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Grocer", "", true, map[string]int64{"Groceries": 10000}), http.StatusCreated)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Grocer", "", true, map[string]int64{"Groceries": -2000}), http.StatusCreated)
+	withdrawalID, _ := c.GetJSONFieldAsString("id")
+	assert.Equal(t, int64(8000), capitalOf(checkingID))
+
+	// updates are undone latest first, until there are none left to undo
+	c.Request(c.UpdateTransaction(jwt1, budgetID, withdrawalID, "Checking", "", dateSeptember, "Grocer", "", true, map[string]int64{"Groceries": -3000}), http.StatusNoContent)
+	c.Request(c.UpdateTransaction(jwt1, budgetID, withdrawalID, "Checking", "", dateSeptember, "Grocer", "", true, map[string]int64{"Groceries": -4000}), http.StatusNoContent)
+	assert.Equal(t, int64(6000), capitalOf(checkingID))
+	c.Request(c.UndoTransactionUpdate(jwt1, budgetID, withdrawalID), http.StatusOK)
+	assert.Equal(t, int64(7000), capitalOf(checkingID))
+	c.Request(c.UndoTransactionUpdate(jwt1, budgetID, withdrawalID), http.StatusOK)
+	assert.Equal(t, int64(8000), capitalOf(checkingID))
+	c.Request(c.UndoTransactionUpdate(jwt1, budgetID, withdrawalID), http.StatusNotFound)
+
+	// deleted transactions are kept in the trash, and may be restored
+	c.Request(c.DeleteTransaction(jwt1, budgetID, withdrawalID), http.StatusNoContent)
+	assert.Equal(t, int64(10000), capitalOf(checkingID))
+	c.Request(c.GetTransaction(jwt1, budgetID, withdrawalID), http.StatusNotFound)
+	c.Request(c.GetTrashedTransactions(jwt1, budgetID), http.StatusOK)
+	trashed, _ := c.GetJSONField("data")
+	require.Len(t, trashed.([]any), 1)
+	assert.Equal(t, withdrawalID, trashed.([]any)[0].(map[string]any)["id"])
+	assert.Equal(t, "-2000", trashed.([]any)[0].(map[string]any)["total_amount"].(json.Number).String())
+	c.Request(c.RestoreTransaction(jwt1, budgetID, withdrawalID), http.StatusOK)
+	assert.Equal(t, int64(8000), capitalOf(checkingID))
+	c.Request(c.RestoreTransaction(jwt1, budgetID, withdrawalID), http.StatusNotFound)
+
+	// both legs of a transfer are trashed and restored together
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "Savings", dateSeptember, "TRANSFER", "", true, map[string]int64{"TRANSFER": -5000}), http.StatusCreated)
+	fromID, _ := c.GetJSONFieldAsString("from_transaction.id")
+	toID, _ := c.GetJSONFieldAsString("to_transaction.id")
+	c.Request(c.DeleteTransaction(jwt1, budgetID, fromID), http.StatusNoContent)
+	assert.Equal(t, int64(8000), capitalOf(checkingID))
+	assert.Equal(t, int64(0), capitalOf(savingsID))
+	c.Request(c.GetTrashedTransactions(jwt1, budgetID), http.StatusOK)
+	trashed, _ = c.GetJSONField("data")
+	assert.Len(t, trashed.([]any), 2)
+	c.Request(c.RestoreTransaction(jwt1, budgetID, toID), http.StatusOK)
+	assert.Equal(t, int64(3000), capitalOf(checkingID))
+	assert.Equal(t, int64(5000), capitalOf(savingsID))
+
+	// undoing an update to a transfer reverts both legs
+	c.Request(c.UpdateTransaction(jwt1, budgetID, fromID, "Checking", "Savings", dateSeptember, "TRANSFER", "", true, map[string]int64{"TRANSFER": -6000}), http.StatusNoContent)
+	assert.Equal(t, int64(6000), capitalOf(savingsID))
+	c.Request(c.UndoTransactionUpdate(jwt1, budgetID, fromID), http.StatusOK)
+	assert.Equal(t, int64(3000), capitalOf(checkingID))
+	assert.Equal(t, int64(5000), capitalOf(savingsID))
+
+	// updates may still be undone once a transaction is restored from the trash
+	c.Request(c.UpdateTransaction(jwt1, budgetID, withdrawalID, "Checking", "", dateSeptember, "Grocer", "", true, map[string]int64{"Groceries": -2500}), http.StatusNoContent)
+	c.Request(c.DeleteTransaction(jwt1, budgetID, withdrawalID), http.StatusNoContent)
+	c.Request(c.RestoreTransaction(jwt1, budgetID, withdrawalID), http.StatusOK)
+	c.Request(c.UndoTransactionUpdate(jwt1, budgetID, withdrawalID), http.StatusOK)
+	assert.Equal(t, int64(3000), capitalOf(checkingID))
+
+	// neither restoring nor undoing may change a period since reconciled
+	c.Request(c.UpdateTransaction(jwt1, budgetID, withdrawalID, "Checking", "", dateSeptember, "Grocer", "", false, map[string]int64{"Groceries": -2000}), http.StatusNoContent)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateSeptember, "Grocer", "", true, map[string]int64{"Groceries": -1000}), http.StatusCreated)
+	spentID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.DeleteTransaction(jwt1, budgetID, spentID), http.StatusNoContent)
+	c.Request(c.ReconcileAccount(jwt1, budgetID, checkingID, "2025-09-30", 5000, false), http.StatusOK)
+	locked, _ := c.GetJSONFieldAsInt64("transactions_locked")
+	assert.Equal(t, int64(2), locked)
+	c.Request(c.RestoreTransaction(jwt1, budgetID, spentID), http.StatusConflict)
+	c.Request(c.UndoTransactionUpdate(jwt1, budgetID, withdrawalID), http.StatusConflict)
+	assert.Equal(t, int64(3000), capitalOf(checkingID))
+}
+
+func Test_BudgetExportImport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.CreateUser(username2, password2), http.StatusCreated)
+	c.Request(c.LoginUser(username2, password2), http.StatusOK)
+	jwt2, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Household", ""), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.JoinBudget(jwt1, budgetID, username2, jwt2, roleViewer)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Savings", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Closed", ""), http.StatusCreated)
+	closedID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateGroup(jwt1, budgetID, "Spending", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Spending", "Groceries", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Grocer", ""), http.StatusCreated)
+	c.Request(c.AssignMoneyToCategory(jwt1, budgetID, dateOctober, "Groceries", 4000), http.StatusOK)
+
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateOctober, "Grocer", "", true, map[string]int64{"Groceries": 10000}), http.StatusCreated)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateOctober, "Grocer", "", true, map[string]int64{"Groceries": -2000}), http.StatusCreated)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "Savings", dateOctober, "TRANSFER", "", true, map[string]int64{"TRANSFER": -3000}), http.StatusCreated)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Closed", "", dateOctober, "Grocer", "", true, map[string]int64{"Groceries": -500}), http.StatusCreated)
+	c.Request(c.DeleteBudgetAccount(jwt1, budgetID, closedID, "Closed", false), http.StatusOK)
+
+	// only managers may export the budget
+	c.Request(c.ExportBudget(jwt2, budgetID), http.StatusForbidden)
+	c.Request(c.ExportBudget(jwt1, budgetID), http.StatusOK)
+	exported := c.W.Body.String()
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(exported), &doc))
+	assert.Equal(t, "pincher-budget", doc["format"])
+	assert.Len(t, doc["members"].([]any), 2)
+	assert.Len(t, doc["accounts"].([]any), 3)
+	assert.Len(t, doc["assignments"].([]any), 1)
+	assert.Len(t, doc["transactions"].([]any), 5)
+
+	// the import is a new budget, owned by the importer,
+	// with other members invited to it
+	c.Request(c.ImportBudget(jwt1, exported), http.StatusCreated)
+	importedID, _ := c.GetJSONFieldAsString("id")
+	assert.NotEqual(t, budgetID, importedID)
+	invited, _ := c.GetJSONField("invited")
+	assert.Equal(t, []any{username2}, invited)
+	c.Request(c.GetUserInvitations(jwt2), http.StatusOK)
+	invitations, _ := c.GetJSONField("data")
+	assert.Len(t, invitations.([]any), 1)
+
+	c.Request(c.GetBudgetAccounts(jwt1, importedID), http.StatusOK)
+	accounts, _ := c.GetJSONField("data")
+	require.Len(t, accounts.([]any), 2)
+	for _, account := range accounts.([]any) {
+		accountID := account.(map[string]any)["id"].(string)
+		c.Request(c.GetBudgetCapital(jwt1, importedID, accountID), http.StatusOK)
+		capital, _ := c.GetJSONFieldAsInt64("capital")
+		switch account.(map[string]any)["name"] {
+		case "Checking":
+			assert.Equal(t, int64(5000), capital)
+		case "Savings":
+			assert.Equal(t, int64(3000), capital)
+		}
+	}
+	c.Request(c.GetTransactions(jwt1, budgetID, "", "", "", "", ""), http.StatusOK)
+	txns, _ := c.GetJSONField("data")
+	c.Request(c.GetTransactions(jwt1, importedID, "", "", "", "", ""), http.StatusOK)
+	importedTxns, _ := c.GetJSONField("data")
+	assert.Len(t, importedTxns.([]any), len(txns.([]any)))
+
+	// transactions are attributed to the importer, whoever logged them before
+	c.Request(c.ImportBudget(jwt2, exported), http.StatusCreated)
+	importedByUser2ID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.GetTransactions(jwt2, importedByUser2ID, "", "", "", "", ""), http.StatusOK)
+	importedTxns, _ = c.GetJSONField("data")
+	require.NotEmpty(t, importedTxns.([]any))
+	for _, txn := range importedTxns.([]any) {
+		assert.Equal(t, username2, txn.(map[string]any)["logger_name"])
+	}
+
+	// splits may only name categories exported along with them
+	for _, txn := range doc["transactions"].([]any) {
+		for _, split := range txn.(map[string]any)["splits"].([]any) {
+			if split.(map[string]any)["category_id"] != nil {
+				split.(map[string]any)["category_id"] = uuid.NewString()
+			}
+		}
+	}
+	tampered, err := json.Marshal(doc)
+	require.NoError(t, err)
+	c.Request(c.ImportBudget(jwt1, string(tampered)), http.StatusBadRequest)
+
+	// nor may transactions be reconciled without being cleared
+	require.NoError(t, json.Unmarshal([]byte(exported), &doc))
+	for _, txn := range doc["transactions"].([]any) {
+		txn.(map[string]any)["is_cleared"] = false
+		txn.(map[string]any)["is_reconciled"] = true
+	}
+	tampered, err = json.Marshal(doc)
+	require.NoError(t, err)
+	c.Request(c.ImportBudget(jwt1, string(tampered)), http.StatusBadRequest)
+
+	// an export which is cut short is rejected as a whole
+	c.Request(c.GetUserBudgets(jwt1), http.StatusOK)
+	budgets, _ := c.GetJSONField("data")
+	c.Request(c.ImportBudget(jwt1, exported[:len(exported)/2]), http.StatusBadRequest)
+	c.Request(c.ImportBudget(jwt1, `{"format":"other","version":1}`), http.StatusBadRequest)
+	c.Request(c.GetUserBudgets(jwt1), http.StatusOK)
+	budgetsAfter, _ := c.GetJSONField("data")
+	assert.Len(t, budgetsAfter.([]any), len(budgets.([]any)))
+}
+
+func Test_BudgetTemplatesAndCloning(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+	c.Request(c.CreateUser(username2, password2), http.StatusCreated)
+	c.Request(c.LoginUser(username2, password2), http.StatusOK)
+	jwt2, _ := c.GetJSONFieldAsString("token")
+
+	countOf := func(req *http.Request) int {
+		c.Request(req, http.StatusOK)
+		data, _ := c.GetJSONField("data")
+		return len(data.([]any))
+	}
+
+	c.Request(c.GetBudgetTemplates(jwt1), http.StatusOK)
+	templates, _ := c.GetJSONField("data")
+	assert.Len(t, templates.([]any), len(budgetTemplates))
+
+	// budgets may start from a template, or empty
+	c.Request(c.CreateBudgetFromTemplate(jwt1, "Home", "", "student"), http.StatusBadRequest)
+	c.Request(c.CreateBudgetFromTemplate(jwt1, "Home", "", "Household"), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	assert.Equal(t, 4, countOf(c.GetBudgetGroups(jwt1, budgetID)))
+	assert.Equal(t, 17, countOf(c.GetBudgetCategories(jwt1, budgetID, "")))
+
+	c.JoinBudget(jwt1, budgetID, username2, jwt2, roleViewer)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Closed", ""), http.StatusCreated)
+	closedID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.DeleteBudgetAccount(jwt1, budgetID, closedID, "Closed", false), http.StatusOK)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Grocer", ""), http.StatusCreated)
+	c.Request(c.LogTransaction(jwt1, budgetID, "Checking", "", dateOctober, "Grocer", "", true, map[string]int64{"Groceries": 10000}), http.StatusCreated)
+	c.Request(c.AssignMoneyToCategory(jwt1, budgetID, dateOctober, "Groceries", 4000), http.StatusOK)
+	c.Request(c.AssignMoneyToCategory(jwt1, budgetID, dateSeptember, "Groceries", 1000), http.StatusOK)
+
+	// only managers may clone the budget
+	c.Request(c.CloneBudget(jwt2, budgetID, "", "", ""), http.StatusForbidden)
+
+	// clones keep the structure, and the assignments of the month chosen,
+	// but no transactions, members or closed accounts
+	c.Request(c.CloneBudget(jwt1, budgetID, "", "", dateOctober), http.StatusCreated)
+	cloneID, _ := c.GetJSONFieldAsString("id")
+	cloneName, _ := c.GetJSONFieldAsString("name")
+	assert.NotEqual(t, budgetID, cloneID)
+	assert.Equal(t, "Home", cloneName)
+	assert.Equal(t, 4, countOf(c.GetBudgetGroups(jwt1, cloneID)))
+	assert.Equal(t, 17, countOf(c.GetBudgetCategories(jwt1, cloneID, "")))
+	assert.Equal(t, 1, countOf(c.GetBudgetAccounts(jwt1, cloneID)))
+	assert.Equal(t, countOf(c.GetBudgetPayees(jwt1, budgetID)), countOf(c.GetBudgetPayees(jwt1, cloneID)))
+	assert.Equal(t, 0, countOf(c.GetTransactions(jwt1, cloneID, "", "", "", "", "")))
+	c.Request(c.GetMonthReport(jwt1, cloneID, dateOctober), http.StatusOK)
+	assigned, _ := c.GetJSONFieldAsInt64("assigned")
+	assert.Equal(t, int64(4000), assigned)
+	assert.Equal(t, 1, countOf(c.GetUserBudgets(jwt2)))
+
+	c.Request(c.CloneBudget(jwt1, budgetID, "Home Next Year", "", ""), http.StatusCreated)
+	cloneName, _ = c.GetJSONFieldAsString("name")
+	assert.Equal(t, "Home Next Year", cloneName)
+}
+
+func Test_ImportYNABAndActual(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	pincherServer := doServerSetup(t)
+	c := APITestClient{Mux: pincherServer.Handler, testState: t}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	reconciled := func(report BudgetImportReport) map[string]AccountReconciliation {
+		byName := map[string]AccountReconciliation{}
+		for _, entry := range report.Reconciliation {
+			byName[entry.Name] = entry
+		}
+		return byName
+	}
+
+	// YNAB
+	c.Request(c.CreateBudget(jwt1, "From YNAB", ""), http.StatusCreated)
+	ynabBudgetID, _ := c.GetJSONFieldAsString("id")
+
+	register := `"Account","Flag","Date","Payee","Category Group/Category","Category Group","Category","Memo","Outflow","Inflow","Cleared"
+"Checking","","01/01/2025","Starting Balance","Inflow: Ready to Assign","Inflow","Ready to Assign","",$0.00,$1000.00,"Reconciled"
+"Checking","Red","01/02/2025","Landlord","Bills: Rent","Bills","Rent","",$500.00,$0.00,"Cleared"
+"Checking","","01/03/2025","Corner Market","Food: Groceries","Food","Groceries","Split (1/2) ",$40.00,$0.00,"Cleared"
+"Checking","","01/03/2025","Transfer : Savings","","","","Split (2/2) ",$20.00,$0.00,"Cleared"
+"Checking","","01/04/2025","Transfer : Visa","","","","",$100.00,$0.00,"Cleared"
+"Savings","","01/03/2025","Transfer : Checking","","","","",$0.00,$20.00,"Cleared"
+"Visa","","01/04/2025","Transfer : Checking","","","","",$0.00,$100.00,"Cleared"
+"Visa","","01/05/2025","Cafe","Food: Dining Out","Food","Dining Out","",$15.00,$0.00,"Uncleared"
+`
+	budget := `"Month","Category Group/Category","Category Group","Category","Budgeted","Activity","Available"
+"Jan 2025","Inflow: Ready to Assign","Inflow","Ready to Assign",$0.00,$1000.00,$1000.00
+"Jan 2025","Bills: Rent","Bills","Rent",$500.00,-$500.00,$0.00
+"Jan 2025","Credit Card Payments: Visa","Credit Card Payments","Visa",$0.00,$0.00,$0.00
+"Jan 2025","Food: Groceries","Food","Groceries",$40.00,-$40.00,$0.00
+`
+
+	var report BudgetImportReport
+	c.Request(c.ImportBudgetForm(jwt1, ynabBudgetID, "ynab", map[string]string{
+		"register": register,
+		"budget":   budget,
+	}), http.StatusOK)
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&report))
+	assert.ElementsMatch(t, []string{"Checking", "Savings", "Visa"}, report.CreatedResources.Accounts)
+	assert.Subset(t, report.CreatedResources.Groups, []string{"Bills", "Food", "Credit Card Payments"})
+	assert.Subset(t, report.CreatedResources.Categories, []string{"Rent", "Groceries", "Dining Out", "Visa"})
+	assert.Equal(t, 3, report.Assignments.Accepted)
+	assert.Equal(t, 0, report.Assignments.Rejected)
+
+	accounts := reconciled(report)
+	require.Len(t, accounts, 3)
+	assert.Equal(t, int64(100000-50000-6000-10000), accounts["Checking"].BalanceAfter)
+	assert.Equal(t, int64(2000), accounts["Savings"].BalanceAfter)
+	assert.Equal(t, int64(10000-1500), accounts["Visa"].BalanceAfter)
+	for name, entry := range accounts {
+		assert.Equal(t, int64(0), entry.BalanceBefore, name)
+		require.NotNil(t, entry.Difference, name)
+		assert.Equal(t, int64(0), *entry.Difference, name)
+	}
+
+	// budgets may not be uploaded without a credit card account to pay
+	c.Request(c.CreateBudget(jwt1, "From YNAB Budget", ""), http.StatusCreated)
+	budgetOnlyID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.ImportBudgetForm(jwt1, budgetOnlyID, "ynab", map[string]string{"budget": budget}), http.StatusOK)
+	report = BudgetImportReport{}
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&report))
+	assert.Equal(t, 2, report.Assignments.Accepted)
+	assert.Equal(t, 1, report.Assignments.Rejected)
+	assert.Empty(t, report.Reconciliation)
+
+	c.Request(c.ImportBudgetFile(jwt1, ynabBudgetID, "ynab", "Date,Payee,Amount\n"), http.StatusBadRequest)
+	c.Request(c.ImportBudgetForm(jwt1, ynabBudgetID, "ynab", map[string]string{"other": register}), http.StatusBadRequest)
+	errMsg, _ := c.GetJSONFieldAsString("error")
+	assert.Equal(t, "no register or budget file", errMsg)
+
+	// transfers to tracking accounts keep their category,
+	// and the accounts are kept off-budget
+	c.Request(c.CreateBudget(jwt1, "From YNAB Tracking", ""), http.StatusCreated)
+	trackingBudgetID, _ := c.GetJSONFieldAsString("id")
+	trackingRegister := `"Account","Flag","Date","Payee","Category Group/Category","Category Group","Category","Memo","Outflow","Inflow","Cleared"
+"Checking","","01/01/2025","Starting Balance","Inflow: Ready to Assign","Inflow","Ready to Assign","",$0.00,$1000.00,"Cleared"
+"Checking","","01/02/2025","Transfer : Brokerage","Savings: Retirement","Savings","Retirement","",$200.00,$0.00,"Cleared"
+"Brokerage","","01/02/2025","Transfer : Checking","","","","",$0.00,$200.00,"Cleared"
+`
+	c.Request(c.ImportBudgetForm(jwt1, trackingBudgetID, "ynab", map[string]string{"register": trackingRegister}), http.StatusOK)
+	report = BudgetImportReport{}
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&report))
+	assert.Contains(t, report.CreatedResources.Categories, "Retirement")
+	accounts = reconciled(report)
+	assert.Equal(t, int64(100000-20000), accounts["Checking"].BalanceAfter)
+	assert.Equal(t, int64(20000), accounts["Brokerage"].BalanceAfter)
+	for name, entry := range accounts {
+		require.NotNil(t, entry.Difference, name)
+		assert.Equal(t, int64(0), *entry.Difference, name)
+	}
+	c.Request(c.GetBudgetAccounts(jwt1, trackingBudgetID), http.StatusOK)
+	trackingAccounts, _ := c.GetJSONField("data")
+	for _, account := range trackingAccounts.([]any) {
+		if account.(map[string]any)["name"] == "Brokerage" {
+			assert.Equal(t, "OFF_BUDGET", account.(map[string]any)["account_type"])
+		}
+	}
+
+	// Actual Budget
+	c.Request(c.CreateBudget(jwt1, "From Actual", ""), http.StatusCreated)
+	actualBudgetID, _ := c.GetJSONFieldAsString("id")
+
+	export := "Account,Date,Payee,Notes,Category,Amount,Split_Amount,Cleared\n" +
+		"Checking,2025-01-01,Employer,,Income,1000.00,0,true\n" +
+		"Checking,2025-01-02,Corner Market,,Groceries,-40.00,0,true\n" +
+		"Checking,2025-01-03,Transfer : Savings,,,-100.00,0,false\n" +
+		"Savings,2025-01-03,Transfer : Checking,,,100.00,0,false\n"
+
+	c.Request(c.ImportBudgetFile(jwt1, actualBudgetID, "actual", export), http.StatusOK)
+	report = BudgetImportReport{}
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&report))
+	assert.ElementsMatch(t, []string{"Checking", "Savings"}, report.CreatedResources.Accounts)
+	assert.ElementsMatch(t, []string{"Groceries"}, report.CreatedResources.Categories)
+	accounts = reconciled(report)
+	assert.Equal(t, int64(100000-4000-10000), accounts["Checking"].BalanceAfter)
+	assert.Equal(t, int64(10000), accounts["Savings"].BalanceAfter)
+
+	// importing the same file again logs nothing, which the reconciliation shows
+	c.Request(c.ImportBudgetFile(jwt1, actualBudgetID, "actual", export), http.StatusOK)
+	report = BudgetImportReport{}
+	require.NoError(t, json.NewDecoder(c.W.Body).Decode(&report))
+	accounts = reconciled(report)
+	assert.Equal(t, accounts["Checking"].BalanceBefore, accounts["Checking"].BalanceAfter)
+	require.NotNil(t, accounts["Checking"].Difference)
+	assert.Equal(t, int64(100000-4000-10000), *accounts["Checking"].Difference)
+}
+
+func Test_RecurringTransactions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	cfg := doConfigSetup(t)
+	c := APITestClient{Mux: SetupMux(cfg), testState: t}
+	ctx := t.Context()
+
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	c.Request(c.CreateUser(username1, password1), http.StatusCreated)
+	c.Request(c.LoginUser(username1, password1), http.StatusOK)
+	jwt1, _ := c.GetJSONFieldAsString("token")
+
+	c.Request(c.CreateBudget(jwt1, "Household", ""), http.StatusCreated)
+	budgetID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateGroup(jwt1, budgetID, "Bills", ""), http.StatusCreated)
+	c.Request(c.CreateCategory(jwt1, budgetID, "Bills", "Rent", ""), http.StatusCreated)
+	rentID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.CreateCategory(jwt1, budgetID, "Bills", "Housing", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetAccount(jwt1, budgetID, "ON_BUDGET", "Checking", ""), http.StatusCreated)
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Landlord", ""), http.StatusCreated)
+
+	getRecurring := func(recurringID string) RecurringTransaction {
+		var recurring RecurringTransaction
+		c.Request(c.GetRecurringTransaction(jwt1, budgetID, recurringID), http.StatusOK)
+		require.NoError(t, json.NewDecoder(c.W.Body).Decode(&recurring))
+		return recurring
+	}
+	countTransactions := func() int {
+		c.Request(c.GetTransactions(jwt1, budgetID, "", "", "", "", ""), http.StatusOK)
+		transactions, _ := c.GetJSONField("data")
+		return len(transactions.([]any))
+	}
+
+	c.Request(c.CreateRecurringTransaction(jwt1, budgetID, "Checking", "2025-01-15", "Landlord", "MONTHLY", map[string]int64{"Rent": -50000}), http.StatusCreated)
+	recurringID, _ := c.GetJSONFieldAsString("id")
+
+	// every occurrence due is posted, and only once
+	cfg.postDueRecurringTxns(ctx, date(2025, time.March, 20))
+	cfg.postDueRecurringTxns(ctx, date(2025, time.March, 20))
+	assert.Equal(t, 3, countTransactions())
+	recurring := getRecurring(recurringID)
+	require.NotNil(t, recurring.NextDate)
+	assert.Equal(t, date(2025, time.April, 15), *recurring.NextDate)
+
+	// updates resume the schedule where it left off
+	c.Request(c.UpdateRecurringTransaction(jwt1, budgetID, recurringID, "Checking", "2025-01-15", "Landlord", "MONTHLY", map[string]int64{"Rent": -55000}), http.StatusOK)
+	recurring = getRecurring(recurringID)
+	require.NotNil(t, recurring.NextDate)
+	assert.Equal(t, date(2025, time.April, 15), *recurring.NextDate)
+	assert.Equal(t, int64(-55000), recurring.Amounts[rentID])
+	cfg.postDueRecurringTxns(ctx, date(2025, time.April, 20))
+	assert.Equal(t, 4, countTransactions())
+
+	// those failing to post are retried after a delay, until disabled
+	c.Request(c.DeleteBudgetCategory(jwt1, budgetID, rentID), http.StatusNoContent)
+	now := date(2025, time.May, 20)
+	cfg.postDueRecurringTxns(ctx, now)
+	recurring = getRecurring(recurringID)
+	assert.Equal(t, int32(1), recurring.FailureCount)
+	assert.NotEmpty(t, recurring.LastError)
+	assert.NotNil(t, recurring.RetryAfter)
+	assert.Nil(t, recurring.DisabledAt)
+
+	cfg.postDueRecurringTxns(ctx, now.Add(time.Minute))
+	assert.Equal(t, int32(1), getRecurring(recurringID).FailureCount)
+
+	for range maxRecurringFailures {
+		now = now.Add(maxRecurringRetryDelay)
+		cfg.postDueRecurringTxns(ctx, now)
+	}
+	recurring = getRecurring(recurringID)
+	assert.Equal(t, int32(maxRecurringFailures), recurring.FailureCount)
+	assert.NotNil(t, recurring.DisabledAt)
+	assert.Equal(t, 4, countTransactions())
+
+	// updating a disabled transaction enables it again
+	c.Request(c.UpdateRecurringTransaction(jwt1, budgetID, recurringID, "Checking", "2025-01-15", "Landlord", "MONTHLY", map[string]int64{"Housing": -55000}), http.StatusOK)
+	recurring = getRecurring(recurringID)
+	assert.Equal(t, int32(0), recurring.FailureCount)
+	assert.Nil(t, recurring.DisabledAt)
+	cfg.postDueRecurringTxns(ctx, now)
+	assert.Equal(t, 5, countTransactions())
+
+	// payees scheduled to are replaced in the schedule when deleted
+	c.Request(c.CreateBudgetPayee(jwt1, budgetID, "Property Manager", ""), http.StatusCreated)
+	managerID, _ := c.GetJSONFieldAsString("id")
+	c.Request(c.GetBudgetPayees(jwt1, budgetID), http.StatusOK)
+	payees, _ := c.GetJSONField("data")
+	for _, payee := range payees.([]any) {
+		if payee.(map[string]any)["name"] == "Landlord" {
+			c.Request(c.DeletePayee(jwt1, budgetID, payee.(map[string]any)["id"].(string), "Property Manager"), http.StatusNoContent)
+		}
+	}
+	recurring = getRecurring(recurringID)
+	require.NotNil(t, recurring.PayeeID)
+	assert.Equal(t, managerID, recurring.PayeeID.String())
+
+	c.Request(c.DeleteRecurringTransaction(jwt1, budgetID, recurringID), http.StatusNoContent)
+	c.Request(c.GetRecurringTransaction(jwt1, budgetID, recurringID), http.StatusNotFound)
+	cfg.postDueRecurringTxns(ctx, date(2025, time.December, 31))
+	assert.Equal(t, 5, countTransactions())
 }
@@ -0,0 +1,334 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/YouWantToPinch/pincher-api/internal/auth"
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// invitationLifetime is how long an invitation may be accepted.
+const invitationLifetime = time.Hour * 24 * 7
+
+// handleCreateBudgetInvitation invites a user, by username, to join the budget
+// with the proposed role. Where no username is given, a single-use link
+// invitation is created instead, which whoever holds its token may accept.
+// The token itself is only ever returned here; only its hash is kept.
+func (cfg *APIConfig) handleCreateBudgetInvitation(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	type rqSchema struct {
+		UserName   string `json:"username"`
+		MemberRole string `json:"member_role"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	memberRole, err := BMRFromString(rqPayload.MemberRole)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failure interpreting role", err)
+		return
+	}
+	if memberRole == ADMIN {
+		respondWithError(w, http.StatusBadRequest, "could not invite admin to budget (only one is permitted)", nil)
+		return
+	}
+
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	params := db.CreateBudgetInvitationParams{
+		BudgetID:   pathBudgetID,
+		InviterID:  &validatedUserID,
+		MemberRole: memberRole.String(),
+		ExpiresAt:  time.Now().UTC().Add(invitationLifetime),
+	}
+	var token string
+	if rqPayload.UserName != "" {
+		invitee, err := cfg.db.GetUserByUsername(r.Context(), rqPayload.UserName)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "user does not exist", err)
+			return
+		}
+		_, err = cfg.db.GetBudgetMemberRole(r.Context(), db.GetBudgetMemberRoleParams{
+			BudgetID: pathBudgetID,
+			UserID:   invitee.ID,
+		})
+		if err == nil {
+			respondWithError(w, http.StatusConflict, "user is already a member of this budget", nil)
+			return
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "could not check budget membership", err)
+			return
+		}
+		params.InviteeID = &invitee.ID
+	} else {
+		token, err = auth.MakeInvitationToken()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not create invitation token", err)
+			return
+		}
+		params.HashedToken = pgtype.Text{String: auth.HashInvitationToken(token), Valid: true}
+	}
+
+	// invitations never answered are forgotten as new ones are made
+	if err := cfg.db.DeleteExpiredBudgetInvitations(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not clear expired invitations", err)
+		return
+	}
+	dbInvitation, err := cfg.db.CreateBudgetInvitation(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not save invitation", err)
+		return
+	}
+
+	rspPayload := invitationFromDB(&dbInvitation)
+	rspPayload.Token = token
+	respondWithJSON(w, http.StatusCreated, rspPayload)
+}
+
+func (cfg *APIConfig) handleGetBudgetInvitations(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	dbInvitations, err := cfg.db.GetBudgetInvitations(r.Context(), pathBudgetID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve invitations", err)
+		return
+	}
+
+	invitations := []BudgetInvitation{}
+	for _, dbInvitation := range dbInvitations {
+		invitations = append(invitations, invitationFromDB(&dbInvitation))
+	}
+
+	type rspSchema struct {
+		Invitations []BudgetInvitation `json:"data"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{Invitations: invitations})
+}
+
+func (cfg *APIConfig) handleRevokeBudgetInvitation(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	pathInvitationID, err := parseUUIDFromPath("invitation_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	revoked, err := cfg.db.RevokeBudgetInvitation(r.Context(), db.RevokeBudgetInvitationParams{
+		ID:       pathInvitationID,
+		BudgetID: pathBudgetID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not revoke invitation", err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "no invitation found", nil)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+// handleGetUserInvitations returns every pending invitation addressed to the user.
+func (cfg *APIConfig) handleGetUserInvitations(w http.ResponseWriter, r *http.Request) {
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	dbInvitations, err := cfg.db.GetUserInvitations(r.Context(), &validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve invitations", err)
+		return
+	}
+
+	invitations := []BudgetInvitation{}
+	for _, dbInvitation := range dbInvitations {
+		invitations = append(invitations, BudgetInvitation{
+			CreatedAt:  dbInvitation.CreatedAt,
+			ExpiresAt:  dbInvitation.ExpiresAt,
+			ID:         dbInvitation.ID,
+			BudgetID:   dbInvitation.BudgetID,
+			BudgetName: dbInvitation.BudgetName,
+			InviterID:  dbInvitation.InviterID,
+			InviteeID:  dbInvitation.InviteeID,
+			MemberRole: dbInvitation.MemberRole,
+		})
+	}
+
+	type rspSchema struct {
+		Invitations []BudgetInvitation `json:"data"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{Invitations: invitations})
+}
+
+func (cfg *APIConfig) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	pathInvitationID, err := parseUUIDFromPath("invitation_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	cfg.acceptInvitation(w, r, func(q *db.Queries) (db.BudgetInvitation, error) {
+		return q.ConsumeUserInvitation(r.Context(), db.ConsumeUserInvitationParams{
+			ID:        pathInvitationID,
+			InviteeID: &validatedUserID,
+		})
+	})
+}
+
+// handleRedeemInvitationLink accepts a link invitation by its token.
+func (cfg *APIConfig) handleRedeemInvitationLink(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		Token string `json:"token"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if rqPayload.Token == "" {
+		respondWithError(w, http.StatusBadRequest, "missing invitation token", nil)
+		return
+	}
+
+	hashedToken := pgtype.Text{String: auth.HashInvitationToken(rqPayload.Token), Valid: true}
+	cfg.acceptInvitation(w, r, func(q *db.Queries) (db.BudgetInvitation, error) {
+		return q.ConsumeInvitationByToken(r.Context(), hashedToken)
+	})
+}
+
+// acceptInvitation makes the user a member of the budget to which they were
+// invited, with the role proposed, using up the invitation found by consume.
+func (cfg *APIConfig) acceptInvitation(w http.ResponseWriter, r *http.Request, consume func(*db.Queries) (db.BudgetInvitation, error)) {
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		dbInvitation, err := consume(q)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "no pending invitation found", nil)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "could not get invitation", err)
+			return
+		}
+
+		dbMembership, err := q.CreateBudgetMembership(r.Context(), db.CreateBudgetMembershipParams{
+			BudgetID:   dbInvitation.BudgetID,
+			UserID:     validatedUserID,
+			MemberRole: dbInvitation.MemberRole,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				respondWithError(w, http.StatusConflict, "user is already a member of this budget", nil)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "could not add member to budget", err)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+
+		memberRole, _ := BMRFromString(dbMembership.MemberRole)
+		respondWithJSON(w, http.StatusCreated, BudgetMembership{
+			BudgetID:   dbMembership.BudgetID,
+			UserID:     dbMembership.UserID,
+			MemberRole: memberRole,
+		})
+	}
+}
+
+func (cfg *APIConfig) handleDeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	pathInvitationID, err := parseUUIDFromPath("invitation_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		dbInvitation, err := q.ConsumeUserInvitation(r.Context(), db.ConsumeUserInvitationParams{
+			ID:        pathInvitationID,
+			InviteeID: &validatedUserID,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "no pending invitation found", nil)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "could not decline invitation", err)
+			return
+		}
+
+		// the invitation is recorded in the log of the budget it was to,
+		// as its revocation would be
+		err = recordAudit(r.Context(), q, db.CreateAuditEntryParams{
+			BudgetID:     &dbInvitation.BudgetID,
+			ActorID:      &validatedUserID,
+			ResourceType: auditInvitation.kind,
+			ResourceID:   dbInvitation.ID.String(),
+			Action:       auditDelete,
+		}, invitationFromDB(&dbInvitation), nil)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record audit entry", err)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+func invitationFromDB(dbInvitation *db.BudgetInvitation) BudgetInvitation {
+	return BudgetInvitation{
+		CreatedAt:  dbInvitation.CreatedAt,
+		ExpiresAt:  dbInvitation.ExpiresAt,
+		ID:         dbInvitation.ID,
+		BudgetID:   dbInvitation.BudgetID,
+		InviterID:  dbInvitation.InviterID,
+		InviteeID:  dbInvitation.InviteeID,
+		MemberRole: dbInvitation.MemberRole,
+	}
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 
 	"github.com/YouWantToPinch/pincher-api/internal/auth"
 	db "github.com/YouWantToPinch/pincher-api/internal/database"
@@ -55,26 +59,89 @@ func (cfg *APIConfig) middlewareHandleCORS(next http.Handler) http.Handler {
 	})
 }
 
-// middlewareAuthenticate authenticates an expected bearer JSON Web Token found in
-// the Authorization header before passing off requests to another handler.
+// middlewareAuthenticate authenticates either an expected bearer JSON Web Token
+// or a personal API key found in the Authorization header before passing off
+// requests to another handler. Requests authenticated by an API key carry the
+// key within their context, so that its scope may be enforced. Tokens of
+// sessions since revoked, whether signed out or ended by a change of
+// password, are refused even before they expire.
 func (cfg *APIConfig) middlewareAuthenticate(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxUserID := ctxKey("user_id")
+
+		if apiKey, err := auth.GetAPIKey(r.Header); err == nil {
+			dbAPIKey, err := cfg.db.GetActiveAPIKeyByHash(r.Context(), auth.HashAPIKey(apiKey))
+			if err != nil {
+				respondWithError(w, http.StatusUnauthorized, "invalid API key provided", err)
+				return
+			}
+			if err := cfg.db.TouchAPIKey(r.Context(), dbAPIKey.ID); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not record API key use", err)
+				return
+			}
+			ctxAPIKey := ctxKey("api_key")
+			ctx := context.WithValue(r.Context(), ctxUserID, dbAPIKey.UserID)
+			ctx = context.WithValue(ctx, ctxAPIKey, &dbAPIKey)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		tokenString, err := auth.GetBearerToken(r.Header)
 		if err != nil {
 			respondWithError(w, http.StatusBadRequest, "no token provided", err)
 			return
 		}
-		validatedUserID, err := auth.ValidateJWT(tokenString, cfg.jwtSecret, "HS256")
+		validatedUserID, err := auth.ValidateJWT(tokenString, cfg.jwtKeys)
 		if err != nil {
 			respondWithError(w, http.StatusUnauthorized, "invalid token provided", err)
 			return
 		}
-		ctxUserID := ctxKey("user_id")
+		// an access token is only good for as long as its session,
+		// and one issued for no session could never be revoked
+		sessionID, err := auth.GetJWTSessionID(tokenString, cfg.jwtKeys)
+		if err != nil || sessionID == uuid.Nil {
+			respondWithError(w, http.StatusUnauthorized, "invalid token provided", err)
+			return
+		}
+		active, err := cfg.db.IsSessionActive(r.Context(), sessionID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not check session", err)
+			return
+		}
+		if !active {
+			respondWithError(w, http.StatusUnauthorized, "session has been revoked", nil)
+			return
+		}
 		ctx := context.WithValue(r.Context(), ctxUserID, validatedUserID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// middlewareDenyAPIKeys rejects requests authenticated by an API key,
+// for actions reserved to users signed in with their credentials.
+func (cfg *APIConfig) middlewareDenyAPIKeys(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if getContextAPIKey(r.Context()) != nil {
+			respondWithError(w, http.StatusForbidden, "action not permitted with an API key", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// middlewareRequireAdmin rejects requests from any user but those
+// configured to administer the server.
+func (cfg *APIConfig) middlewareRequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+		if _, ok := cfg.admins[validatedUserID]; !ok {
+			respondWithCode(w, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // middlewareCheckClearance evaluates whether or not a user can perform
 // the action that they intend to perform at a persmissions level,
 // dependent on their member role within the budget in question.
@@ -103,6 +170,26 @@ func (cfg *APIConfig) middlewareCheckClearance(required BudgetMemberRole, next h
 			return
 		}
 
+		// API keys act with no more clearance than they were granted,
+		// and only within the budgets they were granted access to
+		if apiKey := getContextAPIKey(r.Context()); apiKey != nil {
+			keyBudgetIDs, err := cfg.db.GetAPIKeyBudgetIDs(r.Context(), apiKey.ID)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not get API key budgets", err)
+				return
+			}
+			if !slices.Contains(keyBudgetIDs, pathBudgetID) {
+				respondWithError(w, http.StatusForbidden, "API key not granted access to budget", nil)
+				return
+			}
+			keyRole, err := BMRFromString(apiKey.MaxRole)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "invalid API key role", err)
+				return
+			}
+			callerBudgetMemberRole = max(callerBudgetMemberRole, keyRole)
+		}
+
 		if callerBudgetMemberRole > required {
 			respondWithError(w, http.StatusForbidden, "user does not have clearance for action", err)
 			return
@@ -131,87 +218,163 @@ func (cfg *APIConfig) middlewareValidateTxn(next http.HandlerFunc) http.HandlerF
 		}
 		if validatedTxn.txnType == "NONE" {
 			respondWithError(w, http.StatusInternalServerError, "transaction type could not be inferred", nil)
+			return
 		}
 
-		accountID, err := lookupResourceIDByName(r.Context(),
-			db.GetBudgetAccountIDByNameParams{
-				AccountName: rqPayload.AccountName,
-				BudgetID:    pathBudgetID,
-			}, cfg.db.GetBudgetAccountIDByName)
+		if msg, err := resolveTxnNames(r.Context(), cfg.db, pathBudgetID, &rqPayload, validatedTxn); err != nil {
+			respondWithError(w, http.StatusBadRequest, msg, err)
+			return
+		}
+
+		validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+		restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "could not get account by given name", err)
+			respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
 			return
 		}
-		validatedTxn.accountID = accountID
+		if restriction != nil {
+			// a transaction being updated must already be within reach
+			if r.PathValue("transaction_id") != "" {
+				pathTransactionID, err := parseUUIDFromPath("transaction_id", r)
+				if err != nil {
+					respondWithError(w, http.StatusBadRequest, "", err)
+					return
+				}
+				dbTransaction, err := cfg.db.GetTransactionByID(r.Context(), pathTransactionID)
+				if err != nil {
+					respondWithError(w, http.StatusNotFound, "could not get transaction", err)
+					return
+				}
+				if !permitsAccount(restriction, dbTransaction.AccountID) {
+					respondWithError(w, http.StatusNotFound, "could not get transaction", nil)
+					return
+				}
+			}
+			if err := checkTxnRestrictions(restriction, validatedTxn); err != nil {
+				respondWithError(w, http.StatusForbidden, err.Error(), nil)
+				return
+			}
+		}
 
-		accountIDAndType, err := cfg.db.GetBudgetAccountIDAndTypeByName(r.Context(), db.GetBudgetAccountIDAndTypeByNameParams{
-			AccountName: rqPayload.AccountName,
-			BudgetID:    pathBudgetID,
-		})
+		ctxValidatedTxn := ctxKey("validated_txn")
+		ctx := context.WithValue(r.Context(), ctxValidatedTxn, validatedTxn)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// middlewareAudit records a change made by the wrapped handler to a
+// resource of a budget in the audit log, along with who made it, and the
+// state of the resource before and after. The handler runs within a
+// transaction of its own, in which the entry is recorded, and which commits
+// only once it has been; its response is held back until then. Where the
+// handler does not succeed, nothing is recorded, and nothing it changed is
+// kept; where it succeeds without changing anything, nothing is recorded.
+// The budget is taken from the context where clearance was checked, or else
+// from the response.
+func (cfg *APIConfig) middlewareAudit(resource auditResource, action string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		budgetID, _ := r.Context().Value(ctxKey("budget_id")).(uuid.UUID)
+		validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+		// the request body is read here, then given back to the handler
+		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "could not get account by given name", err)
+			respondWithError(w, http.StatusBadRequest, "could not read request body", err)
 			return
 		}
-		validatedTxn.accountID = accountIDAndType.ID
+		r.Body = io.NopCloser(bytes.NewReader(body))
 
-		if validatedTxn.isTransfer {
-			transferAccountID, err := lookupResourceIDByName(r.Context(),
-				db.GetBudgetAccountIDByNameParams{
-					AccountName: rqPayload.TransferAccountName,
-					BudgetID:    pathBudgetID,
-				}, cfg.db.GetBudgetAccountIDByName)
+		// DB TRANSACTION BLOCK
+		{
+			tx, err := cfg.Pool.Begin(r.Context())
 			if err != nil {
-				respondWithError(w, http.StatusBadRequest, "could not get transfer account by given name", err)
+				respondWithError(w, http.StatusInternalServerError, "", err)
 				return
 			}
-			validatedTxn.transferAccountID = transferAccountID
-			validatedTxn.payeeID = uuid.Nil
-		} else {
-			payeeID, err := lookupResourceIDByName(r.Context(),
-				db.GetBudgetPayeeIDByNameParams{
-					PayeeName: rqPayload.PayeeName,
-					BudgetID:  pathBudgetID,
-				}, cfg.db.GetBudgetPayeeIDByName)
-			if err != nil {
-				respondWithError(w, http.StatusBadRequest, "could not get payee by given name", err)
+			defer tx.Rollback(r.Context())
+
+			ctxAuditTx := ctxKey("audit_tx")
+			r = r.WithContext(context.WithValue(r.Context(), ctxAuditTx, tx))
+			q := cfg.db.WithTx(tx)
+
+			var resourceID string
+			if resource.locate != nil {
+				resourceID = resource.locate(r, body, q)
+			}
+
+			var before any
+			if resource.snapshot != nil && resourceID != "" && budgetID != uuid.Nil {
+				before, err = resource.snapshot(r.Context(), q, budgetID, resourceID)
+				if err != nil {
+					respondWithError(w, http.StatusInternalServerError, "could not read resource for audit", err)
+					return
+				}
+			}
+
+			rec := newAuditRecorder()
+			next.ServeHTTP(rec, r)
+			if rec.code < 200 || rec.code > 299 {
+				// nothing the handler did before failing is kept unrecorded
+				if err := tx.Rollback(r.Context()); err != nil {
+					respondWithError(w, http.StatusInternalServerError, "", err)
+					return
+				}
+				rec.flush(w)
 				return
 			}
-			validatedTxn.payeeID = payeeID
-			validatedTxn.transferAccountID = uuid.Nil
-		}
 
-		// convert names to IDs if needed
-		for k, v := range rqPayload.Amounts {
-			if _, ok := validatedTxn.amounts[k]; !ok {
-				// validation already weeded this one out; move on to the next
-				continue
+			rspBody := rec.body.Bytes()
+			if resource.changed != nil && !resource.changed(rspBody) {
+				if err := tx.Commit(r.Context()); err != nil {
+					respondWithError(w, http.StatusInternalServerError, "", err)
+					return
+				}
+				rec.flush(w)
+				return
 			}
-			if accountIDAndType.AccountType == "OFF_BUDGET" ||
-				k == "TRANSFER" ||
-				(k == "UNCATEGORIZED" && validatedTxn.txnType == "DEPOSIT") {
-				// categories are not relevant
-				continue
+
+			if resourceID == "" {
+				resourceID = responseField(rspBody, resource.idField)
 			}
-			categoryID, err := lookupResourceIDByName(r.Context(),
-				db.GetBudgetCategoryIDByNameParams{
-					CategoryName: k,
-					BudgetID:     pathBudgetID,
-				}, cfg.db.GetBudgetCategoryIDByName)
-			if err != nil {
-				errMessage := "could not get category by given name for transaction split"
-				if len(rqPayload.Amounts) > 1 {
-					errMessage = "could not get category by given name for one or more transaction splits"
+			if budgetID == uuid.Nil {
+				if resource.kind == auditBudget.kind {
+					budgetID, _ = uuid.Parse(resourceID)
+				} else {
+					budgetID, _ = uuid.Parse(responseField(rspBody, "budget_id"))
 				}
-				respondWithError(w, http.StatusBadRequest, errMessage, err)
+			}
+
+			var after any
+			if resource.snapshot == nil {
+				if json.Valid(rspBody) {
+					after = json.RawMessage(rspBody)
+				}
+			} else if action != auditDelete {
+				after, err = resource.snapshot(r.Context(), q, budgetID, resourceID)
+				if err != nil {
+					respondWithError(w, http.StatusInternalServerError, "could not read resource for audit", err)
+					return
+				}
+			}
+
+			err = recordAudit(r.Context(), q, db.CreateAuditEntryParams{
+				BudgetID:     &budgetID,
+				ActorID:      &validatedUserID,
+				ResourceType: resource.kind,
+				ResourceID:   resourceID,
+				Action:       action,
+			}, before, after)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not record audit entry", err)
 				return
 			}
-			validatedTxn.amounts[categoryID.String()] = v
-			delete(validatedTxn.amounts, k)
-		}
 
-		ctxValidatedTxn := ctxKey("validated_txn")
-		ctx := context.WithValue(r.Context(), ctxValidatedTxn, validatedTxn)
-		next.ServeHTTP(w, r.WithContext(ctx))
+			if err := tx.Commit(r.Context()); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "", err)
+				return
+			}
+			rec.flush(w)
+		}
 	})
 }
 
@@ -234,3 +397,13 @@ func getContextKeyValueAsTxn(ctx context.Context, key string) *validatedTxnPaylo
 	}
 	return contextKeyValue
 }
+
+// getContextAPIKey returns the API key which authenticated a request,
+// or nil where the request was not authenticated by one.
+func getContextAPIKey(ctx context.Context) *db.ApiKey {
+	contextKeyValue, ok := ctx.Value(ctxKey("api_key")).(*db.ApiKey)
+	if !ok {
+		return nil
+	}
+	return contextKeyValue
+}
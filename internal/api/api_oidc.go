@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/YouWantToPinch/pincher-api/internal/auth"
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// oidcLoginLifetime is how long a user has to sign in
+// with the OpenID Connect provider, once sent there.
+const oidcLoginLifetime = time.Minute * 10
+
+// oidcLoginCookie holds the secret binding a login to the browser that
+// began it, without which the login cannot be completed. It is sent only
+// to the callback, and survives the redirect back from the provider.
+const oidcLoginCookie = "oidc_login"
+
+// handleStartOIDCLogin begins a login with the OpenID Connect provider,
+// returning the URL to which the user is to be sent to sign in.
+func (cfg *APIConfig) handleStartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	cfg.startOIDCLogin(w, r, nil)
+}
+
+// handleLinkOIDCIdentity begins a login with the OpenID Connect provider
+// on behalf of a signed-in user, to whom the identity they sign in as
+// will be linked.
+func (cfg *APIConfig) handleLinkOIDCIdentity(w http.ResponseWriter, r *http.Request) {
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+	cfg.startOIDCLogin(w, r, &validatedUserID)
+}
+
+func (cfg *APIConfig) startOIDCLogin(w http.ResponseWriter, r *http.Request, linkUserID *uuid.UUID) {
+	if cfg.oidc == nil {
+		respondWithError(w, http.StatusNotFound, "OpenID Connect login not configured", nil)
+		return
+	}
+
+	var secrets [4]string
+	for i := range secrets {
+		secret, err := auth.MakeOIDCSecret()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not begin login", err)
+			return
+		}
+		secrets[i] = secret
+	}
+	state, nonce, verifier, binding := secrets[0], secrets[1], secrets[2], secrets[3]
+
+	authURL, err := cfg.oidc.AuthCodeURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "could not reach identity provider", err)
+		return
+	}
+
+	// logins never finished are forgotten as new ones begin
+	if err := cfg.db.DeleteExpiredOIDCLogins(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not clear expired logins", err)
+		return
+	}
+	err = cfg.db.CreateOIDCLogin(r.Context(), db.CreateOIDCLoginParams{
+		State:        state,
+		ExpiresAt:    time.Now().UTC().Add(oidcLoginLifetime),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		UserID:       linkUserID,
+		BindingHash:  auth.HashOIDCBinding(binding),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not save login", err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcLoginCookie,
+		Value:    binding,
+		Path:     "/api/login/oidc",
+		MaxAge:   int(oidcLoginLifetime.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	type rspSchema struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+	respondWithJSON(w, http.StatusOK, rspSchema{AuthorizationURL: authURL})
+}
+
+// handleOIDCCallback completes a login with the OpenID Connect provider.
+// The user is found by the identity they signed in as or, where allowed,
+// created for it, and then signed in as with a password. Logins begun by
+// a signed-in user instead link the identity to that user.
+func (cfg *APIConfig) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if cfg.oidc == nil {
+		respondWithError(w, http.StatusNotFound, "OpenID Connect login not configured", nil)
+		return
+	}
+
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		respondWithError(w, http.StatusUnauthorized, "identity provider refused login: "+errCode, nil)
+		return
+	}
+	code, state := query.Get("code"), query.Get("state")
+	if code == "" || state == "" {
+		respondWithError(w, http.StatusBadRequest, "missing code or state", nil)
+		return
+	}
+
+	// each login may only be completed once, and only by the browser that began it
+	cookie, err := r.Cookie(oidcLoginCookie)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "login was not begun by this browser", nil)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcLoginCookie,
+		Path:     "/api/login/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	dbLogin, err := cfg.db.ConsumeOIDCLogin(r.Context(), db.ConsumeOIDCLoginParams{
+		State:       state,
+		BindingHash: auth.HashOIDCBinding(cookie.Value),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusUnauthorized, "unknown or expired login", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not get login", err)
+		return
+	}
+
+	idToken, err := cfg.oidc.Exchange(r.Context(), code, dbLogin.CodeVerifier)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "could not redeem authorization code", err)
+		return
+	}
+	claims, err := cfg.oidc.VerifyIDToken(r.Context(), idToken, dbLogin.Nonce)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid ID token", err)
+		return
+	}
+
+	if dbLogin.UserID != nil {
+		// DB TRANSACTION BLOCK
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		dbIdentity, err := q.CreateUserIdentity(r.Context(), db.CreateUserIdentityParams{
+			Issuer:  cfg.oidc.Issuer(),
+			Subject: claims.Subject,
+			UserID:  *dbLogin.UserID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusConflict, "identity already linked", err)
+			return
+		}
+		// linking an identity changes no budget, and is recorded without one
+		err = recordAudit(r.Context(), q, db.CreateAuditEntryParams{
+			ActorID:      dbLogin.UserID,
+			ResourceType: "USER_IDENTITY",
+			ResourceID:   dbIdentity.Issuer + " " + dbIdentity.Subject,
+			Action:       auditCreate,
+		}, nil, dbIdentity)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record audit entry", err)
+			return
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+
+		type rspSchema struct {
+			CreatedAt time.Time `json:"created_at"`
+			Issuer    string    `json:"issuer"`
+			Subject   string    `json:"subject"`
+		}
+		respondWithJSON(w, http.StatusCreated, rspSchema{
+			CreatedAt: dbIdentity.CreatedAt,
+			Issuer:    dbIdentity.Issuer,
+			Subject:   dbIdentity.Subject,
+		})
+		return
+	}
+
+	dbUser, err := cfg.db.GetUserByIdentity(r.Context(), db.GetUserByIdentityParams{
+		Issuer:  cfg.oidc.Issuer(),
+		Subject: claims.Subject,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		if !cfg.oidcAutoProvision {
+			respondWithError(w, http.StatusForbidden, "no user linked to this identity", nil)
+			return
+		}
+		dbUser, err = cfg.provisionOIDCUser(r.Context(), claims)
+		if err != nil {
+			respondWithError(w, http.StatusConflict, "could not create user for identity", err)
+			return
+		}
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user by identity", err)
+		return
+	}
+
+	cfg.completeLogin(w, r, &dbUser)
+}
+
+// provisionOIDCUser creates a user for an identity to which none is linked,
+// named as the provider prefers. Its password is random and never revealed,
+// so it may only sign in through the provider until it sets one of its own.
+func (cfg *APIConfig) provisionOIDCUser(ctx context.Context, claims *auth.IDTokenClaims) (db.User, error) {
+	username := claims.PreferredUsername
+	if username == "" {
+		username = claims.Email
+	}
+	if username == "" {
+		return db.User{}, errors.New("identity has no preferred username or email")
+	}
+
+	password, err := auth.MakeOIDCSecret()
+	if err != nil {
+		return db.User{}, err
+	}
+	hashedPass, err := auth.HashPassword(password)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	tx, err := cfg.beginTx(ctx)
+	if err != nil {
+		return db.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := cfg.db.WithTx(tx)
+
+	dbUser, err := q.CreateUser(ctx, db.CreateUserParams{
+		Username:       username,
+		HashedPassword: hashedPass,
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+	_, err = q.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		Issuer:  cfg.oidc.Issuer(),
+		Subject: claims.Subject,
+		UserID:  dbUser.ID,
+	})
+	if err != nil {
+		return db.User{}, err
+	}
+
+	return dbUser, tx.Commit(ctx)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+func (cfg *APIConfig) handleSetBudgetOverspendingRule(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		OverspendingRule string `json:"overspending_rule"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if err := validateOverspendingRule(&rqPayload.OverspendingRule, false); err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	err = cfg.db.SetBudgetOverspendingRule(r.Context(), db.SetBudgetOverspendingRuleParams{
+		ID:               pathBudgetID,
+		OverspendingRule: rqPayload.OverspendingRule,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not set budget overspending rule", err)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+func (cfg *APIConfig) handleSetCategoryOverspendingRule(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		OverspendingRule string `json:"overspending_rule"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if err := validateOverspendingRule(&rqPayload.OverspendingRule, true); err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	dbCategory := cfg.getBudgetCategory(w, r)
+	if dbCategory == nil {
+		return
+	}
+
+	err = cfg.db.SetCategoryOverspendingRule(r.Context(), db.SetCategoryOverspendingRuleParams{
+		ID:               dbCategory.ID,
+		OverspendingRule: rqPayload.OverspendingRule,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not set category overspending rule", err)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
@@ -147,7 +147,7 @@ func (cfg *APIConfig) handleDeletePayee(w http.ResponseWriter, r *http.Request)
 
 	// DB TRANSACTION BLOCK
 	{
-		tx, err := cfg.Pool.Begin(r.Context())
+		tx, err := cfg.beginTx(r.Context())
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "", err)
 			return
@@ -207,6 +207,14 @@ func (cfg *APIConfig) handleDeletePayee(w http.ResponseWriter, r *http.Request)
 				respondWithError(w, http.StatusInternalServerError, "could not reassign payee for transactions", err)
 				return
 			}
+			err = q.ReassignRecurringTransactions(r.Context(), db.ReassignRecurringTransactionsParams{
+				OldPayeeID: &pathPayeeID,
+				NewPayeeID: &PayeeID,
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not reassign payee for recurring transactions", err)
+				return
+			}
 		}
 
 		err = q.DeletePayee(r.Context(), pathPayeeID)
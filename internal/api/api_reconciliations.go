@@ -0,0 +1,228 @@
+package api
+
+import (
+	"net/http"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+// reconcileAdjustmentPayee is the payee of adjustment transactions logged
+// to bring the cleared balance of an account in line with a statement.
+const reconcileAdjustmentPayee = "Reconciliation Balance Adjustment"
+
+// handleReconcileAccount compares the balance of a bank statement against the
+// cleared balance of an account as of the statement date. Where the two match,
+// the cleared transactions up to that date are marked as reconciled, which
+// locks them against further changes. Where they do not, the difference is
+// reported; if requested, an adjustment transaction is logged to cover it,
+// and the account is reconciled all the same.
+func (cfg *APIConfig) handleReconcileAccount(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		// StatementDate is a time string in the custom format "2006-01-02" (YYYY-MM-DD)
+		StatementDate    string `json:"statement_date"`
+		StatementBalance int64  `json:"statement_balance"`
+		LogAdjustment    bool   `json:"log_adjustment"`
+		// AdjustmentCategory is optional; adjustments are otherwise uncategorized.
+		AdjustmentCategory string `json:"adjustment_category"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if rqPayload.StatementDate == "" {
+		respondWithError(w, http.StatusBadRequest, "statement date not provided", nil)
+		return
+	}
+	statementDate, err := parseDate(rqPayload.StatementDate)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	pathAccountID, err := parseUUIDFromPath("account_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+	dbAccount, err := cfg.db.GetAccountByID(r.Context(), pathAccountID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get account", err)
+		return
+	}
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	if pathBudgetID != dbAccount.BudgetID {
+		respondWithCode(w, http.StatusForbidden)
+		return
+	}
+	if dbAccount.IsDeleted {
+		respondWithError(w, http.StatusConflict, "cannot reconcile a deleted account", nil)
+		return
+	}
+
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+	if !permitsAccount(restriction, dbAccount.ID) {
+		respondWithError(w, http.StatusNotFound, "could not get account", nil)
+		return
+	}
+
+	report := ReconciliationReport{
+		AccountID:        dbAccount.ID,
+		StatementDate:    statementDate,
+		StatementBalance: rqPayload.StatementBalance,
+	}
+
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		clearedBalance, err := q.GetClearedBalance(r.Context(), db.GetClearedBalanceParams{
+			AccountID:     dbAccount.ID,
+			StatementDate: statementDate,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not calculate cleared balance", err)
+			return
+		}
+		report.ClearedBalance = clearedBalance
+		report.Difference = rqPayload.StatementBalance - clearedBalance
+
+		if report.Difference != 0 && !rqPayload.LogAdjustment {
+			respondWithJSON(w, http.StatusOK, report)
+			return
+		}
+
+		params := db.CreateReconciliationParams{
+			AccountID:        dbAccount.ID,
+			ReconcilerID:     validatedUserID,
+			StatementDate:    statementDate,
+			StatementBalance: rqPayload.StatementBalance,
+		}
+
+		if report.Difference != 0 {
+			category := rqPayload.AdjustmentCategory
+			if category == "" {
+				category = "UNCATEGORIZED"
+			}
+			adjustment := UpsertTransactionRqSchema{
+				AccountName:     dbAccount.Name,
+				TransactionDate: rqPayload.StatementDate,
+				PayeeName:       reconcileAdjustmentPayee,
+				Notes:           "Adjustment for statement dated " + rqPayload.StatementDate,
+				Cleared:         true,
+				Amounts:         map[string]int64{category: report.Difference},
+			}
+			validatedTxn, err := validateTxnInput(&adjustment)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "", err)
+				return
+			}
+			if _, err := ensurePayee(r.Context(), q, pathBudgetID, reconcileAdjustmentPayee); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not create adjustment payee", err)
+				return
+			}
+			if msg, err := resolveTxnNames(r.Context(), q, pathBudgetID, &adjustment, validatedTxn); err != nil {
+				respondWithError(w, http.StatusBadRequest, msg, err)
+				return
+			}
+			if err := checkTxnRestrictions(restriction, validatedTxn); err != nil {
+				respondWithError(w, http.StatusForbidden, "could not log adjustment: "+err.Error(), nil)
+				return
+			}
+			adjustmentTxn, _, msg, err := pgxLogValidatedTxn(q, r.Context(), pathBudgetID, validatedUserID, validatedTxn)
+			if err != nil {
+				respondWithError(w, http.StatusConflict, "could not log adjustment: "+msg, err)
+				return
+			}
+			params.AdjustmentTransactionID = &adjustmentTxn.ID
+		}
+
+		locked, err := q.ReconcileTransactions(r.Context(), db.ReconcileTransactionsParams{
+			AccountID:     dbAccount.ID,
+			StatementDate: statementDate,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not reconcile transactions", err)
+			return
+		}
+
+		dbReconciliation, err := q.CreateReconciliation(r.Context(), params)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record reconciliation", err)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+
+		reconciliation := reconciliationFromDB(&dbReconciliation)
+		report.Reconciled = true
+		report.Locked = locked
+		report.Reconciliation = &reconciliation
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+func (cfg *APIConfig) handleGetReconciliations(w http.ResponseWriter, r *http.Request) {
+	pathAccountID, err := parseUUIDFromPath("account_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+	dbAccount, err := cfg.db.GetAccountByID(r.Context(), pathAccountID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get account", err)
+		return
+	}
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	if pathBudgetID != dbAccount.BudgetID {
+		respondWithCode(w, http.StatusForbidden)
+		return
+	}
+
+	dbReconciliations, err := cfg.db.GetReconciliationsByAccountID(r.Context(), dbAccount.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve account reconciliations", err)
+		return
+	}
+
+	reconciliations := []Reconciliation{}
+	for _, dbReconciliation := range dbReconciliations {
+		reconciliations = append(reconciliations, reconciliationFromDB(&dbReconciliation))
+	}
+
+	type rspSchema struct {
+		Reconciliations []Reconciliation `json:"data"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{Reconciliations: reconciliations})
+}
+
+func reconciliationFromDB(dbReconciliation *db.Reconciliation) Reconciliation {
+	return Reconciliation{
+		CreatedAt:               dbReconciliation.CreatedAt,
+		ID:                      dbReconciliation.ID,
+		AccountID:               dbReconciliation.AccountID,
+		ReconcilerID:            dbReconciliation.ReconcilerID,
+		StatementDate:           dbReconciliation.StatementDate,
+		StatementBalance:        dbReconciliation.StatementBalance,
+		AdjustmentTransactionID: dbReconciliation.AdjustmentTransactionID,
+	}
+}
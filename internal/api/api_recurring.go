@@ -0,0 +1,435 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+// maxUpcomingDays limits how far ahead recurring transactions may be previewed.
+const maxUpcomingDays = 366
+
+// UpsertRecurringTransactionRqSchema describes a transaction to be posted
+// at every occurrence of a recurring transaction, along with the rule by
+// which it recurs.
+type UpsertRecurringTransactionRqSchema struct {
+	// TransactionDate of the embedded schema is used as the
+	// date of the first occurrence.
+	UpsertTransactionRqSchema
+	Frequency         string `json:"frequency"`
+	FrequencyInterval int    `json:"frequency_interval"`
+	DayOption         string `json:"day_option"`
+	// EndDate is optional; when set, no occurrences are posted after it.
+	EndDate string `json:"end_date"`
+}
+
+// recurrenceRuleFromRq returns the validated recurrence rule of a request,
+// starting on the given date. Any error returned implies a bad request.
+func recurrenceRuleFromRq(rqPayload *UpsertRecurringTransactionRqSchema, startDate time.Time) (*recurrenceRule, error) {
+	rule := &recurrenceRule{
+		frequency: rqPayload.Frequency,
+		interval:  rqPayload.FrequencyInterval,
+		dayOption: rqPayload.DayOption,
+		startDate: startDate,
+	}
+	if rqPayload.EndDate != "" {
+		endDate, err := parseDate(rqPayload.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		rule.endDate = &endDate
+	}
+	if err := validateRecurrenceRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (cfg *APIConfig) handleCreateRecurringTransaction(w http.ResponseWriter, r *http.Request) {
+	rqPayload, err := decodePayload[UpsertRecurringTransactionRqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	validatedTxn, err := validateTxnInput(&rqPayload.UpsertTransactionRqSchema)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+	if validatedTxn.txnType == "NONE" {
+		respondWithError(w, http.StatusInternalServerError, "", fmt.Errorf("transaction type could not be determined"))
+		return
+	}
+
+	rule, err := recurrenceRuleFromRq(&rqPayload, validatedTxn.txnDate)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+	nextDate, ok := rule.nextOnOrAfter(rule.startDate)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "recurrence rule yields no occurrences before its end date", nil)
+		return
+	}
+
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	if msg, err := resolveTxnNames(r.Context(), cfg.db, pathBudgetID, &rqPayload.UpsertTransactionRqSchema, validatedTxn); err != nil {
+		respondWithError(w, http.StatusBadRequest, msg, err)
+		return
+	}
+
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+	if err := checkTxnRestrictions(restriction, validatedTxn); err != nil {
+		respondWithError(w, http.StatusForbidden, err.Error(), nil)
+		return
+	}
+
+	amountsJSONBytes, err := json.Marshal(validatedTxn.amounts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not marshal amounts for recurring transaction", err)
+		return
+	}
+
+	params := db.CreateRecurringTransactionParams{
+		BudgetID:          pathBudgetID,
+		LoggerID:          validatedUserID,
+		AccountID:         validatedTxn.accountID,
+		TransactionType:   validatedTxn.txnType,
+		Notes:             validatedTxn.notes,
+		Cleared:           validatedTxn.cleared,
+		Amounts:           amountsJSONBytes,
+		Frequency:         rule.frequency,
+		FrequencyInterval: int32(rule.interval),
+		DayOption:         rule.dayOption,
+		StartDate:         rule.startDate,
+		EndDate:           rule.endDate,
+		NextDate:          &nextDate,
+	}
+	if validatedTxn.isTransfer {
+		params.TransferAccountID = &validatedTxn.transferAccountID
+	} else {
+		params.PayeeID = &validatedTxn.payeeID
+	}
+
+	dbRecurring, err := cfg.db.CreateRecurringTransaction(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusConflict, "could not create recurring transaction", err)
+		return
+	}
+
+	rspPayload, err := recurringFromDB(&dbRecurring)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, rspPayload)
+}
+
+func (cfg *APIConfig) handleGetRecurringTransactions(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	dbRecurrings, err := cfg.db.GetRecurringTransactionsByBudgetID(r.Context(), pathBudgetID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve budget recurring transactions", err)
+		return
+	}
+
+	var recurrings []RecurringTransaction
+	for _, dbRecurring := range dbRecurrings {
+		recurring, err := recurringFromDB(&dbRecurring)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		recurrings = append(recurrings, *recurring)
+	}
+
+	type rspSchema struct {
+		RecurringTransactions []RecurringTransaction `json:"data"`
+	}
+
+	rspPayload := rspSchema{
+		RecurringTransactions: recurrings,
+	}
+
+	respondWithJSON(w, http.StatusOK, rspPayload)
+}
+
+func (cfg *APIConfig) handleGetRecurringTransaction(w http.ResponseWriter, r *http.Request) {
+	pathRecurringID, err := parseUUIDFromPath("recurring_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	dbRecurring, err := cfg.db.GetRecurringTransactionByID(r.Context(), pathRecurringID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get recurring transaction", err)
+		return
+	}
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	if pathBudgetID != dbRecurring.BudgetID {
+		respondWithCode(w, http.StatusForbidden)
+		return
+	}
+
+	rspPayload, err := recurringFromDB(&dbRecurring)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rspPayload)
+}
+
+// handleUpdateRecurringTransaction replaces the transaction and recurrence
+// rule of a recurring transaction. Its schedule resumes where it left off:
+// occurrences already posted are not posted again, even where the start date
+// changes. Updating a recurring transaction disabled after failing to post
+// enables it again.
+func (cfg *APIConfig) handleUpdateRecurringTransaction(w http.ResponseWriter, r *http.Request) {
+	pathRecurringID, err := parseUUIDFromPath("recurring_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	rqPayload, err := decodePayload[UpsertRecurringTransactionRqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	validatedTxn, err := validateTxnInput(&rqPayload.UpsertTransactionRqSchema)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+	if validatedTxn.txnType == "NONE" {
+		respondWithError(w, http.StatusInternalServerError, "", fmt.Errorf("transaction type could not be determined"))
+		return
+	}
+
+	rule, err := recurrenceRuleFromRq(&rqPayload, validatedTxn.txnDate)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	dbRecurring, err := cfg.db.GetRecurringTransactionByID(r.Context(), pathRecurringID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get recurring transaction", err)
+		return
+	}
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	if pathBudgetID != dbRecurring.BudgetID {
+		respondWithCode(w, http.StatusForbidden)
+		return
+	}
+
+	// a recurring transaction being updated must already be within reach
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+	if !permitsAccount(restriction, dbRecurring.AccountID) ||
+		(dbRecurring.TransferAccountID != nil && !permitsAccount(restriction, *dbRecurring.TransferAccountID)) {
+		respondWithError(w, http.StatusNotFound, "could not get recurring transaction", nil)
+		return
+	}
+
+	// every occurrence before the next date, or through the former
+	// end date where none remained, has already been posted
+	resumeDate := rule.startDate
+	if dbRecurring.NextDate != nil {
+		resumeDate = *dbRecurring.NextDate
+	} else if dbRecurring.EndDate != nil {
+		resumeDate = dbRecurring.EndDate.AddDate(0, 0, 1)
+	}
+	var nextDate *time.Time
+	if following, ok := rule.nextOnOrAfter(resumeDate); ok {
+		nextDate = &following
+	}
+
+	if msg, err := resolveTxnNames(r.Context(), cfg.db, pathBudgetID, &rqPayload.UpsertTransactionRqSchema, validatedTxn); err != nil {
+		respondWithError(w, http.StatusBadRequest, msg, err)
+		return
+	}
+	if err := checkTxnRestrictions(restriction, validatedTxn); err != nil {
+		respondWithError(w, http.StatusForbidden, err.Error(), nil)
+		return
+	}
+
+	amountsJSONBytes, err := json.Marshal(validatedTxn.amounts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not marshal amounts for recurring transaction", err)
+		return
+	}
+
+	params := db.UpdateRecurringTransactionParams{
+		AccountID:         validatedTxn.accountID,
+		TransactionType:   validatedTxn.txnType,
+		Notes:             validatedTxn.notes,
+		Cleared:           validatedTxn.cleared,
+		Amounts:           amountsJSONBytes,
+		Frequency:         rule.frequency,
+		FrequencyInterval: int32(rule.interval),
+		DayOption:         rule.dayOption,
+		StartDate:         rule.startDate,
+		EndDate:           rule.endDate,
+		NextDate:          nextDate,
+		ID:                pathRecurringID,
+	}
+	if validatedTxn.isTransfer {
+		params.TransferAccountID = &validatedTxn.transferAccountID
+	} else {
+		params.PayeeID = &validatedTxn.payeeID
+	}
+
+	dbRecurring, err = cfg.db.UpdateRecurringTransaction(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusConflict, "could not update recurring transaction", err)
+		return
+	}
+
+	rspPayload, err := recurringFromDB(&dbRecurring)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rspPayload)
+}
+
+func (cfg *APIConfig) handleDeleteRecurringTransaction(w http.ResponseWriter, r *http.Request) {
+	pathRecurringID, err := parseUUIDFromPath("recurring_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	dbRecurring, err := cfg.db.GetRecurringTransactionByID(r.Context(), pathRecurringID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get recurring transaction", err)
+		return
+	}
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	if pathBudgetID != dbRecurring.BudgetID {
+		respondWithCode(w, http.StatusForbidden)
+		return
+	}
+
+	if err := cfg.db.DeleteRecurringTransaction(r.Context(), pathRecurringID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not delete recurring transaction", err)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+// handleGetUpcomingTransactions previews every transaction to be posted
+// by the budget's recurring transactions within the following days.
+// Those overdue, but not yet posted by the scheduler, are included.
+func (cfg *APIConfig) handleGetUpcomingTransactions(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsedDays, err := strconv.Atoi(daysStr)
+		if err != nil || parsedDays < 0 || parsedDays > maxUpcomingDays {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("days must be a number from 0 to %d", maxUpcomingDays), err)
+			return
+		}
+		days = parsedDays
+	}
+
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	dbRecurrings, err := cfg.db.GetRecurringTransactionsByBudgetID(r.Context(), pathBudgetID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve budget recurring transactions", err)
+		return
+	}
+
+	until := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, days)
+
+	upcoming := []UpcomingTransaction{}
+	for _, dbRecurring := range dbRecurrings {
+		if dbRecurring.NextDate == nil {
+			continue
+		}
+		var amounts map[string]int64
+		if err := json.Unmarshal(dbRecurring.Amounts, &amounts); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not unmarshal amounts for recurring transaction", err)
+			return
+		}
+		rule := recurrenceRuleFromDB(&dbRecurring)
+		for _, txnDate := range rule.occurrencesBetween(*dbRecurring.NextDate, until) {
+			upcoming = append(upcoming, UpcomingTransaction{
+				RecurringID:     dbRecurring.ID,
+				TransactionDate: txnDate,
+				TransactionType: dbRecurring.TransactionType,
+				AccountID:       dbRecurring.AccountID,
+				PayeeID:         dbRecurring.PayeeID,
+				Notes:           dbRecurring.Notes,
+				TotalAmount:     totalFromAmountsMap(amounts),
+				Amounts:         amounts,
+			})
+		}
+	}
+	slices.SortStableFunc(upcoming, func(a, b UpcomingTransaction) int {
+		return a.TransactionDate.Compare(b.TransactionDate)
+	})
+
+	type rspSchema struct {
+		UpcomingTransactions []UpcomingTransaction `json:"data"`
+	}
+
+	rspPayload := rspSchema{
+		UpcomingTransactions: upcoming,
+	}
+
+	respondWithJSON(w, http.StatusOK, rspPayload)
+}
+
+func recurringFromDB(dbRecurring *db.RecurringTransaction) (*RecurringTransaction, error) {
+	var amounts map[string]int64
+	if err := json.Unmarshal(dbRecurring.Amounts, &amounts); err != nil {
+		return nil, fmt.Errorf("could not unmarshal amounts for recurring transaction: %w", err)
+	}
+	return &RecurringTransaction{
+		ID:                dbRecurring.ID,
+		CreatedAt:         dbRecurring.CreatedAt,
+		UpdatedAt:         dbRecurring.UpdatedAt,
+		BudgetID:          dbRecurring.BudgetID,
+		LoggerID:          dbRecurring.LoggerID,
+		AccountID:         dbRecurring.AccountID,
+		TransferAccountID: dbRecurring.TransferAccountID,
+		TransactionType:   dbRecurring.TransactionType,
+		PayeeID:           dbRecurring.PayeeID,
+		Notes:             dbRecurring.Notes,
+		Cleared:           dbRecurring.Cleared,
+		Amounts:           amounts,
+		Frequency:         dbRecurring.Frequency,
+		FrequencyInterval: dbRecurring.FrequencyInterval,
+		DayOption:         dbRecurring.DayOption,
+		StartDate:         dbRecurring.StartDate,
+		EndDate:           dbRecurring.EndDate,
+		NextDate:          dbRecurring.NextDate,
+		FailureCount:      dbRecurring.FailureCount,
+		LastError:         dbRecurring.LastError,
+		RetryAfter:        timeFromPGTimestamp(dbRecurring.RetryAfter),
+		DisabledAt:        timeFromPGTimestamp(dbRecurring.DisabledAt),
+	}, nil
+}
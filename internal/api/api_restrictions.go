@@ -0,0 +1,194 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// handleSetMemberRestrictions restricts a member of the budget to the named
+// accounts and categories, and to transactions no greater than the given
+// amount. Whatever is left out of the request is left unrestricted, and any
+// restrictions set before are replaced.
+func (cfg *APIConfig) handleSetMemberRestrictions(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	pathUserID, err := parseUUIDFromPath("user_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	type rqSchema struct {
+		AccountNames         []string `json:"account_names"`
+		CategoryNames        []string `json:"category_names"`
+		MaxTransactionAmount *int64   `json:"max_transaction_amount"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if rqPayload.AccountNames == nil && rqPayload.CategoryNames == nil && rqPayload.MaxTransactionAmount == nil {
+		respondWithError(w, http.StatusBadRequest, "no restrictions given; delete them to lift them", nil)
+		return
+	}
+	if rqPayload.MaxTransactionAmount != nil && *rqPayload.MaxTransactionAmount < 0 {
+		respondWithError(w, http.StatusBadRequest, "max transaction amount may not be negative", nil)
+		return
+	}
+
+	if !cfg.checkRestrictable(w, r, pathBudgetID, pathUserID) {
+		return
+	}
+
+	params := db.UpsertMemberRestrictionParams{
+		BudgetID: pathBudgetID,
+		UserID:   pathUserID,
+	}
+	if rqPayload.AccountNames != nil {
+		params.AllowedAccountIds = []uuid.UUID{}
+		for _, name := range rqPayload.AccountNames {
+			accountID, err := lookupResourceIDByName(r.Context(),
+				db.GetBudgetAccountIDByNameParams{
+					AccountName: name,
+					BudgetID:    pathBudgetID,
+				}, cfg.db.GetBudgetAccountIDByName)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "could not get account by given name: "+name, err)
+				return
+			}
+			params.AllowedAccountIds = append(params.AllowedAccountIds, accountID)
+		}
+	}
+	if rqPayload.CategoryNames != nil {
+		params.AllowedCategoryIds = []uuid.UUID{}
+		for _, name := range rqPayload.CategoryNames {
+			categoryID, err := lookupResourceIDByName(r.Context(),
+				db.GetBudgetCategoryIDByNameParams{
+					CategoryName: name,
+					BudgetID:     pathBudgetID,
+				}, cfg.db.GetBudgetCategoryIDByName)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "could not get category by given name: "+name, err)
+				return
+			}
+			params.AllowedCategoryIds = append(params.AllowedCategoryIds, categoryID)
+		}
+	}
+	if rqPayload.MaxTransactionAmount != nil {
+		params.MaxTransactionAmount = pgtype.Int8{Int64: *rqPayload.MaxTransactionAmount, Valid: true}
+	}
+
+	dbRestriction, err := cfg.db.UpsertMemberRestriction(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not save member restrictions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, restrictionFromDB(&dbRestriction))
+}
+
+func (cfg *APIConfig) handleGetMemberRestrictions(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	pathUserID, err := parseUUIDFromPath("user_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	dbRestriction, err := cfg.db.GetMemberRestriction(r.Context(), db.GetMemberRestrictionParams{
+		BudgetID: pathBudgetID,
+		UserID:   pathUserID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "member is not restricted", nil)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, restrictionFromDB(&dbRestriction))
+}
+
+func (cfg *APIConfig) handleDeleteMemberRestrictions(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+
+	pathUserID, err := parseUUIDFromPath("user_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	if !cfg.checkRestrictable(w, r, pathBudgetID, pathUserID) {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteMemberRestriction(r.Context(), db.DeleteMemberRestrictionParams{
+		BudgetID: pathBudgetID,
+		UserID:   pathUserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not lift member restrictions", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "member is not restricted", nil)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+// checkRestrictable reports whether the restrictions of a member may be
+// changed by the requesting user, responding to the request where they may
+// not. The admin is never restricted, and no member may change their own
+// restrictions.
+func (cfg *APIConfig) checkRestrictable(w http.ResponseWriter, r *http.Request, budgetID, userID uuid.UUID) bool {
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+	if userID == validatedUserID {
+		respondWithError(w, http.StatusForbidden, "members may not change their own restrictions", nil)
+		return false
+	}
+
+	memberRole, err := cfg.db.GetBudgetMemberRole(r.Context(), db.GetBudgetMemberRoleParams{
+		BudgetID: budgetID,
+		UserID:   userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "user is not a member of this budget", nil)
+			return false
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not check budget membership", err)
+		return false
+	}
+	if memberRole == ADMIN.String() {
+		respondWithError(w, http.StatusBadRequest, "the admin of a budget may not be restricted", nil)
+		return false
+	}
+	return true
+}
+
+func restrictionFromDB(dbRestriction *db.MemberRestriction) MemberRestriction {
+	restriction := MemberRestriction{
+		UpdatedAt:          dbRestriction.UpdatedAt,
+		BudgetID:           dbRestriction.BudgetID,
+		UserID:             dbRestriction.UserID,
+		AllowedAccountIDs:  dbRestriction.AllowedAccountIds,
+		AllowedCategoryIDs: dbRestriction.AllowedCategoryIds,
+	}
+	if dbRestriction.MaxTransactionAmount.Valid {
+		restriction.MaxTransactionAmount = &dbRestriction.MaxTransactionAmount.Int64
+	}
+	return restriction
+}
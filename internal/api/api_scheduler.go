@@ -0,0 +1,320 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+// defaultSchedulerInterval is how often background jobs run
+// where SCHEDULER_INTERVAL is not set.
+const defaultSchedulerInterval = time.Hour
+
+// defaultTrashRetention is how long deleted transactions, and the prior
+// versions of updated ones, are kept where TRASH_RETENTION_DAYS is not set.
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// RunScheduler performs background jobs immediately, and then again at every
+// scheduler interval, until the given context is cancelled.
+func (cfg *APIConfig) RunScheduler(ctx context.Context) {
+	interval := cfg.schedulerInterval
+	if interval <= 0 {
+		interval = defaultSchedulerInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now().UTC()
+		cfg.postDueRecurringTxns(ctx, now)
+		cfg.purgeExpiredTxnHistory(ctx, now)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// postDueRecurringTxns logs a transaction for every occurrence of a recurring
+// transaction falling on or before the given time that has not yet been posted.
+// Those that fail to post are retried later, unless they keep failing.
+func (cfg *APIConfig) postDueRecurringTxns(ctx context.Context, now time.Time) {
+	today := now.Truncate(24 * time.Hour)
+	dueIDs, err := cfg.db.GetDueRecurringTransactionIDs(ctx, db.GetDueRecurringTransactionIDsParams{
+		AsOf: today,
+		Now:  now,
+	})
+	if err != nil {
+		slog.Error("could not get due recurring transactions: " + err.Error())
+		return
+	}
+	for _, id := range dueIDs {
+		posted, err := cfg.postRecurringTxn(ctx, id, today)
+		if err != nil {
+			cfg.recordRecurringFailure(ctx, id, now, err)
+			continue
+		}
+		if posted > 0 {
+			slog.Info("posted recurring transaction",
+				slog.String("recurring_id", id.String()),
+				slog.Int("count", posted))
+		}
+	}
+}
+
+// recordRecurringFailure records that a recurring transaction could not be
+// posted, so that it is retried after a delay; or disabled, where it has
+// failed too many times in a row.
+func (cfg *APIConfig) recordRecurringFailure(ctx context.Context, id uuid.UUID, now time.Time, postErr error) {
+	dbRecurring, err := cfg.db.GetRecurringTransactionByID(ctx, id)
+	if err != nil {
+		slog.Error("could not record recurring transaction failure",
+			slog.String("recurring_id", id.String()),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	failures := int(dbRecurring.FailureCount) + 1
+	params := db.SetRecurringTransactionFailureParams{
+		FailureCount: int32(failures),
+		LastError:    postErr.Error(),
+		ID:           id,
+	}
+	retryAfter, retry := recurringRetryAfter(now, failures)
+	if retry {
+		params.RetryAfter = pgtype.Timestamp{Time: retryAfter, Valid: true}
+	} else {
+		params.DisabledAt = pgtype.Timestamp{Time: now, Valid: true}
+	}
+	if err := cfg.db.SetRecurringTransactionFailure(ctx, params); err != nil {
+		slog.Error("could not record recurring transaction failure",
+			slog.String("recurring_id", id.String()),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	if retry {
+		slog.Warn("could not post recurring transaction",
+			slog.String("recurring_id", id.String()),
+			slog.Int("failures", failures),
+			slog.Time("retry_after", retryAfter),
+			slog.String("error", postErr.Error()))
+	} else {
+		slog.Error("disabled recurring transaction after repeated failures",
+			slog.String("recurring_id", id.String()),
+			slog.Int("failures", failures),
+			slog.String("error", postErr.Error()))
+	}
+}
+
+// purgeExpiredTxnHistory permanently deletes transactions left in the trash,
+// and prior versions of updated transactions kept, beyond the retention period.
+// Versions of a transaction are kept while it is in the trash, and purged
+// along with it.
+func (cfg *APIConfig) purgeExpiredTxnHistory(ctx context.Context, now time.Time) {
+	retention := cfg.trashRetention
+	if retention <= 0 {
+		retention = defaultTrashRetention
+	}
+	cutoff := now.Add(-retention)
+
+	purgedTxns, err := cfg.purgeTrashedTxns(ctx, cutoff)
+	if err != nil {
+		slog.Error("could not purge trashed transactions: " + err.Error())
+	} else if purgedTxns > 0 {
+		slog.Info("purged trashed transactions", slog.Int("count", purgedTxns))
+	}
+
+	purged, err := cfg.db.PurgeTransactionVersions(ctx, cutoff)
+	if err != nil {
+		slog.Error("could not purge transaction versions: " + err.Error())
+	} else if purged > 0 {
+		slog.Info("purged transaction versions", slog.Int64("count", purged))
+	}
+}
+
+// purgeTrashedTxns permanently deletes transactions left in the trash since
+// before the given time, recording each in the audit log of its budget.
+// It returns how many transactions were purged.
+func (cfg *APIConfig) purgeTrashedTxns(ctx context.Context, cutoff time.Time) (int, error) {
+	tx, err := cfg.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := cfg.db.WithTx(tx)
+
+	purged, err := q.PurgeTrashedTransactions(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	for _, dbTrashed := range purged {
+		err := recordAudit(ctx, q, db.CreateAuditEntryParams{
+			BudgetID:     &dbTrashed.BudgetID,
+			ResourceType: auditTransaction.kind,
+			ResourceID:   dbTrashed.ID.String(),
+			Action:       auditPurge,
+		}, dbTrashed, nil)
+		if err != nil {
+			return 0, fmt.Errorf("could not record audit entry: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(purged), nil
+}
+
+// postRecurringTxn logs every due occurrence of a single recurring transaction,
+// and advances its next date, all within one database transaction.
+// It returns how many occurrences were posted.
+func (cfg *APIConfig) postRecurringTxn(ctx context.Context, id uuid.UUID, today time.Time) (int, error) {
+	tx, err := cfg.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := cfg.db.WithTx(tx)
+
+	dbRecurring, err := q.GetRecurringTransactionForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// deleted, or being posted elsewhere
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	// the member who scheduled the transaction remains bound by their
+	// role and restrictions, including any placed upon them since
+	loggerRole, err := q.GetBudgetMemberRole(ctx, db.GetBudgetMemberRoleParams{
+		BudgetID: dbRecurring.BudgetID,
+		UserID:   dbRecurring.LoggerID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, errors.New("member who scheduled the transaction has left the budget")
+		}
+		return 0, err
+	}
+	if role, err := BMRFromString(loggerRole); err != nil || role > CONTRIBUTOR {
+		return 0, errors.New("member who scheduled the transaction may no longer log transactions")
+	}
+	restriction, err := getMemberRestriction(ctx, q, dbRecurring.BudgetID, dbRecurring.LoggerID)
+	if err != nil {
+		return 0, err
+	}
+
+	rule := recurrenceRuleFromDB(&dbRecurring)
+	nextDate := dbRecurring.NextDate
+	posted := 0
+	for nextDate != nil && !nextDate.After(today) {
+		validatedTxn, err := validatedTxnFromRecurring(&dbRecurring, *nextDate)
+		if err != nil {
+			return 0, err
+		}
+		if err := checkRecurringTargets(ctx, q, dbRecurring.BudgetID, validatedTxn); err != nil {
+			return 0, err
+		}
+		if err := checkTxnRestrictions(restriction, validatedTxn); err != nil {
+			return 0, err
+		}
+		txn, linkedTxn, msg, err := pgxLogValidatedTxn(q, ctx, dbRecurring.BudgetID, dbRecurring.LoggerID, validatedTxn)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", msg, err)
+		}
+		for _, dbTxn := range []*db.Transaction{txn, linkedTxn} {
+			if dbTxn == nil {
+				continue
+			}
+			if err := recordRecurringPosted(ctx, q, dbRecurring.BudgetID, dbTxn.ID); err != nil {
+				return 0, err
+			}
+		}
+		posted++
+
+		if following, ok := rule.nextOnOrAfter(nextDate.AddDate(0, 0, 1)); ok {
+			nextDate = &following
+		} else {
+			nextDate = nil
+		}
+	}
+
+	if err := q.SetRecurringTransactionNextDate(ctx, db.SetRecurringTransactionNextDateParams{
+		NextDate: nextDate,
+		ID:       id,
+	}); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return posted, nil
+}
+
+// recordRecurringPosted records a transaction posted on schedule in the
+// audit log. It is made by no one, though logged by the member who
+// scheduled it.
+func recordRecurringPosted(ctx context.Context, q *db.Queries, budgetID, txnID uuid.UUID) error {
+	after, err := auditTransaction.snapshot(ctx, q, budgetID, txnID.String())
+	if err != nil {
+		return fmt.Errorf("could not read resource for audit: %w", err)
+	}
+	if err := recordAudit(ctx, q, db.CreateAuditEntryParams{
+		BudgetID:     &budgetID,
+		ResourceType: auditTransaction.kind,
+		ResourceID:   txnID.String(),
+		Action:       auditCreate,
+	}, nil, after); err != nil {
+		return fmt.Errorf("could not record audit entry: %w", err)
+	}
+	return nil
+}
+
+// checkRecurringTargets reports whether the payee, accounts and categories
+// to which a recurring transaction posts remain in its budget, as they may
+// have been deleted since the transaction was scheduled.
+func checkRecurringTargets(ctx context.Context, q *db.Queries, budgetID uuid.UUID, validatedTxn *validatedTxnPayload) error {
+	if !validatedTxn.isTransfer && validatedTxn.payeeID == uuid.Nil {
+		return errors.New("payee no longer exists")
+	}
+	accountIDs := []uuid.UUID{validatedTxn.accountID}
+	if validatedTxn.isTransfer {
+		accountIDs = append(accountIDs, validatedTxn.transferAccountID)
+	}
+	for _, accountID := range accountIDs {
+		dbAccount, err := q.GetAccountByID(ctx, accountID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if err != nil || dbAccount.BudgetID != budgetID || dbAccount.IsDeleted {
+			return fmt.Errorf("account %s no longer exists", accountID)
+		}
+	}
+	for key := range validatedTxn.amounts {
+		categoryID, err := uuid.Parse(key)
+		if err != nil {
+			// transfers and uncategorized amounts have no category
+			continue
+		}
+		dbCategory, err := q.GetCategoryByID(ctx, categoryID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if err != nil || dbCategory.BudgetID != budgetID {
+			return fmt.Errorf("category %s no longer exists", categoryID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/YouWantToPinch/pincher-api/internal/auth"
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+)
+
+// A session is a family of refresh tokens, begun by a login and carried on
+// by every refresh since. Its ID is the family ID of those tokens, which is
+// also carried by each access token issued on its behalf.
+
+func (cfg *APIConfig) handleGetSessions(w http.ResponseWriter, r *http.Request) {
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+	currentSessionID := cfg.getCallerSessionID(r)
+
+	dbSessions, err := cfg.db.GetActiveSessionsByUserID(r.Context(), validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve sessions", err)
+		return
+	}
+
+	sessions := []Session{}
+	for _, dbSession := range dbSessions {
+		sessions = append(sessions, Session{
+			ID:         dbSession.ID,
+			CreatedAt:  dbSession.CreatedAt,
+			LastUsedAt: dbSession.LastUsedAt,
+			UserAgent:  dbSession.UserAgent,
+			IPAddress:  dbSession.IpAddress,
+			Current:    dbSession.ID == currentSessionID,
+		})
+	}
+
+	type rspSchema struct {
+		Sessions []Session `json:"data"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{Sessions: sessions})
+}
+
+func (cfg *APIConfig) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	pathSessionID, err := parseUUIDFromPath("session_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	revoked, err := cfg.db.RevokeUserSession(r.Context(), db.RevokeUserSessionParams{
+		UserID:   validatedUserID,
+		FamilyID: pathSessionID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not revoke session", err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "no active session found", nil)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+// handleRevokeOtherSessions signs the user out everywhere
+// but the session making the request.
+func (cfg *APIConfig) handleRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	_, err := cfg.db.RevokeOtherUserSessions(r.Context(), db.RevokeOtherUserSessionsParams{
+		UserID:   validatedUserID,
+		FamilyID: cfg.getCallerSessionID(r),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not revoke sessions", err)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
+
+// getCallerSessionID returns the ID of the session on whose behalf
+// the request's access token was issued, or uuid.Nil if there is none.
+// The token is assumed to have been validated already.
+func (cfg *APIConfig) getCallerSessionID(r *http.Request) uuid.UUID {
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil
+	}
+	sessionID, err := auth.GetJWTSessionID(tokenString, cfg.jwtKeys)
+	if err != nil {
+		return uuid.Nil
+	}
+	return sessionID
+}
+
+// clientIP returns the address of the client which made the request.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
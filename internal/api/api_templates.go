@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+func (cfg *APIConfig) handleGetBudgetTemplates(w http.ResponseWriter, r *http.Request) {
+	type rspSchema struct {
+		Templates []BudgetTemplate `json:"data"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{
+		Templates: budgetTemplates,
+	})
+}
+
+// handleCloneBudget creates a new budget with the structure of the budget
+// at the path: its groups, categories, payees and open accounts, but none
+// of its transactions or members. The assignments of a single month may
+// optionally be copied to the same month of the new budget.
+func (cfg *APIConfig) handleCloneBudget(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	type rqSchema struct {
+		// Meta names the new budget; where empty, the name and notes of
+		// the budget cloned are kept.
+		Meta
+		AssignmentsMonth string `json:"assignments_month"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	parsedMonth, err := parseDate(rqPayload.AssignmentsMonth)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+	if restriction != nil {
+		respondWithError(w, http.StatusForbidden, "restricted members may not clone the budget", nil)
+		return
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		structure, errMsg, err := readBudgetStructure(r.Context(), q, pathBudgetID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, errMsg, err)
+			return
+		}
+		if !parsedMonth.IsZero() {
+			dbAssignments, err := q.GetBudgetMonthAssignments(r.Context(), db.GetBudgetMonthAssignmentsParams{
+				BudgetID: pathBudgetID,
+				MonthID:  parsedMonth,
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not get assignments", err)
+				return
+			}
+			structure.setAssignments(dbAssignments)
+		}
+
+		if rqPayload.Name != "" {
+			structure.budget.Name = rqPayload.Name
+			structure.budget.Notes = rqPayload.Notes
+		}
+		structure.members = nil
+		structure.accounts = slices.DeleteFunc(structure.accounts, func(account ExportAccount) bool {
+			return account.IsDeleted
+		})
+
+		importer := newDBBudgetImporter(r.Context(), q, dbUser)
+		if err := structure.importInto(importer); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not clone budget", err)
+			return
+		}
+
+		if err := recordBudgetCreated(r.Context(), q, validatedUserID, importer.budget.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record audit entry", err)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusCreated, Budget{
+			ID:               importer.budget.ID,
+			CreatedAt:        importer.budget.CreatedAt,
+			UpdatedAt:        importer.budget.UpdatedAt,
+			AdminID:          importer.budget.AdminID,
+			OverspendingRule: importer.budget.OverspendingRule,
+			Meta: Meta{
+				Name:  importer.budget.Name,
+				Notes: importer.budget.Notes,
+			},
+		})
+	}
+}
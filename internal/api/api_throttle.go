@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+// throttleKey identifies a subject whose failed attempts
+// are counted within some scope.
+type throttleKey struct {
+	scope   string
+	subject string
+}
+
+// checkThrottles reports whether an attempt may be made by all of the
+// given keys. Where it may not, the response has already been written,
+// with a Retry-After header for the latest time any key is blocked until.
+func (cfg *APIConfig) checkThrottles(w http.ResponseWriter, r *http.Request, keys ...throttleKey) bool {
+	now := time.Now().UTC()
+
+	var until time.Time
+	for _, key := range keys {
+		dbThrottle, err := cfg.db.GetLoginThrottle(r.Context(), db.GetLoginThrottleParams{
+			Scope:   key.scope,
+			Subject: key.subject,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not check failed attempts", err)
+			return false
+		}
+		blockedUntil := dbThrottle.BlockedUntil.Time
+		if dbThrottle.BlockedUntil.Valid && blockedUntil.After(now) && blockedUntil.After(until) {
+			until = blockedUntil
+		}
+	}
+
+	if until.IsZero() {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(until, now)))
+	respondWithError(w, http.StatusTooManyRequests, "too many failed attempts; try again later", nil)
+	return false
+}
+
+// recordFailedAttempts counts a failed login against each of the given
+// keys, blocking further attempts by them as the login policy dictates.
+func (cfg *APIConfig) recordFailedAttempts(ctx context.Context, keys ...throttleKey) error {
+	return cfg.recordAttempts(ctx, &cfg.loginThrottle, keys...)
+}
+
+// recordAttempts counts an attempt against each of the given keys,
+// blocking further attempts by them as the given policy dictates.
+func (cfg *APIConfig) recordAttempts(ctx context.Context, policy *throttlePolicy, keys ...throttleKey) error {
+	now := time.Now().UTC()
+
+	for _, key := range keys {
+		dbThrottle, err := cfg.db.RecordFailedAttempt(ctx, db.RecordFailedAttemptParams{
+			Scope:        key.scope,
+			Subject:      key.subject,
+			FailedAt:     now,
+			ForgetBefore: now.Add(-policy.lockoutDuration),
+		})
+		if err != nil {
+			return err
+		}
+
+		until := policy.blockedUntil(int(dbThrottle.FailedAttempts), now)
+		err = cfg.db.SetLoginThrottleBlockedUntil(ctx, db.SetLoginThrottleBlockedUntilParams{
+			Scope:        key.scope,
+			Subject:      key.subject,
+			BlockedUntil: pgtype.Timestamp{Time: until, Valid: !until.IsZero()},
+		})
+		if err != nil {
+			return err
+		}
+
+		if int(dbThrottle.FailedAttempts) == policy.lockoutAttempts {
+			slog.Warn("too many failed attempts; locked out",
+				slog.String("scope", key.scope),
+				slog.String("subject", key.subject),
+				slog.Time("until", until))
+		}
+	}
+	return nil
+}
+
+func (cfg *APIConfig) handleGetLockouts(w http.ResponseWriter, r *http.Request) {
+	dbThrottles, err := cfg.db.GetBlockedLoginThrottles(r.Context(), pgtype.Timestamp{Time: time.Now().UTC(), Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve lockouts", err)
+		return
+	}
+
+	lockouts := []Lockout{}
+	for _, dbThrottle := range dbThrottles {
+		lockouts = append(lockouts, Lockout{
+			Scope:          dbThrottle.Scope,
+			Subject:        dbThrottle.Subject,
+			FailedAttempts: int(dbThrottle.FailedAttempts),
+			LastFailedAt:   dbThrottle.LastFailedAt,
+			BlockedUntil:   dbThrottle.BlockedUntil.Time,
+		})
+	}
+
+	type rspSchema struct {
+		Lockouts []Lockout `json:"data"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{Lockouts: lockouts})
+}
+
+// handleClearLockouts forgets the failed attempts of the given
+// scope and subject, or of every one, where none is given.
+func (cfg *APIConfig) handleClearLockouts(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		Scope   string `json:"scope"`
+		Subject string `json:"subject"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if rqPayload.Scope == "" && rqPayload.Subject == "" {
+		if _, err := cfg.db.ClearLoginThrottles(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not clear lockouts", err)
+			return
+		}
+		respondWithCode(w, http.StatusNoContent)
+		return
+	}
+
+	cleared, err := cfg.db.ClearLoginThrottle(r.Context(), db.ClearLoginThrottleParams{
+		Scope:   rqPayload.Scope,
+		Subject: rqPayload.Subject,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not clear lockout", err)
+		return
+	}
+	if cleared == 0 {
+		respondWithError(w, http.StatusNotFound, "no failed attempts found", nil)
+		return
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
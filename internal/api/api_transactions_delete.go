@@ -2,8 +2,12 @@ package api
 
 import (
 	"net/http"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
 )
 
+// handleDeleteTransaction moves a transaction into the trash, from which it
+// may be restored until purged. Both legs of a transfer are trashed together.
 func (cfg *APIConfig) handleDeleteTransaction(w http.ResponseWriter, r *http.Request) {
 	pathTransactionID, err := parseUUIDFromPath("transaction_id", r)
 	if err != nil {
@@ -21,10 +25,22 @@ func (cfg *APIConfig) handleDeleteTransaction(w http.ResponseWriter, r *http.Req
 		respondWithCode(w, http.StatusForbidden)
 		return
 	}
+	if visible, err := cfg.checkTxnVisible(r, &dbTransaction); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	} else if !visible {
+		respondWithError(w, http.StatusNotFound, "could not get transaction", nil)
+		return
+	}
+	if dbTransaction.Reconciled {
+		respondWithError(w, http.StatusConflict, "cannot delete a reconciled transaction", nil)
+		return
+	}
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
 
 	// DB TRANSACTION BLOCK
 	{
-		tx, err := cfg.Pool.Begin(r.Context())
+		tx, err := cfg.beginTx(r.Context())
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "", err)
 			return
@@ -33,18 +49,27 @@ func (cfg *APIConfig) handleDeleteTransaction(w http.ResponseWriter, r *http.Req
 
 		q := cfg.db.WithTx(tx)
 
-		if err = q.DeleteTransaction(r.Context(), pathTransactionID); err != nil {
-			respondWithError(w, http.StatusInternalServerError, "could not delete transaction", err)
-			return
-		}
+		var linkedTxn *db.Transaction
 		if checkIsTransfer(dbTransaction.TransactionType) {
-			linkedTxn, err := cfg.db.GetLinkedTransaction(r.Context(), pathTransactionID)
+			dbLinkedTxn, err := q.GetLinkedTransaction(r.Context(), pathTransactionID)
 			if err != nil {
 				respondWithError(w, http.StatusInternalServerError, "could not get corresponding transfer transaction to delete", err)
 				return
 			}
-			if err := q.DeleteTransaction(r.Context(), linkedTxn.ID); err != nil {
-				respondWithError(w, http.StatusInternalServerError, "could not complete database transaction", err)
+			if dbLinkedTxn.Reconciled {
+				respondWithError(w, http.StatusConflict, "cannot delete a transfer whose corresponding transaction is reconciled", nil)
+				return
+			}
+			linkedTxn = &dbLinkedTxn
+		}
+
+		if msg, err := pgxTrashTxn(q, r.Context(), validatedUserID, &dbTransaction, linkedTxn); err != nil {
+			respondWithError(w, http.StatusInternalServerError, msg, err)
+			return
+		}
+		if linkedTxn != nil {
+			if msg, err := pgxTrashTxn(q, r.Context(), validatedUserID, linkedTxn, &dbTransaction); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not delete corresponding transfer transaction: "+msg, err)
 				return
 			}
 		}
@@ -16,6 +16,19 @@ func (cfg *APIConfig) handleGetTransactionSplits(w http.ResponseWriter, r *http.
 		return
 	}
 
+	dbTransaction, err := cfg.db.GetTransactionByID(r.Context(), pathTransactionID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get transaction", err)
+		return
+	}
+	if visible, err := cfg.checkTxnVisible(r, &dbTransaction); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	} else if !visible {
+		respondWithError(w, http.StatusNotFound, "could not get transaction", nil)
+		return
+	}
+
 	dbSplits, err := cfg.db.GetSplitsByTransactionID(r.Context(), pathTransactionID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "could not get splits associated with transaction", err)
@@ -44,27 +57,21 @@ func (cfg *APIConfig) handleGetTransaction(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if !getDetails {
-		dbTransaction, err := cfg.db.GetTransactionByID(r.Context(), pathTransactionID)
-		if err != nil {
-			respondWithError(w, http.StatusNotFound, "could not get transaction", err)
-			return
-		}
-
-		rspPayload := Transaction{
-			ID:              dbTransaction.ID,
-			CreatedAt:       dbTransaction.CreatedAt,
-			UpdatedAt:       dbTransaction.UpdatedAt,
-			BudgetID:        dbTransaction.BudgetID,
-			LoggerID:        dbTransaction.LoggerID,
-			AccountID:       dbTransaction.AccountID,
-			TransactionDate: dbTransaction.TransactionDate,
-			PayeeID:         dbTransaction.PayeeID,
-			Notes:           dbTransaction.Notes,
-			Cleared:         dbTransaction.Cleared,
-		}
+	dbTransaction, err := cfg.db.GetTransactionByID(r.Context(), pathTransactionID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get transaction", err)
+		return
+	}
+	if visible, err := cfg.checkTxnVisible(r, &dbTransaction); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	} else if !visible {
+		respondWithError(w, http.StatusNotFound, "could not get transaction", nil)
+		return
+	}
 
-		respondWithJSON(w, http.StatusOK, rspPayload)
+	if !getDetails {
+		respondWithJSON(w, http.StatusOK, txnFromDB(&dbTransaction))
 		return
 	} else {
 		detailedTxn, err := cfg.db.GetTransactionDetailsByID(r.Context(), pathTransactionID)
@@ -73,29 +80,10 @@ func (cfg *APIConfig) handleGetTransaction(w http.ResponseWriter, r *http.Reques
 			return
 		}
 
-		respSplits := make(map[string]int64)
-		{
-			data := []byte(detailedTxn.Splits)
-			source := (*json.RawMessage)(&data)
-			err := json.Unmarshal(*source, &respSplits)
-			if err != nil {
-				respondWithError(w, http.StatusInternalServerError, "failure unmarshalling transaction splits", err)
-				return
-			}
-		}
-
-		rspPayload := TransactionDetail{
-			ID:              detailedTxn.ID,
-			TransactionType: detailedTxn.TransactionType,
-			TransactionDate: detailedTxn.TransactionDate,
-			PayeeName:       detailedTxn.PayeeName,
-			BudgetName:      detailedTxn.BudgetName.String,
-			AccountName:     detailedTxn.AccountName.String,
-			LoggerName:      detailedTxn.LoggerName.String,
-			TotalAmount:     detailedTxn.TotalAmount,
-			Notes:           detailedTxn.Notes,
-			Cleared:         detailedTxn.Cleared,
-			Splits:          respSplits,
+		rspPayload, err := txnDetailFromDB(&detailedTxn)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "failure unmarshalling transaction splits", err)
+			return
 		}
 
 		respondWithJSON(w, http.StatusOK, rspPayload)
@@ -124,7 +112,7 @@ func (cfg *APIConfig) handleGetTransactions(w http.ResponseWriter, r *http.Reque
 	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
 
 	{
-		tx, err := cfg.Pool.Begin(r.Context())
+		tx, err := cfg.beginTx(r.Context())
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "", err)
 			return
@@ -133,6 +121,14 @@ func (cfg *APIConfig) handleGetTransactions(w http.ResponseWriter, r *http.Reque
 
 		q := cfg.db.WithTx(tx)
 
+		// members restricted to some accounts see nothing of the others
+		validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+		restriction, err := getMemberRestriction(r.Context(), q, pathBudgetID, validatedUserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+			return
+		}
+
 		parsedAccountID := uuid.Nil
 		if accountName != "" {
 			parsedAccountID, err = lookupResourceIDByName(r.Context(),
@@ -175,12 +171,13 @@ func (cfg *APIConfig) handleGetTransactions(w http.ResponseWriter, r *http.Reque
 		if !getDetails {
 
 			dbTransactions, err := q.GetTransactions(r.Context(), db.GetTransactionsParams{
-				AccountID:  parsedAccountID,
-				CategoryID: parsedCategoryID,
-				PayeeID:    parsedPayeeID,
-				StartDate:  parsedStartDate,
-				EndDate:    parsedEndDate,
-				BudgetID:   pathBudgetID,
+				AccountID:         parsedAccountID,
+				CategoryID:        parsedCategoryID,
+				PayeeID:           parsedPayeeID,
+				StartDate:         parsedStartDate,
+				EndDate:           parsedEndDate,
+				BudgetID:          pathBudgetID,
+				VisibleAccountIds: visibleAccountIDs(restriction),
 			})
 			if err != nil {
 				respondWithError(w, http.StatusInternalServerError, "could not retrieve transactions", err)
@@ -189,19 +186,7 @@ func (cfg *APIConfig) handleGetTransactions(w http.ResponseWriter, r *http.Reque
 
 			var transactions []Transaction
 			for _, transaction := range dbTransactions {
-				transactions = append(transactions, Transaction{
-					ID:              transaction.ID,
-					CreatedAt:       transaction.CreatedAt,
-					UpdatedAt:       transaction.UpdatedAt,
-					BudgetID:        transaction.BudgetID,
-					LoggerID:        transaction.LoggerID,
-					AccountID:       transaction.AccountID,
-					TransactionType: transaction.TransactionType,
-					TransactionDate: transaction.TransactionDate,
-					PayeeID:         transaction.PayeeID,
-					Notes:           transaction.Notes,
-					Cleared:         transaction.Cleared,
-				})
+				transactions = append(transactions, txnFromDB(&transaction))
 			}
 
 			type rspSchema struct {
@@ -222,12 +207,13 @@ func (cfg *APIConfig) handleGetTransactions(w http.ResponseWriter, r *http.Reque
 
 		} else {
 			detailedTxns, err := q.GetTransactionDetails(r.Context(), db.GetTransactionDetailsParams{
-				AccountID:  parsedAccountID,
-				CategoryID: parsedCategoryID,
-				PayeeID:    parsedPayeeID,
-				StartDate:  parsedStartDate,
-				EndDate:    parsedEndDate,
-				BudgetID:   pathBudgetID,
+				AccountID:         parsedAccountID,
+				CategoryID:        parsedCategoryID,
+				PayeeID:           parsedPayeeID,
+				StartDate:         parsedStartDate,
+				EndDate:           parsedEndDate,
+				BudgetID:          pathBudgetID,
+				VisibleAccountIds: visibleAccountIDs(restriction),
 			})
 			if err != nil {
 				respondWithError(w, http.StatusInternalServerError, "could not retrieve transactions", err)
@@ -236,31 +222,12 @@ func (cfg *APIConfig) handleGetTransactions(w http.ResponseWriter, r *http.Reque
 
 			var transactions []TransactionDetail
 			for _, detailedTxn := range detailedTxns {
-
-				respSplits := make(map[string]int64)
-				{
-					data := []byte(detailedTxn.Splits)
-					source := (*json.RawMessage)(&data)
-					err := json.Unmarshal(*source, &respSplits)
-					if err != nil {
-						respondWithError(w, http.StatusInternalServerError, "failure unmarshalling transaction splits", err)
-						return
-					}
+				transaction, err := txnDetailFromDB(&detailedTxn)
+				if err != nil {
+					respondWithError(w, http.StatusInternalServerError, "failure unmarshalling transaction splits", err)
+					return
 				}
-
-				transactions = append(transactions, TransactionDetail{
-					ID:              detailedTxn.ID,
-					TransactionType: detailedTxn.TransactionType,
-					TransactionDate: detailedTxn.TransactionDate,
-					PayeeName:       detailedTxn.PayeeName,
-					BudgetName:      detailedTxn.BudgetName.String,
-					AccountName:     detailedTxn.AccountName.String,
-					LoggerName:      detailedTxn.LoggerName.String,
-					TotalAmount:     detailedTxn.TotalAmount,
-					Notes:           detailedTxn.Notes,
-					Cleared:         detailedTxn.Cleared,
-					Splits:          respSplits,
-				})
+				transactions = append(transactions, transaction)
 			}
 
 			type rspSchema struct {
@@ -280,3 +247,42 @@ func (cfg *APIConfig) handleGetTransactions(w http.ResponseWriter, r *http.Reque
 		}
 	}
 }
+
+func txnFromDB(dbTransaction *db.Transaction) Transaction {
+	return Transaction{
+		ID:              dbTransaction.ID,
+		CreatedAt:       dbTransaction.CreatedAt,
+		UpdatedAt:       dbTransaction.UpdatedAt,
+		BudgetID:        dbTransaction.BudgetID,
+		LoggerID:        dbTransaction.LoggerID,
+		AccountID:       dbTransaction.AccountID,
+		TransactionType: dbTransaction.TransactionType,
+		TransactionDate: dbTransaction.TransactionDate,
+		PayeeID:         dbTransaction.PayeeID,
+		Notes:           dbTransaction.Notes,
+		Cleared:         dbTransaction.Cleared,
+		Reconciled:      dbTransaction.Reconciled,
+	}
+}
+
+func txnDetailFromDB(detailedTxn *db.TransactionDetail) (TransactionDetail, error) {
+	splits := make(map[string]int64)
+	if err := json.Unmarshal(detailedTxn.Splits, &splits); err != nil {
+		return TransactionDetail{}, err
+	}
+
+	return TransactionDetail{
+		ID:              detailedTxn.ID,
+		TransactionType: detailedTxn.TransactionType,
+		TransactionDate: detailedTxn.TransactionDate,
+		PayeeName:       detailedTxn.PayeeName,
+		BudgetName:      detailedTxn.BudgetName.String,
+		AccountName:     detailedTxn.AccountName.String,
+		LoggerName:      detailedTxn.LoggerName.String,
+		TotalAmount:     detailedTxn.TotalAmount,
+		Notes:           detailedTxn.Notes,
+		Cleared:         detailedTxn.Cleared,
+		Reconciled:      detailedTxn.Reconciled,
+		Splits:          splits,
+	}, nil
+}
@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"time"
 
-	db "github.com/YouWantToPinch/pincher-api/internal/database"
 	"github.com/google/uuid"
 )
 
@@ -49,7 +48,7 @@ func (cfg *APIConfig) handleLogTransaction(w http.ResponseWriter, r *http.Reques
 
 	// DB TRANSACTION BLOCK
 	{
-		tx, err := cfg.Pool.Begin(r.Context())
+		tx, err := cfg.beginTx(r.Context())
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "", err)
 			return
@@ -87,62 +86,32 @@ func (cfg *APIConfig) handleLogTransaction(w http.ResponseWriter, r *http.Reques
 				TotalAmount:     detailedTxn.TotalAmount,
 				Notes:           detailedTxn.Notes,
 				Cleared:         detailedTxn.Cleared,
+				Reconciled:      detailedTxn.Reconciled,
 				Splits:          respSplits,
 			}, nil
 		}
 
-		newTxn, msg, err := pgxLogTxn(q, r.Context(), db.LogTransactionParams{
-			BudgetID:        pathBudgetID,
-			LoggerID:        validatedUserID,
-			AccountID:       validatedTxn.accountID,
-			TransactionType: validatedTxn.txnType,
-			TransactionDate: validatedTxn.txnDate,
-			PayeeID:         validatedTxn.payeeID,
-			Notes:           validatedTxn.notes,
-			Cleared:         validatedTxn.cleared,
-		}, validatedTxn.amounts)
+		inPeriod, err := pgxValidatedTxnInReconciledPeriod(q, r.Context(), validatedTxn)
 		if err != nil {
-			errMsgPrefix := "could not log transaction"
-			if validatedTxn.isTransfer {
-				errMsgPrefix = "could not log transfer transaction"
-			}
-			respondWithError(w, http.StatusConflict, errMsgPrefix+": "+msg, err)
+			respondWithError(w, http.StatusInternalServerError, "could not check reconciled period", err)
+			return
+		}
+		if inPeriod {
+			respondWithError(w, http.StatusConflict, "cannot log a cleared transaction within a reconciled period", nil)
+			return
+		}
+
+		newTxn, linkedTxn, msg, err := pgxLogValidatedTxn(q, r.Context(), pathBudgetID, validatedUserID, validatedTxn)
+		if err != nil {
+			respondWithError(w, http.StatusConflict, msg, err)
 			return
 		}
 		if validatedTxn.isTransfer {
-			// log the corresponding transaction
-			transferTxn, msg, err := pgxLogTxn(q, r.Context(), db.LogTransactionParams{
-				BudgetID:        pathBudgetID,
-				LoggerID:        validatedUserID,
-				AccountID:       validatedTxn.transferAccountID,
-				TransactionType: invertTransferType(validatedTxn.txnType),
-				TransactionDate: validatedTxn.txnDate,
-				PayeeID:         validatedTxn.payeeID,
-				Notes:           validatedTxn.notes,
-				Cleared:         validatedTxn.cleared,
-			}, invertAmountsMap(validatedTxn.amounts))
-			if err != nil {
-				errMsgPrefix := "could not log transaction"
-				if validatedTxn.isTransfer {
-					errMsgPrefix = "could not log corresponding transfer transaction"
-				}
-				respondWithError(w, http.StatusInternalServerError, errMsgPrefix+": "+msg, err)
-				return
-			}
-			// link transfer transactions
-			toPtr, fromPtr, err := getOrderedTransferIDs(newTxn, transferTxn)
+			toPtr, fromPtr, err := getOrderedTransferIDs(newTxn, linkedTxn)
 			if err != nil {
 				respondWithError(w, http.StatusInternalServerError, "could not link transfer transactions", err)
 				return
 			}
-			_, err = q.LogAccountTransfer(r.Context(), db.LogAccountTransferParams{
-				FromTransactionID: (*fromPtr).ID,
-				ToTransactionID:   (*toPtr).ID,
-			})
-			if err != nil {
-				respondWithError(w, http.StatusConflict, "could not link transfer transactions", err)
-				return
-			}
 			// if a transfer, get the details for both txns logged
 			type rspSchema struct {
 				FromTransaction TransactionDetail `json:"from_transaction"`
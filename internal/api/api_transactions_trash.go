@@ -0,0 +1,191 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// handleGetTrashedTransactions returns every transaction of a budget
+// which has been deleted, but not yet purged, latest first.
+func (cfg *APIConfig) handleGetTrashedTransactions(w http.ResponseWriter, r *http.Request) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+
+	dbTrashed, err := cfg.db.GetTrashedTransactions(r.Context(), db.GetTrashedTransactionsParams{
+		BudgetID:          pathBudgetID,
+		VisibleAccountIds: visibleAccountIDs(restriction),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not retrieve trashed transactions", err)
+		return
+	}
+
+	var trashed []TrashedTransaction
+	for _, dbTxn := range dbTrashed {
+		trashed = append(trashed, trashedTxnFromDB(&dbTxn))
+	}
+
+	type rspSchema struct {
+		Transactions []TrashedTransaction `json:"data"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{
+		Transactions: trashed,
+	})
+}
+
+// handleRestoreTransaction moves a transaction out of the trash, just as it
+// was when deleted. Both legs of a transfer are restored together, by either.
+// A cleared transaction may not be restored to a period since reconciled.
+func (cfg *APIConfig) handleRestoreTransaction(w http.ResponseWriter, r *http.Request) {
+	pathTransactionID, err := parseUUIDFromPath("transaction_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	dbTrashed, err := cfg.db.GetTrashedTransactionByID(r.Context(), pathTransactionID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not find transaction in trash", err)
+		return
+	}
+	if pathBudgetID != dbTrashed.BudgetID {
+		respondWithCode(w, http.StatusForbidden)
+		return
+	}
+	trashed := []db.TrashedTransaction{dbTrashed}
+	if dbTrashed.LinkedTransactionID != nil {
+		dbLinkedTrashed, err := cfg.db.GetTrashedTransactionByID(r.Context(), *dbTrashed.LinkedTransactionID)
+		if err != nil {
+			respondWithError(w, http.StatusConflict, "corresponding transfer transaction is no longer in the trash", err)
+			return
+		}
+		trashed = append(trashed, dbLinkedTrashed)
+	}
+
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+	for _, dbTxn := range trashed {
+		if !permitsAccount(restriction, dbTxn.AccountID) {
+			respondWithError(w, http.StatusNotFound, "could not find transaction in trash", nil)
+			return
+		}
+		dbAccount, err := cfg.db.GetAccountByID(r.Context(), dbTxn.AccountID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not get account of trashed transaction", err)
+			return
+		}
+		if dbAccount.IsDeleted {
+			respondWithError(w, http.StatusConflict, "cannot restore a transaction to a deleted account", nil)
+			return
+		}
+		// payees may have been deleted while no live transaction used them
+		if dbTxn.PayeeID != uuid.Nil {
+			if _, err := cfg.db.GetPayeeByID(r.Context(), dbTxn.PayeeID); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					respondWithError(w, http.StatusConflict, "payee of trashed transaction no longer exists", nil)
+					return
+				}
+				respondWithError(w, http.StatusInternalServerError, "could not get payee of trashed transaction", err)
+				return
+			}
+		}
+	}
+
+	var restored []db.Transaction
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		for _, dbTxn := range trashed {
+			inPeriod, err := pgxInReconciledPeriod(q, r.Context(), dbTxn.AccountID, dbTxn.TransactionDate, dbTxn.Cleared)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not check reconciled period", err)
+				return
+			}
+			if inPeriod {
+				respondWithError(w, http.StatusConflict, "cannot restore a cleared transaction within a reconciled period", nil)
+				return
+			}
+		}
+
+		for _, dbTxn := range trashed {
+			restoredTxn, err := q.RestoreTransaction(r.Context(), dbTxn.ID)
+			if err != nil {
+				respondWithError(w, http.StatusConflict, "could not restore transaction", err)
+				return
+			}
+			if err := q.RestoreTransactionSplits(r.Context(), dbTxn.ID); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not restore transaction splits", err)
+				return
+			}
+			if err := q.DeleteTrashedTransaction(r.Context(), dbTxn.ID); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not remove transaction from trash", err)
+				return
+			}
+			restored = append(restored, restoredTxn)
+		}
+		if len(restored) == 2 {
+			toPtr, fromPtr, err := getOrderedTransferIDs(&restored[0], &restored[1])
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not link transfer transactions", err)
+				return
+			}
+			if _, err = q.LogAccountTransfer(r.Context(), db.LogAccountTransferParams{
+				FromTransactionID: fromPtr.ID,
+				ToTransactionID:   toPtr.ID,
+			}); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not link transfer transactions", err)
+				return
+			}
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not complete database transaction", err)
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, txnFromDB(&restored[0]))
+}
+
+func trashedTxnFromDB(dbTxn *db.GetTrashedTransactionsRow) TrashedTransaction {
+	return TrashedTransaction{
+		ID:                  dbTxn.ID,
+		CreatedAt:           dbTxn.CreatedAt,
+		UpdatedAt:           dbTxn.UpdatedAt,
+		BudgetID:            dbTxn.BudgetID,
+		LoggerID:            dbTxn.LoggerID,
+		AccountID:           dbTxn.AccountID,
+		TransactionType:     dbTxn.TransactionType,
+		TransactionDate:     dbTxn.TransactionDate,
+		PayeeID:             dbTxn.PayeeID,
+		Notes:               dbTxn.Notes,
+		Cleared:             dbTxn.Cleared,
+		TotalAmount:         dbTxn.TotalAmount,
+		DeletedAt:           dbTxn.DeletedAt,
+		DeletedBy:           dbTxn.DeletedBy,
+		LinkedTransactionID: dbTxn.LinkedTransactionID,
+	}
+}
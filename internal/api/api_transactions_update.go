@@ -1,9 +1,11 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/jackc/pgx/v5"
 )
 
 func (cfg *APIConfig) handleUpdateTransaction(w http.ResponseWriter, r *http.Request) {
@@ -20,6 +22,10 @@ func (cfg *APIConfig) handleUpdateTransaction(w http.ResponseWriter, r *http.Req
 		respondWithError(w, http.StatusNotFound, "could not get transaction", err)
 		return
 	}
+	if dbTxnDetails.Reconciled {
+		respondWithError(w, http.StatusConflict, "cannot update a reconciled transaction", nil)
+		return
+	}
 	// Non-transfer TXNs may not be updated as transfer TXNs, and vice versa
 	if validatedTxn.isTransfer != checkIsTransfer(dbTxnDetails.TransactionType) {
 		respondWithError(w, http.StatusBadRequest, "cannot change transfer txn to non-transfer txn, nor vice-versa", nil)
@@ -28,7 +34,7 @@ func (cfg *APIConfig) handleUpdateTransaction(w http.ResponseWriter, r *http.Req
 
 	// DB TRANSACTION BLOCK
 	{
-		tx, err := cfg.Pool.Begin(r.Context())
+		tx, err := cfg.beginTx(r.Context())
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "", err)
 			return
@@ -37,6 +43,22 @@ func (cfg *APIConfig) handleUpdateTransaction(w http.ResponseWriter, r *http.Req
 
 		q := cfg.db.WithTx(tx)
 
+		inPeriod, err := pgxInReconciledPeriod(q, r.Context(), validatedTxn.accountID, validatedTxn.txnDate, validatedTxn.cleared)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not check reconciled period", err)
+			return
+		}
+		if inPeriod {
+			respondWithError(w, http.StatusConflict, "cannot update a cleared transaction to fall within a reconciled period", nil)
+			return
+		}
+
+		validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+		if err := pgxRecordTxnVersion(q, r.Context(), validatedUserID, pathTransactionID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not keep prior version of transaction", err)
+			return
+		}
+
 		splits := map[string]int64{}
 		// if amount values did not change, splits may remain untouched
 		if dbTxnDetails.TotalAmount != totalFromAmountsMap(validatedTxn.amounts) {
@@ -64,6 +86,23 @@ func (cfg *APIConfig) handleUpdateTransaction(w http.ResponseWriter, r *http.Req
 				respondWithError(w, http.StatusInternalServerError, "could not find corresponding txn to update", err)
 				return
 			}
+			if linkedTxn.Reconciled {
+				respondWithError(w, http.StatusConflict, "cannot update a transfer whose corresponding transaction is reconciled", nil)
+				return
+			}
+			inPeriod, err := pgxInReconciledPeriod(q, r.Context(), linkedTxn.AccountID, validatedTxn.txnDate, linkedTxn.Cleared)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not check reconciled period", err)
+				return
+			}
+			if inPeriod {
+				respondWithError(w, http.StatusConflict, "cannot update a transfer whose corresponding transaction would fall within a reconciled period", nil)
+				return
+			}
+			if err := pgxRecordTxnVersion(q, r.Context(), validatedUserID, linkedTxn.ID); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not keep prior version of corresponding transfer transaction", err)
+				return
+			}
 			if msg, err := pgxUpdateTxn(q, r.Context(), db.UpdateTransactionParams{
 				TransactionID:   linkedTxn.ID,
 				AccountID:       linkedTxn.AccountID,
@@ -86,3 +125,136 @@ func (cfg *APIConfig) handleUpdateTransaction(w http.ResponseWriter, r *http.Req
 
 	respondWithCode(w, http.StatusNoContent)
 }
+
+// handleUndoTransactionUpdate reverts a transaction to its state before the
+// latest update made to it. Undoing again reverts the update before that.
+// An update may not be undone where that would return a cleared transaction
+// to a period since reconciled.
+func (cfg *APIConfig) handleUndoTransactionUpdate(w http.ResponseWriter, r *http.Request) {
+	pathTransactionID, err := parseUUIDFromPath("transaction_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	dbTransaction, err := cfg.db.GetTransactionByID(r.Context(), pathTransactionID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get transaction", err)
+		return
+	}
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	if pathBudgetID != dbTransaction.BudgetID {
+		respondWithCode(w, http.StatusForbidden)
+		return
+	}
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return
+	}
+	if !permitsAccount(restriction, dbTransaction.AccountID) {
+		respondWithError(w, http.StatusNotFound, "could not get transaction", nil)
+		return
+	}
+	if dbTransaction.Reconciled {
+		respondWithError(w, http.StatusConflict, "cannot undo an update to a reconciled transaction", nil)
+		return
+	}
+
+	var reverted db.Transaction
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		version, err := q.GetLatestTransactionVersion(r.Context(), pathTransactionID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "transaction has no update to undo", nil)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "could not get prior version of transaction", err)
+			return
+		}
+		versions := []db.TransactionVersion{version}
+
+		var linkedVersion *db.TransactionVersion
+		if checkIsTransfer(dbTransaction.TransactionType) {
+			linkedTxn, err := q.GetLinkedTransaction(r.Context(), pathTransactionID)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not find corresponding txn to revert", err)
+				return
+			}
+			if linkedTxn.Reconciled {
+				respondWithError(w, http.StatusConflict, "cannot undo an update to a transfer whose corresponding transaction is reconciled", nil)
+				return
+			}
+			dbLinkedVersion, err := q.GetLatestTransactionVersion(r.Context(), linkedTxn.ID)
+			if err != nil {
+				respondWithError(w, http.StatusConflict, "corresponding transfer transaction has no update to undo", err)
+				return
+			}
+			linkedVersion = &dbLinkedVersion
+			versions = append(versions, dbLinkedVersion)
+		}
+
+		// the member must be permitted to log the transaction as it was
+		versionSplits, err := q.GetTransactionVersionSplits(r.Context(), version.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not get prior splits of transaction", err)
+			return
+		}
+		if err := checkTxnRestrictions(restriction, validatedTxnFromVersion(&version, linkedVersion, versionSplits)); err != nil {
+			respondWithError(w, http.StatusForbidden, err.Error(), nil)
+			return
+		}
+
+		for _, v := range versions {
+			inPeriod, err := pgxInReconciledPeriod(q, r.Context(), v.AccountID, v.TransactionDate, v.Cleared)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not check reconciled period", err)
+				return
+			}
+			if inPeriod {
+				respondWithError(w, http.StatusConflict, "cannot undo an update such that a cleared transaction would fall within a reconciled period", nil)
+				return
+			}
+		}
+
+		for i, v := range versions {
+			if err := q.DeleteTransactionSplits(r.Context(), v.TransactionID); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not delete transaction splits", err)
+				return
+			}
+			revertedTxn, err := q.RevertTransaction(r.Context(), v.ID)
+			if err != nil {
+				respondWithError(w, http.StatusConflict, "could not revert transaction", err)
+				return
+			}
+			if err := q.RevertTransactionSplits(r.Context(), v.ID); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not revert transaction splits", err)
+				return
+			}
+			if err := q.DeleteTransactionVersion(r.Context(), v.ID); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not discard prior version of transaction", err)
+				return
+			}
+			if i == 0 {
+				reverted = revertedTxn
+			}
+		}
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not complete database transaction", err)
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, txnFromDB(&reverted))
+}
@@ -0,0 +1,324 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/YouWantToPinch/pincher-api/internal/auth"
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/jackc/pgx/v5"
+)
+
+// totpIssuer names the service to authenticator apps.
+const totpIssuer = "Pincher"
+
+// recoveryCodeCount is the number of one-time recovery codes
+// issued upon enabling two-factor authentication.
+const recoveryCodeCount = 10
+
+// handleLoginTwoFactor completes the second step of a two-step login,
+// exchanging a challenge token and a TOTP or recovery code for the
+// tokens that a one-step login would have issued.
+func (cfg *APIConfig) handleLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if rqPayload.ChallengeToken == "" || rqPayload.Code == "" {
+		respondWithError(w, http.StatusBadRequest, "missing challenge token or code", nil)
+		return
+	}
+
+	userID, err := auth.ValidateChallengeJWT(rqPayload.ChallengeToken, cfg.jwtKeys)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid challenge token provided", err)
+		return
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "could not get user", err)
+		return
+	}
+
+	throttleKeys := []throttleKey{
+		{scope: throttleUsername, subject: dbUser.Username},
+		{scope: throttleIP, subject: clientIP(r)},
+	}
+	if !cfg.checkThrottles(w, r, throttleKeys...) {
+		return
+	}
+
+	twoFactor, err := cfg.db.GetUserTwoFactor(r.Context(), userID)
+	if err != nil || !twoFactor.EnabledAt.Valid {
+		respondWithError(w, http.StatusUnauthorized, "two-factor authentication not enabled", err)
+		return
+	}
+
+	var used int64
+	if step, err := auth.ValidateTOTP(twoFactor.TotpSecret, rqPayload.Code, time.Now(), twoFactor.LastUsedStep); err == nil {
+		// the step is claimed atomically, so that no code is accepted twice
+		used, err = cfg.db.UseTOTPStep(r.Context(), db.UseTOTPStepParams{
+			Step:   step,
+			UserID: userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record code use", err)
+			return
+		}
+	} else {
+		used, err = cfg.db.UseRecoveryCode(r.Context(), db.UseRecoveryCodeParams{
+			UserID:     userID,
+			HashedCode: auth.HashRecoveryCode(rqPayload.Code),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record code use", err)
+			return
+		}
+	}
+	if used == 0 {
+		if err := cfg.recordFailedAttempts(r.Context(), throttleKeys...); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record failed attempt", err)
+			return
+		}
+		respondWithError(w, http.StatusUnauthorized, "invalid code", nil)
+		return
+	}
+
+	cfg.startSession(w, r, &dbUser)
+}
+
+// handleEnrollTwoFactor begins enrollment in two-factor authentication,
+// returning a new TOTP secret for the user to add to an authenticator app.
+// It takes no effect until confirmed with a code from that app.
+func (cfg *APIConfig) handleEnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	twoFactor, err := cfg.db.GetUserTwoFactor(r.Context(), validatedUserID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "could not check two-factor authentication", err)
+		return
+	}
+	if err == nil && twoFactor.EnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "two-factor authentication already enabled", nil)
+		return
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get user", err)
+		return
+	}
+
+	secret, err := auth.MakeTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create TOTP secret", err)
+		return
+	}
+
+	_, err = cfg.db.UpsertUserTwoFactor(r.Context(), db.UpsertUserTwoFactorParams{
+		UserID:     validatedUserID,
+		TotpSecret: secret,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not save TOTP secret", err)
+		return
+	}
+
+	type rspSchema struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+
+	respondWithJSON(w, http.StatusCreated, rspSchema{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(secret, totpIssuer, dbUser.Username),
+	})
+}
+
+// handleConfirmTwoFactor enables two-factor authentication, given a valid
+// code for the secret issued upon enrollment. The recovery codes returned
+// are shown only here; only their hashes are kept.
+func (cfg *APIConfig) handleConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		Code string `json:"code"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	twoFactor, err := cfg.db.GetUserTwoFactor(r.Context(), validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "no two-factor enrollment found", err)
+		return
+	}
+	if twoFactor.EnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "two-factor authentication already enabled", nil)
+		return
+	}
+
+	step, err := auth.ValidateTOTP(twoFactor.TotpSecret, rqPayload.Code, time.Now(), twoFactor.LastUsedStep)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return
+	}
+
+	recoveryCodes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := auth.MakeRecoveryCode()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not create recovery code", err)
+			return
+		}
+		recoveryCodes = append(recoveryCodes, code)
+	}
+
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		_, err = q.UseTOTPStep(r.Context(), db.UseTOTPStepParams{
+			Step:   step,
+			UserID: validatedUserID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record code use", err)
+			return
+		}
+		if err := q.EnableUserTwoFactor(r.Context(), validatedUserID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not enable two-factor authentication", err)
+			return
+		}
+		if err := q.DeleteRecoveryCodes(r.Context(), validatedUserID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not replace recovery codes", err)
+			return
+		}
+		for _, code := range recoveryCodes {
+			err := q.CreateRecoveryCode(r.Context(), db.CreateRecoveryCodeParams{
+				UserID:     validatedUserID,
+				HashedCode: auth.HashRecoveryCode(code),
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "could not save recovery code", err)
+				return
+			}
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+
+	type rspSchema struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	respondWithJSON(w, http.StatusOK, rspSchema{RecoveryCodes: recoveryCodes})
+}
+
+// handleDisableTwoFactor turns off two-factor authentication,
+// which requires the user to provide their password once more.
+func (cfg *APIConfig) handleDisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type rqSchema struct {
+		Password string `json:"password"`
+	}
+
+	rqPayload, err := decodePayload[rqSchema](r)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "", err)
+		return
+	}
+
+	if rqPayload.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "missing password", nil)
+		return
+	}
+
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get user", err)
+		return
+	}
+
+	// an enrollment never confirmed was never enabled, and is not disabled
+	twoFactor, err := cfg.db.GetUserTwoFactor(r.Context(), validatedUserID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "could not check two-factor authentication", err)
+		return
+	}
+	if err != nil || !twoFactor.EnabledAt.Valid {
+		respondWithError(w, http.StatusNotFound, "two-factor authentication not enabled", nil)
+		return
+	}
+
+	// the password is guarded as at login, lest a stolen
+	// access token be used to guess it
+	throttleKeys := []throttleKey{
+		{scope: throttleUsername, subject: dbUser.Username},
+		{scope: throttleIP, subject: clientIP(r)},
+	}
+	if !cfg.checkThrottles(w, r, throttleKeys...) {
+		return
+	}
+
+	match, err := auth.CheckPasswordHash(rqPayload.Password, dbUser.HashedPassword)
+	if err != nil || !match {
+		if err := cfg.recordFailedAttempts(r.Context(), throttleKeys...); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not record failed attempt", err)
+			return
+		}
+		respondWithError(w, http.StatusUnauthorized, "incorrect password", err)
+		return
+	}
+
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		if err := q.DeleteUserTwoFactor(r.Context(), validatedUserID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not disable two-factor authentication", err)
+			return
+		}
+		if err := q.DeleteRecoveryCodes(r.Context(), validatedUserID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not delete recovery codes", err)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+	}
+
+	respondWithCode(w, http.StatusNoContent)
+}
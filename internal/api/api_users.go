@@ -24,6 +24,17 @@ func (cfg *APIConfig) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// every signup counts against the client, successful or not,
+	// since each is as costly to hash as a failed login
+	signupKey := throttleKey{scope: throttleSignup, subject: clientIP(r)}
+	if !cfg.checkThrottles(w, r, signupKey) {
+		return
+	}
+	if err := cfg.recordAttempts(r.Context(), &cfg.signupThrottle, signupKey); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not record signup attempt", err)
+		return
+	}
+
 	hashedPass, err := auth.HashPassword(rqPayload.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "failure processing request to create user", err)
@@ -73,13 +84,42 @@ func (cfg *APIConfig) handleUpdateUserCredentials(w http.ResponseWriter, r *http
 
 	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
 
-	_, err = cfg.db.UpdateUserCredentials(r.Context(), db.UpdateUserCredentialsParams{
-		ID:             validatedUserID,
-		Username:       rqPayload.Username,
-		HashedPassword: hashedPass,
-	})
-	if err != nil {
-		respondWithError(w, http.StatusConflict, "could not update user credentials", err)
+	// DB TRANSACTION BLOCK
+	{
+		tx, err := cfg.beginTx(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+		defer tx.Rollback(r.Context())
+
+		q := cfg.db.WithTx(tx)
+
+		_, err = q.UpdateUserCredentials(r.Context(), db.UpdateUserCredentialsParams{
+			ID:             validatedUserID,
+			Username:       rqPayload.Username,
+			HashedPassword: hashedPass,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusConflict, "could not update user credentials", err)
+			return
+		}
+
+		// the password is always set anew, so any other
+		// device signed in with the old one is signed out
+		_, err = q.RevokeOtherUserSessions(r.Context(), db.RevokeOtherUserSessionsParams{
+			UserID:   validatedUserID,
+			FamilyID: cfg.getCallerSessionID(r),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not revoke sessions", err)
+			return
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "", err)
+			return
+		}
 	}
 
 	respondWithCode(w, http.StatusNoContent)
@@ -120,6 +160,17 @@ func (cfg *APIConfig) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// budgets shared with others would be deleted along with their admin
+	sharedBudgets, err := cfg.db.GetSharedBudgetsByAdmin(r.Context(), validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not check budget ownership", err)
+		return
+	}
+	if len(sharedBudgets) > 0 {
+		respondWithError(w, http.StatusConflict, "user is admin of budgets shared with other members; transfer their ownership first", nil)
+		return
+	}
+
 	err = cfg.db.DeleteUserByID(r.Context(), validatedUserID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "could not delete user", err)
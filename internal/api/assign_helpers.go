@@ -0,0 +1,129 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Strategies by which money may be assigned to many categories at once.
+const (
+	// assignFundUnderfunded assigns whatever remains underfunded of each goal.
+	assignFundUnderfunded = "FUND_UNDERFUNDED"
+	// assignLastMonthAssigned assigns what was assigned the month before.
+	assignLastMonthAssigned = "LAST_MONTH_ASSIGNED"
+	// assignLastMonthSpent assigns what was spent the month before.
+	assignLastMonthSpent = "LAST_MONTH_SPENT"
+	// assignAverageSpent assigns the average spent over the months before.
+	assignAverageSpent = "AVERAGE_SPENT"
+	// assignReset assigns nothing at all.
+	assignReset = "RESET"
+)
+
+// defaultAverageMonths is the number of months averaged by the
+// AVERAGE_SPENT strategy, unless specified otherwise.
+const defaultAverageMonths = 3
+
+// maxAverageMonths limits how many months the AVERAGE_SPENT strategy may average.
+const maxAverageMonths = 24
+
+// autoAssignRule describes how money is to be assigned to many categories at once.
+type autoAssignRule struct {
+	strategy string
+	// months is the number of months averaged by the AVERAGE_SPENT strategy.
+	months int
+}
+
+// validateAutoAssignRule normalizes the given rule and reports whether
+// it can be used to assign money.
+// Any error returned implies a bad request.
+func validateAutoAssignRule(rule *autoAssignRule) error {
+	rule.strategy = strings.ToUpper(strings.TrimSpace(rule.strategy))
+
+	switch rule.strategy {
+	case assignFundUnderfunded, assignLastMonthAssigned, assignLastMonthSpent, assignReset:
+		if rule.months != 0 {
+			return fmt.Errorf("months are only supported for the %s strategy", assignAverageSpent)
+		}
+	case assignAverageSpent:
+		if rule.months == 0 {
+			rule.months = defaultAverageMonths
+		}
+		if rule.months < 0 || rule.months > maxAverageMonths {
+			return fmt.Errorf("months must be between 1 and %d", maxAverageMonths)
+		}
+	default:
+		return fmt.Errorf("invalid strategy: %s", rule.strategy)
+	}
+	return nil
+}
+
+// historyMonths returns the number of months before the assigned month
+// which the rule draws upon.
+func (rule *autoAssignRule) historyMonths() int {
+	switch rule.strategy {
+	case assignLastMonthAssigned, assignLastMonthSpent:
+		return 1
+	case assignAverageSpent:
+		return rule.months
+	default:
+		return 0
+	}
+}
+
+// categoryMonth holds the figures reported for a category in a given month.
+type categoryMonth struct {
+	categoryID  uuid.UUID
+	name        string
+	assigned    int64
+	activity    int64
+	underfunded int64
+}
+
+// planAutoAssign returns the change in assignment the rule makes to each of
+// the given categories, as reported for the assigned month. History holds the
+// reports of the months before it; months missing from it count as empty.
+// Categories the rule leaves as they are, are left out.
+func planAutoAssign(rule *autoAssignRule, current []categoryMonth, history []categoryMonth) []AutoAssignChange {
+	type totals struct {
+		assigned int64
+		activity int64
+	}
+	pastTotals := map[uuid.UUID]totals{}
+	for _, h := range history {
+		t := pastTotals[h.categoryID]
+		t.assigned += h.assigned
+		t.activity += h.activity
+		pastTotals[h.categoryID] = t
+	}
+
+	changes := []AutoAssignChange{}
+	for _, c := range current {
+		var target int64
+		switch rule.strategy {
+		case assignFundUnderfunded:
+			target = c.assigned + c.underfunded
+		case assignLastMonthAssigned:
+			target = pastTotals[c.categoryID].assigned
+		case assignLastMonthSpent:
+			target = max(-pastTotals[c.categoryID].activity, 0)
+		case assignAverageSpent:
+			spent := max(-pastTotals[c.categoryID].activity, 0)
+			target = (spent + int64(rule.months) - 1) / int64(rule.months)
+		case assignReset:
+			target = 0
+		}
+		if target == c.assigned {
+			continue
+		}
+		changes = append(changes, AutoAssignChange{
+			CategoryID:     c.categoryID,
+			CategoryName:   c.name,
+			AssignedBefore: c.assigned,
+			AssignedAfter:  target,
+			Change:         target - c.assigned,
+		})
+	}
+	return changes
+}
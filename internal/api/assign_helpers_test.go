@@ -0,0 +1,126 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateAutoAssignRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      autoAssignRule
+		wantMonths int
+		wantErr    bool
+	}{
+		{
+			name:    "Valid: fund underfunded goals",
+			input:   autoAssignRule{strategy: "fund_underfunded"},
+			wantErr: false,
+		},
+		{
+			name:       "Valid: average spent with default months",
+			input:      autoAssignRule{strategy: assignAverageSpent},
+			wantMonths: defaultAverageMonths,
+			wantErr:    false,
+		},
+		{
+			name:    "Invalid: unknown strategy",
+			input:   autoAssignRule{strategy: "DOUBLE_IT"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: months on reset",
+			input:   autoAssignRule{strategy: assignReset, months: 2},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: too many months averaged",
+			input:   autoAssignRule{strategy: assignAverageSpent, months: maxAverageMonths + 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAutoAssignRule(&tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error: %v | actual: %v", tt.wantErr, err)
+			}
+			if err == nil && tt.input.months != tt.wantMonths {
+				t.Errorf("want months: %d | actual: %d", tt.wantMonths, tt.input.months)
+			}
+		})
+	}
+}
+
+func TestPlanAutoAssign(t *testing.T) {
+	rentID, groceriesID := uuid.New(), uuid.New()
+	current := []categoryMonth{
+		{categoryID: rentID, name: "Rent", assigned: 40000, underfunded: 60000},
+		{categoryID: groceriesID, name: "Groceries", assigned: 0, activity: -2000},
+	}
+	history := []categoryMonth{
+		{categoryID: rentID, name: "Rent", assigned: 100000, activity: -100000},
+		{categoryID: groceriesID, name: "Groceries", assigned: 30000, activity: -25000},
+		{categoryID: groceriesID, name: "Groceries", assigned: 30000, activity: -35000},
+		{categoryID: groceriesID, name: "Groceries", assigned: 30000, activity: 1000},
+	}
+
+	tests := []struct {
+		name    string
+		rule    autoAssignRule
+		history []categoryMonth
+		want    map[uuid.UUID]int64
+	}{
+		{
+			name: "Fund underfunded goals",
+			rule: autoAssignRule{strategy: assignFundUnderfunded},
+			want: map[uuid.UUID]int64{rentID: 100000},
+		},
+		{
+			name:    "Last month assigned",
+			rule:    autoAssignRule{strategy: assignLastMonthAssigned},
+			history: history[:2],
+			want:    map[uuid.UUID]int64{rentID: 100000, groceriesID: 30000},
+		},
+		{
+			name:    "Last month spent",
+			rule:    autoAssignRule{strategy: assignLastMonthSpent},
+			history: history[:2],
+			want:    map[uuid.UUID]int64{rentID: 100000, groceriesID: 25000},
+		},
+		{
+			name:    "Average spent, rounded up",
+			rule:    autoAssignRule{strategy: assignAverageSpent, months: 3},
+			history: history[1:],
+			want:    map[uuid.UUID]int64{rentID: 0, groceriesID: 19667},
+		},
+		{
+			name: "Reset",
+			rule: autoAssignRule{strategy: assignReset},
+			want: map[uuid.UUID]int64{rentID: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := planAutoAssign(&tt.rule, current, tt.history)
+			got := map[uuid.UUID]int64{}
+			for _, change := range changes {
+				if change.Change != change.AssignedAfter-change.AssignedBefore {
+					t.Errorf("change for %s does not match its before and after amounts", change.CategoryName)
+				}
+				got[change.CategoryID] = change.AssignedAfter
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("want %d changes | actual: %d", len(tt.want), len(got))
+			}
+			for id, want := range tt.want {
+				if got[id] != want {
+					t.Errorf("want assigned: %d | actual: %d", want, got[id])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Actions recorded in the audit log.
+const (
+	auditCreate  = "CREATE"
+	auditUpdate  = "UPDATE"
+	auditDelete  = "DELETE"
+	auditRestore = "RESTORE"
+	// transactions purged from the trash are deleted for good
+	auditPurge = "PURGE"
+)
+
+// snapshotFunc reads the state of a resource of a budget,
+// or returns nil where no such resource exists.
+type snapshotFunc func(ctx context.Context, q *db.Queries, budgetID uuid.UUID, resourceID string) (any, error)
+
+// auditResource describes a kind of resource changed by an audited route,
+// and how to read its state before and after the change.
+type auditResource struct {
+	kind string
+	// locate finds the ID of the resource from the request before it is
+	// handled, or returns "" where the resource does not exist yet.
+	locate func(r *http.Request, body []byte, q *db.Queries) string
+	// idField names the field of the response holding the ID of the
+	// resource, where it could not be located beforehand.
+	idField string
+	// snapshot reads the state of the resource. Where nil, there is no
+	// state to read before the change, and the response is kept as the
+	// state after it.
+	snapshot snapshotFunc
+	// changed reports from the response whether anything was changed,
+	// where a request may succeed without changing anything; where nil,
+	// every successful request is taken to have changed something.
+	changed func(rspBody []byte) bool
+}
+
+var (
+	auditBudget = auditResource{
+		kind:     "BUDGET",
+		locate:   locateInPath("budget_id"),
+		idField:  "id",
+		snapshot: snapshotByID((*db.Queries).GetBudgetByID, nil),
+	}
+	auditMember = auditResource{
+		kind:     "MEMBER",
+		locate:   locateInPath("user_id"),
+		idField:  "user_id",
+		snapshot: snapshotMember,
+	}
+	auditMemberRestriction = auditResource{
+		kind:     "MEMBER_RESTRICTION",
+		locate:   locateInPath("user_id"),
+		snapshot: snapshotMemberRestriction,
+	}
+	auditInvitation = auditResource{
+		kind:    "INVITATION",
+		locate:  locateInPath("invitation_id"),
+		idField: "id",
+		snapshot: snapshotByID((*db.Queries).GetBudgetInvitationByID, func(dbInvitation *db.BudgetInvitation) (any, error) {
+			return invitationFromDB(dbInvitation), nil
+		}),
+	}
+	auditOwnershipTransfer = auditResource{
+		kind:   "OWNERSHIP_TRANSFER",
+		locate: locateInPath("budget_id"),
+		snapshot: snapshotByID((*db.Queries).GetOwnershipTransfer, func(dbOwnershipTransfer *db.BudgetOwnershipTransfer) (any, error) {
+			return ownershipTransferFromDB(dbOwnershipTransfer), nil
+		}),
+	}
+	auditGroup = auditResource{
+		kind:     "GROUP",
+		locate:   locateInPath("group_id"),
+		idField:  "id",
+		snapshot: snapshotByID((*db.Queries).GetGroupByID, nil),
+	}
+	auditCategory = auditResource{
+		kind:     "CATEGORY",
+		locate:   locateInPath("category_id"),
+		idField:  "id",
+		snapshot: snapshotByID((*db.Queries).GetCategoryByID, nil),
+	}
+	auditCategoryGoal = auditResource{
+		kind:     "CATEGORY_GOAL",
+		locate:   locateInPath("category_id"),
+		snapshot: snapshotByID((*db.Queries).GetCategoryGoal, nil),
+	}
+	auditPayee = auditResource{
+		kind:     "PAYEE",
+		locate:   locateInPath("payee_id"),
+		idField:  "id",
+		snapshot: snapshotByID((*db.Queries).GetPayeeByID, nil),
+	}
+	auditAccount = auditResource{
+		kind:     "ACCOUNT",
+		locate:   locateInPath("account_id"),
+		idField:  "id",
+		snapshot: snapshotByID((*db.Queries).GetAccountByID, nil),
+	}
+	auditImportProfile = auditResource{
+		kind:   "IMPORT_PROFILE",
+		locate: locateInPath("account_id"),
+		snapshot: snapshotByID((*db.Queries).GetImportProfileByAccountID, func(dbProfile *db.ImportProfile) (any, error) {
+			return importProfileFromDB(dbProfile), nil
+		}),
+	}
+	// imports and reconciliations are kept by their reports alone
+	auditImport = auditResource{
+		kind:   "IMPORT",
+		locate: locateInPath("account_id"),
+	}
+	// statements not matching the cleared balance are reported
+	// without reconciling anything
+	auditReconciliation = auditResource{
+		kind:   "RECONCILIATION",
+		locate: locateInPath("account_id"),
+		changed: func(rspBody []byte) bool {
+			var report ReconciliationReport
+			return json.Unmarshal(rspBody, &report) == nil && report.Reconciled
+		},
+	}
+	auditTransaction = auditResource{
+		kind:    "TRANSACTION",
+		locate:  locateInPath("transaction_id"),
+		idField: "id",
+		snapshot: snapshotByID((*db.Queries).GetTransactionDetailsByID, func(dbTxn *db.TransactionDetail) (any, error) {
+			return txnDetailFromDB(dbTxn)
+		}),
+	}
+	auditRecurring = auditResource{
+		kind:    "RECURRING_TRANSACTION",
+		locate:  locateInPath("recurring_id"),
+		idField: "id",
+		snapshot: snapshotByID((*db.Queries).GetRecurringTransactionByID, func(dbRecurring *db.RecurringTransaction) (any, error) {
+			return recurringFromDB(dbRecurring)
+		}),
+	}
+	// assignments are kept as the category reports of their month
+	auditAssignment = auditResource{
+		kind: "ASSIGNMENT",
+		locate: func(r *http.Request, body []byte, q *db.Queries) string {
+			return r.PathValue("month_id")
+		},
+		snapshot: snapshotMonth,
+	}
+)
+
+func locateInPath(pathParam string) func(r *http.Request, body []byte, q *db.Queries) string {
+	return func(r *http.Request, body []byte, q *db.Queries) string {
+		parsedID, err := parseUUIDFromPath(pathParam, r)
+		if err != nil {
+			return ""
+		}
+		return parsedID.String()
+	}
+}
+
+// snapshotByID reads a resource by its ID alone, optionally presenting
+// it as it would be presented by the API.
+func snapshotByID[T any](get func(*db.Queries, context.Context, uuid.UUID) (T, error), present func(*T) (any, error)) snapshotFunc {
+	return func(ctx context.Context, q *db.Queries, budgetID uuid.UUID, resourceID string) (any, error) {
+		parsedID, err := uuid.Parse(resourceID)
+		if err != nil {
+			return nil, nil
+		}
+		resource, err := get(q, ctx, parsedID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if present == nil {
+			return resource, nil
+		}
+		return present(&resource)
+	}
+}
+
+func snapshotMember(ctx context.Context, q *db.Queries, budgetID uuid.UUID, resourceID string) (any, error) {
+	userID, err := uuid.Parse(resourceID)
+	if err != nil {
+		return nil, nil
+	}
+	memberRole, err := q.GetBudgetMemberRole(ctx, db.GetBudgetMemberRoleParams{
+		BudgetID: budgetID,
+		UserID:   userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return map[string]any{
+		"user_id":     userID,
+		"member_role": memberRole,
+	}, nil
+}
+
+func snapshotMemberRestriction(ctx context.Context, q *db.Queries, budgetID uuid.UUID, resourceID string) (any, error) {
+	userID, err := uuid.Parse(resourceID)
+	if err != nil {
+		return nil, nil
+	}
+	restriction, err := getMemberRestriction(ctx, q, budgetID, userID)
+	if err != nil || restriction == nil {
+		return nil, err
+	}
+	return restrictionFromDB(restriction), nil
+}
+
+func snapshotMonth(ctx context.Context, q *db.Queries, budgetID uuid.UUID, resourceID string) (any, error) {
+	month, err := parseDate(resourceID)
+	if err != nil {
+		return nil, nil
+	}
+	return q.GetMonthCategoryReports(ctx, db.GetMonthCategoryReportsParams{
+		MonthID:  month,
+		BudgetID: budgetID,
+	})
+}
+
+// auditedPool is the connection pool the queries of the API are run on.
+// Within a request being audited, it runs every query within the transaction
+// begun for the request by middlewareAudit instead, so that the changes made
+// by the handler commit along with their audit entry, or not at all.
+type auditedPool struct {
+	*pgxpool.Pool
+}
+
+// auditTx returns the transaction of the audited request of the given
+// context, or nil where there is none.
+func auditTx(ctx context.Context) pgx.Tx {
+	tx, _ := ctx.Value(ctxKey("audit_tx")).(pgx.Tx)
+	return tx
+}
+
+// beginTx begins a database transaction; or, within a request being
+// audited, one nested within the transaction of the request.
+func (cfg *APIConfig) beginTx(ctx context.Context) (pgx.Tx, error) {
+	if tx := auditTx(ctx); tx != nil {
+		return tx.Begin(ctx)
+	}
+	return cfg.Pool.Begin(ctx)
+}
+
+func (p *auditedPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if tx := auditTx(ctx); tx != nil {
+		return tx.Exec(ctx, sql, args...)
+	}
+	return p.Pool.Exec(ctx, sql, args...)
+}
+
+func (p *auditedPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if tx := auditTx(ctx); tx != nil {
+		return tx.Query(ctx, sql, args...)
+	}
+	return p.Pool.Query(ctx, sql, args...)
+}
+
+func (p *auditedPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if tx := auditTx(ctx); tx != nil {
+		return tx.QueryRow(ctx, sql, args...)
+	}
+	return p.Pool.QueryRow(ctx, sql, args...)
+}
+
+// auditRecorder holds back a response until the change it reports
+// has been audited, keeping its status code and body to be audited.
+type auditRecorder struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newAuditRecorder() *auditRecorder {
+	return &auditRecorder{header: http.Header{}}
+}
+
+func (rec *auditRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *auditRecorder) WriteHeader(code int) {
+	if rec.code == 0 {
+		rec.code = code
+	}
+}
+
+func (rec *auditRecorder) Write(b []byte) (int, error) {
+	if rec.code == 0 {
+		rec.code = http.StatusOK
+	}
+	return rec.body.Write(b)
+}
+
+// flush writes the response held back to the client.
+func (rec *auditRecorder) flush(w http.ResponseWriter) {
+	maps.Copy(w.Header(), rec.header)
+	if rec.code != 0 {
+		w.WriteHeader(rec.code)
+	}
+	w.Write(rec.body.Bytes())
+}
+
+// responseField returns a field of a JSON response body as a string,
+// or "" where there is no such field.
+func responseField(body []byte, field string) string {
+	if field == "" {
+		return ""
+	}
+	var rspPayload map[string]any
+	if err := json.Unmarshal(body, &rspPayload); err != nil {
+		return ""
+	}
+	value, _ := rspPayload[field].(string)
+	return value
+}
+
+// marshalAuditState encodes the state of a resource for the audit log,
+// or returns nil where there is no state.
+func marshalAuditState(state any) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+	if raw, ok := state.(json.RawMessage); ok {
+		return raw, nil
+	}
+	return json.Marshal(state)
+}
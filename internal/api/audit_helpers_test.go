@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseField(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		field string
+		want  string
+	}{
+		{
+			name:  "Found: string field",
+			body:  `{"id": "abc", "budget_id": "def"}`,
+			field: "budget_id",
+			want:  "def",
+		},
+		{
+			name:  "Not found: missing field",
+			body:  `{"id": "abc"}`,
+			field: "user_id",
+			want:  "",
+		},
+		{
+			name:  "Not found: field is not a string",
+			body:  `{"id": 12}`,
+			field: "id",
+			want:  "",
+		},
+		{
+			name:  "Not found: body is not an object",
+			body:  `[{"id": "abc"}]`,
+			field: "id",
+			want:  "",
+		},
+		{
+			name:  "Not found: no field named",
+			body:  `{"id": "abc"}`,
+			field: "",
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := responseField([]byte(tt.body), tt.field)
+			if actual != tt.want {
+				t.Errorf("want: %v | actual: %v", tt.want, actual)
+			}
+		})
+	}
+}
+
+func TestAuditRecorder(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "Body written without a status code",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"id":"abc"}`))
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"id":"abc"}`,
+		},
+		{
+			name: "Status code without a body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				respondWithCode(w, http.StatusNoContent)
+			},
+			wantCode: http.StatusNoContent,
+			wantBody: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rec := newAuditRecorder()
+			tt.handler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+			if w.Body.Len() != 0 {
+				t.Errorf("response passed through before being flushed: %v", w.Body.String())
+			}
+			rec.flush(w)
+			if rec.code != tt.wantCode || w.Code != tt.wantCode {
+				t.Errorf("want: %v | actual: %v (flushed: %v)", tt.wantCode, rec.code, w.Code)
+			}
+			if rec.body.String() != tt.wantBody || w.Body.String() != tt.wantBody {
+				t.Errorf("want: %v | actual: %v (flushed: %v)", tt.wantBody, rec.body.String(), w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/google/uuid"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+// Identify documents written by a budget export. The version is raised
+// whenever the document changes in a way older imports cannot read.
+const (
+	budgetExportFormat  = "pincher-budget"
+	budgetExportVersion = 1
+)
+
+// exportSections names the sections of a budget export, in the order they
+// are written and must be read. Each section only refers to those before it.
+var exportSections = []string{
+	"members",
+	"groups",
+	"categories",
+	"accounts",
+	"payees",
+	"assignments",
+	"transactions",
+}
+
+// errInvalidExport is wrapped by errors reading a budget export which
+// imply a bad request.
+var errInvalidExport = errors.New("invalid budget export")
+
+func invalidExportErr(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errInvalidExport, fmt.Sprintf(format, args...))
+}
+
+// exportWriter streams a budget export document, one entry at a time.
+// The first error encountered is kept, after which nothing is written.
+type exportWriter struct {
+	w io.Writer
+	// flush, where set, sends what has been written so far to the client.
+	flush   func() error
+	entries int
+	err     error
+}
+
+func newExportWriter(w io.Writer, flush func() error) *exportWriter {
+	return &exportWriter{w: w, flush: flush}
+}
+
+func (ew *exportWriter) write(b []byte) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = ew.w.Write(b)
+}
+
+// writeHeader opens the document with its header. The closing brace of
+// the header is left off, so that sections may follow it.
+func (ew *exportWriter) writeHeader(header BudgetExportHeader) {
+	data, err := json.Marshal(header)
+	if err != nil {
+		ew.err = err
+		return
+	}
+	ew.write(data[:len(data)-1])
+}
+
+func (ew *exportWriter) beginSection(name string) {
+	ew.write([]byte(`,"` + name + `":[`))
+	ew.entries = 0
+}
+
+func (ew *exportWriter) writeEntry(entry any) {
+	if ew.err != nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		ew.err = err
+		return
+	}
+	if ew.entries > 0 {
+		ew.write([]byte(","))
+	}
+	ew.write(data)
+	ew.entries++
+}
+
+func (ew *exportWriter) endSection() {
+	ew.write([]byte("]"))
+	ew.sendBuffered()
+}
+
+// sendBuffered flushes what has been written so far, where it can be.
+func (ew *exportWriter) sendBuffered() {
+	if ew.err != nil || ew.flush == nil {
+		return
+	}
+	ew.err = ew.flush()
+}
+
+// close ends the document, and returns the first error encountered
+// while writing it.
+func (ew *exportWriter) close() error {
+	ew.write([]byte("}\n"))
+	ew.sendBuffered()
+	return ew.err
+}
+
+func writeExportSection[T any](ew *exportWriter, name string, entries []T) {
+	ew.beginSection(name)
+	for _, entry := range entries {
+		ew.writeEntry(entry)
+	}
+	ew.endSection()
+}
+
+// budgetImporter recreates a budget from the entries of an export,
+// in the order they are read.
+type budgetImporter interface {
+	importBudget(budget ExportBudget) error
+	importMember(member ExportMember) error
+	importGroup(group ExportGroup) error
+	importCategory(category ExportCategory) error
+	importAccount(account ExportAccount) error
+	importPayee(payee ExportPayee) error
+	importAssignment(assignment ExportAssignment) error
+	importTransaction(txn ExportTransaction) error
+}
+
+// readBudgetExport reads a budget export document from r, one entry at a
+// time, handing each to the importer as it is read. The format and version
+// must come before the budget, which must come before its sections.
+// Fields which are not known are skipped.
+func readBudgetExport(r io.Reader, importer budgetImporter) error {
+	dec := json.NewDecoder(r)
+
+	sectionReaders := map[string]func() error{
+		"members":      func() error { return readExportSection(dec, importer.importMember) },
+		"groups":       func() error { return readExportSection(dec, importer.importGroup) },
+		"categories":   func() error { return readExportSection(dec, importer.importCategory) },
+		"accounts":     func() error { return readExportSection(dec, importer.importAccount) },
+		"payees":       func() error { return readExportSection(dec, importer.importPayee) },
+		"assignments":  func() error { return readExportSection(dec, importer.importAssignment) },
+		"transactions": func() error { return readExportSection(dec, importer.importTransaction) },
+	}
+
+	if err := expectExportDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	var format string
+	var version int
+	budgetRead := false
+	lastSection := -1
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return invalidExportErr("%v", err)
+		}
+		key, _ := token.(string)
+
+		switch key {
+		case "format":
+			if err := dec.Decode(&format); err != nil {
+				return invalidExportErr("could not read format: %v", err)
+			}
+			if format != budgetExportFormat {
+				return invalidExportErr("unknown format: %s", format)
+			}
+		case "version":
+			if err := dec.Decode(&version); err != nil {
+				return invalidExportErr("could not read version: %v", err)
+			}
+			if version < 1 || version > budgetExportVersion {
+				return invalidExportErr("unsupported version: %d", version)
+			}
+		case "budget":
+			if format == "" || version == 0 {
+				return invalidExportErr("format and version must come before the budget")
+			}
+			var budget ExportBudget
+			if err := dec.Decode(&budget); err != nil {
+				return invalidExportErr("could not read budget: %v", err)
+			}
+			if err := importer.importBudget(budget); err != nil {
+				return err
+			}
+			budgetRead = true
+		default:
+			section := slices.Index(exportSections, key)
+			if section == -1 {
+				var skipped json.RawMessage
+				if err := dec.Decode(&skipped); err != nil {
+					return invalidExportErr("%v", err)
+				}
+				continue
+			}
+			if !budgetRead {
+				return invalidExportErr("budget must come before %s", key)
+			}
+			if section <= lastSection {
+				return invalidExportErr("%s must come before %s", key, exportSections[lastSection])
+			}
+			if err := sectionReaders[key](); err != nil {
+				return err
+			}
+			lastSection = section
+		}
+	}
+
+	if err := expectExportDelim(dec, '}'); err != nil {
+		return err
+	}
+	if !budgetRead {
+		return invalidExportErr("no budget found")
+	}
+	return nil
+}
+
+// readExportSection reads each entry of a section array in turn,
+// handing it to add before reading the next.
+func readExportSection[T any](dec *json.Decoder, add func(T) error) error {
+	if err := expectExportDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		var entry T
+		if err := dec.Decode(&entry); err != nil {
+			return invalidExportErr("%v", err)
+		}
+		if err := add(entry); err != nil {
+			return err
+		}
+	}
+	return expectExportDelim(dec, ']')
+}
+
+func expectExportDelim(dec *json.Decoder, want json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return invalidExportErr("%v", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return invalidExportErr("expected %v but found %v", want, token)
+	}
+	return nil
+}
+
+// budgetStructure holds all of a budget but its transactions, as exported.
+type budgetStructure struct {
+	budget      ExportBudget
+	members     []ExportMember
+	groups      []ExportGroup
+	categories  []ExportCategory
+	accounts    []ExportAccount
+	payees      []ExportPayee
+	assignments []ExportAssignment
+}
+
+// readBudgetStructure reads all of a budget but its transactions and
+// assignments, which are left for the caller to read as it needs.
+func readBudgetStructure(ctx context.Context, q *db.Queries, budgetID uuid.UUID) (structure *budgetStructure, errMsg string, err error) {
+	dbBudget, err := q.GetBudgetByID(ctx, budgetID)
+	if err != nil {
+		return nil, "could not get budget", err
+	}
+	dbMembers, err := q.GetBudgetMemberUsernames(ctx, budgetID)
+	if err != nil {
+		return nil, "could not get budget members", err
+	}
+	dbGroups, err := q.GetGroupsByBudgetID(ctx, budgetID)
+	if err != nil {
+		return nil, "could not get groups", err
+	}
+	dbCategories, err := q.GetCategories(ctx, db.GetCategoriesParams{
+		BudgetID: budgetID,
+	})
+	if err != nil {
+		return nil, "could not get categories", err
+	}
+	dbAccounts, err := q.GetAccountsFromBudget(ctx, budgetID)
+	if err != nil {
+		return nil, "could not get accounts", err
+	}
+	dbPayees, err := q.GetBudgetPayees(ctx, budgetID)
+	if err != nil {
+		return nil, "could not get payees", err
+	}
+
+	structure = &budgetStructure{
+		budget: ExportBudget{
+			Name:             dbBudget.Name,
+			Notes:            dbBudget.Notes,
+			OverspendingRule: dbBudget.OverspendingRule,
+		},
+		members:     make([]ExportMember, 0, len(dbMembers)),
+		groups:      make([]ExportGroup, 0, len(dbGroups)),
+		categories:  make([]ExportCategory, 0, len(dbCategories)),
+		accounts:    make([]ExportAccount, 0, len(dbAccounts)),
+		payees:      make([]ExportPayee, 0, len(dbPayees)),
+		assignments: []ExportAssignment{},
+	}
+	for _, m := range dbMembers {
+		structure.members = append(structure.members, ExportMember{Username: m.Username, MemberRole: m.MemberRole})
+	}
+	for _, g := range dbGroups {
+		structure.groups = append(structure.groups, ExportGroup{ID: g.ID, Name: g.Name, Notes: g.Notes})
+	}
+	for _, c := range dbCategories {
+		structure.categories = append(structure.categories, ExportCategory{
+			ID:               c.ID,
+			GroupID:          c.GroupID,
+			Name:             c.Name,
+			Notes:            c.Notes,
+			OverspendingRule: c.OverspendingRule,
+		})
+	}
+	for _, a := range dbAccounts {
+		structure.accounts = append(structure.accounts, ExportAccount{
+			ID:                a.ID,
+			AccountType:       a.AccountType,
+			Name:              a.Name,
+			Notes:             a.Notes,
+			IsDeleted:         a.IsDeleted,
+			PaymentCategoryID: a.PaymentCategoryID,
+		})
+	}
+	for _, p := range dbPayees {
+		structure.payees = append(structure.payees, ExportPayee{ID: p.ID, Name: p.Name, Notes: p.Notes})
+	}
+	return structure, "", nil
+}
+
+func (s *budgetStructure) setAssignments(dbAssignments []db.Assignment) {
+	s.assignments = make([]ExportAssignment, 0, len(dbAssignments))
+	for _, a := range dbAssignments {
+		s.assignments = append(s.assignments, ExportAssignment{
+			Month:      a.Month,
+			CategoryID: a.CategoryID,
+			Assigned:   a.Assigned,
+		})
+	}
+}
+
+// importInto hands the whole structure to the importer, in the order
+// it would be read from an export.
+func (s *budgetStructure) importInto(importer budgetImporter) error {
+	if err := importer.importBudget(s.budget); err != nil {
+		return err
+	}
+	for _, member := range s.members {
+		if err := importer.importMember(member); err != nil {
+			return err
+		}
+	}
+	for _, group := range s.groups {
+		if err := importer.importGroup(group); err != nil {
+			return err
+		}
+	}
+	for _, category := range s.categories {
+		if err := importer.importCategory(category); err != nil {
+			return err
+		}
+	}
+	for _, account := range s.accounts {
+		if err := importer.importAccount(account); err != nil {
+			return err
+		}
+	}
+	for _, payee := range s.payees {
+		if err := importer.importPayee(payee); err != nil {
+			return err
+		}
+	}
+	for _, assignment := range s.assignments {
+		if err := importer.importAssignment(assignment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
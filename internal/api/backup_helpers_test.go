@@ -0,0 +1,181 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// recordingImporter keeps a line for each entry it is given, in order.
+type recordingImporter struct {
+	entries []string
+}
+
+func (ri *recordingImporter) add(kind string, v any) error {
+	ri.entries = append(ri.entries, fmt.Sprintf("%s %v", kind, v))
+	return nil
+}
+
+func (ri *recordingImporter) importBudget(b ExportBudget) error { return ri.add("budget", b.Name) }
+func (ri *recordingImporter) importMember(m ExportMember) error { return ri.add("member", m.Username) }
+func (ri *recordingImporter) importGroup(g ExportGroup) error   { return ri.add("group", g.Name) }
+func (ri *recordingImporter) importCategory(c ExportCategory) error {
+	return ri.add("category", c.Name)
+}
+func (ri *recordingImporter) importAccount(a ExportAccount) error { return ri.add("account", a.Name) }
+func (ri *recordingImporter) importPayee(p ExportPayee) error     { return ri.add("payee", p.Name) }
+func (ri *recordingImporter) importAssignment(a ExportAssignment) error {
+	return ri.add("assignment", a.Assigned)
+}
+func (ri *recordingImporter) importTransaction(txn ExportTransaction) error {
+	return ri.add("transaction", len(txn.Splits))
+}
+
+func TestBudgetExportRoundTrip(t *testing.T) {
+	groupID, categoryID := uuid.New(), uuid.New()
+
+	var buf bytes.Buffer
+	flushes := 0
+	ew := newExportWriter(&buf, func() error { flushes++; return nil })
+	ew.writeHeader(BudgetExportHeader{
+		Format:     budgetExportFormat,
+		Version:    budgetExportVersion,
+		ExportedAt: time.Now(),
+		Budget:     ExportBudget{Name: "Home", OverspendingRule: overspendingCarry},
+	})
+	writeExportSection(ew, "members", []ExportMember{{Username: "alice", MemberRole: "ADMIN"}, {Username: "bob", MemberRole: "VIEWER"}})
+	writeExportSection(ew, "groups", []ExportGroup{{ID: groupID, Name: "Bills"}})
+	writeExportSection(ew, "categories", []ExportCategory{{ID: categoryID, GroupID: &groupID, Name: "Rent"}})
+	writeExportSection(ew, "accounts", []ExportAccount{})
+	writeExportSection(ew, "payees", []ExportPayee{{ID: uuid.New(), Name: "Landlord"}})
+	writeExportSection(ew, "assignments", []ExportAssignment{{CategoryID: categoryID, Assigned: 120000}})
+	ew.beginSection("transactions")
+	ew.writeEntry(ExportTransaction{Splits: []ExportSplit{{CategoryID: &categoryID, Amount: -120000}}})
+	ew.writeEntry(ExportTransaction{Splits: []ExportSplit{}})
+	ew.endSection()
+	if err := ew.close(); err != nil {
+		t.Fatalf("could not write export: %v", err)
+	}
+	if flushes != 8 {
+		t.Errorf("want: %v | actual: %v", 8, flushes)
+	}
+
+	importer := &recordingImporter{}
+	if err := readBudgetExport(&buf, importer); err != nil {
+		t.Fatalf("could not read export: %v", err)
+	}
+
+	want := []string{
+		"budget Home",
+		"member alice",
+		"member bob",
+		"group Bills",
+		"category Rent",
+		"payee Landlord",
+		"assignment 120000",
+		"transaction 1",
+		"transaction 0",
+	}
+	if strings.Join(importer.entries, "; ") != strings.Join(want, "; ") {
+		t.Errorf("want: %v | actual: %v", want, importer.entries)
+	}
+}
+
+func TestReadBudgetExport(t *testing.T) {
+	header := `"format":"pincher-budget","version":1,"budget":{"name":"Home","overspending_rule":"CARRY"}`
+
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr bool
+	}{
+		{
+			name:    "Valid: budget alone",
+			doc:     `{` + header + `}`,
+			wantErr: false,
+		},
+		{
+			name:    "Valid: unknown fields are skipped",
+			doc:     `{` + header + `,"goals":[{"id":"x"}],"groups":[]}`,
+			wantErr: false,
+		},
+		{
+			name:    "Invalid: not an object",
+			doc:     `[]`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: unknown format",
+			doc:     `{"format":"other","version":1,"budget":{"name":"Home"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: unsupported version",
+			doc:     `{"format":"pincher-budget","version":99,"budget":{"name":"Home"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: budget before format",
+			doc:     `{"budget":{"name":"Home"},"format":"pincher-budget","version":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: section before budget",
+			doc:     `{"format":"pincher-budget","version":1,"groups":[]}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: sections out of order",
+			doc:     `{` + header + `,"categories":[],"groups":[]}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: no budget",
+			doc:     `{"format":"pincher-budget","version":1}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: cut short",
+			doc:     `{` + header + `,"transactions":[{"splits":[]},`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := readBudgetExport(strings.NewReader(tc.doc), &recordingImporter{})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("want: %v | actual: %v", tc.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, errInvalidExport) {
+				t.Errorf("want: %v | actual: %v", errInvalidExport, err)
+			}
+		})
+	}
+}
+
+func TestBudgetStructureImportInto(t *testing.T) {
+	structure := &budgetStructure{
+		budget:      ExportBudget{Name: "Home"},
+		groups:      []ExportGroup{{Name: "Bills"}},
+		categories:  []ExportCategory{{Name: "Rent"}, {Name: "Water"}},
+		accounts:    []ExportAccount{{Name: "Checking"}},
+		payees:      []ExportPayee{{Name: "Landlord"}},
+		assignments: []ExportAssignment{{Assigned: 500}},
+	}
+
+	importer := &recordingImporter{}
+	if err := structure.importInto(importer); err != nil {
+		t.Fatalf("could not import structure: %v", err)
+	}
+
+	want := "budget Home; group Bills; category Rent; category Water; account Checking; payee Landlord; assignment 500"
+	if actual := strings.Join(importer.entries, "; "); actual != want {
+		t.Errorf("want: %v | actual: %v", want, actual)
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+// Types of goal which may be set for a category.
+// The amount needed each month toward a goal is calculated by
+// rep.get_goal_needed, and how much of it remains unfunded by
+// rep.get_goal_underfunded.
+const (
+	// goalMonthlyFunding asks that the target amount be assigned every month.
+	goalMonthlyFunding = "MONTHLY_FUNDING"
+	// goalTargetBalance asks that the balance reach the target amount.
+	goalTargetBalance = "TARGET_BALANCE"
+	// goalTargetBalanceByDate asks that the balance reach the target amount
+	// by the target date, funded evenly across the months before it.
+	goalTargetBalanceByDate = "TARGET_BALANCE_BY_DATE"
+	// goalMonthlySpending asks that the balance be refilled up to the
+	// target amount every month, to be spent within that month.
+	goalMonthlySpending = "MONTHLY_SPENDING"
+)
+
+// categoryGoal describes a goal set for a category.
+type categoryGoal struct {
+	goalType     string
+	targetAmount int64
+	targetDate   *time.Time
+}
+
+// validateCategoryGoal normalizes the given goal and reports whether
+// it can be set for a category.
+// Any error returned implies a bad request.
+func validateCategoryGoal(goal *categoryGoal) error {
+	goal.goalType = strings.ToUpper(strings.TrimSpace(goal.goalType))
+
+	switch goal.goalType {
+	case goalMonthlyFunding, goalTargetBalance, goalMonthlySpending:
+		if goal.targetDate != nil {
+			return fmt.Errorf("target date is only supported for %s goals", goalTargetBalanceByDate)
+		}
+	case goalTargetBalanceByDate:
+		if goal.targetDate == nil {
+			return fmt.Errorf("target date not provided")
+		}
+	default:
+		return fmt.Errorf("invalid goal type: %s", goal.goalType)
+	}
+
+	if goal.targetAmount <= 0 {
+		return fmt.Errorf("target amount must be positive")
+	}
+	return nil
+}
+
+// goalPercentComplete returns how far along a category is toward its goal,
+// from 0 to 100. Goals toward a target balance are measured by the balance
+// of the category; goals repeated each month, by how much of the amount
+// needed that month has been funded.
+func goalPercentComplete(goalType string, targetAmount, needed, underfunded, balance int64) int64 {
+	var percent int64
+	switch goalType {
+	case goalTargetBalance, goalTargetBalanceByDate:
+		percent = balance * 100 / targetAmount
+	default:
+		if needed == 0 {
+			return 100
+		}
+		percent = (needed - underfunded) * 100 / needed
+	}
+	return min(max(percent, 0), 100)
+}
+
+func categoryGoalFromDB(dbGoal *db.CategoryGoal) CategoryGoal {
+	return CategoryGoal{
+		CreatedAt:    dbGoal.CreatedAt,
+		UpdatedAt:    dbGoal.UpdatedAt,
+		CategoryID:   dbGoal.CategoryID,
+		GoalType:     dbGoal.GoalType,
+		TargetAmount: dbGoal.TargetAmount,
+		TargetDate:   dbGoal.TargetDate,
+	}
+}
+
+// goalProgressFromReport returns the progress made toward the goal of a
+// category report, or nil where the category has no goal.
+func goalProgressFromReport(goalType string, targetAmount int64, targetDate *time.Time, needed, underfunded, balance int64) *GoalProgress {
+	if goalType == "" {
+		return nil
+	}
+	return &GoalProgress{
+		GoalType:        goalType,
+		TargetAmount:    targetAmount,
+		TargetDate:      targetDate,
+		PercentComplete: goalPercentComplete(goalType, targetAmount, needed, underfunded, balance),
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateCategoryGoal(t *testing.T) {
+	targetDate := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		input   categoryGoal
+		wantErr bool
+	}{
+		{
+			name:    "Valid: monthly funding",
+			input:   categoryGoal{goalType: "monthly_funding", targetAmount: 5000},
+			wantErr: false,
+		},
+		{
+			name:    "Valid: target balance by date",
+			input:   categoryGoal{goalType: goalTargetBalanceByDate, targetAmount: 120000, targetDate: &targetDate},
+			wantErr: false,
+		},
+		{
+			name:    "Invalid: unknown goal type",
+			input:   categoryGoal{goalType: "WEEKLY_FUNDING", targetAmount: 5000},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: target balance by date without date",
+			input:   categoryGoal{goalType: goalTargetBalanceByDate, targetAmount: 120000},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: target date on spending goal",
+			input:   categoryGoal{goalType: goalMonthlySpending, targetAmount: 40000, targetDate: &targetDate},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: zero target amount",
+			input:   categoryGoal{goalType: goalTargetBalance},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCategoryGoal(&tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error: %v | actual: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGoalPercentComplete(t *testing.T) {
+	tests := []struct {
+		name         string
+		goalType     string
+		targetAmount int64
+		needed       int64
+		underfunded  int64
+		balance      int64
+		want         int64
+	}{
+		{
+			name:         "Monthly funding partially assigned",
+			goalType:     goalMonthlyFunding,
+			targetAmount: 10000,
+			needed:       10000,
+			underfunded:  7500,
+			balance:      2500,
+			want:         25,
+		},
+		{
+			name:         "Spending target with nothing needed",
+			goalType:     goalMonthlySpending,
+			targetAmount: 10000,
+			balance:      10000,
+			want:         100,
+		},
+		{
+			name:         "Target balance measured by balance",
+			goalType:     goalTargetBalance,
+			targetAmount: 40000,
+			needed:       30000,
+			underfunded:  30000,
+			balance:      10000,
+			want:         25,
+		},
+		{
+			name:         "Overspent target balance",
+			goalType:     goalTargetBalanceByDate,
+			targetAmount: 40000,
+			needed:       20000,
+			underfunded:  20000,
+			balance:      -500,
+			want:         0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := goalPercentComplete(tt.goalType, tt.targetAmount, tt.needed, tt.underfunded, tt.balance)
+			if got != tt.want {
+				t.Errorf("want: %d | actual: %d", tt.want, got)
+			}
+		})
+	}
+}
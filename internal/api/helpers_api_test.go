@@ -3,21 +3,29 @@ package api
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/require"
 	tc "github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/YouWantToPinch/pincher-api/internal/auth"
 )
 
 type postgresContainer struct {
@@ -128,8 +136,23 @@ func (c *APITestClient) DeleteAllUsers() *http.Request {
 	return MakeRequest(http.MethodPost, "/admin/reset", "", nil)
 }
 
+func (c *APITestClient) GetLockouts(token string) *http.Request {
+	return MakeRequest(http.MethodGet, "/admin/lockouts", token, nil)
+}
+
+func (c *APITestClient) ClearLockouts(token, scope, subject string) *http.Request {
+	return MakeRequest(http.MethodDelete, "/admin/lockouts", token, map[string]any{
+		"scope":   scope,
+		"subject": subject,
+	})
+}
+
 // USER AUTH
 
+func (c *APITestClient) GetJWKS() *http.Request {
+	return MakeRequest(http.MethodGet, "/.well-known/jwks.json", "", nil)
+}
+
 func (c *APITestClient) LoginUser(username, password string) *http.Request {
 	return MakeRequest(http.MethodPost, "/api/login", "", map[string]any{
 		"username": username,
@@ -137,6 +160,100 @@ func (c *APITestClient) LoginUser(username, password string) *http.Request {
 	})
 }
 
+func (c *APITestClient) RefreshToken(refreshToken string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/refresh", refreshToken, nil)
+}
+
+// RefreshTokenByCookie refreshes as a browser would, with the
+// refresh token sent in the refresh_token cookie.
+func (c *APITestClient) RefreshTokenByCookie(refreshToken string) *http.Request {
+	req := MakeRequest(http.MethodPost, "/api/refresh", "", nil)
+	req.Header.Set("X-Auth-Transport", "cookie")
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	return req
+}
+
+func (c *APITestClient) StartOIDCLogin() *http.Request {
+	return MakeRequest(http.MethodGet, "/api/login/oidc", "", nil)
+}
+
+func (c *APITestClient) CompleteOIDCLogin(code, state, binding string) *http.Request {
+	query := url.Values{"code": {code}, "state": {state}}
+	req := MakeRequest(http.MethodGet, "/api/login/oidc/callback?"+query.Encode(), "", nil)
+	if binding != "" {
+		req.AddCookie(&http.Cookie{Name: "oidc_login", Value: binding})
+	}
+	return req
+}
+
+func (c *APITestClient) LinkOIDCIdentity(token string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/users/oidc", token, nil)
+}
+
+func (c *APITestClient) LoginUserTwoFactor(challengeToken, code string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/login/2fa", "", map[string]any{
+		"challenge_token": challengeToken,
+		"code":            code,
+	})
+}
+
+// USER -> TWO-FACTOR AUTH
+
+func (c *APITestClient) EnrollTwoFactor(token string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/users/2fa", token, nil)
+}
+
+func (c *APITestClient) ConfirmTwoFactor(token, code string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/users/2fa/confirm", token, map[string]any{
+		"code": code,
+	})
+}
+
+func (c *APITestClient) DisableTwoFactor(token, password string) *http.Request {
+	return MakeRequest(http.MethodDelete, "/api/users/2fa", token, map[string]any{
+		"password": password,
+	})
+}
+
+// USER -> SESSIONS
+
+func (c *APITestClient) GetSessions(token string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/sessions", token, nil)
+}
+
+func (c *APITestClient) RevokeSession(token, sessionID string) *http.Request {
+	return MakeRequest(http.MethodDelete, "/api/sessions/"+sessionID, token, nil)
+}
+
+func (c *APITestClient) RevokeOtherSessions(token string) *http.Request {
+	return MakeRequest(http.MethodDelete, "/api/sessions", token, nil)
+}
+
+// WithAPIKey authenticates a request by the given API key, in place of a token.
+func WithAPIKey(req *http.Request, apiKey string) *http.Request {
+	req.Header.Set("Authorization", "ApiKey "+apiKey)
+	return req
+}
+
+// USER -> API KEY CRUD
+
+func (c *APITestClient) CreateAPIKey(token, name string, budgetIDs []string, maxRole, expiresAt string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/api-keys", token, map[string]any{
+		"name":       name,
+		"budget_ids": budgetIDs,
+		"max_role":   maxRole,
+		"expires_at": expiresAt,
+	})
+}
+
+func (c *APITestClient) GetAPIKeys(token string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/api-keys", token, nil)
+}
+
+func (c *APITestClient) RevokeAPIKey(token, apiKeyID string) *http.Request {
+	return MakeRequest(http.MethodDelete, "/api/api-keys/"+apiKeyID, token, nil)
+}
+
 // USER -> BUDGET CRUD
 
 func (c *APITestClient) CreateBudget(token, name, notes string) *http.Request {
@@ -161,6 +278,39 @@ func (c *APITestClient) DeleteUserBudget(token, budgetID string) *http.Request {
 	return MakeRequest(http.MethodDelete, "/api/budgets/"+budgetID, token, nil)
 }
 
+func (c *APITestClient) CreateBudgetFromTemplate(token, name, notes, template string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets", token, map[string]any{
+		"name":     name,
+		"notes":    notes,
+		"template": template,
+	})
+}
+
+func (c *APITestClient) GetBudgetTemplates(token string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/budgets/templates", token, nil)
+}
+
+func (c *APITestClient) CloneBudget(token, budgetID, name, notes, assignmentsMonth string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/clone", token, map[string]any{
+		"name":              name,
+		"notes":             notes,
+		"assignments_month": assignmentsMonth,
+	})
+}
+
+func (c *APITestClient) ExportBudget(token, budgetID string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/budgets/"+budgetID+"/export", token, nil)
+}
+
+func (c *APITestClient) ImportBudget(token, contents string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/budgets/import", strings.NewReader(contents))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
 // BUDGET -> ACCOUNT CRUD
 
 func (c *APITestClient) CreateBudgetAccount(token, budgetID, accountType, name, notes string) *http.Request {
@@ -185,13 +335,100 @@ func (c *APITestClient) GetBudgetCapital(token, budgetID, accountID string) *htt
 	return MakeRequest(http.MethodGet, path, token, nil)
 }
 
-func (c *APITestClient) AssignMemberToBudget(token, budgetID, username, memberRole string) *http.Request {
+func (c *APITestClient) AddBudgetMember(token, budgetID, username, memberRole string) *http.Request {
 	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/members", token, map[string]any{
 		"username":    username,
 		"member_role": memberRole,
 	})
 }
 
+func (c *APITestClient) SetBudgetMemberRole(token, budgetID, userID, memberRole string) *http.Request {
+	return MakeRequest(http.MethodPatch, "/api/budgets/"+budgetID+"/members/"+userID, token, map[string]any{
+		"member_role": memberRole,
+	})
+}
+
+func (c *APITestClient) SetMemberRestrictions(token, budgetID, userID string, restrictions map[string]any) *http.Request {
+	return MakeRequest(http.MethodPut, "/api/budgets/"+budgetID+"/members/"+userID+"/restrictions", token, restrictions)
+}
+
+func (c *APITestClient) GetMemberRestrictions(token, budgetID, userID string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/budgets/"+budgetID+"/members/"+userID+"/restrictions", token, nil)
+}
+
+func (c *APITestClient) DeleteMemberRestrictions(token, budgetID, userID string) *http.Request {
+	return MakeRequest(http.MethodDelete, "/api/budgets/"+budgetID+"/members/"+userID+"/restrictions", token, nil)
+}
+
+func (c *APITestClient) GetAuditLog(token, budgetID, query string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/budgets/"+budgetID+"/audit"+query, token, nil)
+}
+
+// BUDGET -> INVITATIONS
+
+func (c *APITestClient) InviteMemberToBudget(token, budgetID, username, memberRole string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/invitations", token, map[string]any{
+		"username":    username,
+		"member_role": memberRole,
+	})
+}
+
+func (c *APITestClient) CreateInvitationLink(token, budgetID, memberRole string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/invitations", token, map[string]any{
+		"member_role": memberRole,
+	})
+}
+
+func (c *APITestClient) GetBudgetInvitations(token, budgetID string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/budgets/"+budgetID+"/invitations", token, nil)
+}
+
+func (c *APITestClient) RevokeBudgetInvitation(token, budgetID, invitationID string) *http.Request {
+	return MakeRequest(http.MethodDelete, "/api/budgets/"+budgetID+"/invitations/"+invitationID, token, nil)
+}
+
+func (c *APITestClient) GetUserInvitations(token string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/users/invitations", token, nil)
+}
+
+func (c *APITestClient) AcceptInvitation(token, invitationID string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/users/invitations/"+invitationID+"/accept", token, nil)
+}
+
+func (c *APITestClient) DeclineInvitation(token, invitationID string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/users/invitations/"+invitationID+"/decline", token, nil)
+}
+
+func (c *APITestClient) RedeemInvitationLink(token, invitationToken string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/users/invitations/redeem", token, map[string]any{
+		"token": invitationToken,
+	})
+}
+
+// BUDGET -> OWNERSHIP TRANSFERS
+
+func (c *APITestClient) NominateBudgetOwner(token, budgetID, username string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/ownership-transfer", token, map[string]any{
+		"username": username,
+	})
+}
+
+func (c *APITestClient) CancelOwnershipTransfer(token, budgetID string) *http.Request {
+	return MakeRequest(http.MethodDelete, "/api/budgets/"+budgetID+"/ownership-transfer", token, nil)
+}
+
+func (c *APITestClient) GetUserOwnershipTransfers(token string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/users/ownership-transfers", token, nil)
+}
+
+func (c *APITestClient) AcceptOwnershipTransfer(token, budgetID string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/ownership-transfer/accept", token, nil)
+}
+
+func (c *APITestClient) DeclineOwnershipTransfer(token, budgetID string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/ownership-transfer/decline", token, nil)
+}
+
 func (c *APITestClient) UpdateAccount(token, budgetID, accountID, newName, newNotes string) *http.Request {
 	return MakeRequest(http.MethodPut, "/api/budgets/"+budgetID+"/accounts/"+accountID, token, map[string]any{
 		"name":  newName,
@@ -262,6 +499,26 @@ func (c *APITestClient) DeleteBudgetCategory(token, budgetID, categoryID string)
 	return MakeRequest(http.MethodDelete, "/api/budgets/"+budgetID+"/categories/"+categoryID, token, nil)
 }
 
+func (c *APITestClient) SetCategoryGoal(token, budgetID, categoryID, goalType string, targetAmount int64, targetDate string) *http.Request {
+	return MakeRequest(http.MethodPut, "/api/budgets/"+budgetID+"/categories/"+categoryID+"/goal", token, map[string]any{
+		"goal_type":     goalType,
+		"target_amount": targetAmount,
+		"target_date":   targetDate,
+	})
+}
+
+func (c *APITestClient) SetCategoryOverspendingRule(token, budgetID, categoryID, rule string) *http.Request {
+	return MakeRequest(http.MethodPut, "/api/budgets/"+budgetID+"/categories/"+categoryID+"/overspending-rule", token, map[string]any{
+		"overspending_rule": rule,
+	})
+}
+
+func (c *APITestClient) SetBudgetOverspendingRule(token, budgetID, rule string) *http.Request {
+	return MakeRequest(http.MethodPut, "/api/budgets/"+budgetID+"/overspending-rule", token, map[string]any{
+		"overspending_rule": rule,
+	})
+}
+
 // BUDGET -> GROUP CRUD
 
 func (c *APITestClient) CreateGroup(token, budgetID, name, notes string) *http.Request {
@@ -342,6 +599,48 @@ func (c *APITestClient) DeleteTransaction(token, budgetID, transactionID string)
 	return MakeRequest(http.MethodDelete, "/api/budgets/"+budgetID+"/transactions/"+transactionID, token, nil)
 }
 
+func (c *APITestClient) UndoTransactionUpdate(token, budgetID, transactionID string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/transactions/"+transactionID+"/undo", token, nil)
+}
+
+func (c *APITestClient) GetTrashedTransactions(token, budgetID string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/budgets/"+budgetID+"/transactions/trash", token, nil)
+}
+
+func (c *APITestClient) RestoreTransaction(token, budgetID, transactionID string) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/transactions/"+transactionID+"/restore", token, nil)
+}
+
+// BUDGET -> RECURRING TRANSACTION CRUD
+
+func (c *APITestClient) CreateRecurringTransaction(token, budgetID, accountName, startDate, payeeName, frequency string, amounts map[string]int64) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/recurring", token, map[string]any{
+		"account_name":     accountName,
+		"transaction_date": startDate,
+		"payee_name":       payeeName,
+		"amounts":          amounts,
+		"frequency":        frequency,
+	})
+}
+
+func (c *APITestClient) GetRecurringTransaction(token, budgetID, recurringID string) *http.Request {
+	return MakeRequest(http.MethodGet, "/api/budgets/"+budgetID+"/recurring/"+recurringID, token, nil)
+}
+
+func (c *APITestClient) UpdateRecurringTransaction(token, budgetID, recurringID, accountName, startDate, payeeName, frequency string, amounts map[string]int64) *http.Request {
+	return MakeRequest(http.MethodPut, "/api/budgets/"+budgetID+"/recurring/"+recurringID, token, map[string]any{
+		"account_name":     accountName,
+		"transaction_date": startDate,
+		"payee_name":       payeeName,
+		"amounts":          amounts,
+		"frequency":        frequency,
+	})
+}
+
+func (c *APITestClient) DeleteRecurringTransaction(token, budgetID, recurringID string) *http.Request {
+	return MakeRequest(http.MethodDelete, "/api/budgets/"+budgetID+"/recurring/"+recurringID, token, nil)
+}
+
 // BUDGET -> ASSIGNMENT CRUD
 
 func (c *APITestClient) AssignMoneyToCategory(token, budgetID, monthID, categoryName string, amount int64) *http.Request {
@@ -352,6 +651,14 @@ func (c *APITestClient) AssignMoneyToCategory(token, budgetID, monthID, category
 	})
 }
 
+func (c *APITestClient) AutoAssign(token, budgetID, monthID, strategy string, months int, dryRun bool) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/months/"+monthID+"/auto-assign", token, map[string]any{
+		"strategy": strategy,
+		"months":   months,
+		"dry_run":  dryRun,
+	})
+}
+
 func (c *APITestClient) GetMonthCategoryReport(token, budgetID, monthID, categoryID string) *http.Request {
 	return MakeRequest(http.MethodGet, "/api/budgets/"+budgetID+"/months/"+monthID+"/categories/"+categoryID, token, nil)
 }
@@ -363,3 +670,161 @@ func (c *APITestClient) GetMonthCategoryReports(token, budgetID, monthID string)
 func (c *APITestClient) GetMonthReport(token, budgetID, monthID string) *http.Request {
 	return MakeRequest(http.MethodGet, "/api/budgets/"+budgetID+"/months/"+monthID, token, nil)
 }
+
+// BUDGET -> ACCOUNT -> RECONCILIATION
+
+func (c *APITestClient) ReconcileAccount(token, budgetID, accountID, statementDate string, statementBalance int64, logAdjustment bool) *http.Request {
+	return MakeRequest(http.MethodPost, "/api/budgets/"+budgetID+"/accounts/"+accountID+"/reconcile", token, map[string]any{
+		"statement_date":    statementDate,
+		"statement_balance": statementBalance,
+		"log_adjustment":    logAdjustment,
+	})
+}
+
+// BUDGET -> ACCOUNT -> IMPORTING
+
+func (c *APITestClient) SaveImportProfile(token, budgetID, accountID string, profile map[string]any) *http.Request {
+	return MakeRequest(http.MethodPut, "/api/budgets/"+budgetID+"/accounts/"+accountID+"/import-profile", token, profile)
+}
+
+func (c *APITestClient) ImportFile(token, budgetID, accountID, format, contents string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/budgets/"+budgetID+"/accounts/"+accountID+"/import/"+format, strings.NewReader(contents))
+	req.Header.Set("Content-Type", "text/plain")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func (c *APITestClient) ImportBudgetFile(token, budgetID, format, contents string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/budgets/"+budgetID+"/import/"+format, strings.NewReader(contents))
+	req.Header.Set("Content-Type", "text/plain")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// ImportBudgetForm uploads each of the given files as a field of a multipart form.
+func (c *APITestClient) ImportBudgetForm(token, budgetID, format string, files map[string]string) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for field, contents := range files {
+		w, err := form.CreateFormFile(field, field+".csv")
+		require.NoError(c.testState, err)
+		_, err = w.Write([]byte(contents))
+		require.NoError(c.testState, err)
+	}
+	require.NoError(c.testState, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/budgets/"+budgetID+"/import/"+format, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// ------------------
+//  OIDC STUB ISSUER
+// ------------------
+
+// stubIssuer is a minimal OpenID Connect provider. Whoever redeems a code
+// with the verifier of the login it was granted for receives an ID token
+// naming the subject who signed in.
+type stubIssuer struct {
+	*httptest.Server
+	key *auth.SigningKey
+	// private signs ID tokens by key
+	private ed25519.PrivateKey
+
+	mu     sync.Mutex
+	issued int
+	grants map[string]stubGrant
+}
+
+type stubGrant struct {
+	challenge string
+	nonce     string
+	subject   string
+	username  string
+}
+
+func newStubIssuer(t *testing.T) *stubIssuer {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	key, err := auth.NewSigningKey(public)
+	require.NoError(t, err)
+
+	s := &stubIssuer{key: key, private: private, grants: map[string]stubGrant{}}
+	mux := http.NewServeMux()
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 s.URL,
+			"authorization_endpoint": s.URL + "/authorize",
+			"token_endpoint":         s.URL + "/token",
+			"jwks_uri":               s.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []auth.JWK{s.key.JWK()}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		grant, ok := s.grants[r.FormValue("code")]
+		delete(s.grants, r.FormValue("code"))
+		s.mu.Unlock()
+		if !ok || auth.PKCEChallenge(r.FormValue("code_verifier")) != grant.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, auth.IDTokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    s.URL,
+				Subject:   grant.subject,
+				Audience:  jwt.ClaimStrings{r.FormValue("client_id")},
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+			Nonce:             grant.nonce,
+			PreferredUsername: grant.username,
+		})
+		token.Header["kid"] = s.key.ID
+		idToken, err := token.SignedString(s.private)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idToken})
+	})
+	return s
+}
+
+// signIn plays the part of a user who, sent to the given authorization URL,
+// signs in as the given subject. It returns the code and state with which
+// the provider would send them back.
+func (s *stubIssuer) signIn(t *testing.T, authURL, subject, username string) (string, string) {
+	t.Helper()
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issued++
+	code := fmt.Sprintf("code-%d", s.issued)
+	s.grants[code] = stubGrant{
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+		subject:   subject,
+		username:  username,
+	}
+	return code, query.Get("state")
+}
@@ -0,0 +1,382 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/YouWantToPinch/pincher-api/internal/ingest"
+)
+
+// maxImportBytes limits the size of files uploaded for import.
+const maxImportBytes = 10 << 20
+
+// Statuses of rows reported by an import.
+const (
+	importAccepted  = "ACCEPTED"
+	importRejected  = "REJECTED"
+	importDuplicate = "DUPLICATE"
+)
+
+// readImportUpload returns the contents of a file uploaded for import.
+// The file may be sent either as the 'file' field of a multipart form,
+// or as the entire request body.
+func readImportUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	defer r.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("could not read uploaded file: %w", err)
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read uploaded file: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no file uploaded")
+	}
+	return data, nil
+}
+
+// getImportAccount returns the account at the path of an import request,
+// after ensuring that it belongs to the budget, is open, and is within reach
+// of the requesting member, whose restrictions are returned alongside it.
+// Where it is not, a response is written, and nil is returned.
+func (cfg *APIConfig) getImportAccount(w http.ResponseWriter, r *http.Request) (*db.Account, *db.MemberRestriction) {
+	pathAccountID, err := parseUUIDFromPath("account_id", r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "", err)
+		return nil, nil
+	}
+	dbAccount, err := cfg.db.GetAccountByID(r.Context(), pathAccountID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get account", err)
+		return nil, nil
+	}
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	if pathBudgetID != dbAccount.BudgetID {
+		respondWithCode(w, http.StatusForbidden)
+		return nil, nil
+	}
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get member restrictions", err)
+		return nil, nil
+	}
+	if !permitsAccount(restriction, dbAccount.ID) {
+		respondWithError(w, http.StatusNotFound, "could not get account", nil)
+		return nil, nil
+	}
+	if dbAccount.IsDeleted {
+		respondWithError(w, http.StatusConflict, "cannot import transactions to a deleted account", nil)
+		return nil, nil
+	}
+	return &dbAccount, restriction
+}
+
+// ensurePayee returns the ID of the budget payee with the given name,
+// creating the payee where it does not yet exist.
+func ensurePayee(ctx context.Context, q *db.Queries, budgetID uuid.UUID, name string) (uuid.UUID, error) {
+	payeeID, err := q.GetBudgetPayeeIDByName(ctx, db.GetBudgetPayeeIDByNameParams{
+		PayeeName: name,
+		BudgetID:  budgetID,
+	})
+	if err == nil {
+		return payeeID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, err
+	}
+	dbPayee, err := q.CreatePayee(ctx, db.CreatePayeeParams{
+		BudgetID: budgetID,
+		Name:     name,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return dbPayee.ID, nil
+}
+
+// ensureGroup returns the ID of the budget group with the given name,
+// creating the group where it does not yet exist.
+func ensureGroup(ctx context.Context, q *db.Queries, budgetID uuid.UUID, name string, created *CreatedResources) (uuid.UUID, error) {
+	groupID, err := q.GetBudgetGroupIDByName(ctx, db.GetBudgetGroupIDByNameParams{
+		GroupName: name,
+		BudgetID:  budgetID,
+	})
+	if err == nil {
+		return groupID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, err
+	}
+	dbGroup, err := q.CreateGroup(ctx, db.CreateGroupParams{
+		BudgetID: budgetID,
+		Name:     name,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	created.Groups = append(created.Groups, name)
+	return dbGroup.ID, nil
+}
+
+// ensureCategory returns the ID of the budget category with the given name,
+// creating the category where it does not yet exist. New categories are
+// assigned to the named group, if any, which is also created where necessary.
+// Existing categories are left in whichever group they belong to.
+func ensureCategory(ctx context.Context, q *db.Queries, budgetID uuid.UUID, groupName, name string, created *CreatedResources) (uuid.UUID, error) {
+	categoryID, err := q.GetBudgetCategoryIDByName(ctx, db.GetBudgetCategoryIDByNameParams{
+		CategoryName: name,
+		BudgetID:     budgetID,
+	})
+	if err == nil {
+		return categoryID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, err
+	}
+	var groupID *uuid.UUID
+	if groupName != "" {
+		id, err := ensureGroup(ctx, q, budgetID, groupName, created)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		groupID = &id
+	}
+	dbCategory, err := q.CreateCategory(ctx, db.CreateCategoryParams{
+		BudgetID: budgetID,
+		GroupID:  groupID,
+		Name:     name,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	created.Categories = append(created.Categories, name)
+	return dbCategory.ID, nil
+}
+
+// truncateName shortens a name to fit within the given number of characters.
+func truncateName(name string, length int) string {
+	runes := []rune(name)
+	if len(runes) <= length {
+		return name
+	}
+	return string(runes[:length])
+}
+
+// txnImporter logs records read from an imported file to a single account,
+// all within one database transaction. Each record is logged within its own
+// savepoint, so that a rejected record does not abort the import.
+type txnImporter struct {
+	tx       pgx.Tx
+	q        *db.Queries
+	budgetID uuid.UUID
+	loggerID uuid.UUID
+	account  *db.Account
+	// restriction is that placed upon the importing member, if any,
+	// which each record must satisfy to be logged.
+	restriction *db.MemberRestriction
+	// defaultCategory is used for records not naming their own category.
+	defaultCategory string
+	// seen and logged count records by their duplicate key, so that records
+	// legitimately repeated within one file are not mistaken for duplicates.
+	seen   map[string]int
+	logged map[string]int
+	report ImportReport
+}
+
+func newTxnImporter(tx pgx.Tx, q *db.Queries, budgetID, loggerID uuid.UUID, restriction *db.MemberRestriction, account *db.Account, defaultCategory string) *txnImporter {
+	return &txnImporter{
+		tx:              tx,
+		q:               q.WithTx(tx),
+		budgetID:        budgetID,
+		loggerID:        loggerID,
+		account:         account,
+		restriction:     restriction,
+		defaultCategory: defaultCategory,
+		seen:            map[string]int{},
+		logged:          map[string]int{},
+		report: ImportReport{
+			AccountID: account.ID,
+			Rows:      []ImportRowReport{},
+		},
+	}
+}
+
+func (imp *txnImporter) reject(line int, reason string) {
+	imp.report.Rejected++
+	imp.report.Rows = append(imp.report.Rows, ImportRowReport{
+		Line:   line,
+		Status: importRejected,
+		Reason: reason,
+	})
+}
+
+func (imp *txnImporter) duplicate(line int) {
+	imp.report.Duplicates++
+	imp.report.Rows = append(imp.report.Rows, ImportRowReport{
+		Line:   line,
+		Status: importDuplicate,
+	})
+}
+
+// rememberRecord records the source ID of a record imported as the given
+// transaction against the account, so that the record is known to be a
+// duplicate should it be imported again.
+func (imp *txnImporter) rememberRecord(ctx context.Context, q *db.Queries, sourceID string, txnID uuid.UUID) error {
+	if sourceID == "" {
+		return nil
+	}
+	return q.RecordImportedTransaction(ctx, db.RecordImportedTransactionParams{
+		AccountID:     imp.account.ID,
+		Fitid:         sourceID,
+		TransactionID: &txnID,
+	})
+}
+
+// duplicateKey identifies the transactions an imported transaction
+// would be considered a duplicate of.
+func duplicateKey(payeeID uuid.UUID, txnDate string, amount int64) string {
+	return fmt.Sprintf("%s|%s|%d", payeeID, txnDate, amount)
+}
+
+// importRecord logs a single record, and adds the outcome to the import report.
+// Errors returned are not specific to the record, and should abort the import.
+func (imp *txnImporter) importRecord(ctx context.Context, record ingest.Record) error {
+	if record.Err != nil {
+		imp.reject(record.Line, record.Err.Error())
+		return nil
+	}
+	if record.ID != "" {
+		imported, err := imp.q.IsTransactionImported(ctx, db.IsTransactionImportedParams{
+			AccountID: imp.account.ID,
+			Fitid:     record.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if imported {
+			imp.duplicate(record.Line)
+			return nil
+		}
+	}
+
+	category := record.Category
+	if category == "" {
+		category = imp.defaultCategory
+	}
+	if category == "" {
+		category = "UNCATEGORIZED"
+	}
+	_, err := imp.importTxn(ctx, record.Line, record.ID, UpsertTransactionRqSchema{
+		AccountName:     imp.account.Name,
+		TransactionDate: record.Date.Format("2006-01-02"),
+		PayeeName:       truncateName(record.Payee, 50),
+		Notes:           record.Memo,
+		Amounts:         map[string]int64{category: record.Amount},
+	})
+	return err
+}
+
+// importTxn logs a transaction read from the given line of an imported file,
+// unless it is a duplicate of one already logged. Where the transaction has no
+// source ID, any other of the same payee, date and amount is taken to be such
+// a duplicate. The payee is created where it does not yet exist.
+// The transaction logged, if any, is returned.
+// Errors returned are not specific to the transaction, and should abort the import.
+func (imp *txnImporter) importTxn(ctx context.Context, line int, sourceID string, rqPayload UpsertTransactionRqSchema) (*db.Transaction, error) {
+	validatedTxn, err := validateTxnInput(&rqPayload)
+	if err != nil {
+		imp.reject(line, err.Error())
+		return nil, nil
+	}
+
+	savepoint, err := imp.tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer savepoint.Rollback(ctx)
+	q := imp.q.WithTx(savepoint)
+
+	if !validatedTxn.isTransfer {
+		if _, err := ensurePayee(ctx, q, imp.budgetID, rqPayload.PayeeName); err != nil {
+			imp.reject(line, "could not create payee: "+err.Error())
+			return nil, nil
+		}
+	}
+	if msg, err := resolveTxnNames(ctx, q, imp.budgetID, &rqPayload, validatedTxn); err != nil {
+		imp.reject(line, msg)
+		return nil, nil
+	}
+	if err := checkTxnRestrictions(imp.restriction, validatedTxn); err != nil {
+		imp.reject(line, err.Error())
+		return nil, nil
+	}
+
+	amount := totalFromAmountsMap(validatedTxn.amounts)
+	key := duplicateKey(validatedTxn.payeeID, rqPayload.TransactionDate, amount)
+	// records with a source ID are only duplicates of those imported under
+	// it, which the caller checks; similar transactions may well be genuine
+	if sourceID == "" {
+		imp.seen[key]++
+		matches, err := q.CountMatchingTransactions(ctx, db.CountMatchingTransactionsParams{
+			AccountID:       imp.account.ID,
+			TransactionDate: validatedTxn.txnDate,
+			PayeeID:         validatedTxn.payeeID,
+			TotalAmount:     amount,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// matches logged by this import are not duplicates of the transaction
+		if int(matches)-imp.logged[key] >= imp.seen[key] {
+			imp.duplicate(line)
+			return nil, nil
+		}
+	}
+
+	inPeriod, err := pgxValidatedTxnInReconciledPeriod(q, ctx, validatedTxn)
+	if err != nil {
+		return nil, err
+	}
+	if inPeriod {
+		imp.reject(line, "cannot import a cleared transaction within a reconciled period")
+		return nil, nil
+	}
+
+	txn, _, msg, err := pgxLogValidatedTxn(q, ctx, imp.budgetID, imp.loggerID, validatedTxn)
+	if err != nil {
+		imp.reject(line, msg)
+		return nil, nil
+	}
+	if err := imp.rememberRecord(ctx, q, sourceID, txn.ID); err != nil {
+		return nil, err
+	}
+	if err := savepoint.Commit(ctx); err != nil {
+		return nil, err
+	}
+	imp.logged[key]++
+
+	imp.report.Accepted++
+	imp.report.Rows = append(imp.report.Rows, ImportRowReport{
+		Line:          line,
+		Status:        importAccepted,
+		TransactionID: &txn.ID,
+	})
+	return txn, nil
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules for what becomes of a category balance overspent by the end of a
+// month. Balances are calculated according to them by rep.get_category_reports.
+const (
+	// overspendingCarry carries the negative balance forward into the next month.
+	overspendingCarry = "CARRY"
+	// overspendingReset clears the negative balance by the next month.
+	// Overspending paid in cash is taken from the money ready to assign that
+	// month, while overspending on a credit card becomes debt on the card.
+	overspendingReset = "RESET"
+	// overspendingBudget has a category follow the rule of its budget.
+	overspendingBudget = "BUDGET"
+)
+
+// validateOverspendingRule normalizes the given rule and reports whether
+// it can be set. Only categories may follow the rule of their budget.
+// Any error returned implies a bad request.
+func validateOverspendingRule(rule *string, forCategory bool) error {
+	*rule = strings.ToUpper(strings.TrimSpace(*rule))
+
+	switch *rule {
+	case overspendingCarry, overspendingReset:
+		return nil
+	case overspendingBudget:
+		if forCategory {
+			return nil
+		}
+		return fmt.Errorf("the %s rule is only supported for categories", overspendingBudget)
+	case "":
+		return fmt.Errorf("overspending rule not provided")
+	default:
+		return fmt.Errorf("invalid overspending rule: %s", *rule)
+	}
+}
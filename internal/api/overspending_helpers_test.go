@@ -0,0 +1,54 @@
+package api
+
+import "testing"
+
+func TestValidateOverspendingRule(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		forCategory bool
+		wantErr     bool
+	}{
+		{
+			name:    "Valid: carry",
+			input:   "CARRY",
+			wantErr: false,
+		},
+		{
+			name:    "Valid: reset, in lowercase",
+			input:   " reset ",
+			wantErr: false,
+		},
+		{
+			name:        "Valid: category follows budget",
+			input:       overspendingBudget,
+			forCategory: true,
+			wantErr:     false,
+		},
+		{
+			name:    "Invalid: budget follows budget",
+			input:   overspendingBudget,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: no rule",
+			input:   "",
+			wantErr: true,
+		},
+		{
+			name:        "Invalid: unknown rule",
+			input:       "FORGIVE",
+			forCategory: true,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOverspendingRule(&tt.input, tt.forCategory)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error: %v | actual: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+// Frequencies at which a recurring transaction may be scheduled.
+const (
+	freqDaily    = "DAILY"
+	freqWeekly   = "WEEKLY"
+	freqBiweekly = "BIWEEKLY"
+	freqMonthly  = "MONTHLY"
+	freqYearly   = "YEARLY"
+)
+
+// Options governing which day of the month a MONTHLY or YEARLY
+// recurring transaction falls on.
+// Business days are considered to be Monday through Friday;
+// holidays are not accounted for.
+const (
+	daySame             = "SAME_DAY"
+	dayLast             = "LAST_DAY"
+	dayFirstBusiness    = "FIRST_BUSINESS_DAY"
+	dayLastBusiness     = "LAST_BUSINESS_DAY"
+	defaultRecurringDay = daySame
+)
+
+// Recurring transactions that fail to post are retried after a delay,
+// doubled with each failure in a row, and disabled after too many.
+const (
+	recurringRetryDelay    = time.Hour
+	maxRecurringRetryDelay = 24 * time.Hour
+	maxRecurringFailures   = 5
+)
+
+// recurrenceRule describes the dates on which a recurring transaction occurs.
+type recurrenceRule struct {
+	frequency string
+	interval  int
+	dayOption string
+	startDate time.Time
+	endDate   *time.Time
+}
+
+// validateRecurrenceRule normalizes the given rule and reports whether
+// it can be used to schedule transactions.
+// Any error returned implies a bad request.
+func validateRecurrenceRule(rule *recurrenceRule) error {
+	rule.frequency = strings.ToUpper(strings.TrimSpace(rule.frequency))
+	rule.dayOption = strings.ToUpper(strings.TrimSpace(rule.dayOption))
+	if rule.dayOption == "" {
+		rule.dayOption = defaultRecurringDay
+	}
+	if rule.interval == 0 {
+		rule.interval = 1
+	}
+
+	switch rule.frequency {
+	case freqDaily, freqWeekly, freqBiweekly:
+		if rule.dayOption != daySame {
+			return fmt.Errorf("day option '%s' is only supported for %s and %s frequencies", rule.dayOption, freqMonthly, freqYearly)
+		}
+	case freqMonthly, freqYearly:
+		switch rule.dayOption {
+		case daySame, dayLast, dayFirstBusiness, dayLastBusiness:
+		default:
+			return fmt.Errorf("invalid day option: %s", rule.dayOption)
+		}
+	default:
+		return fmt.Errorf("invalid frequency: %s", rule.frequency)
+	}
+
+	if rule.interval < 0 {
+		return fmt.Errorf("frequency interval must be positive")
+	}
+	if rule.startDate.IsZero() {
+		return fmt.Errorf("start date not provided")
+	}
+	if rule.endDate != nil && rule.endDate.Before(rule.startDate) {
+		return fmt.Errorf("end date may not precede start date")
+	}
+	return nil
+}
+
+// occurrence returns the date of the nth occurrence of the rule, counting from zero,
+// irrespective of its start and end dates.
+func (rule *recurrenceRule) occurrence(n int) time.Time {
+	start := rule.startDate
+	steps := n * rule.interval
+	switch rule.frequency {
+	case freqDaily:
+		return start.AddDate(0, 0, steps)
+	case freqWeekly:
+		return start.AddDate(0, 0, 7*steps)
+	case freqBiweekly:
+		return start.AddDate(0, 0, 14*steps)
+	case freqYearly:
+		return rule.dayInMonth(start.Year()+steps, start.Month())
+	default:
+		// month arithmetic is done by hand, since time.AddDate normalizes
+		// overflowing days into the following month (e.g. Jan 31 -> Mar 3)
+		months := int(start.Month()) - 1 + steps
+		return rule.dayInMonth(start.Year()+months/12, time.Month(months%12+1))
+	}
+}
+
+// dayInMonth resolves the rule's day option against a particular month.
+func (rule *recurrenceRule) dayInMonth(year int, month time.Month) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	switch rule.dayOption {
+	case dayLast:
+		return lastDay
+	case dayLastBusiness:
+		d := lastDay
+		for isWeekend(d) {
+			d = d.AddDate(0, 0, -1)
+		}
+		return d
+	case dayFirstBusiness:
+		d := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		for isWeekend(d) {
+			d = d.AddDate(0, 0, 1)
+		}
+		return d
+	default:
+		return time.Date(year, month, min(rule.startDate.Day(), lastDay.Day()), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func isWeekend(d time.Time) bool {
+	return d.Weekday() == time.Saturday || d.Weekday() == time.Sunday
+}
+
+// firstIndexNear estimates the index of the first occurrence on or after the given date,
+// erring early, so that callers need not walk every occurrence since the start date.
+func (rule *recurrenceRule) firstIndexNear(d time.Time) int {
+	if !d.After(rule.startDate) {
+		return 0
+	}
+	var n int
+	switch rule.frequency {
+	case freqDaily:
+		n = int(d.Sub(rule.startDate).Hours()/24) / rule.interval
+	case freqWeekly:
+		n = int(d.Sub(rule.startDate).Hours()/24) / (7 * rule.interval)
+	case freqBiweekly:
+		n = int(d.Sub(rule.startDate).Hours()/24) / (14 * rule.interval)
+	case freqYearly:
+		n = (d.Year() - rule.startDate.Year()) / rule.interval
+	default:
+		months := (d.Year()-rule.startDate.Year())*12 + int(d.Month()) - int(rule.startDate.Month())
+		n = months / rule.interval
+	}
+	return max(n-1, 0)
+}
+
+// nextOnOrAfter returns the first occurrence of the rule that falls on or after
+// both the given date and the rule's start date. If the rule ends before any such
+// occurrence, false is returned.
+func (rule *recurrenceRule) nextOnOrAfter(d time.Time) (time.Time, bool) {
+	if d.Before(rule.startDate) {
+		d = rule.startDate
+	}
+	for n := rule.firstIndexNear(d); ; n++ {
+		next := rule.occurrence(n)
+		if rule.endDate != nil && next.After(*rule.endDate) {
+			return time.Time{}, false
+		}
+		if !next.Before(d) {
+			return next, true
+		}
+	}
+}
+
+// occurrencesBetween returns every occurrence of the rule falling within
+// the given dates, inclusive.
+func (rule *recurrenceRule) occurrencesBetween(from, to time.Time) []time.Time {
+	var dates []time.Time
+	for next, ok := rule.nextOnOrAfter(from); ok && !next.After(to); next, ok = rule.nextOnOrAfter(next.AddDate(0, 0, 1)) {
+		dates = append(dates, next)
+	}
+	return dates
+}
+
+// recurringRetryAfter returns when a recurring transaction that has failed
+// to post the given number of times in a row should next be retried.
+// If it should instead be disabled, false is returned.
+func recurringRetryAfter(now time.Time, failures int) (time.Time, bool) {
+	if failures >= maxRecurringFailures {
+		return time.Time{}, false
+	}
+	delay := recurringRetryDelay
+	for i := 1; i < failures && delay < maxRecurringRetryDelay; i++ {
+		delay *= 2
+	}
+	return now.Add(min(delay, maxRecurringRetryDelay)), true
+}
+
+// recurrenceRuleFromDB returns the recurrence rule of a stored recurring transaction.
+func recurrenceRuleFromDB(dbRecurring *db.RecurringTransaction) *recurrenceRule {
+	return &recurrenceRule{
+		frequency: dbRecurring.Frequency,
+		interval:  int(dbRecurring.FrequencyInterval),
+		dayOption: dbRecurring.DayOption,
+		startDate: dbRecurring.StartDate,
+		endDate:   dbRecurring.EndDate,
+	}
+}
+
+// validatedTxnFromRecurring returns a transaction payload, ready to be logged,
+// for an occurrence of a stored recurring transaction on the given date.
+func validatedTxnFromRecurring(dbRecurring *db.RecurringTransaction, txnDate time.Time) (*validatedTxnPayload, error) {
+	validatedTxn := &validatedTxnPayload{
+		accountID:  dbRecurring.AccountID,
+		txnType:    dbRecurring.TransactionType,
+		txnDate:    txnDate,
+		isTransfer: dbRecurring.TransferAccountID != nil,
+		notes:      dbRecurring.Notes,
+		cleared:    dbRecurring.Cleared,
+	}
+	if validatedTxn.isTransfer {
+		validatedTxn.transferAccountID = *dbRecurring.TransferAccountID
+	}
+	if dbRecurring.PayeeID != nil {
+		validatedTxn.payeeID = *dbRecurring.PayeeID
+	}
+	if err := json.Unmarshal(dbRecurring.Amounts, &validatedTxn.amounts); err != nil {
+		return nil, fmt.Errorf("could not unmarshal amounts for recurring transaction: %w", err)
+	}
+	return validatedTxn, nil
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateRecurrenceRule(t *testing.T) {
+	start := time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC)
+	before := start.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name    string
+		input   recurrenceRule
+		wantErr bool
+	}{
+		{
+			name:    "Valid: monthly with defaults",
+			input:   recurrenceRule{frequency: "monthly", startDate: start},
+			wantErr: false,
+		},
+		{
+			name:    "Valid: yearly on last business day",
+			input:   recurrenceRule{frequency: "YEARLY", dayOption: "LAST_BUSINESS_DAY", startDate: start},
+			wantErr: false,
+		},
+		{
+			name:    "Invalid: unknown frequency",
+			input:   recurrenceRule{frequency: "HOURLY", startDate: start},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: day option on weekly frequency",
+			input:   recurrenceRule{frequency: "WEEKLY", dayOption: "LAST_DAY", startDate: start},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: negative interval",
+			input:   recurrenceRule{frequency: "DAILY", interval: -2, startDate: start},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid: end date before start date",
+			input:   recurrenceRule{frequency: "DAILY", startDate: start, endDate: &before},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRecurrenceRule(&tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error: %v | actual: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestOccurrencesBetween(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	end := date(2025, time.March, 1)
+
+	tests := []struct {
+		name   string
+		rule   recurrenceRule
+		from   time.Time
+		to     time.Time
+		expect []time.Time
+	}{
+		{
+			name:   "Daily every 3 days",
+			rule:   recurrenceRule{frequency: freqDaily, interval: 3, dayOption: daySame, startDate: date(2025, time.January, 1)},
+			from:   date(2025, time.January, 5),
+			to:     date(2025, time.January, 13),
+			expect: []time.Time{date(2025, time.January, 7), date(2025, time.January, 10), date(2025, time.January, 13)},
+		},
+		{
+			name:   "Biweekly",
+			rule:   recurrenceRule{frequency: freqBiweekly, interval: 1, dayOption: daySame, startDate: date(2025, time.January, 3)},
+			from:   date(2025, time.January, 1),
+			to:     date(2025, time.February, 14),
+			expect: []time.Time{date(2025, time.January, 3), date(2025, time.January, 17), date(2025, time.January, 31), date(2025, time.February, 14)},
+		},
+		{
+			name:   "Monthly on the 31st clamps without drifting",
+			rule:   recurrenceRule{frequency: freqMonthly, interval: 1, dayOption: daySame, startDate: date(2025, time.January, 31)},
+			from:   date(2025, time.January, 1),
+			to:     date(2025, time.April, 30),
+			expect: []time.Time{date(2025, time.January, 31), date(2025, time.February, 28), date(2025, time.March, 31), date(2025, time.April, 30)},
+		},
+		{
+			name:   "Monthly on last business day",
+			rule:   recurrenceRule{frequency: freqMonthly, interval: 1, dayOption: dayLastBusiness, startDate: date(2025, time.May, 1)},
+			from:   date(2025, time.May, 1),
+			to:     date(2025, time.August, 31),
+			expect: []time.Time{date(2025, time.May, 30), date(2025, time.June, 30), date(2025, time.July, 31), date(2025, time.August, 29)},
+		},
+		{
+			name:   "Monthly on first business day skips occurrence before start",
+			rule:   recurrenceRule{frequency: freqMonthly, interval: 1, dayOption: dayFirstBusiness, startDate: date(2025, time.May, 15)},
+			from:   date(2025, time.May, 1),
+			to:     date(2025, time.July, 31),
+			expect: []time.Time{date(2025, time.June, 2), date(2025, time.July, 1)},
+		},
+		{
+			name:   "Yearly on leap day",
+			rule:   recurrenceRule{frequency: freqYearly, interval: 1, dayOption: daySame, startDate: date(2024, time.February, 29)},
+			from:   date(2024, time.January, 1),
+			to:     date(2028, time.December, 31),
+			expect: []time.Time{date(2024, time.February, 29), date(2025, time.February, 28), date(2026, time.February, 28), date(2027, time.February, 28), date(2028, time.February, 29)},
+		},
+		{
+			name:   "Weekly stops at end date",
+			rule:   recurrenceRule{frequency: freqWeekly, interval: 2, dayOption: daySame, startDate: date(2025, time.January, 1), endDate: &end},
+			from:   date(2025, time.January, 1),
+			to:     date(2025, time.December, 31),
+			expect: []time.Time{date(2025, time.January, 1), date(2025, time.January, 15), date(2025, time.January, 29), date(2025, time.February, 12), date(2025, time.February, 26)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.rule.occurrencesBetween(tt.from, tt.to)
+			if len(actual) != len(tt.expect) {
+				t.Fatalf("want: %v | actual: %v", tt.expect, actual)
+			}
+			for i := range actual {
+				if !actual[i].Equal(tt.expect[i]) {
+					t.Errorf("want: %v | actual: %v", tt.expect, actual)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestRecurringRetryAfter(t *testing.T) {
+	now := time.Date(2025, time.January, 15, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		failures  int
+		wantRetry bool
+		wantAfter time.Time
+	}{
+		{
+			name:      "First failure retried after the base delay",
+			failures:  1,
+			wantRetry: true,
+			wantAfter: now.Add(recurringRetryDelay),
+		},
+		{
+			name:      "Delay doubles with each failure",
+			failures:  3,
+			wantRetry: true,
+			wantAfter: now.Add(4 * recurringRetryDelay),
+		},
+		{
+			name:      "Disabled after too many failures",
+			failures:  maxRecurringFailures,
+			wantRetry: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryAfter, retry := recurringRetryAfter(now, tt.failures)
+			if retry != tt.wantRetry {
+				t.Fatalf("want retry: %v | actual: %v", tt.wantRetry, retry)
+			}
+			if retry && !retryAfter.Equal(tt.wantAfter) {
+				t.Errorf("want: %v | actual: %v", tt.wantAfter, retryAfter)
+			}
+		})
+	}
+}
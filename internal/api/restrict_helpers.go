@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// getMemberRestriction returns the restrictions placed upon a member of a
+// budget, or nil where the member is restricted by their role alone.
+func getMemberRestriction(ctx context.Context, q *db.Queries, budgetID, userID uuid.UUID) (*db.MemberRestriction, error) {
+	restriction, err := q.GetMemberRestriction(ctx, db.GetMemberRestrictionParams{
+		BudgetID: budgetID,
+		UserID:   userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &restriction, nil
+}
+
+// visibleAccountIDs returns the accounts to which a member is restricted,
+// or nil where the member may see every account of the budget.
+func visibleAccountIDs(restriction *db.MemberRestriction) []uuid.UUID {
+	if restriction == nil || restriction.AllowedAccountIds == nil {
+		return nil
+	}
+	return restriction.AllowedAccountIds
+}
+
+// permitsAccount reports whether a member may see and log to an account.
+func permitsAccount(restriction *db.MemberRestriction, accountID uuid.UUID) bool {
+	allowed := visibleAccountIDs(restriction)
+	return allowed == nil || slices.Contains(allowed, accountID)
+}
+
+// checkTxnRestrictions reports why a member may not log the given
+// transaction, if they may not. Splits which touch no category, such as
+// those of transfers or income, are limited by account and amount alone.
+func checkTxnRestrictions(restriction *db.MemberRestriction, txn *validatedTxnPayload) error {
+	if restriction == nil {
+		return nil
+	}
+
+	if !permitsAccount(restriction, txn.accountID) {
+		return errors.New("member may not log transactions to this account")
+	}
+	if txn.isTransfer && !permitsAccount(restriction, txn.transferAccountID) {
+		return errors.New("member may not log transfers to the given account")
+	}
+
+	if restriction.AllowedCategoryIds != nil {
+		for k := range txn.amounts {
+			categoryID, err := uuid.Parse(k)
+			if err != nil {
+				continue
+			}
+			if !slices.Contains(restriction.AllowedCategoryIds, categoryID) {
+				return errors.New("member may not log transactions to one or more of the given categories")
+			}
+		}
+	}
+
+	if restriction.MaxTransactionAmount.Valid {
+		total := totalFromAmountsMap(txn.amounts)
+		if total < 0 {
+			total = -total
+		}
+		if total > restriction.MaxTransactionAmount.Int64 {
+			return fmt.Errorf("member may not log transactions greater than %d", restriction.MaxTransactionAmount.Int64)
+		}
+	}
+	return nil
+}
+
+// checkTxnVisible reports whether a transaction belongs to an account
+// which the requesting member may see.
+func (cfg *APIConfig) checkTxnVisible(r *http.Request, txn *db.Transaction) (bool, error) {
+	pathBudgetID := getContextKeyValueAsUUID(r.Context(), "budget_id")
+	validatedUserID := getContextKeyValueAsUUID(r.Context(), "user_id")
+
+	restriction, err := getMemberRestriction(r.Context(), cfg.db, pathBudgetID, validatedUserID)
+	if err != nil {
+		return false, err
+	}
+	return permitsAccount(restriction, txn.AccountID), nil
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"testing"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCheckTxnRestrictions(t *testing.T) {
+	card, checking := uuid.New(), uuid.New()
+	fuel, meals := uuid.New(), uuid.New()
+
+	restriction := &db.MemberRestriction{
+		AllowedAccountIds:    []uuid.UUID{card},
+		AllowedCategoryIds:   []uuid.UUID{fuel},
+		MaxTransactionAmount: pgtype.Int8{Int64: 5000, Valid: true},
+	}
+
+	tests := []struct {
+		name        string
+		restriction *db.MemberRestriction
+		txn         validatedTxnPayload
+		wantErr     bool
+	}{
+		{
+			name: "Valid: unrestricted member",
+			txn: validatedTxnPayload{
+				accountID: checking,
+				amounts:   map[string]int64{meals.String(): -100000},
+			},
+			wantErr: false,
+		},
+		{
+			name:        "Valid: within every restriction",
+			restriction: restriction,
+			txn: validatedTxnPayload{
+				accountID: card,
+				amounts:   map[string]int64{fuel.String(): -5000},
+			},
+			wantErr: false,
+		},
+		{
+			name:        "Valid: split without a category",
+			restriction: restriction,
+			txn: validatedTxnPayload{
+				accountID: card,
+				amounts:   map[string]int64{"UNCATEGORIZED": 2000},
+			},
+			wantErr: false,
+		},
+		{
+			name:        "Valid: restricted by amount alone",
+			restriction: &db.MemberRestriction{MaxTransactionAmount: pgtype.Int8{Int64: 5000, Valid: true}},
+			txn: validatedTxnPayload{
+				accountID: checking,
+				amounts:   map[string]int64{meals.String(): -3000, fuel.String(): -2000},
+			},
+			wantErr: false,
+		},
+		{
+			name:        "Invalid: account out of reach",
+			restriction: restriction,
+			txn: validatedTxnPayload{
+				accountID: checking,
+				amounts:   map[string]int64{fuel.String(): -100},
+			},
+			wantErr: true,
+		},
+		{
+			name:        "Invalid: transfer to account out of reach",
+			restriction: restriction,
+			txn: validatedTxnPayload{
+				accountID:         card,
+				transferAccountID: checking,
+				isTransfer:        true,
+				amounts:           map[string]int64{"TRANSFER": -100},
+			},
+			wantErr: true,
+		},
+		{
+			name:        "Invalid: one split to a category out of reach",
+			restriction: restriction,
+			txn: validatedTxnPayload{
+				accountID: card,
+				amounts:   map[string]int64{fuel.String(): -100, meals.String(): -100},
+			},
+			wantErr: true,
+		},
+		{
+			name:        "Invalid: over the maximum amount",
+			restriction: restriction,
+			txn: validatedTxnPayload{
+				accountID: card,
+				amounts:   map[string]int64{fuel.String(): -5001},
+			},
+			wantErr: true,
+		},
+		{
+			name:        "Invalid: no accounts at all",
+			restriction: &db.MemberRestriction{AllowedAccountIds: []uuid.UUID{}},
+			txn: validatedTxnPayload{
+				accountID: card,
+				amounts:   map[string]int64{fuel.String(): -100},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTxnRestrictions(tt.restriction, &tt.txn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error: %v | actual: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
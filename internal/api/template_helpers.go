@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	db "github.com/YouWantToPinch/pincher-api/internal/database"
+)
+
+// budgetTemplates are the starter templates a new budget may be created from.
+// Category names must be unique across each template, as within a budget.
+var budgetTemplates = []BudgetTemplate{
+	{
+		Name:        "household",
+		Description: "Bills, everyday spending and savings goals for a home.",
+		Groups: []BudgetTemplateGroup{
+			{Name: "Bills", Categories: []string{"Rent/Mortgage", "Electricity", "Water", "Internet", "Phone", "Insurance"}},
+			{Name: "Everyday", Categories: []string{"Groceries", "Dining Out", "Transportation", "Household Supplies", "Personal Care"}},
+			{Name: "Savings Goals", Categories: []string{"Emergency Fund", "Vacation", "Car Replacement"}},
+			{Name: "Quality of Life", Categories: []string{"Entertainment", "Hobbies", "Gifts"}},
+		},
+	},
+	{
+		Name:        "freelancer",
+		Description: "Irregular income, set aside for taxes and lean months.",
+		Groups: []BudgetTemplateGroup{
+			{Name: "Set Aside", Categories: []string{"Income Tax", "Retirement", "Income Buffer"}},
+			{Name: "Business", Categories: []string{"Software & Subscriptions", "Equipment", "Coworking", "Professional Development"}},
+			{Name: "Living", Categories: []string{"Rent", "Utilities", "Groceries", "Health Insurance", "Transportation"}},
+			{Name: "Discretionary", Categories: []string{"Dining Out", "Entertainment", "Travel"}},
+		},
+	},
+	{
+		Name:        "small-business",
+		Description: "Operating costs, payroll and reserves for a small business.",
+		Groups: []BudgetTemplateGroup{
+			{Name: "Operations", Categories: []string{"Rent & Utilities", "Inventory", "Supplies", "Software", "Shipping"}},
+			{Name: "People", Categories: []string{"Payroll", "Payroll Taxes", "Contractors", "Benefits"}},
+			{Name: "Growth", Categories: []string{"Marketing", "Equipment", "Training"}},
+			{Name: "Obligations", Categories: []string{"Sales Tax", "Estimated Taxes", "Loan Payments", "Business Insurance"}},
+			{Name: "Reserves", Categories: []string{"Operating Reserve", "Owner's Draw"}},
+		},
+	},
+}
+
+// getBudgetTemplate returns the starter template of the given name.
+// Any error returned implies a bad request.
+func getBudgetTemplate(name string) (*BudgetTemplate, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i := range budgetTemplates {
+		if budgetTemplates[i].Name == name {
+			return &budgetTemplates[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no such budget template: %s", name)
+}
+
+// pgxApplyBudgetTemplate creates the groups and categories of a template
+// within a budget.
+func pgxApplyBudgetTemplate(q *db.Queries, ctx context.Context, budgetID uuid.UUID, template *BudgetTemplate) (errMsg string, err error) {
+	for _, group := range template.Groups {
+		dbGroup, err := q.CreateGroup(ctx, db.CreateGroupParams{
+			BudgetID: budgetID,
+			Name:     group.Name,
+		})
+		if err != nil {
+			return "could not create group from template", err
+		}
+		for _, categoryName := range group.Categories {
+			if _, err := q.CreateCategory(ctx, db.CreateCategoryParams{
+				BudgetID: budgetID,
+				GroupID:  &dbGroup.ID,
+				Name:     categoryName,
+			}); err != nil {
+				return "could not create category from template", err
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestBudgetTemplates(t *testing.T) {
+	templateNames := map[string]bool{}
+	for _, template := range budgetTemplates {
+		if templateNames[template.Name] {
+			t.Errorf("template %s is defined more than once", template.Name)
+		}
+		templateNames[template.Name] = true
+
+		// names must fit within, and be unique within, a budget
+		names := map[string]bool{}
+		for _, group := range template.Groups {
+			for _, name := range append([]string{group.Name}, group.Categories...) {
+				if utf8.RuneCountInString(name) > 50 {
+					t.Errorf("template %s: name %s is too long", template.Name, name)
+				}
+			}
+			for _, category := range group.Categories {
+				if names[category] {
+					t.Errorf("template %s: category %s is defined more than once", template.Name, category)
+				}
+				names[category] = true
+			}
+		}
+	}
+}
+
+func TestGetBudgetTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Valid: exact name", input: "household", want: "household"},
+		{name: "Valid: any case, with spaces", input: " Small-Business ", want: "small-business"},
+		{name: "Invalid: unknown", input: "student", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			template, err := getBudgetTemplate(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("want: %v | actual: %v", tc.wantErr, err)
+			}
+			if err == nil && template.Name != tc.want {
+				t.Errorf("want: %v | actual: %v", tc.want, template.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"math"
+	"time"
+)
+
+// Scopes within which failed attempts are counted.
+const (
+	// throttleUsername counts failed logins against a username.
+	throttleUsername = "USERNAME"
+	// throttleIP counts failed logins from a client address.
+	throttleIP = "IP"
+	// throttleSignup counts accounts created from a client address.
+	throttleSignup = "SIGNUP"
+)
+
+// throttlePolicy describes how attempts are slowed, and at last locked out,
+// as they fail. Every failure past the free attempts doubles the wait before
+// another attempt may be made, up to maxDelay. Once lockoutAttempts have
+// failed, every further attempt is locked out for lockoutDuration. Failures
+// are forgotten once none has been made for lockoutDuration.
+type throttlePolicy struct {
+	freeAttempts    int
+	baseDelay       time.Duration
+	maxDelay        time.Duration
+	lockoutAttempts int
+	lockoutDuration time.Duration
+}
+
+// defaultThrottlePolicy is used where no LOGIN_ environment variables are set.
+var defaultThrottlePolicy = throttlePolicy{
+	freeAttempts:    5,
+	baseDelay:       time.Second,
+	maxDelay:        time.Minute * 5,
+	lockoutAttempts: 10,
+	lockoutDuration: time.Minute * 15,
+}
+
+// defaultSignupPolicy is used where no SIGNUP_ environment variables are set.
+// Signups are not slowed, only limited in number within the window.
+var defaultSignupPolicy = signupPolicy(20, time.Hour)
+
+// signupPolicy returns the policy allowing as many signups from a client
+// address as the limit, until none has been made for the window.
+func signupPolicy(limit int, window time.Duration) throttlePolicy {
+	return throttlePolicy{
+		freeAttempts:    limit,
+		lockoutAttempts: limit,
+		lockoutDuration: window,
+	}
+}
+
+// blockedUntil returns the time before which no further attempt may be made,
+// given the number of consecutive failures and the time of the last.
+// The zero time is returned where attempts are not blocked.
+func (p *throttlePolicy) blockedUntil(failedAttempts int, lastFailedAt time.Time) time.Time {
+	if p.lockoutAttempts > 0 && failedAttempts >= p.lockoutAttempts {
+		return lastFailedAt.Add(p.lockoutDuration)
+	}
+	if failedAttempts <= p.freeAttempts {
+		return time.Time{}
+	}
+
+	exponent := failedAttempts - p.freeAttempts - 1
+	delay := p.maxDelay
+	if exponent < 62 {
+		scaled := float64(p.baseDelay) * math.Pow(2, float64(exponent))
+		if scaled < float64(p.maxDelay) {
+			delay = time.Duration(scaled)
+		}
+	}
+	return lastFailedAt.Add(delay)
+}
+
+// retryAfterSeconds returns the value of a Retry-After header for an attempt
+// blocked until the given time, rounded up to a whole second.
+func retryAfterSeconds(until, now time.Time) int {
+	return max(int(math.Ceil(until.Sub(now).Seconds())), 1)
+}
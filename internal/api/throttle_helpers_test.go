@@ -0,0 +1,86 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottlePolicyBlockedUntil(t *testing.T) {
+	policy := throttlePolicy{
+		freeAttempts:    3,
+		baseDelay:       time.Second,
+		maxDelay:        time.Second * 10,
+		lockoutAttempts: 10,
+		lockoutDuration: time.Hour,
+	}
+	lastFailedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		failedAttempts int
+		want           time.Time
+	}{
+		{name: "no failures", failedAttempts: 0, want: time.Time{}},
+		{name: "within free attempts", failedAttempts: 3, want: time.Time{}},
+		{name: "first backoff", failedAttempts: 4, want: lastFailedAt.Add(time.Second)},
+		{name: "backoff doubles", failedAttempts: 6, want: lastFailedAt.Add(time.Second * 4)},
+		{name: "backoff is capped", failedAttempts: 9, want: lastFailedAt.Add(time.Second * 10)},
+		{name: "locked out", failedAttempts: 10, want: lastFailedAt.Add(time.Hour)},
+		{name: "still locked out", failedAttempts: 200, want: lastFailedAt.Add(time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.blockedUntil(tt.failedAttempts, lastFailedAt)
+			if !got.Equal(tt.want) {
+				t.Errorf("want: %v | actual: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSignupPolicyBlockedUntil(t *testing.T) {
+	policy := signupPolicy(3, time.Hour)
+	lastSignupAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		signups int
+		want    time.Time
+	}{
+		{name: "within limit", signups: 2, want: time.Time{}},
+		{name: "limit reached", signups: 3, want: lastSignupAt.Add(time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.blockedUntil(tt.signups, lastSignupAt)
+			if !got.Equal(tt.want) {
+				t.Errorf("want: %v | actual: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		until time.Time
+		want  int
+	}{
+		{name: "whole seconds", until: now.Add(time.Second * 30), want: 30},
+		{name: "rounds up", until: now.Add(time.Millisecond * 1500), want: 2},
+		{name: "never less than a second", until: now, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := retryAfterSeconds(tt.until, now)
+			if got != tt.want {
+				t.Errorf("want: %v | actual: %v", tt.want, got)
+			}
+		})
+	}
+}
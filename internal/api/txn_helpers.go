@@ -3,12 +3,15 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
 	"time"
 
 	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // validateTxnInput parses relevant inputs: txn amounts, txnDate, transfer status, txnType.
@@ -69,6 +72,7 @@ func validateTxnInput(rqPayload *UpsertTransactionRqSchema) (*validatedTxnPayloa
 		return nil, fmt.Errorf("no non-zero amount specified for transaction")
 	}
 	validatedTxn.notes = rqPayload.Notes
+	validatedTxn.cleared = rqPayload.Cleared
 	return validatedTxn, nil
 }
 
@@ -165,6 +169,8 @@ func pgxUpdateTxn(q *db.Queries, ctx context.Context, params db.UpdateTransactio
 
 // pgxLogTxn performs an insert on the transasction table given the parameters,
 // and inserts the relevant transaction splits as well.
+// A transaction is never returned without its splits; where there are none to
+// insert, or they could not be inserted, an error is returned instead.
 func pgxLogTxn(q *db.Queries, ctx context.Context, params db.LogTransactionParams, splits map[string]int64) (txn *db.Transaction, errMsg string, err error) {
 	if checkIsTransfer(params.TransactionType) {
 		amount := totalFromAmountsMap(splits)
@@ -181,20 +187,235 @@ func pgxLogTxn(q *db.Queries, ctx context.Context, params db.LogTransactionParam
 		return nil, "could not log transaction", err
 	}
 	if len(splits) == 0 {
-		return nil, "", nil
+		return nil, "no splits provided for transaction", fmt.Errorf("transaction must have at least one split")
 	} else {
 		var amountsJSONBytes []byte
 		if amountsJSONBytes, err = json.Marshal(splits); err != nil {
 			return nil, chooseErrMsg("could not marshal split for corresponding transfer transaction to log",
-				"could not marshal splits for new transaction"), nil
+				"could not marshal splits for new transaction"), err
 		}
 		if _, err := q.LogTransactionSplits(ctx, db.LogTransactionSplitsParams{
 			TransactionID: newTxn.ID,
 			Amounts:       amountsJSONBytes,
 		}); err != nil {
 			return nil, chooseErrMsg("could not log txn splits",
-				"could not log transaction splits"), nil
+				"could not log transaction splits"), err
 		}
 	}
 	return &newTxn, "", nil
 }
+
+// pgxLogValidatedTxn logs a validated transaction. Where the transaction is a
+// transfer, the corresponding transaction is logged to the transfer account,
+// and the two are linked. The transaction logged to the validated account is
+// always returned first; linkedTxn is nil for anything but transfers.
+func pgxLogValidatedTxn(q *db.Queries, ctx context.Context, budgetID, loggerID uuid.UUID, validatedTxn *validatedTxnPayload) (txn, linkedTxn *db.Transaction, errMsg string, err error) {
+	txn, msg, err := pgxLogTxn(q, ctx, db.LogTransactionParams{
+		BudgetID:        budgetID,
+		LoggerID:        loggerID,
+		AccountID:       validatedTxn.accountID,
+		TransactionType: validatedTxn.txnType,
+		TransactionDate: validatedTxn.txnDate,
+		PayeeID:         validatedTxn.payeeID,
+		Notes:           validatedTxn.notes,
+		Cleared:         validatedTxn.cleared,
+	}, validatedTxn.amounts)
+	if err != nil {
+		errMsgPrefix := "could not log transaction"
+		if validatedTxn.isTransfer {
+			errMsgPrefix = "could not log transfer transaction"
+		}
+		return nil, nil, errMsgPrefix + ": " + msg, err
+	}
+	if !validatedTxn.isTransfer {
+		return txn, nil, "", nil
+	}
+
+	linkedTxn, msg, err = pgxLogTxn(q, ctx, db.LogTransactionParams{
+		BudgetID:        budgetID,
+		LoggerID:        loggerID,
+		AccountID:       validatedTxn.transferAccountID,
+		TransactionType: invertTransferType(validatedTxn.txnType),
+		TransactionDate: validatedTxn.txnDate,
+		PayeeID:         validatedTxn.payeeID,
+		Notes:           validatedTxn.notes,
+		Cleared:         validatedTxn.cleared,
+	}, invertAmountsMap(validatedTxn.amounts))
+	if err != nil {
+		return nil, nil, "could not log corresponding transfer transaction: " + msg, err
+	}
+	toPtr, fromPtr, err := getOrderedTransferIDs(txn, linkedTxn)
+	if err != nil {
+		return nil, nil, "could not link transfer transactions", err
+	}
+	if _, err = q.LogAccountTransfer(ctx, db.LogAccountTransferParams{
+		FromTransactionID: fromPtr.ID,
+		ToTransactionID:   toPtr.ID,
+	}); err != nil {
+		return nil, nil, "could not link transfer transactions", err
+	}
+	return txn, linkedTxn, "", nil
+}
+
+// pgxInReconciledPeriod reports whether a transaction of the given account,
+// date and cleared status falls within a period already reconciled, such that
+// logging it would change the balance reconciled. Uncleared transactions
+// never do, as they are not counted toward that balance.
+func pgxInReconciledPeriod(q *db.Queries, ctx context.Context, accountID uuid.UUID, txnDate time.Time, cleared bool) (bool, error) {
+	if !cleared {
+		return false, nil
+	}
+	reconciledDate, err := q.GetLatestReconciledDate(ctx, accountID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !txnDate.After(reconciledDate), nil
+}
+
+// pgxValidatedTxnInReconciledPeriod reports whether a validated transaction,
+// or the corresponding transaction of a transfer, falls within a period
+// already reconciled.
+func pgxValidatedTxnInReconciledPeriod(q *db.Queries, ctx context.Context, validatedTxn *validatedTxnPayload) (bool, error) {
+	inPeriod, err := pgxInReconciledPeriod(q, ctx, validatedTxn.accountID, validatedTxn.txnDate, validatedTxn.cleared)
+	if err != nil || inPeriod || !validatedTxn.isTransfer {
+		return inPeriod, err
+	}
+	return pgxInReconciledPeriod(q, ctx, validatedTxn.transferAccountID, validatedTxn.txnDate, validatedTxn.cleared)
+}
+
+// validatedTxnFromVersion returns a transaction payload describing a prior
+// version of a transaction, so that it may be checked against restrictions.
+// Splits which touched no category are keyed as uncategorized.
+func validatedTxnFromVersion(version, linkedVersion *db.TransactionVersion, splits []db.TransactionVersionSplit) *validatedTxnPayload {
+	validatedTxn := &validatedTxnPayload{
+		accountID:  version.AccountID,
+		payeeID:    version.PayeeID,
+		txnType:    version.TransactionType,
+		txnDate:    version.TransactionDate,
+		isTransfer: linkedVersion != nil,
+		amounts:    map[string]int64{},
+		notes:      version.Notes,
+		cleared:    version.Cleared,
+	}
+	if linkedVersion != nil {
+		validatedTxn.transferAccountID = linkedVersion.AccountID
+	}
+	for _, split := range splits {
+		key := "UNCATEGORIZED"
+		if split.CategoryID != nil {
+			key = split.CategoryID.String()
+		}
+		validatedTxn.amounts[key] += split.Amount
+	}
+	return validatedTxn
+}
+
+// pgxTrashTxn moves a transaction and its splits into the trash, naming the
+// corresponding transaction where it is one leg of a transfer.
+func pgxTrashTxn(q *db.Queries, ctx context.Context, deletedBy uuid.UUID, txn, linkedTxn *db.Transaction) (errMsg string, err error) {
+	var linkedTxnID *uuid.UUID
+	if linkedTxn != nil {
+		linkedTxnID = &linkedTxn.ID
+	}
+	if _, err = q.TrashTransaction(ctx, db.TrashTransactionParams{
+		DeletedBy:           deletedBy,
+		LinkedTransactionID: linkedTxnID,
+		TransactionID:       txn.ID,
+	}); err != nil {
+		return "could not move transaction to trash", err
+	}
+	if err = q.TrashTransactionSplits(ctx, txn.ID); err != nil {
+		return "could not move transaction splits to trash", err
+	}
+	if err = q.DeleteTransaction(ctx, txn.ID); err != nil {
+		return "could not delete transaction", err
+	}
+	return "", nil
+}
+
+// pgxRecordTxnVersion keeps the current state of a transaction and its
+// splits, so that an update about to be made to it may be undone.
+func pgxRecordTxnVersion(q *db.Queries, ctx context.Context, editorID, txnID uuid.UUID) error {
+	version, err := q.CreateTransactionVersion(ctx, db.CreateTransactionVersionParams{
+		EditorID:      editorID,
+		TransactionID: txnID,
+	})
+	if err != nil {
+		return err
+	}
+	return q.CreateTransactionVersionSplits(ctx, db.CreateTransactionVersionSplitsParams{
+		VersionID:     version.ID,
+		TransactionID: txnID,
+	})
+}
+
+// resolveTxnNames converts the resource names found in a transaction request payload
+// into the UUIDs of the corresponding resources within the given budget, and stores
+// them in the validated transaction payload.
+// Any error returned implies a bad request.
+func resolveTxnNames(ctx context.Context, q *db.Queries, budgetID uuid.UUID, rqPayload *UpsertTransactionRqSchema, validatedTxn *validatedTxnPayload) (errMsg string, err error) {
+	accountIDAndType, err := q.GetBudgetAccountIDAndTypeByName(ctx, db.GetBudgetAccountIDAndTypeByNameParams{
+		AccountName: rqPayload.AccountName,
+		BudgetID:    budgetID,
+	})
+	if err != nil {
+		return "could not get account by given name", err
+	}
+	validatedTxn.accountID = accountIDAndType.ID
+
+	if validatedTxn.isTransfer {
+		transferAccountID, err := lookupResourceIDByName(ctx,
+			db.GetBudgetAccountIDByNameParams{
+				AccountName: rqPayload.TransferAccountName,
+				BudgetID:    budgetID,
+			}, q.GetBudgetAccountIDByName)
+		if err != nil {
+			return "could not get transfer account by given name", err
+		}
+		validatedTxn.transferAccountID = transferAccountID
+		validatedTxn.payeeID = uuid.Nil
+	} else {
+		payeeID, err := lookupResourceIDByName(ctx,
+			db.GetBudgetPayeeIDByNameParams{
+				PayeeName: rqPayload.PayeeName,
+				BudgetID:  budgetID,
+			}, q.GetBudgetPayeeIDByName)
+		if err != nil {
+			return "could not get payee by given name", err
+		}
+		validatedTxn.payeeID = payeeID
+		validatedTxn.transferAccountID = uuid.Nil
+	}
+
+	// convert names to IDs if needed
+	for k, v := range rqPayload.Amounts {
+		if _, ok := validatedTxn.amounts[k]; !ok {
+			// validation already weeded this one out; move on to the next
+			continue
+		}
+		if accountIDAndType.AccountType == "OFF_BUDGET" ||
+			k == "TRANSFER" ||
+			(k == "UNCATEGORIZED" && validatedTxn.txnType == "DEPOSIT") {
+			// categories are not relevant
+			continue
+		}
+		categoryID, err := lookupResourceIDByName(ctx,
+			db.GetBudgetCategoryIDByNameParams{
+				CategoryName: k,
+				BudgetID:     budgetID,
+			}, q.GetBudgetCategoryIDByName)
+		if err != nil {
+			errMsg := "could not get category by given name for transaction split"
+			if len(rqPayload.Amounts) > 1 {
+				errMsg = "could not get category by given name for one or more transaction splits"
+			}
+			return errMsg, err
+		}
+		validatedTxn.amounts[categoryID.String()] = v
+		delete(validatedTxn.amounts, k)
+	}
+	return "", nil
+}
@@ -1,10 +1,17 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
 	"testing"
 	"time"
 
 	db "github.com/YouWantToPinch/pincher-api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 func TestCheckIsTransfer(t *testing.T) {
@@ -363,3 +370,185 @@ func TestValidateTXN(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatedTxnFromVersion(t *testing.T) {
+	categoryID := uuid.New()
+	accountID := uuid.New()
+	transferAccountID := uuid.New()
+
+	tests := []struct {
+		name          string
+		version       db.TransactionVersion
+		linkedVersion *db.TransactionVersion
+		splits        []db.TransactionVersionSplit
+		wantTransfer  bool
+		wantAmounts   map[string]int64
+	}{
+		{
+			name:    "Categorized and uncategorized splits",
+			version: db.TransactionVersion{AccountID: accountID, TransactionType: "WITHDRAWAL"},
+			splits: []db.TransactionVersionSplit{
+				{CategoryID: &categoryID, Amount: -2000},
+				{CategoryID: nil, Amount: -500},
+			},
+			wantTransfer: false,
+			wantAmounts: map[string]int64{
+				categoryID.String(): -2000,
+				"UNCATEGORIZED":     -500,
+			},
+		},
+		{
+			name:          "Transfer names the account of its corresponding transaction",
+			version:       db.TransactionVersion{AccountID: accountID, TransactionType: "TRANSFER_FROM"},
+			linkedVersion: &db.TransactionVersion{AccountID: transferAccountID, TransactionType: "TRANSFER_TO"},
+			splits: []db.TransactionVersionSplit{
+				{CategoryID: nil, Amount: -5000},
+			},
+			wantTransfer: true,
+			wantAmounts: map[string]int64{
+				"UNCATEGORIZED": -5000,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := validatedTxnFromVersion(&tt.version, tt.linkedVersion, tt.splits)
+			if actual.accountID != tt.version.AccountID || actual.isTransfer != tt.wantTransfer {
+				t.Errorf("want: %v, %v | actual: %v, %v", tt.version.AccountID, tt.wantTransfer, actual.accountID, actual.isTransfer)
+			}
+			if tt.linkedVersion != nil && actual.transferAccountID != tt.linkedVersion.AccountID {
+				t.Errorf("want: %v | actual: %v", tt.linkedVersion.AccountID, actual.transferAccountID)
+			}
+			if !maps.Equal(actual.amounts, tt.wantAmounts) {
+				t.Errorf("want: %v | actual: %v", tt.wantAmounts, actual.amounts)
+			}
+		})
+	}
+}
+
+// txnLogDB stands in for the database when logging transactions. Logged
+// transactions take the type they were logged with, and inserting splits
+// fails with splitsErr, if set.
+type txnLogDB struct {
+	splitsErr error
+}
+
+func (d *txnLogDB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (d *txnLogDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	if d.splitsErr != nil {
+		return nil, d.splitsErr
+	}
+	return &txnLogRows{}, nil
+}
+
+func (d *txnLogDB) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	return &txnLogRow{sql: sql, args: args}
+}
+
+type txnLogRow struct {
+	sql  string
+	args []any
+}
+
+func (r *txnLogRow) Scan(dest ...any) error {
+	if strings.Contains(r.sql, "-- name: LogTransaction :one") {
+		*dest[0].(*uuid.UUID) = uuid.New()
+		*dest[6].(*string) = r.args[3].(string)
+	}
+	return nil
+}
+
+type txnLogRows struct {
+	pgx.Rows
+}
+
+func (r *txnLogRows) Close()     {}
+func (r *txnLogRows) Err() error { return nil }
+func (r *txnLogRows) Next() bool { return false }
+
+func TestPgxLogValidatedTxn(t *testing.T) {
+	splitsErr := errors.New("splits not inserted")
+	tests := []struct {
+		name         string
+		validatedTxn validatedTxnPayload
+		splitsErr    error
+		wantLinked   bool
+		wantErr      bool
+	}{
+		{
+			name: "Deposit is logged with its splits",
+			validatedTxn: validatedTxnPayload{
+				txnType: "DEPOSIT",
+				amounts: map[string]int64{"UNCATEGORIZED": 5000},
+			},
+		},
+		{
+			name: "Transfer is logged and linked to its corresponding transaction",
+			validatedTxn: validatedTxnPayload{
+				txnType:    "TRANSFER_FROM",
+				isTransfer: true,
+				amounts:    map[string]int64{"TRANSFER": -5000},
+			},
+			wantLinked: true,
+		},
+		{
+			name: "Transaction without splits is not logged",
+			validatedTxn: validatedTxnPayload{
+				txnType: "WITHDRAWAL",
+				amounts: map[string]int64{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Transaction whose splits fail to insert is not logged",
+			validatedTxn: validatedTxnPayload{
+				txnType: "WITHDRAWAL",
+				amounts: map[string]int64{"UNCATEGORIZED": -5000},
+			},
+			splitsErr: splitsErr,
+			wantErr:   true,
+		},
+		{
+			name: "Transfer whose splits fail to insert is not logged",
+			validatedTxn: validatedTxnPayload{
+				txnType:    "TRANSFER_TO",
+				isTransfer: true,
+				amounts:    map[string]int64{"TRANSFER": 5000},
+			},
+			splitsErr: splitsErr,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := db.New(&txnLogDB{splitsErr: tt.splitsErr})
+			txn, linkedTxn, msg, err := pgxLogValidatedTxn(q, context.Background(), uuid.New(), uuid.New(), &tt.validatedTxn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want: %v | actual: %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				if txn != nil || linkedTxn != nil || msg == "" {
+					t.Errorf("want: nil, nil, message | actual: %v, %v, %q", txn, linkedTxn, msg)
+				}
+				if tt.splitsErr != nil && !errors.Is(err, tt.splitsErr) {
+					t.Errorf("want: %v | actual: %v", tt.splitsErr, err)
+				}
+				return
+			}
+			if txn == nil || txn.TransactionType != tt.validatedTxn.txnType {
+				t.Fatalf("want: %v | actual: %v", tt.validatedTxn.txnType, txn)
+			}
+			if (linkedTxn != nil) != tt.wantLinked {
+				t.Errorf("want: %v | actual: %v", tt.wantLinked, linkedTxn)
+			}
+			if tt.wantLinked && linkedTxn.TransactionType != invertTransferType(tt.validatedTxn.txnType) {
+				t.Errorf("want: %v | actual: %v", invertTransferType(tt.validatedTxn.txnType), linkedTxn.TransactionType)
+			}
+		})
+	}
+}
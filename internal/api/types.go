@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -62,11 +63,61 @@ type BudgetMembership struct {
 	MemberRole BudgetMemberRole `json:"member_role"`
 }
 
+type BudgetInvitation struct {
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ID        uuid.UUID `json:"id"`
+	BudgetID  uuid.UUID `json:"budget_id"`
+	// BudgetName is only given to invitees, who cannot yet see the budget.
+	BudgetName string     `json:"budget_name,omitempty"`
+	InviterID  *uuid.UUID `json:"inviter_id"`
+	// InviteeID is null for link invitations, which anyone may accept.
+	InviteeID  *uuid.UUID `json:"invitee_id"`
+	MemberRole string     `json:"member_role"`
+	// Token is only returned once, upon creation of a link invitation.
+	Token string `json:"token,omitempty"`
+}
+
+// AuditEntry records a change made to a budget. Before and After hold the
+// state of the resource changed, and are null where it did not exist.
+type AuditEntry struct {
+	ID           uuid.UUID       `json:"id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	ActorID      *uuid.UUID      `json:"actor_id"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Action       string          `json:"action"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+}
+
+// MemberRestriction narrows what a member may do beyond their role.
+// Where a field is null, the member is not restricted by it.
+type MemberRestriction struct {
+	UpdatedAt            time.Time   `json:"updated_at"`
+	BudgetID             uuid.UUID   `json:"budget_id"`
+	UserID               uuid.UUID   `json:"user_id"`
+	AllowedAccountIDs    []uuid.UUID `json:"allowed_account_ids"`
+	AllowedCategoryIDs   []uuid.UUID `json:"allowed_category_ids"`
+	MaxTransactionAmount *int64      `json:"max_transaction_amount"`
+}
+
+type OwnershipTransfer struct {
+	CreatedAt time.Time `json:"created_at"`
+	BudgetID  uuid.UUID `json:"budget_id"`
+	// BudgetName is only given to nominees listing their nominations.
+	BudgetName string    `json:"budget_name,omitempty"`
+	FromUserID uuid.UUID `json:"from_user_id"`
+	ToUserID   uuid.UUID `json:"to_user_id"`
+}
+
 type Budget struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	ID        uuid.UUID `json:"id"`
 	AdminID   uuid.UUID `json:"admin_id"`
+	// OverspendingRule is followed by all categories which do not set their own.
+	OverspendingRule string `json:"overspending_rule"`
 	Meta
 }
 
@@ -84,9 +135,43 @@ type Category struct {
 	ID        uuid.UUID  `json:"id"`
 	BudgetID  uuid.UUID  `json:"budget_id"`
 	GroupID   *uuid.UUID `json:"group_id"`
+	// OverspendingRule is BUDGET where the category follows the rule of its budget.
+	OverspendingRule string `json:"overspending_rule"`
 	Meta
 }
 
+type APIKey struct {
+	CreatedAt time.Time   `json:"created_at"`
+	ID        uuid.UUID   `json:"id"`
+	Name      string      `json:"name"`
+	KeyPrefix string      `json:"key_prefix"`
+	MaxRole   string      `json:"max_role"`
+	BudgetIDs []uuid.UUID `json:"budget_ids"`
+	// Key is only returned once, upon creation of the key.
+	Key        string     `json:"key,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type Lockout struct {
+	Scope          string    `json:"scope"`
+	Subject        string    `json:"subject"`
+	FailedAttempts int       `json:"failed_attempts"`
+	LastFailedAt   time.Time `json:"last_failed_at"`
+	BlockedUntil   time.Time `json:"blocked_until"`
+}
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	// Current is whether the session is the one making the request.
+	Current bool `json:"current"`
+}
+
 type Account struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
@@ -94,6 +179,8 @@ type Account struct {
 	BudgetID    uuid.UUID `json:"budget_id"`
 	AccountType string    `json:"account_type"`
 	IsDeleted   bool      `json:"is_deleted"`
+	// PaymentCategoryID is only set for CREDIT_CARD accounts.
+	PaymentCategoryID *uuid.UUID `json:"payment_category_id,omitempty"`
 	Meta
 }
 
@@ -109,6 +196,7 @@ type Transaction struct {
 	PayeeID         uuid.UUID `json:"payee_id"`
 	Notes           string    `json:"notes"`
 	Cleared         bool      `json:"is_cleared"`
+	Reconciled      bool      `json:"is_reconciled"`
 }
 
 type TransactionSplit struct {
@@ -130,6 +218,148 @@ type TransactionDetail struct {
 	TotalAmount     int64            `json:"total_amount"`
 	Splits          map[string]int64 `json:"splits"`
 	Cleared         bool             `json:"cleared"`
+	Reconciled      bool             `json:"reconciled"`
+}
+
+type TrashedTransaction struct {
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	ID                  uuid.UUID  `json:"id"`
+	BudgetID            uuid.UUID  `json:"budget_id"`
+	LoggerID            uuid.UUID  `json:"logger_id"`
+	AccountID           uuid.UUID  `json:"account_id"`
+	TransactionType     string     `json:"transaction_type"`
+	TransactionDate     time.Time  `json:"transaction_date"`
+	PayeeID             uuid.UUID  `json:"payee_id"`
+	Notes               string     `json:"notes"`
+	Cleared             bool       `json:"is_cleared"`
+	TotalAmount         int64      `json:"total_amount"`
+	DeletedAt           time.Time  `json:"deleted_at"`
+	DeletedBy           uuid.UUID  `json:"deleted_by"`
+	LinkedTransactionID *uuid.UUID `json:"linked_transaction_id"`
+}
+
+type RecurringTransaction struct {
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+	ID                uuid.UUID        `json:"id"`
+	BudgetID          uuid.UUID        `json:"budget_id"`
+	LoggerID          uuid.UUID        `json:"logger_id"`
+	AccountID         uuid.UUID        `json:"account_id"`
+	TransferAccountID *uuid.UUID       `json:"transfer_account_id"`
+	TransactionType   string           `json:"transaction_type"`
+	PayeeID           *uuid.UUID       `json:"payee_id"`
+	Notes             string           `json:"notes"`
+	Cleared           bool             `json:"is_cleared"`
+	Amounts           map[string]int64 `json:"amounts"`
+	Frequency         string           `json:"frequency"`
+	FrequencyInterval int32            `json:"frequency_interval"`
+	DayOption         string           `json:"day_option"`
+	StartDate         time.Time        `json:"start_date"`
+	EndDate           *time.Time       `json:"end_date"`
+	NextDate          *time.Time       `json:"next_date"`
+	// FailureCount is how many times in a row the transaction has failed
+	// to post; once it reaches the limit, the transaction is disabled
+	// until updated.
+	FailureCount int32      `json:"failure_count"`
+	LastError    string     `json:"last_error"`
+	RetryAfter   *time.Time `json:"retry_after"`
+	DisabledAt   *time.Time `json:"disabled_at"`
+}
+
+type UpcomingTransaction struct {
+	RecurringID     uuid.UUID        `json:"recurring_id"`
+	TransactionDate time.Time        `json:"transaction_date"`
+	TransactionType string           `json:"transaction_type"`
+	AccountID       uuid.UUID        `json:"account_id"`
+	PayeeID         *uuid.UUID       `json:"payee_id"`
+	Notes           string           `json:"notes"`
+	TotalAmount     int64            `json:"total_amount"`
+	Amounts         map[string]int64 `json:"amounts"`
+}
+
+type ImportProfile struct {
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	AccountID       uuid.UUID `json:"account_id"`
+	HasHeader       bool      `json:"has_header"`
+	Delimiter       string    `json:"delimiter"`
+	SkipRows        int32     `json:"skip_rows"`
+	DateColumn      string    `json:"date_column"`
+	DateFormat      string    `json:"date_format"`
+	AmountColumn    string    `json:"amount_column"`
+	DebitColumn     string    `json:"debit_column"`
+	CreditColumn    string    `json:"credit_column"`
+	PayeeColumn     string    `json:"payee_column"`
+	MemoColumn      string    `json:"memo_column"`
+	CategoryColumn  string    `json:"category_column"`
+	DefaultCategory string    `json:"default_category"`
+	SignConvention  string    `json:"sign_convention"`
+}
+
+type ImportRowReport struct {
+	Line          int        `json:"line"`
+	Status        string     `json:"status"`
+	Reason        string     `json:"reason"`
+	TransactionID *uuid.UUID `json:"transaction_id"`
+}
+
+type ImportReport struct {
+	AccountID  uuid.UUID         `json:"account_id"`
+	Accepted   int               `json:"accepted"`
+	Rejected   int               `json:"rejected"`
+	Duplicates int               `json:"duplicates"`
+	Rows       []ImportRowReport `json:"rows"`
+}
+
+// CreatedResources lists the names of budget resources created by an import.
+type CreatedResources struct {
+	Accounts   []string `json:"accounts_created"`
+	Groups     []string `json:"groups_created"`
+	Categories []string `json:"categories_created"`
+}
+
+type QIFImportReport struct {
+	CreatedResources
+	Accounts []ImportReport `json:"accounts"`
+}
+
+// OFXImportReport compares the ledger balance reported by a financial
+// institution against the capital of the account, once imported.
+type OFXImportReport struct {
+	ImportReport
+	LedgerBalance     *int64     `json:"ledger_balance"`
+	LedgerBalanceDate *time.Time `json:"ledger_balance_date"`
+	AccountCapital    int64      `json:"account_capital"`
+	Difference        *int64     `json:"difference"`
+}
+
+// AccountReconciliation compares the capital of an account before and after
+// an import against the total of the transactions the file lists for it.
+type AccountReconciliation struct {
+	AccountID     uuid.UUID `json:"account_id"`
+	Name          string    `json:"name"`
+	BalanceBefore int64     `json:"balance_before"`
+	BalanceAfter  int64     `json:"balance_after"`
+	// FileTotal and Difference are only given for accounts the file lists.
+	FileTotal  *int64 `json:"file_total"`
+	Difference *int64 `json:"difference"`
+}
+
+// AssignmentImportReport counts the assignments read from an imported budget.
+// Rows lists only those rejected.
+type AssignmentImportReport struct {
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Rows     []ImportRowReport `json:"rows"`
+}
+
+// BudgetImportReport reports an import of the data of another budgeting tool,
+// along with the balance of each account it affected.
+type BudgetImportReport struct {
+	QIFImportReport
+	Assignments    AssignmentImportReport  `json:"assignments"`
+	Reconciliation []AccountReconciliation `json:"reconciliation"`
 }
 
 type Payee struct {
@@ -140,6 +370,22 @@ type Payee struct {
 	Meta
 }
 
+type CategoryGoal struct {
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	CategoryID   uuid.UUID  `json:"category_id"`
+	GoalType     string     `json:"goal_type"`
+	TargetAmount int64      `json:"target_amount"`
+	TargetDate   *time.Time `json:"target_date"`
+}
+
+type GoalProgress struct {
+	GoalType        string     `json:"goal_type"`
+	TargetAmount    int64      `json:"target_amount"`
+	TargetDate      *time.Time `json:"target_date"`
+	PercentComplete int64      `json:"percent_complete"`
+}
+
 type CategoryReport struct {
 	MonthID    time.Time `json:"month_id"`
 	CategoryID uuid.UUID `json:"category_id"`
@@ -148,6 +394,17 @@ type CategoryReport struct {
 	Assigned   int64     `json:"assigned"`
 	Activity   int64     `json:"activity"`
 	Balance    int64     `json:"balance"`
+	// Needed is the amount to be assigned this month toward the goal of the
+	// category, and Underfunded, how much of it has yet to be.
+	Needed      int64         `json:"needed"`
+	Underfunded int64         `json:"underfunded"`
+	Goal        *GoalProgress `json:"goal"`
+	// OverspendingRule is the rule the category follows in effect, and
+	// CreditOverspent, how much of what was Overspent became card debt.
+	OverspendingRule string `json:"overspending_rule"`
+	IsOverspent      bool   `json:"is_overspent"`
+	Overspent        int64  `json:"overspent"`
+	CreditOverspent  int64  `json:"credit_overspent"`
 }
 
 type GroupReport struct {
@@ -164,4 +421,137 @@ type MonthReport struct {
 	Assigned int64     `json:"assigned"`
 	Activity int64     `json:"activity"`
 	Balance  int64     `json:"balance"`
+	// ReadyToAssign is the income received up to the end of the month which
+	// has yet to be assigned to any category. It is negative where more
+	// has been assigned than received.
+	ReadyToAssign int64 `json:"ready_to_assign"`
+}
+
+type Reconciliation struct {
+	CreatedAt               time.Time  `json:"created_at"`
+	ID                      uuid.UUID  `json:"id"`
+	AccountID               uuid.UUID  `json:"account_id"`
+	ReconcilerID            uuid.UUID  `json:"reconciler_id"`
+	StatementDate           time.Time  `json:"statement_date"`
+	StatementBalance        int64      `json:"statement_balance"`
+	AdjustmentTransactionID *uuid.UUID `json:"adjustment_transaction_id"`
+}
+
+// ReconciliationReport compares the balance of a bank statement against the
+// cleared balance of an account. Where the two match, the account is
+// reconciled, and Reconciliation is the record made of it.
+type ReconciliationReport struct {
+	AccountID        uuid.UUID       `json:"account_id"`
+	StatementDate    time.Time       `json:"statement_date"`
+	StatementBalance int64           `json:"statement_balance"`
+	ClearedBalance   int64           `json:"cleared_balance"`
+	Difference       int64           `json:"difference"`
+	Reconciled       bool            `json:"reconciled"`
+	Locked           int64           `json:"transactions_locked"`
+	Reconciliation   *Reconciliation `json:"reconciliation"`
+}
+
+type AutoAssignChange struct {
+	CategoryID     uuid.UUID `json:"category_id"`
+	CategoryName   string    `json:"category_name"`
+	AssignedBefore int64     `json:"assigned_before"`
+	AssignedAfter  int64     `json:"assigned_after"`
+	Change         int64     `json:"change"`
+}
+
+// AutoAssignReport lists the changes in assignment made by an auto-assign
+// strategy. Where DryRun is set, the changes are only previewed.
+type AutoAssignReport struct {
+	MonthID     time.Time          `json:"month_id"`
+	Strategy    string             `json:"strategy"`
+	DryRun      bool               `json:"dry_run"`
+	TotalChange int64              `json:"total_change"`
+	Changes     []AutoAssignChange `json:"changes"`
+}
+
+// BudgetExportHeader opens a budget export document, identifying its
+// format and version ahead of the budget and its sections.
+type BudgetExportHeader struct {
+	Format     string       `json:"format"`
+	Version    int          `json:"version"`
+	ExportedAt time.Time    `json:"exported_at"`
+	Budget     ExportBudget `json:"budget"`
+}
+
+type ExportBudget struct {
+	Name             string `json:"name"`
+	Notes            string `json:"notes"`
+	OverspendingRule string `json:"overspending_rule"`
+}
+
+type ExportMember struct {
+	Username   string `json:"username"`
+	MemberRole string `json:"member_role"`
+}
+
+type ExportGroup struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Notes string    `json:"notes"`
+}
+
+type ExportCategory struct {
+	ID               uuid.UUID  `json:"id"`
+	GroupID          *uuid.UUID `json:"group_id"`
+	Name             string     `json:"name"`
+	Notes            string     `json:"notes"`
+	OverspendingRule string     `json:"overspending_rule"`
+}
+
+type ExportAccount struct {
+	ID                uuid.UUID  `json:"id"`
+	AccountType       string     `json:"account_type"`
+	Name              string     `json:"name"`
+	Notes             string     `json:"notes"`
+	IsDeleted         bool       `json:"is_deleted"`
+	PaymentCategoryID *uuid.UUID `json:"payment_category_id"`
+}
+
+type ExportPayee struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Notes string    `json:"notes"`
+}
+
+type ExportAssignment struct {
+	Month      time.Time `json:"month"`
+	CategoryID uuid.UUID `json:"category_id"`
+	Assigned   int64     `json:"assigned"`
+}
+
+type ExportTransaction struct {
+	ID                  uuid.UUID     `json:"id"`
+	AccountID           uuid.UUID     `json:"account_id"`
+	TransactionType     string        `json:"transaction_type"`
+	TransactionDate     time.Time     `json:"transaction_date"`
+	PayeeID             uuid.UUID     `json:"payee_id"`
+	LoggerName          string        `json:"logger_name"`
+	Notes               string        `json:"notes"`
+	Cleared             bool          `json:"is_cleared"`
+	Reconciled          bool          `json:"is_reconciled"`
+	LinkedTransactionID *uuid.UUID    `json:"linked_transaction_id"`
+	Splits              []ExportSplit `json:"splits"`
+}
+
+type ExportSplit struct {
+	CategoryID *uuid.UUID `json:"category_id"`
+	Amount     int64      `json:"amount"`
+}
+
+// BudgetTemplate is a skeleton of groups and categories, built into the
+// server, from which a new budget may be started.
+type BudgetTemplate struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Groups      []BudgetTemplateGroup `json:"groups"`
+}
+
+type BudgetTemplateGroup struct {
+	Name       string   `json:"name"`
+	Categories []string `json:"categories"`
 }
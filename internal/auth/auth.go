@@ -3,6 +3,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"net/http"
@@ -36,16 +37,37 @@ func CheckPasswordHash(password, hash string) (bool, error) {
 	return match, nil
 }
 
-func MakeJWT(userID uuid.UUID, method *jwt.SigningMethodHMAC, tokenSecret string, expiresIn time.Duration) (string, error) {
+// Issuers of the JWTs made here. Tokens from one issuer
+// are never accepted in place of those from another.
+const (
+	accessIssuer    = "pincher"
+	challengeIssuer = "pincher-challenge"
+)
+
+// MakeJWT returns an access token for the given user, signed by the key set.
+// Tokens issued on behalf of a session carry the session ID; pass uuid.Nil for none.
+func MakeJWT(userID, sessionID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return makeJWT(accessIssuer, userID, sessionID, keys, expiresIn)
+}
+
+// MakeChallengeJWT returns a signed token attesting that the given user has
+// passed the first step of a two-step login. It cannot be used as an access token.
+func MakeChallengeJWT(userID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return makeJWT(challengeIssuer, userID, uuid.Nil, keys, expiresIn)
+}
+
+func makeJWT(issuer string, userID, sessionID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
 	claims := jwt.RegisteredClaims{
-		Issuer:    "pincher",
+		Issuer:    issuer,
 		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn).UTC()),
 		Subject:   userID.String(),
 	}
+	if sessionID != uuid.Nil {
+		claims.ID = sessionID.String()
+	}
 
-	token := jwt.NewWithClaims(method, claims)
-	signed, err := token.SignedString([]byte(tokenSecret))
+	signed, err := keys.sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -53,28 +75,51 @@ func MakeJWT(userID uuid.UUID, method *jwt.SigningMethodHMAC, tokenSecret string
 	return signed, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret, algorithm string) (uuid.UUID, error) {
+func parseJWT(issuer, tokenString string, keys *KeySet) (*jwt.RegisteredClaims, error) {
 	jwtClaims := jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, &jwtClaims, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method: " + token.Method.Alg())
-		}
-		if algorithm != token.Method.Alg() {
-			return nil, errors.New("unexpected signing method: " + token.Method.Alg())
-		}
-		return []byte(tokenSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &jwtClaims, keys.keyFunc, jwt.WithIssuer(issuer))
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
 	} else if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok {
-		id, err := uuid.Parse(claims.Subject)
-		if err != nil {
-			return uuid.Nil, err
-		}
-		return id, nil
+		return claims, nil
 	} else {
-		return uuid.Nil, errors.New("unknown claims type, cannot proceed")
+		return nil, errors.New("unknown claims type, cannot proceed")
+	}
+}
+
+func validateJWT(issuer, tokenString string, keys *KeySet) (uuid.UUID, error) {
+	claims, err := parseJWT(issuer, tokenString, keys)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	id, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func ValidateJWT(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	return validateJWT(accessIssuer, tokenString, keys)
+}
+
+// ValidateChallengeJWT returns the ID of the user to whom
+// a valid challenge token was issued.
+func ValidateChallengeJWT(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	return validateJWT(challengeIssuer, tokenString, keys)
+}
+
+// GetJWTSessionID returns the ID of the session on whose behalf a valid
+// access token was issued, or uuid.Nil if it was issued for none.
+func GetJWTSessionID(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	claims, err := parseJWT(accessIssuer, tokenString, keys)
+	if err != nil {
+		return uuid.Nil, err
 	}
+	if claims.ID == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(claims.ID)
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -119,3 +164,42 @@ func GetAPIKey(headers http.Header) (string, error) {
 	}
 	return tokenElements[1], nil
 }
+
+// APIKeyPrefix begins every API key, so that keys may be told apart from
+// other secrets at a glance.
+const APIKeyPrefix = "pincher_"
+
+// MakeAPIKey returns a new random API key.
+func MakeAPIKey() (string, error) {
+	rBytes := make([]byte, 32)
+	_, err := rand.Read(rBytes)
+	if err != nil {
+		return "", err
+	}
+	return APIKeyPrefix + hex.EncodeToString(rBytes), nil
+}
+
+// HashAPIKey returns the hash under which an API key is stored.
+// Keys are random enough that a fast hash suffices, which lets
+// them be looked up by their hash on every request.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// MakeInvitationToken returns a new random token by which
+// a budget invitation link may be accepted.
+func MakeInvitationToken() (string, error) {
+	rBytes := make([]byte, 32)
+	_, err := rand.Read(rBytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(rBytes), nil
+}
+
+// HashInvitationToken returns the hash under which an invitation token is stored.
+func HashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -56,19 +56,33 @@ func TestHashEqual(t *testing.T) {
 func JWTRejectExpired(t *testing.T) {
 	// passes if an expired JWT is properly rejected
 	userID := uuid.New()
-	tokenSecret := "very-secret-secret"
+	keys := mustMakeKeySet(t)
 	expiration := time.Second * 2
-	token, err := MakeJWT(userID, jwt.SigningMethodHS512, tokenSecret, expiration)
+	token, err := MakeJWT(userID, uuid.Nil, keys, expiration)
 	if err != nil {
 		t.Error(err)
 	}
 	time.Sleep(2 * time.Second)
-	_, err = ValidateJWT(token, "very-secret-secret", "HS256")
+	_, err = ValidateJWT(token, keys)
 	if err == nil {
 		t.Error("expired JWT not rejected")
 	}
 }
 
+// mustMakeKeySet returns a key set which signs with a new key.
+func mustMakeKeySet(t *testing.T, verifying ...*SigningKey) *KeySet {
+	t.Helper()
+	signing, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewKeySet(signing, verifying...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
 func TestCheckPasswordHash(t *testing.T) {
 	// First, we need to create some hashed passwords for testing
 	password1 := "correctPassword123!"
@@ -143,41 +157,73 @@ func TestCheckPasswordHash(t *testing.T) {
 
 func TestValidateJWT(t *testing.T) {
 	userID := uuid.New()
-	validToken, _ := MakeJWT(userID, jwt.SigningMethodHS256, "secret", time.Hour)
-	invalidToken, _ := MakeJWT(userID, jwt.SigningMethodHS384, "secret", time.Hour)
+	keys := mustMakeKeySet(t)
+	keys.AcceptLegacySecret("secret")
+	otherKeys := mustMakeKeySet(t)
+
+	validToken, _ := MakeJWT(userID, uuid.Nil, keys, time.Hour)
+	challengeToken, _ := MakeChallengeJWT(userID, keys, time.Hour)
+	claims := jwt.RegisteredClaims{
+		Issuer:    accessIssuer,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+	}
+	legacyToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	invalidToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS384, claims).SignedString([]byte("secret"))
 
 	tests := []struct {
 		name        string
 		tokenString string
-		tokenSecret string
+		keys        *KeySet
 		wantUserID  uuid.UUID
 		wantErr     bool
 	}{
 		{
 			name:        "Valid token",
 			tokenString: validToken,
-			tokenSecret: "secret",
+			keys:        keys,
 			wantUserID:  userID,
 			wantErr:     false,
 		},
 		{
 			name:        "Invalid token",
 			tokenString: "invalid.token.string",
-			tokenSecret: "secret",
+			keys:        keys,
 			wantUserID:  uuid.Nil,
 			wantErr:     true,
 		},
 		{
-			name:        "Wrong secret",
+			name:        "Wrong key",
 			tokenString: validToken,
-			tokenSecret: "wrong_secret",
+			keys:        otherKeys,
 			wantUserID:  uuid.Nil,
 			wantErr:     true,
 		},
 		{
 			name:        "Wrong algorithm",
 			tokenString: invalidToken,
-			tokenSecret: "wrong_secret",
+			keys:        keys,
+			wantUserID:  uuid.Nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Legacy secret",
+			tokenString: legacyToken,
+			keys:        keys,
+			wantUserID:  userID,
+			wantErr:     false,
+		},
+		{
+			name:        "Legacy secret not accepted",
+			tokenString: legacyToken,
+			keys:        otherKeys,
+			wantUserID:  uuid.Nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Challenge token",
+			tokenString: challengeToken,
+			keys:        keys,
 			wantUserID:  uuid.Nil,
 			wantErr:     true,
 		},
@@ -185,7 +231,7 @@ func TestValidateJWT(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUserID, err := ValidateJWT(tt.tokenString, tt.tokenSecret, "HS256")
+			gotUserID, err := ValidateJWT(tt.tokenString, tt.keys)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -197,6 +243,57 @@ func TestValidateJWT(t *testing.T) {
 	}
 }
 
+func TestGetJWTSessionID(t *testing.T) {
+	userID := uuid.New()
+	sessionID := uuid.New()
+	keys := mustMakeKeySet(t)
+	sessionToken, _ := MakeJWT(userID, sessionID, keys, time.Hour)
+	sessionlessToken, _ := MakeJWT(userID, uuid.Nil, keys, time.Hour)
+
+	tests := []struct {
+		name          string
+		tokenString   string
+		keys          *KeySet
+		wantSessionID uuid.UUID
+		wantErr       bool
+	}{
+		{
+			name:          "Token with session",
+			tokenString:   sessionToken,
+			keys:          keys,
+			wantSessionID: sessionID,
+			wantErr:       false,
+		},
+		{
+			name:          "Token without session",
+			tokenString:   sessionlessToken,
+			keys:          keys,
+			wantSessionID: uuid.Nil,
+			wantErr:       false,
+		},
+		{
+			name:          "Wrong key",
+			tokenString:   sessionToken,
+			keys:          mustMakeKeySet(t),
+			wantSessionID: uuid.Nil,
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSessionID, err := GetJWTSessionID(tt.tokenString, tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetJWTSessionID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotSessionID != tt.wantSessionID {
+				t.Errorf("GetJWTSessionID() gotSessionID = %v, want %v", gotSessionID, tt.wantSessionID)
+			}
+		})
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	const tokenWant = "thisIsATokenString"
 
@@ -264,3 +361,82 @@ func TestGetBearerToken(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAPIKey(t *testing.T) {
+	const keyWant = APIKeyPrefix + "thisIsAKeyString"
+
+	type testCases struct {
+		name        string
+		headers     http.Header
+		expectedKey string
+		expectErr   bool
+	}
+
+	cases := []testCases{
+		{
+			name:        "valid header",
+			headers:     http.Header{"Authorization": []string{"ApiKey " + keyWant}},
+			expectedKey: keyWant,
+			expectErr:   false,
+		},
+		{
+			name:        "missing header",
+			headers:     http.Header{},
+			expectedKey: "",
+			expectErr:   true,
+		},
+		{
+			name:        "bearer scheme",
+			headers:     http.Header{"Authorization": []string{"Bearer " + keyWant}},
+			expectedKey: "",
+			expectErr:   true,
+		},
+		{
+			name:        "ApiKey without key value",
+			headers:     http.Header{"Authorization": []string{"ApiKey "}},
+			expectedKey: "",
+			expectErr:   true,
+		},
+		{
+			name:        "scheme is case-insensitive",
+			headers:     http.Header{"Authorization": []string{"apikey " + keyWant}},
+			expectedKey: keyWant,
+			expectErr:   false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			key, err := GetAPIKey(c.headers)
+			if (err != nil) != c.expectErr {
+				t.Errorf("expected error: %v, got: %v", c.expectErr, err)
+			}
+			if key != c.expectedKey {
+				t.Errorf("expected key: %v, got: %v", c.expectedKey, key)
+			}
+		})
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	key, err := MakeAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := MakeAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key == otherKey {
+		t.Error("keys should be unique, but were not")
+	}
+	if HashAPIKey(key) != HashAPIKey(key) {
+		t.Error("hash of the same key should be stable, but was not")
+	}
+	if HashAPIKey(key) == HashAPIKey(otherKey) {
+		t.Error("hashes of different keys should differ, but did not")
+	}
+	if len(HashAPIKey(key)) != 64 {
+		t.Errorf("expected hash length: 64, got: %d", len(HashAPIKey(key)))
+	}
+}
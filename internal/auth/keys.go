@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is an asymmetric key by which JWTs are signed, or only verified
+// where its private part is not held. It is named by its key ID, which is
+// the RFC 7638 thumbprint of its public part.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// NewSigningKey wraps an Ed25519, RSA, or P-256 ECDSA key, private or public.
+// Ed25519 keys sign with EdDSA, RSA keys with RS256, and ECDSA keys with ES256.
+func NewSigningKey(key any) (*SigningKey, error) {
+	sk := &SigningKey{}
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		sk.private, sk.public = k, k.Public()
+	case *rsa.PrivateKey:
+		sk.private, sk.public = k, k.Public()
+	case *ecdsa.PrivateKey:
+		sk.private, sk.public = k, k.Public()
+	case ed25519.PublicKey, *rsa.PublicKey, *ecdsa.PublicKey:
+		sk.public = k
+	default:
+		return nil, fmt.Errorf("unsupported key type: %T", key)
+	}
+
+	switch k := sk.public.(type) {
+	case ed25519.PublicKey:
+		sk.Method = jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		sk.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported ECDSA curve: %s", k.Curve.Params().Name)
+		}
+		sk.Method = jwt.SigningMethodES256
+	}
+
+	thumbprint := sha256.Sum256([]byte(sk.thumbprintInput()))
+	sk.ID = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	return sk, nil
+}
+
+// GenerateSigningKey returns a new random Ed25519 signing key.
+func GenerateSigningKey() (*SigningKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigningKey(private)
+}
+
+// CanSign reports whether the private part of the key is held.
+func (sk *SigningKey) CanSign() bool {
+	return sk.private != nil
+}
+
+// JWK describes the public part of a signing key, as per RFC 7517.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWK returns the public part of the key as a JSON Web Key.
+func (sk *SigningKey) JWK() JWK {
+	jwk := JWK{
+		KeyID:     sk.ID,
+		Use:       "sig",
+		Algorithm: sk.Method.Alg(),
+	}
+	switch k := sk.public.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(k)
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		// an uncompressed point is 0x04, then X and Y at equal length
+		point, _ := k.Bytes()
+		size := (len(point) - 1) / 2
+		jwk.KeyType = "EC"
+		jwk.Curve = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+	}
+	return jwk
+}
+
+// PublicKey returns the public key which the JWK describes.
+func (jwk JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch jwk.KeyType {
+	case "OKP":
+		if jwk.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve: %s", jwk.Curve)
+		}
+		x, err := decode(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("malformed Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, errors.New("malformed RSA modulus")
+		}
+		e, err := decode(jwk.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("malformed RSA exponent")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported EC curve: %s", jwk.Curve)
+		}
+		x, errX := decode(jwk.X)
+		y, errY := decode(jwk.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("malformed EC key")
+		}
+		point := append(append([]byte{0x04}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", jwk.KeyType)
+	}
+}
+
+// thumbprintInput returns the required members of the key's JWK,
+// in the lexical order that RFC 7638 prescribes.
+func (sk *SigningKey) thumbprintInput() string {
+	jwk := sk.JWK()
+	switch jwk.KeyType {
+	case "OKP":
+		return fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s"}`, jwk.Curve, jwk.KeyType, jwk.X)
+	case "EC":
+		return fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s","y":"%s"}`, jwk.Curve, jwk.KeyType, jwk.X, jwk.Y)
+	default:
+		return fmt.Sprintf(`{"e":"%s","kty":"%s","n":"%s"}`, jwk.E, jwk.KeyType, jwk.N)
+	}
+}
+
+// KeySet holds the key by which new JWTs are signed, along with every key
+// by which JWTs are still verified. Keeping a retired key for verification
+// lets the tokens it signed live out their lives after rotation.
+type KeySet struct {
+	signing   *SigningKey
+	verifying map[string]*SigningKey
+	// legacySecret verifies HS256 tokens signed before keys were introduced.
+	legacySecret []byte
+}
+
+// NewKeySet returns a key set which signs with the given key, and
+// verifies with it and any others given.
+func NewKeySet(signing *SigningKey, verifying ...*SigningKey) (*KeySet, error) {
+	if signing == nil || !signing.CanSign() {
+		return nil, errors.New("signing key must hold a private key")
+	}
+	ks := &KeySet{
+		signing:   signing,
+		verifying: map[string]*SigningKey{signing.ID: signing},
+	}
+	for _, key := range verifying {
+		ks.verifying[key.ID] = key
+	}
+	return ks, nil
+}
+
+// AcceptLegacySecret has the key set also verify HS256 tokens which carry
+// no key ID, as were signed with a single shared secret in the past.
+func (ks *KeySet) AcceptLegacySecret(secret string) {
+	ks.legacySecret = []byte(secret)
+}
+
+// SigningKeyID returns the key ID of the key which signs new tokens.
+func (ks *KeySet) SigningKeyID() string {
+	return ks.signing.ID
+}
+
+// JWKS returns the public part of every verification key, ordered by key ID.
+func (ks *KeySet) JWKS() []JWK {
+	keys := []JWK{}
+	for _, key := range ks.verifying {
+		keys = append(keys, key.JWK())
+	}
+	slices.SortFunc(keys, func(a, b JWK) int { return strings.Compare(a.KeyID, b.KeyID) })
+	return keys
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.private)
+}
+
+// keyFunc finds the key by which a token is to be verified, by its key ID.
+// The algorithm of the token must be that of the key, so that no token
+// may choose how it is verified.
+func (ks *KeySet) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if len(ks.legacySecret) > 0 && token.Method == jwt.SigningMethodHS256 {
+			return ks.legacySecret, nil
+		}
+		return nil, errors.New("token has no key ID")
+	}
+
+	key, ok := ks.verifying[kid]
+	if !ok {
+		return nil, errors.New("unknown key ID: " + kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method: " + token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// LoadKeySet reads every PEM-encoded key in the given directory. Private
+// keys (PKCS #8, PKCS #1 for RSA, or SEC 1 for ECDSA) may sign and verify
+// tokens, while public keys (PKIX) only verify them. The key named by
+// signingKeyName, the file name without its extension, signs new tokens;
+// where none is named, the private key whose file name sorts last does.
+// Where the directory holds no private key at all, a new Ed25519 key is
+// generated and saved there.
+func LoadKeySet(dir, signingKeyName string) (*KeySet, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("could not create key directory: %w", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+
+	var signing *SigningKey
+	var lastPrivate *SigningKey
+	var verifying []*SigningKey
+	for _, path := range paths {
+		key, err := readKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read key %s: %w", path, err)
+		}
+		verifying = append(verifying, key)
+		if !key.CanSign() {
+			continue
+		}
+		lastPrivate = key
+		if signingKeyName != "" && strings.TrimSuffix(filepath.Base(path), ".pem") == signingKeyName {
+			signing = key
+		}
+	}
+
+	if signingKeyName != "" && signing == nil {
+		return nil, fmt.Errorf("no private key named %s found in %s", signingKeyName, dir)
+	}
+	if signing == nil {
+		signing = lastPrivate
+	}
+	if signing == nil {
+		signing, err = GenerateSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, time.Now().UTC().Format("20060102T150405Z")+".pem")
+		if err := writeKeyFile(path, signing); err != nil {
+			return nil, fmt.Errorf("could not save generated key: %w", err)
+		}
+	}
+
+	return NewKeySet(signing, verifying...)
+}
+
+func readKeyFile(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewSigningKey(key)
+}
+
+func writeKeyFile(path string, key *SigningKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLoadKeySetGeneratesAndPersists(t *testing.T) {
+	dir := t.TempDir()
+
+	keys, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if len(paths) != 1 {
+		t.Fatalf("want: 1 key file | actual: %d", len(paths))
+	}
+
+	reloaded, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.SigningKeyID() != reloaded.SigningKeyID() {
+		t.Errorf("want: %v | actual: %v", keys.SigningKeyID(), reloaded.SigningKeyID())
+	}
+}
+
+func TestLoadKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+	userID := uuid.New()
+
+	oldKeys, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, _ := MakeJWT(userID, uuid.Nil, oldKeys, time.Hour)
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+	oldName := strings.TrimSuffix(filepath.Base(paths[0]), ".pem")
+
+	// a new RSA key, named to sort last, takes over signing
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, "99999999T999999Z.pem"), rsaPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	newKeys, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newKeys.SigningKeyID() == oldKeys.SigningKeyID() {
+		t.Error("newest key should sign new tokens")
+	}
+	newToken, _ := MakeJWT(userID, uuid.Nil, newKeys, time.Hour)
+
+	for name, token := range map[string]string{"old token": oldToken, "new token": newToken} {
+		gotUserID, err := ValidateJWT(token, newKeys)
+		if err != nil || gotUserID != userID {
+			t.Errorf("%s: want: %v | actual: %v (%v)", name, userID, gotUserID, err)
+		}
+	}
+	if _, err := ValidateJWT(newToken, oldKeys); err == nil {
+		t.Error("token signed by an unknown key should be rejected")
+	}
+
+	jwks := newKeys.JWKS()
+	if len(jwks) != 2 {
+		t.Fatalf("want: 2 keys | actual: %d", len(jwks))
+	}
+	for _, jwk := range jwks {
+		switch jwk.KeyType {
+		case "OKP":
+			if jwk.Algorithm != "EdDSA" || jwk.X == "" {
+				t.Errorf("unexpected Ed25519 JWK: %+v", jwk)
+			}
+		case "RSA":
+			if jwk.Algorithm != "RS256" || jwk.N == "" || jwk.E != "AQAB" {
+				t.Errorf("unexpected RSA JWK: %+v", jwk)
+			}
+		default:
+			t.Errorf("unexpected key type: %s", jwk.KeyType)
+		}
+	}
+
+	// the older key may still be chosen by name
+	namedKeys, err := LoadKeySet(dir, oldName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namedKeys.SigningKeyID() != oldKeys.SigningKeyID() {
+		t.Errorf("want: %v | actual: %v", oldKeys.SigningKeyID(), namedKeys.SigningKeyID())
+	}
+	if _, err := LoadKeySet(dir, "missing"); err == nil {
+		t.Error("naming a missing signing key should fail")
+	}
+}
+
+func TestJWKPublicKeyRoundTrip(t *testing.T) {
+	edKey, _ := GenerateSigningKey()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	for name, private := range map[string]any{"Ed25519": edKey.private, "RSA": rsaKey, "ECDSA": ecKey} {
+		key, err := NewSigningKey(private)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		public, err := key.JWK().PublicKey()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		reparsed, err := NewSigningKey(public)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if reparsed.ID != key.ID || reparsed.Method != key.Method {
+			t.Errorf("%s: want: %v %v | actual: %v %v", name, key.ID, key.Method.Alg(), reparsed.ID, reparsed.Method.Alg())
+		}
+	}
+
+	p384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := NewSigningKey(p384Key); err == nil {
+		t.Error("ECDSA keys on curves other than P-256 should be rejected")
+	}
+}
@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCProvider signs users in through an OpenID Connect identity provider,
+// by the authorization code flow with PKCE (RFC 7636). The endpoints and
+// keys of the provider are discovered from its issuer URL upon first use.
+type OIDCProvider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+
+	mu       sync.Mutex
+	metadata *oidcMetadata
+	// keys verify ID tokens, by the key IDs the provider gives them
+	keys map[string]*SigningKey
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDTokenClaims are the claims of an ID token by which its user is known.
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	PreferredUsername string `json:"preferred_username"`
+	Email             string `json:"email"`
+}
+
+// NewOIDCProvider returns a provider for the given issuer, to which this
+// service is known as the given client. The client secret may be empty for
+// public clients. The redirect URL is where the provider sends users back to
+// once they have signed in.
+func NewOIDCProvider(issuer, clientID, clientSecret, redirectURL string) *OIDCProvider {
+	return &OIDCProvider{
+		issuer:       issuer,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Issuer returns the issuer URL of the provider.
+func (p *OIDCProvider) Issuer() string {
+	return p.issuer
+}
+
+// MakeOIDCSecret returns a random value fit for the state, nonce,
+// or PKCE code verifier of an authorization request.
+func MakeOIDCSecret() (string, error) {
+	rBytes := make([]byte, 32)
+	_, err := rand.Read(rBytes)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(rBytes), nil
+}
+
+// HashOIDCBinding returns the hash under which the secret binding a login
+// to the browser that began it is stored.
+func HashOIDCBinding(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// PKCEChallenge returns the S256 code challenge for a PKCE code verifier.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the URL to which a user is sent to sign in with the
+// provider. The state is returned with the user to the redirect URL, the
+// nonce within the ID token, and only the challenge of the code verifier
+// is sent, so that only whoever holds the verifier may redeem the code.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	authURL, err := url.Parse(md.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("malformed authorization endpoint: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientID)
+	query.Set("redirect_uri", p.redirectURL)
+	query.Set("scope", "openid profile email")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", PKCEChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// Exchange redeems an authorization code, along with the code verifier
+// of the request which produced it, for an ID token.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.clientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	var rsp struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &rsp)
+	if err != nil {
+		return "", fmt.Errorf("could not redeem authorization code: %w", err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("could not redeem authorization code: %s %s", rsp.Error, rsp.ErrorDescription)
+	}
+	if rsp.IDToken == "" {
+		return "", errors.New("no ID token returned")
+	}
+	return rsp.IDToken, nil
+}
+
+// VerifyIDToken checks that an ID token was signed by the provider for this
+// client, has not expired, and carries the nonce of the request which
+// produced it, and returns its claims.
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, idToken, nonce string) (*IDTokenClaims, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &IDTokenClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims,
+		func(token *jwt.Token) (any, error) {
+			return p.keyFunc(ctx, token)
+		},
+		jwt.WithIssuer(md.Issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodES256.Alg(),
+			jwt.SigningMethodEdDSA.Alg(),
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("ID token nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+	return claims, nil
+}
+
+// keyFunc finds the provider key by which an ID token is to be verified.
+// Keys are fetched again once upon meeting an unknown key ID, in case the
+// provider has rotated its keys since they were last fetched.
+func (p *OIDCProvider) keyFunc(ctx context.Context, token *jwt.Token) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	kid, _ := token.Header["kid"].(string)
+	find := func() *SigningKey {
+		if kid == "" && len(p.keys) == 1 {
+			for _, key := range p.keys {
+				return key
+			}
+		}
+		return p.keys[kid]
+	}
+
+	key := find()
+	if key == nil {
+		if err := p.fetchKeys(ctx); err != nil {
+			return nil, err
+		}
+		key = find()
+	}
+	if key == nil {
+		return nil, errors.New("unknown key ID: " + kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method: " + token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// fetchKeys replaces the known keys of the provider with those it now
+// publishes. The caller must hold the lock, and have discovered the provider.
+func (p *OIDCProvider) fetchKeys(ctx context.Context) error {
+	var jwks struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.metadata.JWKSURI, &jwks); err != nil {
+		return fmt.Errorf("could not fetch provider keys: %w", err)
+	}
+
+	keys := map[string]*SigningKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			// keys of unsupported types are of no use, but no harm
+			continue
+		}
+		key, err := NewSigningKey(public)
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	p.keys = keys
+	return nil
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	md := &oidcMetadata{}
+	discoveryURL := strings.TrimSuffix(p.issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discoveryURL, md); err != nil {
+		return nil, fmt.Errorf("could not discover provider: %w", err)
+	}
+	if md.Issuer != p.issuer {
+		return nil, fmt.Errorf("provider issuer mismatch: %s", md.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, errors.New("provider metadata incomplete")
+	}
+	p.metadata = md
+	return md, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	status, err := p.doJSON(req, v)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", status, target)
+	}
+	return nil
+}
+
+func (p *OIDCProvider) doJSON(req *http.Request, v any) (int, error) {
+	rsp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(rsp.Body, 1<<20))
+	if err != nil {
+		return rsp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && rsp.StatusCode == http.StatusOK {
+		return rsp.StatusCode, err
+	}
+	return rsp.StatusCode, nil
+}
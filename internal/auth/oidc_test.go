@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// stubIssuer is a minimal OpenID Connect provider, which issues whatever
+// ID token it was told to for a code, to whoever holds its code verifier.
+type stubIssuer struct {
+	*httptest.Server
+	keys *KeySet
+
+	mu     sync.Mutex
+	grants map[string]stubGrant
+}
+
+type stubGrant struct {
+	challenge string
+	idToken   string
+}
+
+func newStubIssuer(t *testing.T) *stubIssuer {
+	t.Helper()
+	s := &stubIssuer{keys: mustMakeKeySet(t), grants: map[string]stubGrant{}}
+	mux := http.NewServeMux()
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 s.URL,
+			"authorization_endpoint": s.URL + "/authorize",
+			"token_endpoint":         s.URL + "/token",
+			"jwks_uri":               s.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"keys": s.keys.JWKS()})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		grant, ok := s.grants[r.FormValue("code")]
+		delete(s.grants, r.FormValue("code"))
+		s.mu.Unlock()
+		if !ok || PKCEChallenge(r.FormValue("code_verifier")) != grant.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": grant.idToken})
+	})
+	return s
+}
+
+func (s *stubIssuer) grant(t *testing.T, code, challenge string, claims IDTokenClaims) {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idToken, err := s.keys.sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.grants[code] = stubGrant{challenge: challenge, idToken: idToken}
+}
+
+func (s *stubIssuer) claims(audience, subject, nonce string, expiresIn time.Duration) IDTokenClaims {
+	return IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.URL,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+		},
+		Nonce:             nonce,
+		PreferredUsername: "alice",
+	}
+}
+
+func TestOIDCProviderLogin(t *testing.T) {
+	ctx := context.Background()
+	issuer := newStubIssuer(t)
+	provider := NewOIDCProvider(issuer.URL, "pincher", "secret", "http://localhost/callback")
+
+	state, _ := MakeOIDCSecret()
+	nonce, _ := MakeOIDCSecret()
+	verifier, _ := MakeOIDCSecret()
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _ := url.Parse(authURL)
+	query := parsed.Query()
+	for param, want := range map[string]string{
+		"client_id":             "pincher",
+		"redirect_uri":          "http://localhost/callback",
+		"state":                 state,
+		"nonce":                 nonce,
+		"code_challenge":        PKCEChallenge(verifier),
+		"code_challenge_method": "S256",
+	} {
+		if query.Get(param) != want {
+			t.Errorf("%s: want: %v | actual: %v", param, want, query.Get(param))
+		}
+	}
+
+	tests := []struct {
+		name     string
+		claims   IDTokenClaims
+		verifier string
+		nonce    string
+		wantErr  bool
+	}{
+		{
+			name:     "Valid login",
+			claims:   issuer.claims("pincher", "user-1", nonce, time.Hour),
+			verifier: verifier,
+			nonce:    nonce,
+			wantErr:  false,
+		},
+		{
+			name:     "Wrong code verifier",
+			claims:   issuer.claims("pincher", "user-1", nonce, time.Hour),
+			verifier: "not-the-verifier",
+			nonce:    nonce,
+			wantErr:  true,
+		},
+		{
+			name:     "Wrong nonce",
+			claims:   issuer.claims("pincher", "user-1", "other-nonce", time.Hour),
+			verifier: verifier,
+			nonce:    nonce,
+			wantErr:  true,
+		},
+		{
+			name:     "Wrong audience",
+			claims:   issuer.claims("other-client", "user-1", nonce, time.Hour),
+			verifier: verifier,
+			nonce:    nonce,
+			wantErr:  true,
+		},
+		{
+			name:     "Expired token",
+			claims:   issuer.claims("pincher", "user-1", nonce, -time.Minute),
+			verifier: verifier,
+			nonce:    nonce,
+			wantErr:  true,
+		},
+		{
+			name:     "No subject",
+			claims:   issuer.claims("pincher", "", nonce, time.Hour),
+			verifier: verifier,
+			nonce:    nonce,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer.grant(t, "code", PKCEChallenge(verifier), tt.claims)
+			idToken, err := provider.Exchange(ctx, "code", tt.verifier)
+			if err == nil {
+				var claims *IDTokenClaims
+				claims, err = provider.VerifyIDToken(ctx, idToken, tt.nonce)
+				if err == nil && (claims.Subject != "user-1" || claims.PreferredUsername != "alice") {
+					t.Errorf("unexpected claims: %+v", claims)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("login error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	issuer := newStubIssuer(t)
+	provider := NewOIDCProvider(issuer.URL, "pincher", "", "http://localhost/callback")
+
+	claims := issuer.claims("pincher", "user-1", "nonce", time.Hour)
+	oldToken, _ := issuer.keys.sign(claims)
+	if _, err := provider.VerifyIDToken(ctx, oldToken, "nonce"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the provider rotates its keys after they were first fetched
+	issuer.mu.Lock()
+	issuer.keys = mustMakeKeySet(t)
+	issuer.mu.Unlock()
+	newToken, _ := issuer.keys.sign(claims)
+	if _, err := provider.VerifyIDToken(ctx, newToken, "nonce"); err != nil {
+		t.Errorf("token signed by a rotated key: %v", err)
+	}
+	if _, err := provider.VerifyIDToken(ctx, oldToken, "nonce"); err == nil {
+		t.Error("token signed by a retired key should be rejected")
+	}
+
+	// tokens signed by some other party are never accepted
+	forged, _ := mustMakeKeySet(t).sign(claims)
+	if _, err := provider.VerifyIDToken(ctx, forged, "nonce"); err == nil {
+		t.Error("forged token should be rejected")
+	}
+}
+
+func TestOIDCProviderIssuerMismatch(t *testing.T) {
+	issuer := newStubIssuer(t)
+	provider := NewOIDCProvider(issuer.URL+"/", "pincher", "", "http://localhost/callback")
+
+	if _, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Error("provider claiming a different issuer should be rejected")
+	}
+}
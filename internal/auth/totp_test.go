@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 secret of the RFC 6238 test vectors, base32-encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		unixTime int64
+		wantCode string
+	}{
+		{name: "RFC 6238 vector at 59", unixTime: 59, wantCode: "287082"},
+		{name: "RFC 6238 vector at 1111111109", unixTime: 1111111109, wantCode: "081804"},
+		{name: "RFC 6238 vector at 1234567890", unixTime: 1234567890, wantCode: "005924"},
+		{name: "RFC 6238 vector at 2000000000", unixTime: 2000000000, wantCode: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCode, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(tt.unixTime, 0)))
+			if err != nil {
+				t.Errorf("TOTPCode() error = %v", err)
+				return
+			}
+			if gotCode != tt.wantCode {
+				t.Errorf("TOTPCode() gotCode = %v, want %v", gotCode, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := TOTPStep(now)
+	code, _ := TOTPCode(rfcSecret, step)
+	prevCode, _ := TOTPCode(rfcSecret, step-1)
+	staleCode, _ := TOTPCode(rfcSecret, step-2)
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantErr  bool
+	}{
+		{name: "Current code", code: code, lastStep: 0, wantStep: step, wantErr: false},
+		{name: "Previous code within skew", code: prevCode, lastStep: 0, wantStep: step - 1, wantErr: false},
+		{name: "Code outside skew", code: staleCode, lastStep: 0, wantStep: 0, wantErr: true},
+		{name: "Code already used", code: code, lastStep: step, wantStep: 0, wantErr: true},
+		{name: "Malformed code", code: "12345", lastStep: 0, wantStep: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, err := ValidateTOTP(rfcSecret, tt.code, now, tt.lastStep)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTOTP() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP() gotStep = %v, want %v", gotStep, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	code, err := MakeRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 11 || code[5] != '-' {
+		t.Errorf("unexpected recovery code format: %s", code)
+	}
+	if HashRecoveryCode(code) != HashRecoveryCode(" "+code[:5]+code[6:]+" ") {
+		t.Error("recovery code hash should ignore hyphens and spacing")
+	}
+	if HashRecoveryCode(code) == HashRecoveryCode("00000-00000") && code != "00000-00000" {
+		t.Error("distinct recovery codes should not share a hash")
+	}
+}
@@ -23,7 +23,7 @@ VALUES (
     $4,
     DEFAULT
 )
-RETURNING id, created_at, updated_at, budget_id, account_type, name, notes, is_deleted
+RETURNING id, created_at, updated_at, budget_id, account_type, name, notes, is_deleted, payment_category_id
 `
 
 type AddAccountParams struct {
@@ -50,6 +50,7 @@ func (q *Queries) AddAccount(ctx context.Context, arg AddAccountParams) (Account
 		&i.Name,
 		&i.Notes,
 		&i.IsDeleted,
+		&i.PaymentCategoryID,
 	)
 	return i, err
 }
@@ -78,7 +79,7 @@ func (q *Queries) DeleteAccountSoft(ctx context.Context, id uuid.UUID) error {
 }
 
 const getAccountByID = `-- name: GetAccountByID :one
-SELECT id, created_at, updated_at, budget_id, account_type, name, notes, is_deleted
+SELECT id, created_at, updated_at, budget_id, account_type, name, notes, is_deleted, payment_category_id
 FROM accounts
 WHERE id = $1
 `
@@ -95,12 +96,13 @@ func (q *Queries) GetAccountByID(ctx context.Context, id uuid.UUID) (Account, er
 		&i.Name,
 		&i.Notes,
 		&i.IsDeleted,
+		&i.PaymentCategoryID,
 	)
 	return i, err
 }
 
 const getAccountsFromBudget = `-- name: GetAccountsFromBudget :many
-SELECT id, created_at, updated_at, budget_id, account_type, name, notes, is_deleted
+SELECT id, created_at, updated_at, budget_id, account_type, name, notes, is_deleted, payment_category_id
 FROM accounts
 WHERE budget_id = $1
 `
@@ -123,6 +125,7 @@ func (q *Queries) GetAccountsFromBudget(ctx context.Context, budgetID uuid.UUID)
 			&i.Name,
 			&i.Notes,
 			&i.IsDeleted,
+			&i.PaymentCategoryID,
 		); err != nil {
 			return nil, err
 		}
@@ -159,11 +162,27 @@ func (q *Queries) RestoreAccount(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const setAccountPaymentCategory = `-- name: SetAccountPaymentCategory :exec
+UPDATE accounts
+SET updated_at = NOW(), payment_category_id = $2
+WHERE id = $1
+`
+
+type SetAccountPaymentCategoryParams struct {
+	ID                uuid.UUID
+	PaymentCategoryID *uuid.UUID
+}
+
+func (q *Queries) SetAccountPaymentCategory(ctx context.Context, arg SetAccountPaymentCategoryParams) error {
+	_, err := q.db.Exec(ctx, setAccountPaymentCategory, arg.ID, arg.PaymentCategoryID)
+	return err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET updated_at = NOW(), name = $2, notes = $3
 WHERE id = $1
-RETURNING id, created_at, updated_at, budget_id, account_type, name, notes, is_deleted
+RETURNING id, created_at, updated_at, budget_id, account_type, name, notes, is_deleted, payment_category_id
 `
 
 type UpdateAccountParams struct {
@@ -184,6 +203,7 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Name,
 		&i.Notes,
 		&i.IsDeleted,
+		&i.PaymentCategoryID,
 	)
 	return i, err
 }
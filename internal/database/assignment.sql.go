@@ -53,6 +53,40 @@ func (q *Queries) DeleteMonthAssignmentForCat(ctx context.Context, arg DeleteMon
 	return err
 }
 
+const getBudgetMonthAssignments = `-- name: GetBudgetMonthAssignments :many
+SELECT a.month, a.category_id, a.assigned
+FROM assignments a
+JOIN categories c ON c.id = a.category_id
+WHERE c.budget_id = $1
+  AND a.month = DATE_TRUNC('month', $2::timestamp)
+ORDER BY a.category_id
+`
+
+type GetBudgetMonthAssignmentsParams struct {
+	BudgetID uuid.UUID
+	MonthID  time.Time
+}
+
+func (q *Queries) GetBudgetMonthAssignments(ctx context.Context, arg GetBudgetMonthAssignmentsParams) ([]Assignment, error) {
+	rows, err := q.db.Query(ctx, getBudgetMonthAssignments, arg.BudgetID, arg.MonthID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Assignment
+	for rows.Next() {
+		var i Assignment
+		if err := rows.Scan(&i.Month, &i.CategoryID, &i.Assigned); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const reassignAmountToCategory = `-- name: ReassignAmountToCategory :one
 INSERT INTO assignments (month, category_id, assigned)
 VALUES (
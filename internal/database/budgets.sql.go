@@ -54,7 +54,7 @@ VALUES (
     $2,
     $3
 )
-RETURNING id, created_at, updated_at, admin_id, name, notes
+RETURNING id, created_at, updated_at, admin_id, name, notes, overspending_rule
 `
 
 type CreateBudgetParams struct {
@@ -73,6 +73,39 @@ func (q *Queries) CreateBudget(ctx context.Context, arg CreateBudgetParams) (Bud
 		&i.AdminID,
 		&i.Name,
 		&i.Notes,
+		&i.OverspendingRule,
+	)
+	return i, err
+}
+
+const createBudgetMembership = `-- name: CreateBudgetMembership :one
+INSERT INTO memberships (created_at, updated_at, budget_id, user_id, member_role)
+VALUES (
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (budget_id, user_id) DO NOTHING
+RETURNING created_at, updated_at, budget_id, user_id, member_role
+`
+
+type CreateBudgetMembershipParams struct {
+	BudgetID   uuid.UUID
+	UserID     uuid.UUID
+	MemberRole string
+}
+
+func (q *Queries) CreateBudgetMembership(ctx context.Context, arg CreateBudgetMembershipParams) (Membership, error) {
+	row := q.db.QueryRow(ctx, createBudgetMembership, arg.BudgetID, arg.UserID, arg.MemberRole)
+	var i Membership
+	err := row.Scan(
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.BudgetID,
+		&i.UserID,
+		&i.MemberRole,
 	)
 	return i, err
 }
@@ -134,7 +167,7 @@ func (q *Queries) GetBudgetAccountIDByName(ctx context.Context, arg GetBudgetAcc
 }
 
 const getBudgetByID = `-- name: GetBudgetByID :one
-SELECT id, created_at, updated_at, admin_id, name, notes
+SELECT id, created_at, updated_at, admin_id, name, notes, overspending_rule
 FROM budgets
 WHERE id = $1
 `
@@ -149,6 +182,7 @@ func (q *Queries) GetBudgetByID(ctx context.Context, id uuid.UUID) (Budget, erro
 		&i.AdminID,
 		&i.Name,
 		&i.Notes,
+		&i.OverspendingRule,
 	)
 	return i, err
 }
@@ -250,8 +284,49 @@ func (q *Queries) GetBudgetPayeeIDByName(ctx context.Context, arg GetBudgetPayee
 	return id, err
 }
 
+const getSharedBudgetsByAdmin = `-- name: GetSharedBudgetsByAdmin :many
+SELECT id, created_at, updated_at, admin_id, name, notes, overspending_rule
+FROM budgets
+WHERE admin_id = $1
+  AND EXISTS (
+    SELECT 1
+    FROM memberships
+    WHERE memberships.budget_id = budgets.id
+      AND memberships.user_id <> budgets.admin_id
+  )
+ORDER BY name
+`
+
+func (q *Queries) GetSharedBudgetsByAdmin(ctx context.Context, adminID uuid.UUID) ([]Budget, error) {
+	rows, err := q.db.Query(ctx, getSharedBudgetsByAdmin, adminID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Budget
+	for rows.Next() {
+		var i Budget
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AdminID,
+			&i.Name,
+			&i.Notes,
+			&i.OverspendingRule,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserBudgets = `-- name: GetUserBudgets :many
-SELECT DISTINCT budgets.id, budgets.created_at, budgets.updated_at, budgets.admin_id, budgets.name, budgets.notes
+SELECT DISTINCT budgets.id, budgets.created_at, budgets.updated_at, budgets.admin_id, budgets.name, budgets.notes, budgets.overspending_rule
 FROM budgets
 JOIN memberships ON budgets.id = memberships.budget_id
 WHERE memberships.user_id = $1
@@ -283,6 +358,7 @@ func (q *Queries) GetUserBudgets(ctx context.Context, arg GetUserBudgetsParams)
 			&i.AdminID,
 			&i.Name,
 			&i.Notes,
+			&i.OverspendingRule,
 		); err != nil {
 			return nil, err
 		}
@@ -311,11 +387,27 @@ func (q *Queries) RevokeBudgetMembership(ctx context.Context, arg RevokeBudgetMe
 	return err
 }
 
+const setBudgetOverspendingRule = `-- name: SetBudgetOverspendingRule :exec
+UPDATE budgets
+SET updated_at = NOW(), overspending_rule = $2
+WHERE id = $1
+`
+
+type SetBudgetOverspendingRuleParams struct {
+	ID               uuid.UUID
+	OverspendingRule string
+}
+
+func (q *Queries) SetBudgetOverspendingRule(ctx context.Context, arg SetBudgetOverspendingRuleParams) error {
+	_, err := q.db.Exec(ctx, setBudgetOverspendingRule, arg.ID, arg.OverspendingRule)
+	return err
+}
+
 const updateBudget = `-- name: UpdateBudget :one
 UPDATE budgets
 SET updated_at = NOW(), name = $2, notes = $3
 WHERE id = $1
-RETURNING id, created_at, updated_at, admin_id, name, notes
+RETURNING id, created_at, updated_at, admin_id, name, notes, overspending_rule
 `
 
 type UpdateBudgetParams struct {
@@ -334,6 +426,35 @@ func (q *Queries) UpdateBudget(ctx context.Context, arg UpdateBudgetParams) (Bud
 		&i.AdminID,
 		&i.Name,
 		&i.Notes,
+		&i.OverspendingRule,
+	)
+	return i, err
+}
+
+const updateBudgetMemberRole = `-- name: UpdateBudgetMemberRole :one
+UPDATE memberships
+SET updated_at = NOW(), member_role = $3
+WHERE budget_id = $1
+    AND user_id = $2
+    AND member_role <> 'ADMIN'
+RETURNING created_at, updated_at, budget_id, user_id, member_role
+`
+
+type UpdateBudgetMemberRoleParams struct {
+	BudgetID   uuid.UUID
+	UserID     uuid.UUID
+	MemberRole string
+}
+
+func (q *Queries) UpdateBudgetMemberRole(ctx context.Context, arg UpdateBudgetMemberRoleParams) (Membership, error) {
+	row := q.db.QueryRow(ctx, updateBudgetMemberRole, arg.BudgetID, arg.UserID, arg.MemberRole)
+	var i Membership
+	err := row.Scan(
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.BudgetID,
+		&i.UserID,
+		&i.MemberRole,
 	)
 	return i, err
 }
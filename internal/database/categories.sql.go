@@ -22,7 +22,7 @@ VALUES (
     $3,
     $4
 )
-RETURNING id, created_at, updated_at, budget_id, name, group_id, notes
+RETURNING id, created_at, updated_at, budget_id, name, group_id, notes, overspending_rule
 `
 
 type CreateCategoryParams struct {
@@ -48,6 +48,7 @@ func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams)
 		&i.Name,
 		&i.GroupID,
 		&i.Notes,
+		&i.OverspendingRule,
 	)
 	return i, err
 }
@@ -64,7 +65,7 @@ func (q *Queries) DeleteCategoryByID(ctx context.Context, id uuid.UUID) error {
 }
 
 const getCategories = `-- name: GetCategories :many
-SELECT id, created_at, updated_at, budget_id, name, group_id, notes
+SELECT id, created_at, updated_at, budget_id, name, group_id, notes, overspending_rule
 FROM categories c
 WHERE c.budget_id = $1
   AND (
@@ -95,6 +96,7 @@ func (q *Queries) GetCategories(ctx context.Context, arg GetCategoriesParams) ([
 			&i.Name,
 			&i.GroupID,
 			&i.Notes,
+			&i.OverspendingRule,
 		); err != nil {
 			return nil, err
 		}
@@ -107,7 +109,7 @@ func (q *Queries) GetCategories(ctx context.Context, arg GetCategoriesParams) ([
 }
 
 const getCategoryByID = `-- name: GetCategoryByID :one
-SELECT id, created_at, updated_at, budget_id, name, group_id, notes
+SELECT id, created_at, updated_at, budget_id, name, group_id, notes, overspending_rule
 FROM categories
 WHERE id = $1
 `
@@ -123,15 +125,32 @@ func (q *Queries) GetCategoryByID(ctx context.Context, id uuid.UUID) (Category,
 		&i.Name,
 		&i.GroupID,
 		&i.Notes,
+		&i.OverspendingRule,
 	)
 	return i, err
 }
 
+const setCategoryOverspendingRule = `-- name: SetCategoryOverspendingRule :exec
+UPDATE categories
+SET updated_at = NOW(), overspending_rule = $2
+WHERE id = $1
+`
+
+type SetCategoryOverspendingRuleParams struct {
+	ID               uuid.UUID
+	OverspendingRule string
+}
+
+func (q *Queries) SetCategoryOverspendingRule(ctx context.Context, arg SetCategoryOverspendingRuleParams) error {
+	_, err := q.db.Exec(ctx, setCategoryOverspendingRule, arg.ID, arg.OverspendingRule)
+	return err
+}
+
 const updateCategory = `-- name: UpdateCategory :one
 UPDATE categories
 SET updated_at = NOW(), group_id = $2, name = $3, notes = $4
 WHERE id = $1
-RETURNING id, created_at, updated_at, budget_id, name, group_id, notes
+RETURNING id, created_at, updated_at, budget_id, name, group_id, notes, overspending_rule
 `
 
 type UpdateCategoryParams struct {
@@ -157,6 +176,7 @@ func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams)
 		&i.Name,
 		&i.GroupID,
 		&i.Notes,
+		&i.OverspendingRule,
 	)
 	return i, err
 }
@@ -12,14 +12,15 @@ import (
 )
 
 type Account struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	BudgetID    uuid.UUID
-	AccountType string
-	Name        string
-	Notes       string
-	IsDeleted   bool
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	BudgetID          uuid.UUID
+	AccountType       string
+	Name              string
+	Notes             string
+	IsDeleted         bool
+	PaymentCategoryID *uuid.UUID
 }
 
 type AccountTransfer struct {
@@ -27,29 +28,90 @@ type AccountTransfer struct {
 	ToTransactionID   uuid.UUID
 }
 
+type ApiKey struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	KeyPrefix  string
+	HashedKey  string
+	MaxRole    string
+	ExpiresAt  pgtype.Timestamp
+	LastUsedAt pgtype.Timestamp
+	RevokedAt  pgtype.Timestamp
+}
+
+type ApiKeyBudget struct {
+	ApiKeyID uuid.UUID
+	BudgetID uuid.UUID
+}
+
 type Assignment struct {
 	Month      time.Time
 	CategoryID uuid.UUID
 	Assigned   int64
 }
 
+type AuditLog struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	BudgetID     *uuid.UUID
+	ActorID      *uuid.UUID
+	ResourceType string
+	ResourceID   string
+	Action       string
+	Before       []byte
+	After        []byte
+}
+
 type Budget struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	AdminID   uuid.UUID
-	Name      string
-	Notes     string
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	AdminID          uuid.UUID
+	Name             string
+	Notes            string
+	OverspendingRule string
+}
+
+type BudgetInvitation struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	BudgetID    uuid.UUID
+	InviterID   *uuid.UUID
+	InviteeID   *uuid.UUID
+	HashedToken pgtype.Text
+	MemberRole  string
+	ExpiresAt   time.Time
+}
+
+type BudgetOwnershipTransfer struct {
+	BudgetID   uuid.UUID
+	CreatedAt  time.Time
+	FromUserID uuid.UUID
+	ToUserID   uuid.UUID
 }
 
 type Category struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	BudgetID  uuid.UUID
-	Name      string
-	GroupID   *uuid.UUID
-	Notes     string
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	BudgetID         uuid.UUID
+	Name             string
+	GroupID          *uuid.UUID
+	Notes            string
+	OverspendingRule string
+}
+
+type CategoryGoal struct {
+	CategoryID   uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	GoalType     string
+	TargetAmount int64
+	TargetDate   *time.Time
 }
 
 type Group struct {
@@ -61,6 +123,52 @@ type Group struct {
 	Notes     string
 }
 
+type ImportProfile struct {
+	AccountID       uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	HasHeader       bool
+	Delimiter       string
+	SkipRows        int32
+	DateColumn      string
+	DateFormat      string
+	AmountColumn    string
+	DebitColumn     string
+	CreditColumn    string
+	PayeeColumn     string
+	MemoColumn      string
+	CategoryColumn  string
+	DefaultCategory string
+	SignConvention  string
+}
+
+type ImportedTransaction struct {
+	AccountID     uuid.UUID
+	Fitid         string
+	CreatedAt     time.Time
+	TransactionID *uuid.UUID
+}
+
+type LoginThrottle struct {
+	Scope          string
+	Subject        string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	FailedAttempts int32
+	LastFailedAt   time.Time
+	BlockedUntil   pgtype.Timestamp
+}
+
+type MemberRestriction struct {
+	BudgetID             uuid.UUID
+	UserID               uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	AllowedAccountIds    []uuid.UUID
+	AllowedCategoryIds   []uuid.UUID
+	MaxTransactionAmount pgtype.Int8
+}
+
 type Membership struct {
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
@@ -69,6 +177,16 @@ type Membership struct {
 	MemberRole string
 }
 
+type OidcLogin struct {
+	State        string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	Nonce        string
+	CodeVerifier string
+	UserID       *uuid.UUID
+	BindingHash  string
+}
+
 type Payee struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
@@ -78,13 +196,60 @@ type Payee struct {
 	Notes     string
 }
 
+type Reconciliation struct {
+	ID                      uuid.UUID
+	CreatedAt               time.Time
+	AccountID               uuid.UUID
+	ReconcilerID            uuid.UUID
+	StatementDate           time.Time
+	StatementBalance        int64
+	AdjustmentTransactionID *uuid.UUID
+}
+
+type RecoveryCode struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UserID     uuid.UUID
+	HashedCode string
+	UsedAt     pgtype.Timestamp
+}
+
+type RecurringTransaction struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	BudgetID          uuid.UUID
+	LoggerID          uuid.UUID
+	AccountID         uuid.UUID
+	TransferAccountID *uuid.UUID
+	TransactionType   string
+	PayeeID           *uuid.UUID
+	Notes             string
+	Cleared           bool
+	Amounts           []byte
+	Frequency         string
+	FrequencyInterval int32
+	DayOption         string
+	StartDate         time.Time
+	EndDate           *time.Time
+	NextDate          *time.Time
+	FailureCount      int32
+	LastError         string
+	RetryAfter        pgtype.Timestamp
+	DisabledAt        pgtype.Timestamp
+}
+
 type RefreshToken struct {
-	Token     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	ExpiresAt time.Time
-	RevokedAt pgtype.Timestamp
+	Token      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	ExpiresAt  time.Time
+	RevokedAt  pgtype.Timestamp
+	FamilyID   uuid.UUID
+	UserAgent  string
+	IpAddress  string
+	LastUsedAt pgtype.Timestamp
 }
 
 type Transaction struct {
@@ -99,6 +264,7 @@ type Transaction struct {
 	PayeeID         uuid.UUID
 	Notes           string
 	Cleared         bool
+	Reconciled      bool
 }
 
 type TransactionDetail struct {
@@ -113,6 +279,7 @@ type TransactionDetail struct {
 	TotalAmount     int64
 	Splits          []byte
 	Cleared         bool
+	Reconciled      bool
 }
 
 type TransactionSplit struct {
@@ -122,6 +289,49 @@ type TransactionSplit struct {
 	Amount        int64
 }
 
+type TransactionVersion struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	TransactionID   uuid.UUID
+	EditorID        uuid.UUID
+	AccountID       uuid.UUID
+	TransactionType string
+	TransactionDate time.Time
+	PayeeID         uuid.UUID
+	Notes           string
+	Cleared         bool
+}
+
+type TransactionVersionSplit struct {
+	VersionID  uuid.UUID
+	CategoryID *uuid.UUID
+	Amount     int64
+}
+
+type TrashedTransaction struct {
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	BudgetID            uuid.UUID
+	LoggerID            uuid.UUID
+	AccountID           uuid.UUID
+	TransactionType     string
+	TransactionDate     time.Time
+	PayeeID             uuid.UUID
+	Notes               string
+	Cleared             bool
+	DeletedAt           time.Time
+	DeletedBy           uuid.UUID
+	LinkedTransactionID *uuid.UUID
+}
+
+type TrashedTransactionSplit struct {
+	ID            uuid.UUID
+	TransactionID uuid.UUID
+	CategoryID    *uuid.UUID
+	Amount        int64
+}
+
 type User struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
@@ -129,3 +339,19 @@ type User struct {
 	Username       string
 	HashedPassword string
 }
+
+type UserIdentity struct {
+	Issuer    string
+	Subject   string
+	CreatedAt time.Time
+	UserID    uuid.UUID
+}
+
+type UserTwoFactor struct {
+	UserID       uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	TotpSecret   string
+	EnabledAt    pgtype.Timestamp
+	LastUsedStep int64
+}
@@ -113,6 +113,10 @@ SELECT EXISTS (
   SELECT 1
   FROM transactions
   WHERE payee_id = $1
+) OR EXISTS (
+  SELECT 1
+  FROM recurring_transactions
+  WHERE payee_id = $1
 ) AS found
 `
 
@@ -123,6 +127,22 @@ func (q *Queries) IsPayeeInUse(ctx context.Context, payeeID uuid.UUID) (bool, er
 	return found, err
 }
 
+const reassignRecurringTransactions = `-- name: ReassignRecurringTransactions :exec
+UPDATE recurring_transactions
+SET updated_at = NOW(), payee_id = $1
+WHERE payee_id = $2
+`
+
+type ReassignRecurringTransactionsParams struct {
+	NewPayeeID *uuid.UUID
+	OldPayeeID *uuid.UUID
+}
+
+func (q *Queries) ReassignRecurringTransactions(ctx context.Context, arg ReassignRecurringTransactionsParams) error {
+	_, err := q.db.Exec(ctx, reassignRecurringTransactions, arg.NewPayeeID, arg.OldPayeeID)
+	return err
+}
+
 const reassignTransactions = `-- name: ReassignTransactions :exec
 UPDATE transactions
 SET payee_id = $1
@@ -12,21 +12,88 @@ import (
 	"github.com/google/uuid"
 )
 
+const getCategoryReportsBetween = `-- name: GetCategoryReportsBetween :many
+SELECT
+  month::date AS month,
+  category_id::uuid AS category_id,
+  category_name::text AS category_name,
+  assigned::bigint AS assigned,
+  activity::bigint AS activity,
+  balance::bigint AS balance
+FROM rep.get_category_reports(
+  $1::uuid,
+  $2::date,
+  $3::date
+)
+ORDER BY month, category_name
+`
+
+type GetCategoryReportsBetweenParams struct {
+	BudgetID  uuid.UUID
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+type GetCategoryReportsBetweenRow struct {
+	Month        time.Time
+	CategoryID   uuid.UUID
+	CategoryName string
+	Assigned     int64
+	Activity     int64
+	Balance      int64
+}
+
+func (q *Queries) GetCategoryReportsBetween(ctx context.Context, arg GetCategoryReportsBetweenParams) ([]GetCategoryReportsBetweenRow, error) {
+	rows, err := q.db.Query(ctx, getCategoryReportsBetween, arg.BudgetID, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategoryReportsBetweenRow
+	for rows.Next() {
+		var i GetCategoryReportsBetweenRow
+		if err := rows.Scan(
+			&i.Month,
+			&i.CategoryID,
+			&i.CategoryName,
+			&i.Assigned,
+			&i.Activity,
+			&i.Balance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getMonthCategoryReport = `-- name: GetMonthCategoryReport :one
 SELECT 
   $1::date AS month,
-  category_id::uuid AS category_id,
+  r.category_id::uuid AS category_id,
   COALESCE(g.id, '00000000-0000-0000-0000-000000000000')::uuid AS group_id,
   category_name::text AS category_name,
   COALESCE(assigned, 0)::bigint AS assigned,
   COALESCE(activity, 0)::bigint AS activity,
-  COALESCE(balance, 0)::bigint AS balance
+  COALESCE(balance, 0)::bigint AS balance,
+  COALESCE(needed, 0)::bigint AS needed,
+  COALESCE(underfunded, 0)::bigint AS underfunded,
+  COALESCE(cg.goal_type, '')::text AS goal_type,
+  COALESCE(cg.target_amount, 0)::bigint AS goal_target_amount,
+  cg.target_date AS goal_target_date,
+  COALESCE(r.overspending_rule, '')::text AS overspending_rule,
+  COALESCE(r.overspent, 0)::bigint AS overspent,
+  COALESCE(r.credit_overspent, 0)::bigint AS credit_overspent
 FROM rep.get_category_reports_gate(
   $2::uuid, 
   $1::date
 ) AS r
 LEFT JOIN categories c ON r.category_id = c.id
 LEFT JOIN groups g ON c.group_id = g.id
+LEFT JOIN category_goals cg ON r.category_id = cg.category_id
 WHERE r.category_id = $3::uuid
 LIMIT 1
 `
@@ -38,13 +105,21 @@ type GetMonthCategoryReportParams struct {
 }
 
 type GetMonthCategoryReportRow struct {
-	Month        time.Time
-	CategoryID   uuid.UUID
-	GroupID      uuid.UUID
-	CategoryName string
-	Assigned     int64
-	Activity     int64
-	Balance      int64
+	Month            time.Time
+	CategoryID       uuid.UUID
+	GroupID          uuid.UUID
+	CategoryName     string
+	Assigned         int64
+	Activity         int64
+	Balance          int64
+	Needed           int64
+	Underfunded      int64
+	GoalType         string
+	GoalTargetAmount int64
+	GoalTargetDate   *time.Time
+	OverspendingRule string
+	Overspent        int64
+	CreditOverspent  int64
 }
 
 func (q *Queries) GetMonthCategoryReport(ctx context.Context, arg GetMonthCategoryReportParams) (GetMonthCategoryReportRow, error) {
@@ -58,6 +133,14 @@ func (q *Queries) GetMonthCategoryReport(ctx context.Context, arg GetMonthCatego
 		&i.Assigned,
 		&i.Activity,
 		&i.Balance,
+		&i.Needed,
+		&i.Underfunded,
+		&i.GoalType,
+		&i.GoalTargetAmount,
+		&i.GoalTargetDate,
+		&i.OverspendingRule,
+		&i.Overspent,
+		&i.CreditOverspent,
 	)
 	return i, err
 }
@@ -65,18 +148,27 @@ func (q *Queries) GetMonthCategoryReport(ctx context.Context, arg GetMonthCatego
 const getMonthCategoryReports = `-- name: GetMonthCategoryReports :many
 SELECT 
   $1::date AS month,
-  category_id::uuid AS category_id,
+  r.category_id::uuid AS category_id,
   COALESCE(g.id, '00000000-0000-0000-0000-000000000000')::uuid AS group_id,
   category_name::text AS category_name,
   COALESCE(assigned, 0)::bigint AS assigned,
   COALESCE(activity, 0)::bigint AS activity,
-  COALESCE(balance, 0)::bigint AS balance
+  COALESCE(balance, 0)::bigint AS balance,
+  COALESCE(needed, 0)::bigint AS needed,
+  COALESCE(underfunded, 0)::bigint AS underfunded,
+  COALESCE(cg.goal_type, '')::text AS goal_type,
+  COALESCE(cg.target_amount, 0)::bigint AS goal_target_amount,
+  cg.target_date AS goal_target_date,
+  COALESCE(r.overspending_rule, '')::text AS overspending_rule,
+  COALESCE(r.overspent, 0)::bigint AS overspent,
+  COALESCE(r.credit_overspent, 0)::bigint AS credit_overspent
 FROM rep.get_category_reports_gate(
   $2::uuid, 
   $1::date
 ) AS r
 LEFT JOIN categories c ON r.category_id = c.id
 LEFT JOIN groups g ON c.group_id = g.id
+LEFT JOIN category_goals cg ON r.category_id = cg.category_id
 ORDER BY category_name
 `
 
@@ -86,13 +178,21 @@ type GetMonthCategoryReportsParams struct {
 }
 
 type GetMonthCategoryReportsRow struct {
-	Month        time.Time
-	CategoryID   uuid.UUID
-	GroupID      uuid.UUID
-	CategoryName string
-	Assigned     int64
-	Activity     int64
-	Balance      int64
+	Month            time.Time
+	CategoryID       uuid.UUID
+	GroupID          uuid.UUID
+	CategoryName     string
+	Assigned         int64
+	Activity         int64
+	Balance          int64
+	Needed           int64
+	Underfunded      int64
+	GoalType         string
+	GoalTargetAmount int64
+	GoalTargetDate   *time.Time
+	OverspendingRule string
+	Overspent        int64
+	CreditOverspent  int64
 }
 
 func (q *Queries) GetMonthCategoryReports(ctx context.Context, arg GetMonthCategoryReportsParams) ([]GetMonthCategoryReportsRow, error) {
@@ -112,6 +212,14 @@ func (q *Queries) GetMonthCategoryReports(ctx context.Context, arg GetMonthCateg
 			&i.Assigned,
 			&i.Activity,
 			&i.Balance,
+			&i.Needed,
+			&i.Underfunded,
+			&i.GoalType,
+			&i.GoalTargetAmount,
+			&i.GoalTargetDate,
+			&i.OverspendingRule,
+			&i.Overspent,
+			&i.CreditOverspent,
 		); err != nil {
 			return nil, err
 		}
@@ -266,3 +374,22 @@ func (q *Queries) GetMonthReport(ctx context.Context, arg GetMonthReportParams)
 	)
 	return i, err
 }
+
+const getReadyToAssign = `-- name: GetReadyToAssign :one
+SELECT rep.get_ready_to_assign(
+  $1::uuid,
+  $2::date
+)::bigint AS ready_to_assign
+`
+
+type GetReadyToAssignParams struct {
+	BudgetID uuid.UUID
+	MonthID  time.Time
+}
+
+func (q *Queries) GetReadyToAssign(ctx context.Context, arg GetReadyToAssignParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getReadyToAssign, arg.BudgetID, arg.MonthID)
+	var ready_to_assign int64
+	err := row.Scan(&ready_to_assign)
+	return ready_to_assign, err
+}
@@ -34,7 +34,7 @@ func (q *Queries) DeleteTransactionSplits(ctx context.Context, transactionID uui
 }
 
 const getLinkedTransaction = `-- name: GetLinkedTransaction :one
-SELECT t.id, t.created_at, t.updated_at, t.budget_id, t.logger_id, t.account_id, t.transaction_type, t.transaction_date, t.payee_id, t.notes, t.cleared
+SELECT t.id, t.created_at, t.updated_at, t.budget_id, t.logger_id, t.account_id, t.transaction_type, t.transaction_date, t.payee_id, t.notes, t.cleared, t.reconciled
 FROM transactions t
 JOIN account_transfers at
   ON (t.id = at.to_transaction_id AND at.from_transaction_id = $1)
@@ -56,6 +56,7 @@ func (q *Queries) GetLinkedTransaction(ctx context.Context, fromTransactionID uu
 		&i.PayeeID,
 		&i.Notes,
 		&i.Cleared,
+		&i.Reconciled,
 	)
 	return i, err
 }
@@ -92,7 +93,7 @@ func (q *Queries) GetSplitsByTransactionID(ctx context.Context, transactionID uu
 }
 
 const getTransactionByID = `-- name: GetTransactionByID :one
-SELECT id, created_at, updated_at, budget_id, logger_id, account_id, transaction_type, transaction_date, payee_id, notes, cleared
+SELECT id, created_at, updated_at, budget_id, logger_id, account_id, transaction_type, transaction_date, payee_id, notes, cleared, reconciled
 FROM transactions
 WHERE id = $1
 `
@@ -112,13 +113,14 @@ func (q *Queries) GetTransactionByID(ctx context.Context, id uuid.UUID) (Transac
 		&i.PayeeID,
 		&i.Notes,
 		&i.Cleared,
+		&i.Reconciled,
 	)
 	return i, err
 }
 
 const getTransactionDetails = `-- name: GetTransactionDetails :many
 
-SELECT td.id, td.transaction_date, td.transaction_type, td.notes, td.payee_name, td.budget_name, td.account_name, td.logger_name, td.total_amount, td.splits, td.cleared
+SELECT td.id, td.transaction_date, td.transaction_type, td.notes, td.payee_name, td.budget_name, td.account_name, td.logger_name, td.total_amount, td.splits, td.cleared, td.reconciled
 FROM transaction_details td
 JOIN transactions t ON td.id = t.id
 WHERE
@@ -143,16 +145,21 @@ WHERE
     ($5::date = '0001-01-01' AND $6::date = '0001-01-01')
     OR (t.transaction_date BETWEEN $5::date AND $6::date)
   )
+  AND (
+    $7::uuid[] IS NULL
+    OR t.account_id = ANY($7::uuid[])
+  )
 ORDER BY t.transaction_date DESC
 `
 
 type GetTransactionDetailsParams struct {
-	BudgetID   uuid.UUID
-	AccountID  uuid.UUID
-	PayeeID    uuid.UUID
-	CategoryID uuid.UUID
-	StartDate  time.Time
-	EndDate    time.Time
+	BudgetID          uuid.UUID
+	AccountID         uuid.UUID
+	PayeeID           uuid.UUID
+	CategoryID        uuid.UUID
+	StartDate         time.Time
+	EndDate           time.Time
+	VisibleAccountIds []uuid.UUID
 }
 
 // HACK:
@@ -169,6 +176,7 @@ func (q *Queries) GetTransactionDetails(ctx context.Context, arg GetTransactionD
 		arg.CategoryID,
 		arg.StartDate,
 		arg.EndDate,
+		arg.VisibleAccountIds,
 	)
 	if err != nil {
 		return nil, err
@@ -189,6 +197,7 @@ func (q *Queries) GetTransactionDetails(ctx context.Context, arg GetTransactionD
 			&i.TotalAmount,
 			&i.Splits,
 			&i.Cleared,
+			&i.Reconciled,
 		); err != nil {
 			return nil, err
 		}
@@ -201,7 +210,7 @@ func (q *Queries) GetTransactionDetails(ctx context.Context, arg GetTransactionD
 }
 
 const getTransactionDetailsByID = `-- name: GetTransactionDetailsByID :one
-SELECT id, transaction_date, transaction_type, notes, payee_name, budget_name, account_name, logger_name, total_amount, splits, cleared
+SELECT id, transaction_date, transaction_type, notes, payee_name, budget_name, account_name, logger_name, total_amount, splits, cleared, reconciled
 FROM transaction_details
 WHERE id = $1
 `
@@ -221,12 +230,13 @@ func (q *Queries) GetTransactionDetailsByID(ctx context.Context, id uuid.UUID) (
 		&i.TotalAmount,
 		&i.Splits,
 		&i.Cleared,
+		&i.Reconciled,
 	)
 	return i, err
 }
 
 const getTransactions = `-- name: GetTransactions :many
-SELECT t.id, t.created_at, t.updated_at, t.budget_id, t.logger_id, t.account_id, t.transaction_type, t.transaction_date, t.payee_id, t.notes, t.cleared
+SELECT t.id, t.created_at, t.updated_at, t.budget_id, t.logger_id, t.account_id, t.transaction_type, t.transaction_date, t.payee_id, t.notes, t.cleared, t.reconciled
 FROM transactions t
 WHERE
   budget_id = $1::uuid
@@ -250,16 +260,21 @@ WHERE
     ($5::date = '0001-01-01' AND $6::date = '0001-01-01')
     OR (t.transaction_date BETWEEN $5::date AND $6::date)
   )
+  AND (
+    $7::uuid[] IS NULL
+    OR t.account_id = ANY($7::uuid[])
+  )
 ORDER BY t.transaction_date DESC
 `
 
 type GetTransactionsParams struct {
-	BudgetID   uuid.UUID
-	AccountID  uuid.UUID
-	PayeeID    uuid.UUID
-	CategoryID uuid.UUID
-	StartDate  time.Time
-	EndDate    time.Time
+	BudgetID          uuid.UUID
+	AccountID         uuid.UUID
+	PayeeID           uuid.UUID
+	CategoryID        uuid.UUID
+	StartDate         time.Time
+	EndDate           time.Time
+	VisibleAccountIds []uuid.UUID
 }
 
 func (q *Queries) GetTransactions(ctx context.Context, arg GetTransactionsParams) ([]Transaction, error) {
@@ -270,6 +285,7 @@ func (q *Queries) GetTransactions(ctx context.Context, arg GetTransactionsParams
 		arg.CategoryID,
 		arg.StartDate,
 		arg.EndDate,
+		arg.VisibleAccountIds,
 	)
 	if err != nil {
 		return nil, err
@@ -290,6 +306,7 @@ func (q *Queries) GetTransactions(ctx context.Context, arg GetTransactionsParams
 			&i.PayeeID,
 			&i.Notes,
 			&i.Cleared,
+			&i.Reconciled,
 		); err != nil {
 			return nil, err
 		}
@@ -340,7 +357,7 @@ VALUES (
     $7,
     $8
 )
-RETURNING id, created_at, updated_at, budget_id, logger_id, account_id, transaction_type, transaction_date, payee_id, notes, cleared
+RETURNING id, created_at, updated_at, budget_id, logger_id, account_id, transaction_type, transaction_date, payee_id, notes, cleared, reconciled
 `
 
 type LogTransactionParams struct {
@@ -378,6 +395,7 @@ func (q *Queries) LogTransaction(ctx context.Context, arg LogTransactionParams)
 		&i.PayeeID,
 		&i.Notes,
 		&i.Cleared,
+		&i.Reconciled,
 	)
 	return i, err
 }
@@ -0,0 +1,197 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ActualOptions describes how transactions exported by Actual Budget should be read.
+type ActualOptions struct {
+	// IncomeCategories names the categories of the income group of the budget
+	// exported, which the export does not record. Defaults to "Income".
+	IncomeCategories []string
+}
+
+// actualStartingBalances is the category Actual Budget gives the opening
+// balance of each account.
+const actualStartingBalances = "Starting Balances"
+
+// actualColumns holds the position of each column of an Actual Budget
+// export within a row, or -1 where the export does not have the column.
+type actualColumns struct {
+	account, date, payee, notes, category, amount, splitAmount, cleared, reconciled int
+}
+
+// ParseActualCSV reads the transactions exported by Actual Budget as CSV
+// into the accounts they belong to, in the same form as a QIF file.
+// Income categories are listed as such, and opening balances are given no
+// category. Each row with a split amount is read as a split of the nearest
+// row before it without one, should that row be of the same account and date;
+// the amount of that row is the total of its splits.
+// Rows that cannot be read are returned with their Err field set;
+// an error is only returned where the file as a whole cannot be read.
+func ParseActualCSV(r io.Reader, opts ActualOptions) (*QIFFile, error) {
+	incomeCategories := opts.IncomeCategories
+	if len(incomeCategories) == 0 {
+		incomeCategories = []string{"Income"}
+	}
+
+	reader := newExportCSVReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("file has no header")
+		}
+		return nil, err
+	}
+	indices := headerIndices(header)
+	position := func(name string) int {
+		if i, ok := indices[name]; ok {
+			return i
+		}
+		return -1
+	}
+	for _, name := range []string{"Account", "Date", "Amount"} {
+		if _, ok := indices[name]; !ok {
+			return nil, fmt.Errorf("column '%s' not found in header", name)
+		}
+	}
+	cols := actualColumns{
+		account:     position("Account"),
+		date:        position("Date"),
+		payee:       position("Payee"),
+		notes:       position("Notes"),
+		category:    position("Category"),
+		amount:      position("Amount"),
+		splitAmount: position("Split_Amount"),
+		cleared:     position("Cleared"),
+		reconciled:  position("Reconciled"),
+	}
+
+	file := &QIFFile{}
+	for _, name := range incomeCategories {
+		file.Categories = append(file.Categories, QIFCategory{Name: name, Income: true})
+	}
+	accounts := map[string]*QIFAccount{}
+	// parent is the account and position of the latest row without a split amount
+	var parent *QIFAccount
+	parentIndex := -1
+	var splitTotal int64
+
+	finishSplits := func() {
+		if parent == nil {
+			return
+		}
+		txn := &parent.Transactions[parentIndex]
+		if len(txn.Splits) > 0 && splitTotal != txn.Amount && txn.Err == nil {
+			txn.Err = fmt.Errorf("splits total %d, but transaction amount is %d", splitTotal, txn.Amount)
+		}
+		parent, parentIndex = nil, -1
+	}
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isBlankRow(row) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		accountName := field(row, cols.account)
+		if accountName == "" {
+			return nil, fmt.Errorf("line %d does not name its account", line)
+		}
+		account, ok := accounts[accountName]
+		if !ok {
+			account = &QIFAccount{Name: accountName, Type: QIFBank}
+			accounts[accountName] = account
+			file.Accounts = append(file.Accounts, account)
+		}
+
+		txn := parseActualRow(row, line, &cols)
+		splitAmount := int64(0)
+		if val := field(row, cols.splitAmount); val != "" && txn.Err == nil {
+			if splitAmount, err = ParseAmount(val); err != nil {
+				txn.Err = err
+			}
+		}
+
+		if splitAmount != 0 && parent == account && parent.Transactions[parentIndex].Date.Equal(txn.Date) {
+			split := &parent.Transactions[parentIndex]
+			if txn.Err != nil && split.Err == nil {
+				split.Err = txn.Err
+			}
+			split.Splits = append(split.Splits, QIFSplit{
+				Category: txn.Category,
+				Transfer: txn.Transfer,
+				Memo:     txn.Memo,
+				Amount:   splitAmount,
+			})
+			split.Category, split.Transfer = "", ""
+			splitTotal += splitAmount
+			continue
+		}
+
+		finishSplits()
+		if splitAmount != 0 {
+			// a split with no transaction of its own to belong to
+			txn.Amount = splitAmount
+		} else {
+			parent, parentIndex, splitTotal = account, len(account.Transactions), 0
+		}
+		account.Transactions = append(account.Transactions, txn)
+	}
+	finishSplits()
+
+	if len(file.Accounts) == 0 {
+		return nil, fmt.Errorf("file does not contain any transactions")
+	}
+	return file, nil
+}
+
+func parseActualRow(row []string, line int, cols *actualColumns) QIFTransaction {
+	txn := QIFTransaction{Record: Record{
+		Line: line,
+		Memo: field(row, cols.notes),
+	}}
+
+	payee := field(row, cols.payee)
+	if m := ynabTransferPayee.FindStringSubmatch(payee); m != nil {
+		txn.Transfer = strings.TrimSpace(m[1])
+	} else {
+		txn.Payee = payee
+		if category := field(row, cols.category); category != actualStartingBalances {
+			txn.Category = category
+		}
+	}
+	for _, val := range []string{field(row, cols.cleared), field(row, cols.reconciled)} {
+		switch strings.ToLower(val) {
+		case "true", "cleared", "reconciled":
+			txn.Cleared = true
+		}
+	}
+
+	dateVal := field(row, cols.date)
+	date, err := time.Parse("2006-01-02", dateVal)
+	if err != nil {
+		txn.Err = fmt.Errorf("date '%s' is not of the form YYYY-MM-DD", dateVal)
+		return txn
+	}
+	txn.Date = date
+
+	amount, err := ParseAmount(field(row, cols.amount))
+	if err != nil {
+		txn.Err = err
+		return txn
+	}
+	txn.Amount = amount
+	return txn
+}
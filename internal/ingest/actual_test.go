@@ -0,0 +1,83 @@
+package ingest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testActualCSV = `Account,Date,Payee,Notes,Category,Amount,Split_Amount,Cleared
+Checking,2025-01-01,Starting Balance,,Starting Balances,1000.00,0,true
+Checking,2025-01-02,Employer,January,Income,2500.00,0,true
+Checking,2025-01-03,Corner Market,Weekly shop,,-112.40,0,false
+Checking,2025-01-03,Corner Market,Produce,Groceries,-112.40,-100.40,false
+Checking,2025-01-03,Transfer : Savings,,,-112.40,-12.00,false
+Savings,2025-01-03,Transfer : Checking,,,12.00,0,false
+Checking,2025-01-04,Hardware Store,,,-30.00,0,false
+Checking,2025-01-04,Hardware Store,,Home,-30.00,-20.00,false
+Checking,2025-01-05,Cafe,,Dining Out,-4.5x,0,false
+`
+
+func TestParseActualCSV(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	file, err := ParseActualCSV(strings.NewReader(testActualCSV), ActualOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectCategories := []QIFCategory{{Name: "Income", Income: true}}
+	if !reflect.DeepEqual(file.Categories, expectCategories) {
+		t.Errorf("want categories: %+v | actual: %+v", expectCategories, file.Categories)
+	}
+
+	if len(file.Accounts) != 2 {
+		t.Fatalf("want: 2 accounts | actual: %d accounts", len(file.Accounts))
+	}
+	checking, savings := file.Accounts[0], file.Accounts[1]
+
+	expectChecking := []QIFTransaction{
+		{
+			Record:  Record{Line: 2, Date: date(2025, time.January, 1), Amount: 100000, Payee: "Starting Balance"},
+			Cleared: true,
+		},
+		{
+			Record:  Record{Line: 3, Date: date(2025, time.January, 2), Amount: 250000, Payee: "Employer", Memo: "January", Category: "Income"},
+			Cleared: true,
+		},
+		{
+			Record: Record{Line: 4, Date: date(2025, time.January, 3), Amount: -11240, Payee: "Corner Market", Memo: "Weekly shop"},
+			Splits: []QIFSplit{
+				{Category: "Groceries", Memo: "Produce", Amount: -10040},
+				{Transfer: "Savings", Amount: -1200},
+			},
+		},
+	}
+	if len(checking.Transactions) != 5 {
+		t.Fatalf("want: 5 transactions | actual: %d transactions", len(checking.Transactions))
+	}
+	for i, want := range expectChecking {
+		actual := checking.Transactions[i]
+		if !reflect.DeepEqual(actual, want) {
+			t.Errorf("line %d: want: %+v | actual: %+v", actual.Line, want, actual)
+		}
+	}
+	if checking.Transactions[3].Err == nil {
+		t.Errorf("line %d: expected error for splits not matching total, but got none", checking.Transactions[3].Line)
+	}
+	if checking.Transactions[4].Err == nil {
+		t.Errorf("line %d: expected error for invalid amount, but got none", checking.Transactions[4].Line)
+	}
+
+	if len(savings.Transactions) != 1 || savings.Transactions[0].Transfer != "Checking" ||
+		savings.Transactions[0].Amount != 1200 {
+		t.Errorf("unexpected savings transactions: %+v", savings.Transactions)
+	}
+
+	if _, err := ParseActualCSV(strings.NewReader("Date,Payee\n"), ActualOptions{}); err == nil {
+		t.Errorf("expected error for file that is not an Actual Budget export, but got none")
+	}
+}
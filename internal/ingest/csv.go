@@ -0,0 +1,271 @@
+package ingest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Sign conventions used by CSV exports for a single amount column.
+const (
+	// DepositsPositive is used where money entering the account is positive.
+	DepositsPositive = "DEPOSITS_POSITIVE"
+	// WithdrawalsPositive is used where money leaving the account is positive,
+	// as is common for credit card exports.
+	WithdrawalsPositive = "WITHDRAWALS_POSITIVE"
+)
+
+// CSVMapping describes how the columns of a CSV export map onto transaction fields.
+// Columns are referred to by their header name where HasHeader is set,
+// and otherwise by their 1-based position.
+// Either AmountColumn, or one or both of DebitColumn and CreditColumn, must be set.
+type CSVMapping struct {
+	HasHeader bool
+	// Delimiter defaults to a comma.
+	Delimiter rune
+	// SkipRows is the number of lines to skip before the header (or first record).
+	SkipRows       int
+	DateColumn     string
+	DateFormat     string
+	AmountColumn   string
+	DebitColumn    string
+	CreditColumn   string
+	PayeeColumn    string
+	MemoColumn     string
+	CategoryColumn string
+	// SignConvention applies only to AmountColumn; debits are always
+	// treated as withdrawals and credits as deposits.
+	SignConvention string
+}
+
+// Validate reports whether the mapping can be used to parse a CSV file.
+func (m *CSVMapping) Validate() error {
+	if m.DateColumn == "" {
+		return fmt.Errorf("date column not provided")
+	}
+	if _, err := DateLayout(m.DateFormat); err != nil {
+		return err
+	}
+	if m.PayeeColumn == "" {
+		return fmt.Errorf("payee column not provided")
+	}
+	if m.AmountColumn == "" && m.DebitColumn == "" && m.CreditColumn == "" {
+		return fmt.Errorf("either an amount column or debit and credit columns must be provided")
+	}
+	if m.AmountColumn != "" && (m.DebitColumn != "" || m.CreditColumn != "") {
+		return fmt.Errorf("amount column may not be provided alongside debit or credit columns")
+	}
+	switch m.SignConvention {
+	case "", DepositsPositive, WithdrawalsPositive:
+	default:
+		return fmt.Errorf("invalid sign convention: %s", m.SignConvention)
+	}
+	if m.SkipRows < 0 {
+		return fmt.Errorf("rows to skip may not be negative")
+	}
+	if m.Delimiter == '"' || m.Delimiter == '\r' || m.Delimiter == '\n' || m.Delimiter == utf8.RuneError {
+		return fmt.Errorf("invalid delimiter")
+	}
+	if !m.HasHeader {
+		for _, col := range []string{m.DateColumn, m.AmountColumn, m.DebitColumn, m.CreditColumn, m.PayeeColumn, m.MemoColumn, m.CategoryColumn} {
+			if col == "" {
+				continue
+			}
+			if n, err := strconv.Atoi(col); err != nil || n < 1 {
+				return fmt.Errorf("column '%s' must be a position starting from 1 where the file has no header", col)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseCSV reads every record from a CSV export according to the given mapping.
+// Records that cannot be read are returned with their Err field set;
+// an error is only returned where the file as a whole cannot be read.
+func ParseCSV(r io.Reader, m CSVMapping) ([]Record, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	layout, _ := DateLayout(m.DateFormat)
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+	if m.Delimiter != 0 {
+		reader.Comma = m.Delimiter
+	}
+
+	for range m.SkipRows {
+		if _, err := reader.Read(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	// indices maps each header name onto its position within a row
+	indices := map[string]int{}
+	if m.HasHeader {
+		header, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("file has no header")
+			}
+			return nil, err
+		}
+		indices = headerIndices(header)
+	}
+	position := func(col string) (int, error) {
+		if col == "" {
+			return -1, nil
+		}
+		if m.HasHeader {
+			i, ok := indices[col]
+			if !ok {
+				return 0, fmt.Errorf("column '%s' not found in header", col)
+			}
+			return i, nil
+		}
+		n, _ := strconv.Atoi(col)
+		return n - 1, nil
+	}
+
+	var cols csvColumns
+	for _, c := range []struct {
+		name string
+		ptr  *int
+	}{
+		{m.DateColumn, &cols.date},
+		{m.AmountColumn, &cols.amount},
+		{m.DebitColumn, &cols.debit},
+		{m.CreditColumn, &cols.credit},
+		{m.PayeeColumn, &cols.payee},
+		{m.MemoColumn, &cols.memo},
+		{m.CategoryColumn, &cols.category},
+	} {
+		var err error
+		if *c.ptr, err = position(c.name); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				records = append(records, Record{Line: parseErr.StartLine, Err: err})
+				continue
+			}
+			return nil, err
+		}
+		if isBlankRow(row) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, parseCSVRow(row, line, layout, &m, &cols))
+	}
+	return records, nil
+}
+
+// csvColumns holds the position of each mapped column within a row,
+// or -1 where the column is not mapped.
+type csvColumns struct {
+	date, amount, debit, credit, payee, memo, category int
+}
+
+func field(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func parseCSVRow(row []string, line int, layout string, m *CSVMapping, cols *csvColumns) Record {
+	record := Record{
+		Line:     line,
+		Payee:    field(row, cols.payee),
+		Memo:     field(row, cols.memo),
+		Category: field(row, cols.category),
+	}
+
+	dateVal := field(row, cols.date)
+	date, err := time.Parse(layout, dateVal)
+	if err != nil {
+		record.Err = fmt.Errorf("date '%s' does not match format '%s'", dateVal, m.DateFormat)
+		return record
+	}
+	record.Date = date
+
+	if cols.amount >= 0 {
+		amount, err := ParseAmount(field(row, cols.amount))
+		if err != nil {
+			record.Err = err
+			return record
+		}
+		if m.SignConvention == WithdrawalsPositive {
+			amount = -amount
+		}
+		record.Amount = amount
+	} else {
+		var debit, credit int64
+		if val := field(row, cols.debit); val != "" {
+			if debit, err = ParseAmount(val); err != nil {
+				record.Err = err
+				return record
+			}
+		}
+		if val := field(row, cols.credit); val != "" {
+			if credit, err = ParseAmount(val); err != nil {
+				record.Err = err
+				return record
+			}
+		}
+		record.Amount = abs(credit) - abs(debit)
+	}
+
+	if record.Payee == "" {
+		record.Err = fmt.Errorf("payee not found in row")
+	}
+	return record
+}
+
+// headerIndices maps each name of a header row onto its position.
+// Where a name is repeated, the first position is kept.
+func headerIndices(header []string) map[string]int {
+	indices := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if _, ok := indices[name]; !ok {
+			indices[name] = i
+		}
+	}
+	return indices
+}
+
+func isBlankRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
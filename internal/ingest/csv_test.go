@@ -0,0 +1,158 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		mapping CSVMapping
+		expect  []Record
+		// expectErrLines lists the lines of records expected to fail
+		expectErrLines []int
+		wantErr        bool
+	}{
+		{
+			name: "Single amount column with header",
+			input: "Date,Description,Amount,Notes\n" +
+				"2025-03-01,Grocer,-45.10,weekly shop\n" +
+				"2025-03-02,Employer,\"1,500.00\",\n",
+			mapping: CSVMapping{
+				HasHeader:    true,
+				DateColumn:   "Date",
+				DateFormat:   "YYYY-MM-DD",
+				AmountColumn: "Amount",
+				PayeeColumn:  "Description",
+				MemoColumn:   "Notes",
+			},
+			expect: []Record{
+				{Line: 2, Date: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Amount: -4510, Payee: "Grocer", Memo: "weekly shop"},
+				{Line: 3, Date: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), Amount: 150000, Payee: "Employer"},
+			},
+		},
+		{
+			name: "Debit and credit columns without header",
+			input: "Statement for account 1234\n" +
+				"03/01/2025;Grocer;45.10;\n" +
+				"03/02/2025;Employer;;1500\n",
+			mapping: CSVMapping{
+				Delimiter:    ';',
+				SkipRows:     1,
+				DateColumn:   "1",
+				DateFormat:   "MM/DD/YYYY",
+				PayeeColumn:  "2",
+				DebitColumn:  "3",
+				CreditColumn: "4",
+			},
+			expect: []Record{
+				{Line: 2, Date: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Amount: -4510, Payee: "Grocer"},
+				{Line: 3, Date: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC), Amount: 150000, Payee: "Employer"},
+			},
+		},
+		{
+			name: "Withdrawals positive",
+			input: "Posted,Merchant,Charge\n" +
+				"2025-03-01,Cafe,4.50\n" +
+				"2025-03-05,Payment Thank You,-100.00\n",
+			mapping: CSVMapping{
+				HasHeader:      true,
+				DateColumn:     "Posted",
+				DateFormat:     "YYYY-MM-DD",
+				AmountColumn:   "Charge",
+				PayeeColumn:    "Merchant",
+				SignConvention: WithdrawalsPositive,
+			},
+			expect: []Record{
+				{Line: 2, Date: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Amount: -450, Payee: "Cafe"},
+				{Line: 3, Date: time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC), Amount: 10000, Payee: "Payment Thank You"},
+			},
+		},
+		{
+			name: "Bad rows are reported and blank rows skipped",
+			input: "Date,Payee,Amount\n" +
+				"2025-13-01,Grocer,-1.00\n" +
+				"\n" +
+				"2025-03-02,,-1.00\n" +
+				"2025-03-03,Grocer,abc\n" +
+				"2025-03-04,Grocer,-2.00\n",
+			mapping: CSVMapping{
+				HasHeader:    true,
+				DateColumn:   "Date",
+				DateFormat:   "YYYY-MM-DD",
+				AmountColumn: "Amount",
+				PayeeColumn:  "Payee",
+			},
+			expect: []Record{
+				{Line: 2},
+				{Line: 4},
+				{Line: 5},
+				{Line: 6, Date: time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC), Amount: -200, Payee: "Grocer"},
+			},
+			expectErrLines: []int{2, 4, 5},
+		},
+		{
+			name:  "Mapped column missing from header",
+			input: "Date,Payee,Amount\n",
+			mapping: CSVMapping{
+				HasHeader:    true,
+				DateColumn:   "Date",
+				DateFormat:   "YYYY-MM-DD",
+				AmountColumn: "Value",
+				PayeeColumn:  "Payee",
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Amount alongside debit column",
+			input: "",
+			mapping: CSVMapping{
+				DateColumn:   "1",
+				DateFormat:   "YYYY-MM-DD",
+				AmountColumn: "2",
+				DebitColumn:  "3",
+				PayeeColumn:  "4",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParseCSV(strings.NewReader(tt.input), tt.mapping)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error: %v | actual: %v", tt.wantErr, err)
+			}
+			if len(actual) != len(tt.expect) {
+				t.Fatalf("want: %d records | actual: %d records (%v)", len(tt.expect), len(actual), actual)
+			}
+			errLines := map[int]bool{}
+			for _, line := range tt.expectErrLines {
+				errLines[line] = true
+			}
+			for i, record := range actual {
+				want := tt.expect[i]
+				if record.Line != want.Line {
+					t.Errorf("record %d: want line: %d | actual: %d", i, want.Line, record.Line)
+				}
+				if errLines[record.Line] {
+					if record.Err == nil {
+						t.Errorf("line %d: expected error, but got none", record.Line)
+					}
+					continue
+				}
+				if record.Err != nil {
+					t.Errorf("line %d: unexpected error: %v", record.Line, record.Err)
+					continue
+				}
+				if !record.Date.Equal(want.Date) || record.Amount != want.Amount ||
+					record.Payee != want.Payee || record.Memo != want.Memo {
+					t.Errorf("line %d: want: %+v | actual: %+v", record.Line, want, record)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package ingest parses transactions exported by banks and other budgeting tools
+package ingest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is a single transaction read from an imported file.
+// Amounts are in minor currency units (e.g. cents), and are
+// positive for money entering the account.
+type Record struct {
+	// Line is the line (or entry) of the file the record was read from.
+	Line int
+	// ID is the identifier assigned to the transaction by its source, if any,
+	// such as the FITID of an OFX transaction.
+	ID       string
+	Date     time.Time
+	Amount   int64
+	Payee    string
+	Memo     string
+	Category string
+	// Err holds any reason the record could not be read.
+	// All other fields should be disregarded where it is set.
+	Err error
+}
+
+// ParseAmount parses a decimal currency amount into minor units.
+// Currency symbols, thousands separators and surrounding whitespace are ignored.
+// Negative amounts may be written with a leading or trailing minus sign,
+// or within parentheses.
+func ParseAmount(s string) (int64, error) {
+	raw := s
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+
+	var digits strings.Builder
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9', c == '.':
+			digits.WriteRune(c)
+		case c == '-':
+			negative = !negative
+		case c == '+', c == ',', c == ' ', c == '$', c == '€', c == '£', c == '¥':
+		default:
+			return 0, fmt.Errorf("invalid amount: %q", raw)
+		}
+	}
+
+	whole, frac, _ := strings.Cut(digits.String(), ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount: %q", raw)
+	}
+	if len(frac) > 2 && strings.Trim(frac[2:], "0") == "" {
+		frac = frac[:2]
+	}
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("amount has more than two decimal places: %q", raw)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %q", raw)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// dateFormatTokens maps the tokens of a human-readable date format
+// onto those of a Go time layout, longest tokens first.
+var dateFormatTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"},
+	{"MMM", "Jan"},
+	{"YY", "06"},
+	{"MM", "01"},
+	{"DD", "02"},
+	{"M", "1"},
+	{"D", "2"},
+}
+
+// DateLayout converts a human-readable date format, such as "MM/DD/YYYY",
+// into a layout that can be used with time.Parse.
+// Characters other than the tokens YYYY, YY, MMM, MM, M, DD and D are kept as-is.
+func DateLayout(format string) (string, error) {
+	if format == "" {
+		return "", fmt.Errorf("date format not provided")
+	}
+	var layout strings.Builder
+	var hasYear, hasMonth, hasDay bool
+	for i := 0; i < len(format); {
+		matched := false
+		for _, t := range dateFormatTokens {
+			if strings.HasPrefix(format[i:], t.token) {
+				layout.WriteString(t.layout)
+				switch t.token[0] {
+				case 'Y':
+					hasYear = true
+				case 'M':
+					hasMonth = true
+				case 'D':
+					hasDay = true
+				}
+				i += len(t.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			if c := format[i]; (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+				return "", fmt.Errorf("unrecognized character '%c' in date format '%s'", c, format)
+			}
+			layout.WriteByte(format[i])
+			i++
+		}
+	}
+	if !hasYear || !hasMonth || !hasDay {
+		return "", fmt.Errorf("date format '%s' must include a year, month and day", format)
+	}
+	return layout.String(), nil
+}
@@ -0,0 +1,69 @@
+package ingest
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		expect  int64
+		wantErr bool
+	}{
+		{name: "Whole number", input: "12", expect: 1200},
+		{name: "Decimal", input: "12.34", expect: 1234},
+		{name: "Single decimal place", input: "0.5", expect: 50},
+		{name: "Leading decimal point", input: ".99", expect: 99},
+		{name: "Currency and thousands separator", input: "$1,234.56", expect: 123456},
+		{name: "Leading minus", input: "-42.10", expect: -4210},
+		{name: "Trailing minus", input: "42.10-", expect: -4210},
+		{name: "Minus before currency", input: "-$5.00", expect: -500},
+		{name: "Parentheses", input: "(7.25)", expect: -725},
+		{name: "Surrounding whitespace", input: "  3.00 ", expect: 300},
+		{name: "Insignificant decimal places", input: "1.2300", expect: 123},
+		{name: "Empty", input: "", wantErr: true},
+		{name: "Letters", input: "12abc", wantErr: true},
+		{name: "Too many decimal places", input: "1.234", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParseAmount(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error: %v | actual: %v", tt.wantErr, err)
+			}
+			if actual != tt.expect {
+				t.Errorf("want: %v | actual: %v", tt.expect, actual)
+			}
+		})
+	}
+}
+
+func TestDateLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		expect  string
+		wantErr bool
+	}{
+		{name: "ISO", input: "YYYY-MM-DD", expect: "2006-01-02"},
+		{name: "US", input: "MM/DD/YYYY", expect: "01/02/2006"},
+		{name: "Unpadded", input: "M/D/YY", expect: "1/2/06"},
+		{name: "Month abbreviation", input: "DD MMM YYYY", expect: "02 Jan 2006"},
+		{name: "Compact", input: "YYYYMMDD", expect: "20060102"},
+		{name: "Missing day", input: "YYYY-MM", wantErr: true},
+		{name: "Unknown token", input: "YYYY-MM-DDTHH", wantErr: true},
+		{name: "Empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := DateLayout(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error: %v | actual: %v", tt.wantErr, err)
+			}
+			if actual != tt.expect {
+				t.Errorf("want: %v | actual: %v", tt.expect, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package ingest
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// Statement holds the transactions of a single account read from an OFX file.
+type Statement struct {
+	// AccountID is the account number given by the financial institution.
+	AccountID string
+	Currency  string
+	Records   []Record
+	// LedgerBalance is the balance of the account as reported by the
+	// financial institution, or nil where the file did not include one.
+	LedgerBalance *Balance
+}
+
+// Balance is an account balance, in minor currency units, as of a point in time.
+type Balance struct {
+	Amount int64
+	AsOf   time.Time
+}
+
+// ParseOFX reads every bank or credit card statement from an OFX (or QFX) file.
+// Both OFX 1.x, which is SGML that may omit the closing tags of elements,
+// and OFX 2.x, which is XML, are supported.
+// Records are numbered by their position within the file, starting from 1.
+func ParseOFX(r io.Reader) ([]Statement, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	body := string(data)
+	start := strings.Index(strings.ToUpper(body), "<OFX>")
+	if start < 0 {
+		return nil, fmt.Errorf("file does not contain an OFX document")
+	}
+	body = body[start:]
+
+	var statements []Statement
+	var stmt *Statement
+	var txn, bal map[string]string
+	entry := 0
+
+	for len(body) > 0 {
+		open := strings.IndexByte(body, '<')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(body[open:], '>')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated tag in OFX document")
+		}
+		tag := strings.ToUpper(strings.TrimSpace(body[open+1 : open+end]))
+		body = body[open+end+1:]
+
+		// the value of an element runs until the next tag
+		value := body
+		if next := strings.IndexByte(body, '<'); next >= 0 {
+			value = body[:next]
+		}
+		value = strings.TrimSpace(html.UnescapeString(value))
+
+		switch tag {
+		case "STMTRS", "CCSTMTRS":
+			stmt = &Statement{}
+		case "/STMTRS", "/CCSTMTRS":
+			if stmt != nil {
+				statements = append(statements, *stmt)
+			}
+			stmt = nil
+		case "STMTTRN":
+			txn = map[string]string{}
+		case "/STMTTRN":
+			if stmt != nil && txn != nil {
+				entry++
+				stmt.Records = append(stmt.Records, ofxRecord(txn, entry))
+			}
+			txn = nil
+		case "LEDGERBAL":
+			bal = map[string]string{}
+		case "/LEDGERBAL":
+			if stmt != nil && bal != nil {
+				balance, err := ofxBalance(bal)
+				if err != nil {
+					return nil, err
+				}
+				stmt.LedgerBalance = balance
+			}
+			bal = nil
+		default:
+			if strings.HasPrefix(tag, "/") || strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!") {
+				continue
+			}
+			switch {
+			case txn != nil:
+				// the elements of any aggregate nested within the
+				// transaction, such as PAYEE, are read as its own
+				txn[tag] = value
+			case bal != nil:
+				bal[tag] = value
+			case stmt != nil && tag == "ACCTID":
+				stmt.AccountID = value
+			case stmt != nil && tag == "CURDEF":
+				stmt.Currency = value
+			}
+		}
+	}
+	if stmt != nil {
+		return nil, fmt.Errorf("statement was not terminated")
+	}
+	return statements, nil
+}
+
+func ofxRecord(fields map[string]string, entry int) Record {
+	record := Record{
+		Line: entry,
+		ID:   fields["FITID"],
+		Memo: fields["MEMO"],
+	}
+	record.Payee = fields["NAME"]
+	if record.Payee == "" {
+		record.Payee, record.Memo = record.Memo, ""
+	}
+	if checkNum := fields["CHECKNUM"]; checkNum != "" && record.Payee == "" {
+		record.Payee = "Check " + checkNum
+	}
+
+	date, err := parseOFXDate(fields["DTPOSTED"])
+	if err != nil {
+		record.Err = err
+		return record
+	}
+	record.Date = date
+
+	amount, err := ParseAmount(normalizeOFXAmount(fields["TRNAMT"]))
+	if err != nil {
+		record.Err = err
+		return record
+	}
+	record.Amount = amount
+
+	if record.Payee == "" {
+		record.Err = fmt.Errorf("payee not found in transaction")
+	}
+	return record
+}
+
+func ofxBalance(fields map[string]string) (*Balance, error) {
+	amount, err := ParseAmount(normalizeOFXAmount(fields["BALAMT"]))
+	if err != nil {
+		return nil, fmt.Errorf("could not read ledger balance: %w", err)
+	}
+	asOf, err := parseOFXDate(fields["DTASOF"])
+	if err != nil {
+		return nil, fmt.Errorf("could not read ledger balance: %w", err)
+	}
+	return &Balance{Amount: amount, AsOf: asOf}, nil
+}
+
+// parseOFXDate parses the date portion of an OFX datetime,
+// such as 20250301, 20250301120000 or 20250301120000.000[-5:EST].
+func parseOFXDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("invalid OFX date: %q", s)
+	}
+	date, err := time.Parse("20060102", s[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid OFX date: %q", s)
+	}
+	return date, nil
+}
+
+// normalizeOFXAmount accounts for institutions that use a comma
+// as their decimal separator.
+func normalizeOFXAmount(s string) string {
+	if strings.Contains(s, ",") && !strings.Contains(s, ".") {
+		return strings.Replace(s, ",", ".", 1)
+	}
+	return s
+}
@@ -0,0 +1,192 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testOFXSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+CHARSET:1252
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS><DTSERVER>20250305120000<LANGUAGE>ENG</SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<TRNUID>1
+<STMTRS>
+<CURDEF>USD
+<BANKACCTFROM>
+<BANKID>123456789
+<ACCTID>000111222
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20250301
+<DTEND>20250305
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20250301120000.000[-5:EST]
+<TRNAMT>-45.10
+<FITID>2025030101
+<NAME>CORNER MARKET &amp; DELI
+<MEMO>POS PURCHASE
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20250302
+<TRNAMT>1500.00
+<FITID>2025030201
+<MEMO>PAYROLL WEBFLYX
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CHECK
+<DTPOSTED>20250303
+<TRNAMT>-80.00
+<FITID>2025030301
+<CHECKNUM>1042
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>BADDATE
+<TRNAMT>-1.00
+<FITID>2025030401
+<NAME>BAD
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>1374.90
+<DTASOF>20250305
+</LEDGERBAL>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+const testOFXXML = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <TRNUID>1</TRNUID>
+      <CCSTMTRS>
+        <CURDEF>USD</CURDEF>
+        <CCACCTFROM><ACCTID>4111000011112222</ACCTID></CCACCTFROM>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <TRNTYPE>DEBIT</TRNTYPE>
+            <DTPOSTED>20250310000000</DTPOSTED>
+            <TRNAMT>-4.50</TRNAMT>
+            <FITID>CC-1</FITID>
+            <PAYEE><NAME>Cafe</NAME><ADDR1>1 Main St</ADDR1></PAYEE>
+          </STMTTRN>
+        </BANKTRANLIST>
+        <LEDGERBAL>
+          <BALAMT>-4.50</BALAMT>
+          <DTASOF>20250310000000</DTASOF>
+        </LEDGERBAL>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFX(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name          string
+		input         string
+		expectAccount string
+		expect        []Record
+		// expectErrLines lists the entries expected to fail
+		expectErrLines []int
+		expectBalance  *Balance
+		wantErr        bool
+	}{
+		{
+			name:          "OFX 1.x SGML",
+			input:         testOFXSGML,
+			expectAccount: "000111222",
+			expect: []Record{
+				{Line: 1, ID: "2025030101", Date: date(2025, time.March, 1), Amount: -4510, Payee: "CORNER MARKET & DELI", Memo: "POS PURCHASE"},
+				{Line: 2, ID: "2025030201", Date: date(2025, time.March, 2), Amount: 150000, Payee: "PAYROLL WEBFLYX"},
+				{Line: 3, ID: "2025030301", Date: date(2025, time.March, 3), Amount: -8000, Payee: "Check 1042"},
+				{Line: 4},
+			},
+			expectErrLines: []int{4},
+			expectBalance:  &Balance{Amount: 137490, AsOf: date(2025, time.March, 5)},
+		},
+		{
+			name:          "OFX 2.x XML",
+			input:         testOFXXML,
+			expectAccount: "4111000011112222",
+			expect: []Record{
+				{Line: 1, ID: "CC-1", Date: date(2025, time.March, 10), Amount: -450, Payee: "Cafe"},
+			},
+			expectBalance: &Balance{Amount: -450, AsOf: date(2025, time.March, 10)},
+		},
+		{
+			name:    "Not OFX",
+			input:   "Date,Payee,Amount\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := ParseOFX(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error: %v | actual: %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(statements) != 1 {
+				t.Fatalf("want: 1 statement | actual: %d statements", len(statements))
+			}
+			stmt := statements[0]
+			if stmt.AccountID != tt.expectAccount {
+				t.Errorf("want account: %v | actual: %v", tt.expectAccount, stmt.AccountID)
+			}
+			if stmt.LedgerBalance == nil || stmt.LedgerBalance.Amount != tt.expectBalance.Amount ||
+				!stmt.LedgerBalance.AsOf.Equal(tt.expectBalance.AsOf) {
+				t.Errorf("want balance: %+v | actual: %+v", tt.expectBalance, stmt.LedgerBalance)
+			}
+			if len(stmt.Records) != len(tt.expect) {
+				t.Fatalf("want: %d records | actual: %d records (%v)", len(tt.expect), len(stmt.Records), stmt.Records)
+			}
+			errLines := map[int]bool{}
+			for _, line := range tt.expectErrLines {
+				errLines[line] = true
+			}
+			for i, record := range stmt.Records {
+				want := tt.expect[i]
+				if errLines[record.Line] {
+					if record.Err == nil {
+						t.Errorf("entry %d: expected error, but got none", record.Line)
+					}
+					continue
+				}
+				if record.Err != nil {
+					t.Errorf("entry %d: unexpected error: %v", record.Line, record.Err)
+					continue
+				}
+				if record.Line != want.Line || record.ID != want.ID || !record.Date.Equal(want.Date) ||
+					record.Amount != want.Amount || record.Payee != want.Payee || record.Memo != want.Memo {
+					t.Errorf("entry %d: want: %+v | actual: %+v", record.Line, want, record)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package ingest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QIF account types, as given by the T field of an account,
+// or the !Type header of a list of transactions.
+const (
+	QIFBank       = "Bank"
+	QIFCash       = "Cash"
+	QIFCreditCard = "CCard"
+	QIFAsset      = "Oth A"
+	QIFLiability  = "Oth L"
+	QIFInvestment = "Invst"
+)
+
+// QIFFile holds the accounts and categories read from a QIF file.
+type QIFFile struct {
+	Accounts   []*QIFAccount
+	Categories []QIFCategory
+}
+
+// QIFAccount is an account read from a QIF file, along with its transactions.
+// Transactions listed in a file with no !Account blocks
+// belong to an account with no name.
+type QIFAccount struct {
+	Name         string
+	Type         string
+	Description  string
+	Transactions []QIFTransaction
+}
+
+// QIFCategory is a category listed by the !Type:Cat block of a QIF file.
+// Names of subcategories take the form "Parent:Child".
+type QIFCategory struct {
+	Name        string
+	Description string
+	Income      bool
+}
+
+// QIFTransaction is a transaction read from a QIF file.
+// The Category of the embedded Record is empty where the transaction
+// is a transfer, or is split.
+type QIFTransaction struct {
+	Record
+	Cleared bool
+	// Transfer is the name of the account the transaction
+	// transfers money to or from, if any.
+	Transfer string
+	Splits   []QIFSplit
+}
+
+// QIFSplit is a single split of a QIF transaction.
+type QIFSplit struct {
+	Category string
+	Transfer string
+	Memo     string
+	Amount   int64
+}
+
+// QIFOptions configures how a QIF file is read.
+type QIFOptions struct {
+	// DayFirst reads dates as D/M/Y rather than M/D/Y.
+	DayFirst bool
+}
+
+// ParseQIF reads the accounts, categories and transactions of a QIF file.
+// Records are numbered by the line of the file on which they begin.
+// Investment transactions are not supported, and are read as records with an error.
+func ParseQIF(r io.Reader, opts QIFOptions) (*QIFFile, error) {
+	file := &QIFFile{}
+	accounts := map[string]*QIFAccount{}
+	getAccount := func(name string) *QIFAccount {
+		if account, ok := accounts[name]; ok {
+			return account
+		}
+		account := &QIFAccount{Name: name}
+		accounts[name] = account
+		file.Accounts = append(file.Accounts, account)
+		return account
+	}
+
+	const (
+		sectionNone = iota
+		sectionAccount
+		sectionCategory
+		sectionTransaction
+	)
+	section := sectionNone
+	autoSwitch := false
+	var current *QIFAccount
+	var accountFields map[byte]string
+	var txn *qifTxnBuilder
+	var category *QIFCategory
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if line[0] == '!' {
+			header := strings.TrimSpace(line[1:])
+			switch {
+			case strings.EqualFold(header, "Account"):
+				section = sectionAccount
+			case strings.EqualFold(header, "Option:AutoSwitch"):
+				autoSwitch = true
+				section = sectionNone
+			case strings.EqualFold(header, "Clear:AutoSwitch"):
+				autoSwitch = false
+				section = sectionNone
+			case strings.EqualFold(header, "Type:Cat"):
+				section = sectionCategory
+			case strings.HasPrefix(strings.ToLower(header), "type:"):
+				accountType, ok := qifAccountType(header[len("type:"):])
+				if !ok {
+					// lists of classes, securities, memorized transactions and the like
+					section = sectionNone
+					continue
+				}
+				section = sectionTransaction
+				if current == nil {
+					current = getAccount("")
+				}
+				if current.Type == "" {
+					current.Type = accountType
+				}
+			default:
+				section = sectionNone
+			}
+			accountFields, txn, category = nil, nil, nil
+			continue
+		}
+
+		code, value := line[0], strings.TrimSpace(line[1:])
+		switch section {
+		case sectionAccount:
+			if accountFields == nil {
+				accountFields = map[byte]string{}
+			}
+			if code != '^' {
+				accountFields[code] = value
+				continue
+			}
+			if name := accountFields['N']; name != "" {
+				account := getAccount(name)
+				if accountType, ok := qifAccountType(accountFields['T']); ok {
+					account.Type = accountType
+				}
+				if accountFields['D'] != "" {
+					account.Description = accountFields['D']
+				}
+				// while AutoSwitch is set, accounts are only being listed
+				if !autoSwitch {
+					current = account
+				}
+			}
+			accountFields = nil
+		case sectionCategory:
+			if category == nil {
+				category = &QIFCategory{}
+			}
+			switch code {
+			case 'N':
+				category.Name = value
+			case 'D':
+				category.Description = value
+			case 'I':
+				category.Income = true
+			case '^':
+				if category.Name != "" {
+					file.Categories = append(file.Categories, *category)
+				}
+				category = nil
+			}
+		case sectionTransaction:
+			if txn == nil {
+				txn = &qifTxnBuilder{
+					txn:    QIFTransaction{Record: Record{Line: lineNum}},
+					fields: map[byte]string{},
+				}
+			}
+			if code != '^' {
+				txn.read(code, value)
+				continue
+			}
+			txn.finish(current.Type, opts)
+			current.Transactions = append(current.Transactions, txn.txn)
+			txn = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(file.Accounts) == 0 && len(file.Categories) == 0 {
+		return nil, fmt.Errorf("file does not contain any QIF accounts or categories")
+	}
+	return file, nil
+}
+
+// qifTxnBuilder collects the fields of a transaction until it is complete.
+type qifTxnBuilder struct {
+	txn    QIFTransaction
+	fields map[byte]string
+	// splitAmountRead is set once the amount of the latest split is read
+	splitAmountRead bool
+}
+
+// read reads a single field of a transaction. Fields that may only be read
+// once the transaction is complete are kept until then.
+func (b *qifTxnBuilder) read(code byte, value string) {
+	txn := &b.txn
+	switch code {
+	case 'S':
+		split := QIFSplit{}
+		split.Category, split.Transfer = parseQIFCategory(value)
+		txn.Splits = append(txn.Splits, split)
+		b.splitAmountRead = false
+	case 'E':
+		if len(txn.Splits) > 0 {
+			txn.Splits[len(txn.Splits)-1].Memo = value
+		}
+	case '$':
+		if len(txn.Splits) == 0 || b.splitAmountRead {
+			// an amount without a category of its own
+			txn.Splits = append(txn.Splits, QIFSplit{})
+		}
+		amount, err := ParseAmount(value)
+		if err != nil && txn.Err == nil {
+			txn.Err = fmt.Errorf("invalid split amount: %w", err)
+		}
+		txn.Splits[len(txn.Splits)-1].Amount = amount
+		b.splitAmountRead = true
+	default:
+		b.fields[code] = value
+	}
+}
+
+// finish reads the fields of a complete transaction.
+func (b *qifTxnBuilder) finish(accountType string, opts QIFOptions) {
+	txn, fields := &b.txn, b.fields
+	if accountType == QIFInvestment {
+		txn.Err = fmt.Errorf("investment transactions are not supported")
+		return
+	}
+	if txn.Err != nil {
+		return
+	}
+
+	date, err := parseQIFDate(fields['D'], opts.DayFirst)
+	if err != nil {
+		txn.Err = err
+		return
+	}
+	txn.Date = date
+
+	amountField := fields['T']
+	if amountField == "" {
+		amountField = fields['U']
+	}
+	amount, err := ParseAmount(amountField)
+	if err != nil {
+		txn.Err = err
+		return
+	}
+	txn.Amount = amount
+
+	txn.Payee = fields['P']
+	txn.Memo = fields['M']
+	if txn.Payee == "" && fields['N'] != "" {
+		if _, err := strconv.Atoi(fields['N']); err == nil {
+			txn.Payee = "Check " + fields['N']
+		}
+	}
+	switch strings.ToUpper(fields['C']) {
+	case "*", "C", "X", "R":
+		txn.Cleared = true
+	}
+	if len(txn.Splits) == 0 {
+		txn.Category, txn.Transfer = parseQIFCategory(fields['L'])
+	} else {
+		var total int64
+		for _, split := range txn.Splits {
+			total += split.Amount
+		}
+		if total != txn.Amount {
+			txn.Err = fmt.Errorf("splits total %d, but transaction amount is %d", total, txn.Amount)
+		}
+	}
+}
+
+// parseQIFCategory separates the category and transfer account named by the L or S
+// field of a transaction. Transfers are written as [Account Name]. Any class,
+// written following a slash, is discarded.
+func parseQIFCategory(value string) (category, transfer string) {
+	if strings.HasPrefix(value, "[") {
+		if end := strings.Index(value, "]"); end > 0 {
+			return "", strings.TrimSpace(value[1:end])
+		}
+	}
+	category, _, _ = strings.Cut(value, "/")
+	return strings.TrimSpace(category), ""
+}
+
+// SplitQIFCategory separates the name of a QIF category of the form "Parent:Child"
+// into the name of a group and a category. Categories without a parent belong to no group.
+func SplitQIFCategory(name string) (group, category string) {
+	group, category, found := strings.Cut(name, ":")
+	if !found {
+		return "", name
+	}
+	return strings.TrimSpace(group), strings.TrimSpace(category)
+}
+
+func qifAccountType(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	for _, t := range []string{QIFBank, QIFCash, QIFCreditCard, QIFAsset, QIFLiability, QIFInvestment} {
+		if strings.EqualFold(s, t) {
+			return t, true
+		}
+	}
+	switch strings.ToLower(s) {
+	case "port", "401(k)/403(b)", "mutual":
+		return QIFInvestment, true
+	}
+	return "", false
+}
+
+// parseQIFDate parses dates such as 1/2/98, 01/02/1998 or 1/ 2'05.
+// Two digit years following an apostrophe are read as being in the 2000s;
+// any others are read as being within 50 years of 2000.
+func parseQIFDate(s string, dayFirst bool) (time.Time, error) {
+	s = strings.ReplaceAll(s, " ", "")
+	if date, err := time.Parse("2006-01-02", s); err == nil {
+		return date, nil
+	}
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '/' || r == '\'' || r == '-' || r == '.'
+	})
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("invalid QIF date: %q", s)
+	}
+	var nums [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid QIF date: %q", s)
+		}
+		nums[i] = n
+	}
+	month, day, year := nums[0], nums[1], nums[2]
+	if dayFirst {
+		month, day = day, month
+	}
+	if len(parts[2]) <= 2 {
+		switch {
+		case strings.Contains(s, "'"), year < 50:
+			year += 2000
+		default:
+			year += 1900
+		}
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Year() != year || int(date.Month()) != month || date.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid QIF date: %q", s)
+	}
+	return date, nil
+}
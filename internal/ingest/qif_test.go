@@ -0,0 +1,235 @@
+package ingest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testQIF = `!Type:Cat
+NFood
+DFood and drink
+E
+^
+NFood:Groceries
+E
+^
+NSalary
+I
+^
+!Option:AutoSwitch
+!Account
+NChecking
+TBank
+^
+NSavings
+TBank
+^
+!Clear:AutoSwitch
+!Account
+NChecking
+TBank
+^
+!Type:Bank
+D1/ 2'25
+T-1,250.00
+PLandlord
+LHousing:Rent
+CX
+^
+D1/3'25
+T-112.40
+PCorner Market
+MWeekly shop
+SFood:Groceries
+$-100.40
+EProduce
+S[Savings]
+$-12.00
+^
+D1/4'25
+T-500.00
+PTransfer
+L[Savings]/Personal
+^
+D1/5'25
+T-5.00
+PBad split
+SFood
+$-1.00
+^
+!Account
+NSavings
+TBank
+^
+!Type:Bank
+D1/4'25
+T500.00
+L[Checking]
+^
+!Account
+NBrokerage
+TInvst
+^
+!Type:Invst
+D1/6'25
+NBuy
+YACME
+T100.00
+^
+`
+
+func TestParseQIF(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	file, err := ParseQIF(strings.NewReader(testQIF), QIFOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectCategories := []QIFCategory{
+		{Name: "Food", Description: "Food and drink"},
+		{Name: "Food:Groceries"},
+		{Name: "Salary", Income: true},
+	}
+	if !reflect.DeepEqual(file.Categories, expectCategories) {
+		t.Errorf("want categories: %+v | actual: %+v", expectCategories, file.Categories)
+	}
+
+	if len(file.Accounts) != 3 {
+		t.Fatalf("want: 3 accounts | actual: %d accounts", len(file.Accounts))
+	}
+	checking, savings, brokerage := file.Accounts[0], file.Accounts[1], file.Accounts[2]
+	if checking.Name != "Checking" || savings.Name != "Savings" || brokerage.Name != "Brokerage" {
+		t.Fatalf("unexpected accounts: %q, %q, %q", checking.Name, savings.Name, brokerage.Name)
+	}
+	if brokerage.Type != QIFInvestment {
+		t.Errorf("want type: %v | actual: %v", QIFInvestment, brokerage.Type)
+	}
+
+	expectChecking := []QIFTransaction{
+		{
+			Record:  Record{Line: 26, Date: date(2025, time.January, 2), Amount: -125000, Payee: "Landlord", Category: "Housing:Rent"},
+			Cleared: true,
+		},
+		{
+			Record: Record{Line: 32, Date: date(2025, time.January, 3), Amount: -11240, Payee: "Corner Market", Memo: "Weekly shop"},
+			Splits: []QIFSplit{
+				{Category: "Food:Groceries", Memo: "Produce", Amount: -10040},
+				{Transfer: "Savings", Amount: -1200},
+			},
+		},
+		{
+			Record:   Record{Line: 42, Date: date(2025, time.January, 4), Amount: -50000, Payee: "Transfer"},
+			Transfer: "Savings",
+		},
+	}
+	if len(checking.Transactions) != 4 {
+		t.Fatalf("want: 4 transactions | actual: %d transactions", len(checking.Transactions))
+	}
+	for i, want := range expectChecking {
+		actual := checking.Transactions[i]
+		if actual.Err != nil {
+			t.Errorf("line %d: unexpected error: %v", actual.Line, actual.Err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, want) {
+			t.Errorf("line %d: want: %+v | actual: %+v", actual.Line, want, actual)
+		}
+	}
+	if checking.Transactions[3].Err == nil {
+		t.Errorf("line %d: expected error for splits not matching total, but got none", checking.Transactions[3].Line)
+	}
+
+	if len(savings.Transactions) != 1 || savings.Transactions[0].Transfer != "Checking" ||
+		savings.Transactions[0].Amount != 50000 {
+		t.Errorf("unexpected savings transactions: %+v", savings.Transactions)
+	}
+	if len(brokerage.Transactions) != 1 || brokerage.Transactions[0].Err == nil {
+		t.Errorf("expected investment transaction to be read with an error, got: %+v", brokerage.Transactions)
+	}
+}
+
+func TestParseQIFWithoutAccounts(t *testing.T) {
+	input := "!Type:CCard\nD14/02/2025\nT-30.00\nPFlorist\n^\n"
+
+	file, err := ParseQIF(strings.NewReader(input), QIFOptions{DayFirst: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(file.Accounts) != 1 {
+		t.Fatalf("want: 1 account | actual: %d accounts", len(file.Accounts))
+	}
+	account := file.Accounts[0]
+	if account.Name != "" || account.Type != QIFCreditCard {
+		t.Errorf("want unnamed %v account | actual: %q %v account", QIFCreditCard, account.Name, account.Type)
+	}
+	if len(account.Transactions) != 1 {
+		t.Fatalf("want: 1 transaction | actual: %d transactions", len(account.Transactions))
+	}
+	txn := account.Transactions[0]
+	if txn.Err != nil || !txn.Date.Equal(time.Date(2025, time.February, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected transaction: %+v", txn)
+	}
+
+	if _, err := ParseQIF(strings.NewReader("Date,Payee,Amount\n"), QIFOptions{}); err == nil {
+		t.Errorf("expected error for file without QIF content, but got none")
+	}
+}
+
+func TestParseQIFDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		dayFirst bool
+		expect   time.Time
+		wantErr  bool
+	}{
+		{name: "Four digit year", input: "01/02/1998", expect: time.Date(1998, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "Two digit year", input: "1/2/98", expect: time.Date(1998, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "Two digit year after 2000", input: "1/2/05", expect: time.Date(2005, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "Apostrophe", input: "12/31'99", expect: time.Date(2099, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "Padded with spaces", input: " 1/ 2' 5", expect: time.Date(2005, time.January, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "Day first", input: "31.12.2024", dayFirst: true, expect: time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "ISO", input: "2024-12-31", expect: time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "Impossible day", input: "2/30/2024", wantErr: true},
+		{name: "Month out of range", input: "31/12/2024", wantErr: true},
+		{name: "Empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := parseQIFDate(tt.input, tt.dayFirst)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error: %v | actual: %v", tt.wantErr, err)
+			}
+			if !actual.Equal(tt.expect) {
+				t.Errorf("want: %v | actual: %v", tt.expect, actual)
+			}
+		})
+	}
+}
+
+func TestSplitQIFCategory(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectGroup    string
+		expectCategory string
+	}{
+		{input: "Groceries", expectCategory: "Groceries"},
+		{input: "Food:Groceries", expectGroup: "Food", expectCategory: "Groceries"},
+		{input: "Auto:Fuel:Diesel", expectGroup: "Auto", expectCategory: "Fuel:Diesel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			group, category := SplitQIFCategory(tt.input)
+			if group != tt.expectGroup || category != tt.expectCategory {
+				t.Errorf("want: %q, %q | actual: %q, %q", tt.expectGroup, tt.expectCategory, group, category)
+			}
+		})
+	}
+}
@@ -0,0 +1,504 @@
+package ingest
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// YNABPaymentGroup is the category group YNAB keeps the payment category
+// of each credit card account in. Each is named after its account.
+const YNABPaymentGroup = "Credit Card Payments"
+
+// maxYNABExportFileBytes limits the size of each file read from a YNAB export archive.
+const maxYNABExportFileBytes = 64 << 20
+
+// YNABOptions describes how the register of a YNAB export should be read.
+type YNABOptions struct {
+	// DateFormat is the format of the dates within the register, as chosen
+	// in the settings of the budget exported. Defaults to MM/DD/YYYY.
+	DateFormat string
+}
+
+// YNABAssignment is the amount assigned to a category for a single month,
+// as read from the budget (or plan) export of YNAB.
+type YNABAssignment struct {
+	// Line is the line of the file the assignment was read from.
+	Line     int
+	Month    time.Time
+	Group    string
+	Category string
+	Assigned int64
+	// Err holds any reason the assignment could not be read.
+	// All other fields should be disregarded where it is set.
+	Err error
+}
+
+var (
+	// ynabSplitMemo matches the prefix written to the memo of each part of a
+	// split transaction: "Split (1/3)" by YNAB, and "(Split 1/3)" by YNAB 4.
+	ynabSplitMemo = regexp.MustCompile(`^(?:Split \((\d+)/(\d+)\)|\(Split (\d+)/(\d+)\))\s*`)
+	// ynabTransferPayee matches the payee of a transfer, such as "Transfer : Savings".
+	ynabTransferPayee = regexp.MustCompile(`^Transfer\s*:\s*(.+)$`)
+)
+
+// ReadYNABExport returns the register and budget CSV files from the zip
+// archive YNAB exports a budget as. Either may be missing, but not both.
+func ReadYNABExport(data []byte) (register, budget []byte, err error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read YNAB export archive: %w", err)
+	}
+	for _, f := range archive.File {
+		name := path.Base(f.Name)
+		if strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		var dst *[]byte
+		switch {
+		case strings.HasSuffix(name, "Register.csv"):
+			dst = &register
+		case strings.HasSuffix(name, "Budget.csv"), strings.HasSuffix(name, "Plan.csv"):
+			dst = &budget
+		default:
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read '%s' from YNAB export archive: %w", name, err)
+		}
+		contents, err := io.ReadAll(io.LimitReader(rc, maxYNABExportFileBytes+1))
+		rc.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read '%s' from YNAB export archive: %w", name, err)
+		}
+		if len(contents) > maxYNABExportFileBytes {
+			return nil, nil, fmt.Errorf("'%s' is too large to import", name)
+		}
+		*dst = contents
+	}
+	if register == nil && budget == nil {
+		return nil, nil, fmt.Errorf("archive does not contain a YNAB register or budget export")
+	}
+	return register, budget, nil
+}
+
+// ynabColumns holds the position of each column of a YNAB export within
+// a row, or -1 where the export does not have the column.
+type ynabColumns struct {
+	account, flag, date, payee, group, category, memo, outflow, inflow, cleared int
+	month, assigned                                                             int
+}
+
+// readYNABHeader reads the header of a YNAB export, finding the columns
+// written by either YNAB or YNAB 4. Those required must be present.
+func readYNABHeader(reader *csv.Reader, required ...string) (*ynabColumns, error) {
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("file has no header")
+		}
+		return nil, err
+	}
+	indices := headerIndices(header)
+	position := func(names ...string) int {
+		for _, name := range names {
+			if i, ok := indices[name]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	for _, name := range required {
+		if _, ok := indices[name]; !ok {
+			return nil, fmt.Errorf("column '%s' not found in header", name)
+		}
+	}
+	return &ynabColumns{
+		account: position("Account"),
+		flag:    position("Flag"),
+		date:    position("Date"),
+		payee:   position("Payee"),
+		group:   position("Category Group", "Master Category"),
+		// YNAB 4 writes the group and category together under Category
+		category: position("Sub Category", "Category"),
+		memo:     position("Memo"),
+		outflow:  position("Outflow"),
+		inflow:   position("Inflow"),
+		cleared:  position("Cleared"),
+		month:    position("Month"),
+		assigned: position("Assigned", "Budgeted"),
+	}, nil
+}
+
+// newExportCSVReader returns a reader for the CSV exports of other budgeting
+// tools. Any byte order mark is skipped, as it would otherwise keep the
+// quotes around the first name of the header.
+func newExportCSVReader(r io.Reader) *csv.Reader {
+	br := bufio.NewReader(r)
+	if c, _, err := br.ReadRune(); err == nil && c != '\ufeff' {
+		br.UnreadRune()
+	}
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+	return reader
+}
+
+// ynabCategory names the category of a transaction in the "Group:Category"
+// form of a QIF category. Money left to be assigned is given no category.
+func ynabCategory(group, category string) string {
+	switch {
+	case category == "", strings.EqualFold(category, "Uncategorized"):
+		return ""
+	case group == "Inflow":
+		return ""
+	case group == "Income" && strings.HasPrefix(category, "Available "):
+		// YNAB 4 income, available this month or next
+		return ""
+	case group == "":
+		return category
+	}
+	return group + ":" + category
+}
+
+// ParseYNABRegister reads the register export of YNAB into the accounts
+// it lists, in the same form as a QIF file. Categories are named as
+// "Group:Category", flags are kept at the start of the memo, and the parts
+// of a split transaction are read as the splits of a single transaction.
+// Transfers to tracking accounts, which YNAB gives a category, are read as
+// described by resolveYNABTrackingTransfers.
+// Rows that cannot be read are returned with their Err field set;
+// an error is only returned where the file as a whole cannot be read.
+func ParseYNABRegister(r io.Reader, opts YNABOptions) (*QIFFile, error) {
+	dateFormat := opts.DateFormat
+	if dateFormat == "" {
+		dateFormat = "MM/DD/YYYY"
+	}
+	layout, err := DateLayout(dateFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := newExportCSVReader(r)
+	cols, err := readYNABHeader(reader, "Account", "Date", "Payee", "Outflow", "Inflow")
+	if err != nil {
+		return nil, err
+	}
+
+	file := &QIFFile{}
+	accounts := map[string]*QIFAccount{}
+	// split is the split transaction being read, and splitAccount its account
+	var split *QIFTransaction
+	var splitAccount *QIFAccount
+	splitCount := 0
+	finishSplit := func() {
+		if split == nil {
+			return
+		}
+		if len(split.Splits) != splitCount && split.Err == nil {
+			split.Err = fmt.Errorf("split transaction has %d of its %d parts", len(split.Splits), splitCount)
+		}
+		splitAccount.Transactions = append(splitAccount.Transactions, *split)
+		split, splitAccount = nil, nil
+	}
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// a row that cannot be read may be part of a split, so the
+			// rows following it cannot be read reliably either
+			return nil, err
+		}
+		if isBlankRow(row) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		accountName := field(row, cols.account)
+		if accountName == "" {
+			return nil, fmt.Errorf("line %d does not name its account", line)
+		}
+		account, ok := accounts[accountName]
+		if !ok {
+			account = &QIFAccount{Name: accountName, Type: QIFBank}
+			accounts[accountName] = account
+			file.Accounts = append(file.Accounts, account)
+		}
+
+		txn, part := parseYNABRow(row, line, layout, dateFormat, cols)
+		splitNum, splitOf := 0, 0
+		if m := ynabSplitMemo.FindStringSubmatch(field(row, cols.memo)); m != nil {
+			splitNum, _ = strconv.Atoi(m[1] + m[3])
+			splitOf, _ = strconv.Atoi(m[2] + m[4])
+		}
+
+		if splitNum == 0 {
+			finishSplit()
+			txn.Category, txn.Transfer = part.Category, part.Transfer
+			txn.Amount = part.Amount
+			txn.Memo = joinYNABMemo(txn.Memo, part.Memo)
+			account.Transactions = append(account.Transactions, txn)
+			continue
+		}
+
+		if splitNum == 1 {
+			finishSplit()
+			split, splitAccount, splitCount = &txn, account, splitOf
+		} else if split == nil || splitAccount != account || splitNum != len(split.Splits)+1 || splitOf != splitCount {
+			finishSplit()
+			txn.Err = fmt.Errorf("part %d of split transaction does not follow the parts before it", splitNum)
+			account.Transactions = append(account.Transactions, txn)
+			continue
+		}
+		if txn.Err != nil && split.Err == nil {
+			split.Err = txn.Err
+		}
+		if split.Payee == "" {
+			split.Payee = txn.Payee
+		}
+		split.Splits = append(split.Splits, part)
+		split.Amount += part.Amount
+		if len(split.Splits) == splitCount {
+			finishSplit()
+		}
+	}
+	finishSplit()
+
+	if len(file.Accounts) == 0 {
+		return nil, fmt.Errorf("file does not contain any transactions")
+	}
+	resolveYNABTrackingTransfers(file, accounts)
+	return file, nil
+}
+
+// ynabTrackingTransfer is a transfer between an on-budget account and a
+// tracking account, as read from the on-budget side.
+type ynabTrackingTransfer struct {
+	txn      QIFTransaction
+	account  string
+	transfer string
+	amount   int64
+}
+
+// resolveYNABTrackingTransfers reads each transfer YNAB gives a category as
+// money spent from (or received into) its on-budget account under that
+// category, as transfers are never categorized here. The tracking account
+// transferred to is marked as an asset, so that it is kept off-budget, and
+// the other side of the transfer is read as money entering or leaving it
+// alone; or added to it, where the register does not list that side.
+func resolveYNABTrackingTransfers(file *QIFFile, accounts map[string]*QIFAccount) {
+	var transfers []ynabTrackingTransfer
+	for _, account := range file.Accounts {
+		for i := range account.Transactions {
+			txn := &account.Transactions[i]
+			if txn.Err != nil {
+				continue
+			}
+			if txn.Transfer != "" && txn.Category != "" {
+				transfers = append(transfers, ynabTrackingTransfer{*txn, account.Name, txn.Transfer, txn.Amount})
+				txn.Payee = "Transfer : " + txn.Transfer
+				txn.Transfer = ""
+			}
+			for j := range txn.Splits {
+				if split := &txn.Splits[j]; split.Transfer != "" && split.Category != "" {
+					transfers = append(transfers, ynabTrackingTransfer{*txn, account.Name, split.Transfer, split.Amount})
+					split.Transfer = ""
+				}
+			}
+		}
+	}
+
+	for _, transfer := range transfers {
+		tracking, ok := accounts[transfer.transfer]
+		if !ok {
+			tracking = &QIFAccount{Name: transfer.transfer}
+			accounts[transfer.transfer] = tracking
+			file.Accounts = append(file.Accounts, tracking)
+		}
+		tracking.Type = QIFAsset
+		if !resolveYNABTrackingSide(tracking, transfer) {
+			tracking.Transactions = append(tracking.Transactions, QIFTransaction{
+				Record: Record{
+					Line:   transfer.txn.Line,
+					Date:   transfer.txn.Date,
+					Amount: -transfer.amount,
+					Payee:  "Transfer : " + transfer.account,
+					Memo:   transfer.txn.Memo,
+				},
+				Cleared: transfer.txn.Cleared,
+			})
+		}
+	}
+}
+
+// resolveYNABTrackingSide finds the side of a tracking transfer listed by
+// its tracking account, and reads it as money entering or leaving that
+// account alone. It reports whether the side was found.
+func resolveYNABTrackingSide(tracking *QIFAccount, transfer ynabTrackingTransfer) bool {
+	for i := range tracking.Transactions {
+		txn := &tracking.Transactions[i]
+		if txn.Err != nil || !txn.Date.Equal(transfer.txn.Date) {
+			continue
+		}
+		if txn.Transfer == transfer.account && txn.Amount == -transfer.amount {
+			txn.Payee = "Transfer : " + transfer.account
+			txn.Transfer = ""
+			return true
+		}
+		for j := range txn.Splits {
+			if split := &txn.Splits[j]; split.Transfer == transfer.account && split.Amount == -transfer.amount {
+				split.Transfer = ""
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseYNABRow reads a row of a YNAB register into a transaction, along with
+// the split it would make up were the row part of a split transaction.
+// The amount, category and transfer of the row are only set on the split.
+// Transfers keep any category, which YNAB only gives those between an
+// on-budget account and a tracking account.
+func parseYNABRow(row []string, line int, layout, dateFormat string, cols *ynabColumns) (QIFTransaction, QIFSplit) {
+	txn := QIFTransaction{Record: Record{Line: line}}
+	part := QIFSplit{
+		Memo:     ynabSplitMemo.ReplaceAllString(field(row, cols.memo), ""),
+		Category: ynabCategory(field(row, cols.group), field(row, cols.category)),
+	}
+
+	payee := field(row, cols.payee)
+	if m := ynabTransferPayee.FindStringSubmatch(payee); m != nil {
+		part.Transfer = strings.TrimSpace(m[1])
+	} else {
+		txn.Payee = payee
+	}
+	if flag := field(row, cols.flag); flag != "" {
+		txn.Memo = "[" + flag + "]"
+	}
+	switch strings.ToLower(field(row, cols.cleared)) {
+	case "cleared", "reconciled":
+		txn.Cleared = true
+	}
+
+	dateVal := field(row, cols.date)
+	date, err := time.Parse(layout, dateVal)
+	if err != nil {
+		txn.Err = fmt.Errorf("date '%s' does not match format '%s'", dateVal, dateFormat)
+		return txn, part
+	}
+	txn.Date = date
+
+	var outflow, inflow int64
+	if val := field(row, cols.outflow); val != "" {
+		if outflow, err = ParseAmount(val); err != nil {
+			txn.Err = err
+			return txn, part
+		}
+	}
+	if val := field(row, cols.inflow); val != "" {
+		if inflow, err = ParseAmount(val); err != nil {
+			txn.Err = err
+			return txn, part
+		}
+	}
+	part.Amount = abs(inflow) - abs(outflow)
+	return txn, part
+}
+
+func joinYNABMemo(flag, memo string) string {
+	if flag == "" || memo == "" {
+		return flag + memo
+	}
+	return flag + " " + memo
+}
+
+// ynabMonthLayouts lists the layouts of the months of a YNAB budget export.
+var ynabMonthLayouts = []string{"Jan 2006", "January 2006", "2006-01", "01/2006"}
+
+// ParseYNABBudget reads the amounts assigned to each category for each month
+// from the budget (or plan) export of YNAB. Rows for money left to be
+// assigned are skipped. Rows that cannot be read are returned with their
+// Err field set; an error is only returned where the file as a whole
+// cannot be read.
+func ParseYNABBudget(r io.Reader) ([]YNABAssignment, error) {
+	reader := newExportCSVReader(r)
+	cols, err := readYNABHeader(reader, "Month")
+	if err != nil {
+		return nil, err
+	}
+	if cols.assigned < 0 {
+		return nil, fmt.Errorf("column 'Budgeted' not found in header")
+	}
+	if cols.category < 0 {
+		return nil, fmt.Errorf("column 'Category' not found in header")
+	}
+
+	var assignments []YNABAssignment
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isBlankRow(row) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		assignment := YNABAssignment{
+			Line:     line,
+			Group:    field(row, cols.group),
+			Category: field(row, cols.category),
+		}
+		if ynabCategory(assignment.Group, assignment.Category) == "" {
+			continue
+		}
+		monthVal := field(row, cols.month)
+		for _, layout := range ynabMonthLayouts {
+			if month, err := time.Parse(layout, monthVal); err == nil {
+				assignment.Month = month
+				break
+			}
+		}
+		if assignment.Month.IsZero() {
+			assignment.Err = fmt.Errorf("invalid month: %q", monthVal)
+		} else if assignment.Assigned, err = ParseAmount(field(row, cols.assigned)); err != nil {
+			assignment.Err = err
+		}
+		assignments = append(assignments, assignment)
+	}
+	return assignments, nil
+}
+
+// MarkYNABCreditCards marks the accounts of a YNAB register as credit card
+// accounts where the budget has a payment category named after them.
+func MarkYNABCreditCards(file *QIFFile, assignments []YNABAssignment) {
+	cards := map[string]bool{}
+	for _, assignment := range assignments {
+		if assignment.Group == YNABPaymentGroup {
+			cards[assignment.Category] = true
+		}
+	}
+	for _, account := range file.Accounts {
+		if cards[account.Name] {
+			account.Type = QIFCreditCard
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package ingest
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testYNABRegister = "\ufeff" + `"Account","Flag","Date","Payee","Category Group/Category","Category Group","Category","Memo","Outflow","Inflow","Cleared"
+"Checking","","01/01/2025","Employer","Inflow: Ready to Assign","Inflow","Ready to Assign","",$0.00,$2500.00,"Reconciled"
+"Checking","Red","01/02/2025","Landlord","Bills: Rent","Bills","Rent","January",$1250.00,$0.00,"Cleared"
+"Checking","","01/03/2025","Corner Market","Food: Groceries","Food","Groceries","Split (1/2) Produce",$100.40,$0.00,"Uncleared"
+"Checking","","01/03/2025","Transfer : Savings","","","","Split (2/2) ",$12.00,$0.00,"Uncleared"
+"Checking","","01/04/2025","Transfer : Visa","","","","",$300.00,$0.00,"Cleared"
+"Savings","","01/03/2025","Transfer : Checking","","","","",$0.00,$12.00,"Uncleared"
+"Visa","Blue","01/04/2025","Transfer : Checking","","","","",$0.00,$300.00,"Cleared"
+"Visa","","13/45/2025","Cafe","Food: Dining Out","Food","Dining Out","",$4.50,$0.00,"Uncleared"
+"Savings","","01/05/2025","Bank","","","","(Split 1/2) Interest",$0.00,$1.00,"Cleared"
+"Savings","","01/06/2025","Bank","","","","",$0.00,$1.00,"Cleared"
+`
+
+const testYNABBudget = `"Month","Category Group/Category","Category Group","Category","Budgeted","Activity","Available"
+"Jan 2025","Inflow: Ready to Assign","Inflow","Ready to Assign",$0.00,$2500.00,$2500.00
+"Jan 2025","Bills: Rent","Bills","Rent",$1250.00,-$1250.00,$0.00
+"Jan 2025","Credit Card Payments: Visa","Credit Card Payments","Visa",$0.00,$0.00,$0.00
+"Feb 2025","Food: Groceries","Food","Groceries",$400.00,$0.00,$400.00
+"Someday","Food: Groceries","Food","Groceries",$400.00,$0.00,$400.00
+`
+
+func TestParseYNABRegister(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	file, err := ParseYNABRegister(strings.NewReader(testYNABRegister), YNABOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(file.Accounts) != 3 {
+		t.Fatalf("want: 3 accounts | actual: %d accounts", len(file.Accounts))
+	}
+	checking, savings, visa := file.Accounts[0], file.Accounts[1], file.Accounts[2]
+	if checking.Name != "Checking" || savings.Name != "Savings" || visa.Name != "Visa" {
+		t.Fatalf("unexpected accounts: %q, %q, %q", checking.Name, savings.Name, visa.Name)
+	}
+
+	expectChecking := []QIFTransaction{
+		{
+			Record:  Record{Line: 2, Date: date(2025, time.January, 1), Amount: 250000, Payee: "Employer"},
+			Cleared: true,
+		},
+		{
+			Record:  Record{Line: 3, Date: date(2025, time.January, 2), Amount: -125000, Payee: "Landlord", Memo: "[Red] January", Category: "Bills:Rent"},
+			Cleared: true,
+		},
+		{
+			Record: Record{Line: 4, Date: date(2025, time.January, 3), Amount: -11240, Payee: "Corner Market"},
+			Splits: []QIFSplit{
+				{Category: "Food:Groceries", Memo: "Produce", Amount: -10040},
+				{Transfer: "Savings", Amount: -1200},
+			},
+		},
+		{
+			Record:   Record{Line: 6, Date: date(2025, time.January, 4), Amount: -30000},
+			Transfer: "Visa",
+			Cleared:  true,
+		},
+	}
+	if !reflect.DeepEqual(checking.Transactions, expectChecking) {
+		t.Errorf("want: %+v | actual: %+v", expectChecking, checking.Transactions)
+	}
+
+	if len(visa.Transactions) != 2 {
+		t.Fatalf("want: 2 transactions | actual: %d transactions", len(visa.Transactions))
+	}
+	if txn := visa.Transactions[0]; txn.Transfer != "Checking" || txn.Amount != 30000 || txn.Memo != "[Blue]" {
+		t.Errorf("unexpected transfer: %+v", txn)
+	}
+	if visa.Transactions[1].Err == nil {
+		t.Errorf("line %d: expected error for invalid date, but got none", visa.Transactions[1].Line)
+	}
+
+	if len(savings.Transactions) != 3 {
+		t.Fatalf("want: 3 transactions | actual: %d transactions", len(savings.Transactions))
+	}
+	if savings.Transactions[1].Err == nil {
+		t.Errorf("line %d: expected error for incomplete split, but got none", savings.Transactions[1].Line)
+	}
+	if savings.Transactions[2].Err != nil || savings.Transactions[2].Amount != 100 {
+		t.Errorf("unexpected transaction: %+v", savings.Transactions[2])
+	}
+
+	if _, err := ParseYNABRegister(strings.NewReader("Date,Payee,Amount\n"), YNABOptions{}); err == nil {
+		t.Errorf("expected error for file that is not a YNAB register, but got none")
+	}
+}
+
+const testYNABTrackingRegister = `"Account","Flag","Date","Payee","Category Group/Category","Category Group","Category","Memo","Outflow","Inflow","Cleared"
+"Checking","","01/01/2025","Transfer : Brokerage","Savings: Retirement","Savings","Retirement","",$200.00,$0.00,"Cleared"
+"Brokerage","","01/01/2025","Transfer : Checking","","","","",$0.00,$200.00,"Cleared"
+"Checking","","01/02/2025","Transfer : House","Bills: Upkeep","Bills","Upkeep","Gutters",$50.00,$0.00,"Uncleared"
+`
+
+func TestParseYNABTrackingTransfers(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	file, err := ParseYNABRegister(strings.NewReader(testYNABTrackingRegister), YNABOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// categorized transfers are read as spending from the on-budget account,
+	// and money entering the tracking account, which is kept off-budget
+	expect := []*QIFAccount{
+		{
+			Name: "Checking",
+			Type: QIFBank,
+			Transactions: []QIFTransaction{
+				{
+					Record:  Record{Line: 2, Date: date(2025, time.January, 1), Amount: -20000, Payee: "Transfer : Brokerage", Category: "Savings:Retirement"},
+					Cleared: true,
+				},
+				{
+					Record: Record{Line: 4, Date: date(2025, time.January, 2), Amount: -5000, Payee: "Transfer : House", Memo: "Gutters", Category: "Bills:Upkeep"},
+				},
+			},
+		},
+		{
+			Name: "Brokerage",
+			Type: QIFAsset,
+			Transactions: []QIFTransaction{
+				{
+					Record:  Record{Line: 3, Date: date(2025, time.January, 1), Amount: 20000, Payee: "Transfer : Checking"},
+					Cleared: true,
+				},
+			},
+		},
+		{
+			// the register does not list this side of the transfer
+			Name: "House",
+			Type: QIFAsset,
+			Transactions: []QIFTransaction{
+				{
+					Record: Record{Line: 4, Date: date(2025, time.January, 2), Amount: 5000, Payee: "Transfer : Checking", Memo: "Gutters"},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(file.Accounts, expect) {
+		t.Errorf("want: %+v | actual: %+v", expect, file.Accounts)
+	}
+}
+
+func TestParseYNABBudget(t *testing.T) {
+	assignments, err := ParseYNABBudget(strings.NewReader(testYNABBudget))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(assignments) != 4 {
+		t.Fatalf("want: 4 assignments | actual: %d assignments", len(assignments))
+	}
+
+	want := YNABAssignment{
+		Line:     3,
+		Month:    time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Group:    "Bills",
+		Category: "Rent",
+		Assigned: 125000,
+	}
+	if !reflect.DeepEqual(assignments[0], want) {
+		t.Errorf("want: %+v | actual: %+v", want, assignments[0])
+	}
+	if assignments[3].Err == nil {
+		t.Errorf("line %d: expected error for invalid month, but got none", assignments[3].Line)
+	}
+
+	file, err := ParseYNABRegister(strings.NewReader(testYNABRegister), YNABOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	MarkYNABCreditCards(file, assignments)
+	for _, account := range file.Accounts {
+		want := QIFBank
+		if account.Name == "Visa" {
+			want = QIFCreditCard
+		}
+		if account.Type != want {
+			t.Errorf("%s: want: %v | actual: %v", account.Name, want, account.Type)
+		}
+	}
+}
+
+func TestReadYNABExport(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, contents := range map[string]string{
+		"Home as of 2025-01-31 - Register.csv": testYNABRegister,
+		"Home as of 2025-01-31 - Plan.csv":     testYNABBudget,
+		"__MACOSX/._Home - Register.csv":       "",
+	} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		w.Write([]byte(contents))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	register, budget, err := ReadYNABExport(buf.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(register) != testYNABRegister || string(budget) != testYNABBudget {
+		t.Errorf("unexpected files read from archive")
+	}
+
+	if _, _, err := ReadYNABExport([]byte(testYNABRegister)); err == nil {
+		t.Errorf("expected error for file that is not an archive, but got none")
+	}
+}
@@ -48,6 +48,10 @@ type pathSelector interface {
 	Transaction() pathSelector
 	Member() pathSelector
 	Month() pathSelector
+	Recurring() pathSelector
+	APIKey() pathSelector
+	Session() pathSelector
+	Invitation() pathSelector
 	Col() truncatedPathSelector
 	truncatedPathSelector
 }
@@ -187,3 +191,23 @@ func (ef *patternFormatter) Month() pathSelector {
 	ef.Add(ef.single("months", "month"))
 	return ef
 }
+
+func (ef *patternFormatter) Recurring() pathSelector {
+	ef.Add(ef.single("recurring", "recurring"))
+	return ef
+}
+
+func (ef *patternFormatter) APIKey() pathSelector {
+	ef.Add(ef.single("api-keys", "api_key"))
+	return ef
+}
+
+func (ef *patternFormatter) Session() pathSelector {
+	ef.Add(ef.single("sessions", "session"))
+	return ef
+}
+
+func (ef *patternFormatter) Invitation() pathSelector {
+	ef.Add(ef.single("invitations", "invitation"))
+	return ef
+}
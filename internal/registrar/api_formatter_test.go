@@ -123,7 +123,7 @@ func TestPatternBuild(t *testing.T) {
 			api := &patternFormatter{basePath: "api"}
 
 			wrappers := []func() pathSelector{
-				api.Budget, api.Account, api.Group, api.Category, api.Payee, api.Transaction, api.Member, api.Month,
+				api.Budget, api.Account, api.Group, api.Category, api.Payee, api.Transaction, api.Member, api.Month, api.Recurring, api.APIKey, api.Session, api.Invitation,
 			}
 
 			for _, wrapper := range wrappers {
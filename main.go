@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"fmt"
 	"log"
@@ -32,6 +33,10 @@ func main() {
 	cfg.ConnectToDB(embedMigrations, "sql/schema")
 	defer shutdown(cfg)
 
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go cfg.RunScheduler(schedulerCtx)
+
 	pincher := &http.Server{
 		Addr:    ":" + port,
 		Handler: api.SetupMux(cfg),
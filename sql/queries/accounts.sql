@@ -33,6 +33,11 @@ UPDATE accounts
 SET is_deleted = FALSE
 WHERE id = $1;
 
+-- name: SetAccountPaymentCategory :exec
+UPDATE accounts
+SET updated_at = NOW(), payment_category_id = $2
+WHERE id = $1;
+
 -- name: UpdateAccount :one
 UPDATE accounts
 SET updated_at = NOW(), name = $2, notes = $3
@@ -0,0 +1,48 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, created_at, updated_at, user_id, name, key_prefix, hashed_key, max_role, expires_at)
+VALUES (
+    gen_random_uuid(),
+    DEFAULT,
+    DEFAULT,
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: AddAPIKeyBudget :exec
+INSERT INTO api_key_budgets (api_key_id, budget_id)
+VALUES ($1, $2);
+
+-- name: GetAPIKeysByUserID :many
+SELECT *
+FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: GetAPIKeyBudgetIDs :many
+SELECT budget_id
+FROM api_key_budgets
+WHERE api_key_id = $1;
+
+-- name: GetActiveAPIKeyByHash :one
+SELECT *
+FROM api_keys
+WHERE hashed_key = $1
+  AND revoked_at IS NULL
+  AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1
+  AND user_id = $2
+  AND revoked_at IS NULL;
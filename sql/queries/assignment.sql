@@ -32,3 +32,11 @@ RETURNING *,
 DELETE FROM assignments
 WHERE $1 = month AND $2 = category_id;
 
+
+-- name: GetBudgetMonthAssignments :many
+SELECT a.*
+FROM assignments a
+JOIN categories c ON c.id = a.category_id
+WHERE c.budget_id = @budget_id
+  AND a.month = DATE_TRUNC('month', @month_id::timestamp)
+ORDER BY a.category_id;
@@ -0,0 +1,37 @@
+-- name: CreateAuditEntry :exec
+INSERT INTO audit_log (id, created_at, budget_id, actor_id, resource_type, resource_id, action, before, after)
+VALUES (
+    gen_random_uuid(),
+    DEFAULT,
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
+
+-- name: GetAuditEntries :many
+SELECT *
+FROM audit_log
+WHERE
+  budget_id = @budget_id::uuid
+  AND (
+    @resource_type::text = ''
+    OR resource_type = @resource_type::text
+  )
+  AND (
+    @resource_id::text = ''
+    OR resource_id = @resource_id::text
+  )
+  AND (
+    @actor_id::uuid = '00000000-0000-0000-0000-000000000000'
+    OR actor_id = @actor_id::uuid
+  )
+  AND (
+    (@start_date::date = '0001-01-01' AND @end_date::date = '0001-01-01')
+    OR (created_at::date BETWEEN @start_date::date AND @end_date::date)
+  )
+ORDER BY created_at DESC
+LIMIT @max_entries::int;
@@ -1,17 +1,26 @@
 /* TOKEN CRUD */
 
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, user_agent, ip_address)
 VALUES (
     @token,
     NOW(),
     NOW(),
     @user_id,
     @expires_at,
-    NULL
+    NULL,
+    @family_id,
+    @user_agent,
+    @ip_address
 )
 RETURNING *;
 
+-- name: GetRefreshTokenForUpdate :one
+SELECT *
+FROM refresh_tokens
+WHERE token = $1
+FOR UPDATE;
+
 -- name: GetUserByRefreshToken :one
 SELECT users.*
 FROM users
@@ -20,8 +29,58 @@ WHERE refresh_tokens.token = $1
 AND revoked_at IS NULL
 AND expires_at > NOW();
 
+-- name: RedeemRefreshToken :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), last_used_at = NOW(), updated_at = NOW()
+WHERE token = $1
+AND revoked_at IS NULL;
+
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
 WHERE token = $1
 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = $1
+AND revoked_at IS NULL;
+
+/* SESSIONS */
+
+-- name: GetActiveSessionsByUserID :many
+SELECT
+  family_id::uuid AS id,
+  MIN(created_at)::timestamp AS created_at,
+  MAX(COALESCE(last_used_at, created_at))::timestamp AS last_used_at,
+  (ARRAY_AGG(user_agent ORDER BY created_at DESC))[1]::text AS user_agent,
+  (ARRAY_AGG(ip_address ORDER BY created_at DESC))[1]::text AS ip_address
+FROM refresh_tokens
+WHERE user_id = $1
+GROUP BY family_id
+HAVING BOOL_OR(revoked_at IS NULL AND expires_at > NOW())
+ORDER BY last_used_at DESC;
+
+-- name: RevokeOtherUserSessions :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = @user_id
+AND family_id <> @family_id
+AND revoked_at IS NULL;
+
+-- name: RevokeUserSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = @user_id
+AND family_id = @family_id
+AND revoked_at IS NULL;
+
+-- name: IsSessionActive :one
+SELECT EXISTS (
+  SELECT 1
+  FROM refresh_tokens
+  WHERE family_id = $1
+    AND revoked_at IS NULL
+    AND expires_at > NOW()
+) AS active;
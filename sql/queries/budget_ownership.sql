@@ -0,0 +1,48 @@
+-- name: UpsertOwnershipTransfer :one
+INSERT INTO budget_ownership_transfers (budget_id, created_at, from_user_id, to_user_id)
+VALUES (
+    $1,
+    DEFAULT,
+    $2,
+    $3
+)
+ON CONFLICT (budget_id) DO UPDATE
+SET created_at = NOW(),
+    from_user_id = EXCLUDED.from_user_id,
+    to_user_id = EXCLUDED.to_user_id
+RETURNING *;
+
+-- name: GetOwnershipTransfer :one
+SELECT *
+FROM budget_ownership_transfers
+WHERE budget_id = $1;
+
+-- name: GetOwnershipTransfersToUser :many
+SELECT budget_ownership_transfers.*, budgets.name AS budget_name
+FROM budget_ownership_transfers
+JOIN budgets ON budget_ownership_transfers.budget_id = budgets.id
+WHERE budget_ownership_transfers.to_user_id = $1
+ORDER BY budget_ownership_transfers.created_at;
+
+-- name: ConsumeOwnershipTransfer :one
+DELETE FROM budget_ownership_transfers
+WHERE budget_id = $1
+  AND to_user_id = $2
+RETURNING *;
+
+-- name: DeleteOwnershipTransfer :execrows
+DELETE FROM budget_ownership_transfers
+WHERE budget_id = $1;
+
+-- name: SetBudgetAdmin :execrows
+UPDATE budgets
+SET updated_at = NOW(), admin_id = @new_admin_id
+WHERE id = @budget_id
+  AND admin_id = @former_admin_id;
+
+-- name: SwapBudgetAdminRole :execrows
+UPDATE memberships
+SET updated_at = NOW(),
+    member_role = CASE WHEN user_id = @new_admin_id::uuid THEN 'ADMIN' ELSE 'MANAGER' END
+WHERE budget_id = @budget_id
+  AND user_id IN (@new_admin_id::uuid, @former_admin_id::uuid);
@@ -26,6 +26,18 @@ SELECT *
 FROM budgets
 WHERE id = $1;
 
+-- name: GetSharedBudgetsByAdmin :many
+SELECT *
+FROM budgets
+WHERE admin_id = $1
+  AND EXISTS (
+    SELECT 1
+    FROM memberships
+    WHERE memberships.budget_id = budgets.id
+      AND memberships.user_id <> budgets.admin_id
+  )
+ORDER BY name;
+
 -- name: GetBudgetMemberRole :one
 SELECT member_role
 FROM memberships
@@ -44,6 +56,26 @@ ON CONFLICT (budget_id, user_id) DO UPDATE
 SET updated_at = EXCLUDED.updated_at, member_role = EXCLUDED.member_role
 RETURNING *;
 
+-- name: CreateBudgetMembership :one
+INSERT INTO memberships (created_at, updated_at, budget_id, user_id, member_role)
+VALUES (
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (budget_id, user_id) DO NOTHING
+RETURNING *;
+
+-- name: UpdateBudgetMemberRole :one
+UPDATE memberships
+SET updated_at = NOW(), member_role = $3
+WHERE budget_id = $1
+    AND user_id = $2
+    AND member_role <> 'ADMIN'
+RETURNING *;
+
 -- name: GetBudgetCapital :one
 SELECT CAST(COALESCE(SUM(td.total_amount), 0) AS BIGINT) AS total
 FROM transaction_details td
@@ -53,6 +85,11 @@ WHERE t.budget_id = @budget_id
   AND @account_type::text = ''
   OR a.account_type = @account_type;
 
+-- name: SetBudgetOverspendingRule :exec
+UPDATE budgets
+SET updated_at = NOW(), overspending_rule = $2
+WHERE id = $1;
+
 -- name: UpdateBudget :one
 UPDATE budgets
 SET updated_at = NOW(), name = $2, notes = $3
@@ -25,6 +25,11 @@ WHERE c.budget_id = @budget_id
     OR c.group_id = @group_id::uuid
   );
 
+-- name: SetCategoryOverspendingRule :exec
+UPDATE categories
+SET updated_at = NOW(), overspending_rule = $2
+WHERE id = $1;
+
 -- name: UpdateCategory :one
 UPDATE categories
 SET updated_at = NOW(), group_id = $2, name = $3, notes = $4
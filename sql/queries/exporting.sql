@@ -0,0 +1,66 @@
+-- name: GetBudgetMemberUsernames :many
+SELECT u.username, m.member_role
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+WHERE m.budget_id = $1
+ORDER BY m.created_at;
+
+-- name: GetBudgetAssignments :many
+SELECT a.*
+FROM assignments a
+JOIN categories c ON c.id = a.category_id
+WHERE c.budget_id = $1
+ORDER BY a.month, a.category_id;
+
+-- name: GetTransactionsForExport :many
+-- Transactions are exported a page at a time, in order of ID,
+-- each page following the last ID of the page before.
+SELECT
+  t.*,
+  u.username AS logger_name,
+  tf.to_transaction_id AS transferred_to_id,
+  tt.from_transaction_id AS transferred_from_id,
+  COALESCE((
+    SELECT jsonb_agg(jsonb_build_object('category_id', c.id, 'amount', ts.amount))
+    FROM transaction_splits ts
+    LEFT JOIN categories c ON c.id = ts.category_id
+    WHERE ts.transaction_id = t.id
+  ), '[]')::jsonb AS splits
+FROM transactions t
+LEFT JOIN users u ON u.id = t.logger_id
+LEFT JOIN account_transfers tf ON tf.from_transaction_id = t.id
+LEFT JOIN account_transfers tt ON tt.to_transaction_id = t.id
+WHERE t.budget_id = @budget_id
+  AND t.id > @after_id::uuid
+ORDER BY t.id
+LIMIT @page_size::int;
+
+-- name: ImportTransaction :one
+INSERT INTO transactions (
+    id, created_at, updated_at, budget_id, logger_id,
+    account_id, transaction_type, transaction_date,
+    payee_id, notes, cleared, reconciled)
+VALUES (
+    gen_random_uuid(),
+    DEFAULT,
+    DEFAULT,
+    @budget_id,
+    @logger_id,
+    @account_id,
+    @transaction_type,
+    @transaction_date,
+    @payee_id,
+    @notes,
+    @cleared,
+    @reconciled
+)
+RETURNING *;
+
+-- name: ImportTransactionSplit :exec
+INSERT INTO transaction_splits (id, transaction_id, category_id, amount)
+VALUES (
+    gen_random_uuid(),
+    @transaction_id,
+    @category_id,
+    @amount
+);
@@ -0,0 +1,29 @@
+-- name: UpsertCategoryGoal :one
+INSERT INTO category_goals (
+    category_id, created_at, updated_at,
+    goal_type, target_amount, target_date)
+VALUES (
+    @category_id,
+    DEFAULT,
+    DEFAULT,
+    @goal_type,
+    @target_amount,
+    @target_date
+)
+ON CONFLICT (category_id) DO UPDATE
+SET
+  updated_at = NOW(),
+  goal_type = EXCLUDED.goal_type,
+  target_amount = EXCLUDED.target_amount,
+  target_date = EXCLUDED.target_date
+RETURNING *;
+
+-- name: GetCategoryGoal :one
+SELECT *
+FROM category_goals
+WHERE category_id = $1;
+
+-- name: DeleteCategoryGoal :exec
+DELETE
+FROM category_goals
+WHERE category_id = $1;
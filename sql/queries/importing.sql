@@ -0,0 +1,86 @@
+-- name: UpsertImportProfile :one
+INSERT INTO import_profiles (
+    account_id, created_at, updated_at, has_header, delimiter,
+    skip_rows, date_column, date_format, amount_column, debit_column,
+    credit_column, payee_column, memo_column, category_column,
+    default_category, sign_convention)
+VALUES (
+    @account_id,
+    DEFAULT,
+    DEFAULT,
+    @has_header,
+    @delimiter,
+    @skip_rows,
+    @date_column,
+    @date_format,
+    @amount_column,
+    @debit_column,
+    @credit_column,
+    @payee_column,
+    @memo_column,
+    @category_column,
+    @default_category,
+    @sign_convention
+)
+ON CONFLICT (account_id) DO UPDATE
+SET
+  updated_at = NOW(),
+  has_header = EXCLUDED.has_header,
+  delimiter = EXCLUDED.delimiter,
+  skip_rows = EXCLUDED.skip_rows,
+  date_column = EXCLUDED.date_column,
+  date_format = EXCLUDED.date_format,
+  amount_column = EXCLUDED.amount_column,
+  debit_column = EXCLUDED.debit_column,
+  credit_column = EXCLUDED.credit_column,
+  payee_column = EXCLUDED.payee_column,
+  memo_column = EXCLUDED.memo_column,
+  category_column = EXCLUDED.category_column,
+  default_category = EXCLUDED.default_category,
+  sign_convention = EXCLUDED.sign_convention
+RETURNING *;
+
+-- name: GetImportProfileByAccountID :one
+SELECT *
+FROM import_profiles
+WHERE account_id = $1;
+
+-- name: DeleteImportProfile :exec
+DELETE
+FROM import_profiles
+WHERE account_id = $1;
+
+-- NOTE:
+-- Imported rows are considered duplicates of transactions
+-- already logged to the same account on the same date,
+-- with the same payee and total amount.
+
+-- name: CountMatchingTransactions :one
+SELECT COUNT(*)
+FROM transactions t
+WHERE t.account_id = @account_id
+  AND t.transaction_date = @transaction_date
+  AND t.payee_id = @payee_id
+  AND (
+    SELECT COALESCE(SUM(ts.amount), 0)
+    FROM transaction_splits ts
+    WHERE ts.transaction_id = t.id
+  ) = @total_amount::bigint;
+
+-- name: IsTransactionImported :one
+SELECT EXISTS (
+  SELECT 1
+  FROM imported_transactions
+  WHERE account_id = @account_id
+    AND fitid = @fitid
+) AS found;
+
+-- name: RecordImportedTransaction :exec
+INSERT INTO imported_transactions (account_id, fitid, created_at, transaction_id)
+VALUES (
+    @account_id,
+    @fitid,
+    DEFAULT,
+    @transaction_id
+)
+ON CONFLICT (account_id, fitid) DO NOTHING;
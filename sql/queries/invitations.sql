@@ -0,0 +1,61 @@
+-- name: CreateBudgetInvitation :one
+INSERT INTO budget_invitations (id, created_at, updated_at, budget_id, inviter_id, invitee_id, hashed_token, member_role, expires_at)
+VALUES (
+    gen_random_uuid(),
+    DEFAULT,
+    DEFAULT,
+    @budget_id,
+    @inviter_id,
+    @invitee_id,
+    @hashed_token,
+    @member_role,
+    @expires_at
+)
+ON CONFLICT (budget_id, invitee_id) DO UPDATE
+SET updated_at = NOW(),
+    inviter_id = EXCLUDED.inviter_id,
+    member_role = EXCLUDED.member_role,
+    expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: GetBudgetInvitationByID :one
+SELECT *
+FROM budget_invitations
+WHERE id = $1;
+
+-- name: GetBudgetInvitations :many
+SELECT *
+FROM budget_invitations
+WHERE budget_id = $1
+  AND expires_at > NOW()
+ORDER BY created_at;
+
+-- name: GetUserInvitations :many
+SELECT budget_invitations.*, budgets.name AS budget_name
+FROM budget_invitations
+JOIN budgets ON budget_invitations.budget_id = budgets.id
+WHERE budget_invitations.invitee_id = $1
+  AND budget_invitations.expires_at > NOW()
+ORDER BY budget_invitations.created_at;
+
+-- name: ConsumeUserInvitation :one
+DELETE FROM budget_invitations
+WHERE id = @id
+  AND invitee_id = @invitee_id
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: ConsumeInvitationByToken :one
+DELETE FROM budget_invitations
+WHERE hashed_token = $1
+  AND expires_at > NOW()
+RETURNING *;
+
+-- name: RevokeBudgetInvitation :execrows
+DELETE FROM budget_invitations
+WHERE id = $1
+  AND budget_id = $2;
+
+-- name: DeleteExpiredBudgetInvitations :exec
+DELETE FROM budget_invitations
+WHERE expires_at <= NOW();
@@ -0,0 +1,45 @@
+-- name: GetLoginThrottle :one
+SELECT *
+FROM login_throttles
+WHERE scope = $1
+AND subject = $2;
+
+-- name: RecordFailedAttempt :one
+INSERT INTO login_throttles (scope, subject, created_at, updated_at, failed_attempts, last_failed_at, blocked_until)
+VALUES (
+    @scope,
+    @subject,
+    DEFAULT,
+    DEFAULT,
+    1,
+    @failed_at,
+    NULL
+)
+ON CONFLICT (scope, subject) DO UPDATE
+SET failed_attempts = CASE
+      WHEN login_throttles.last_failed_at < @forget_before THEN 1
+      ELSE login_throttles.failed_attempts + 1
+    END,
+    last_failed_at = EXCLUDED.last_failed_at,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: SetLoginThrottleBlockedUntil :exec
+UPDATE login_throttles
+SET blocked_until = $3, updated_at = NOW()
+WHERE scope = $1
+AND subject = $2;
+
+-- name: GetBlockedLoginThrottles :many
+SELECT *
+FROM login_throttles
+WHERE blocked_until > $1
+ORDER BY blocked_until DESC;
+
+-- name: ClearLoginThrottle :execrows
+DELETE FROM login_throttles
+WHERE scope = $1
+AND subject = $2;
+
+-- name: ClearLoginThrottles :execrows
+DELETE FROM login_throttles;
@@ -0,0 +1,41 @@
+-- name: CreateOIDCLogin :exec
+INSERT INTO oidc_logins (state, created_at, expires_at, nonce, code_verifier, user_id, binding_hash)
+VALUES (
+    $1,
+    DEFAULT,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
+
+-- name: ConsumeOIDCLogin :one
+DELETE FROM oidc_logins
+WHERE state = $1
+AND binding_hash = $2
+AND expires_at > NOW()
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLogins :exec
+DELETE FROM oidc_logins
+WHERE expires_at <= NOW();
+
+/* IDENTITIES */
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (issuer, subject, created_at, user_id)
+VALUES (
+    $1,
+    $2,
+    DEFAULT,
+    $3
+)
+RETURNING *;
+
+-- name: GetUserByIdentity :one
+SELECT users.*
+FROM users
+JOIN user_identities ON users.id = user_identities.user_id
+WHERE user_identities.issuer = $1
+AND user_identities.subject = $2;
@@ -31,11 +31,20 @@ UPDATE transactions
 SET payee_id = @new_payee_id
 WHERE payee_id = @old_payee_id;
 
+-- name: ReassignRecurringTransactions :exec
+UPDATE recurring_transactions
+SET updated_at = NOW(), payee_id = @new_payee_id
+WHERE payee_id = @old_payee_id;
+
 -- name: IsPayeeInUse :one
 SELECT EXISTS (
   SELECT 1
   FROM transactions
   WHERE payee_id = $1
+) OR EXISTS (
+  SELECT 1
+  FROM recurring_transactions
+  WHERE payee_id = $1
 ) AS found;
 
 -- name: DeletePayee :exec
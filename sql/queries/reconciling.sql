@@ -0,0 +1,46 @@
+-- name: GetClearedBalance :one
+SELECT CAST(COALESCE(SUM(ts.amount), 0) AS BIGINT) AS total
+FROM transactions t
+JOIN transaction_splits ts ON ts.transaction_id = t.id
+WHERE t.account_id = @account_id
+  AND t.cleared
+  AND t.transaction_date <= @statement_date;
+
+-- name: ReconcileTransactions :execrows
+UPDATE transactions
+SET
+  updated_at = NOW(),
+  cleared = TRUE,
+  reconciled = TRUE
+WHERE account_id = @account_id
+  AND cleared
+  AND NOT reconciled
+  AND transaction_date <= @statement_date;
+
+-- name: CreateReconciliation :one
+INSERT INTO reconciliations (
+    id, created_at, account_id, reconciler_id,
+    statement_date, statement_balance, adjustment_transaction_id)
+VALUES (
+    gen_random_uuid(),
+    DEFAULT,
+    @account_id,
+    @reconciler_id,
+    @statement_date,
+    @statement_balance,
+    @adjustment_transaction_id
+)
+RETURNING *;
+
+-- name: GetLatestReconciledDate :one
+SELECT statement_date
+FROM reconciliations
+WHERE account_id = $1
+ORDER BY statement_date DESC
+LIMIT 1;
+
+-- name: GetReconciliationsByAccountID :many
+SELECT *
+FROM reconciliations
+WHERE account_id = $1
+ORDER BY statement_date DESC, created_at DESC;
@@ -0,0 +1,109 @@
+-- name: CreateRecurringTransaction :one
+INSERT INTO recurring_transactions (
+    id, created_at, updated_at, budget_id, logger_id,
+    account_id, transfer_account_id, transaction_type,
+    payee_id, notes, cleared, amounts, frequency,
+    frequency_interval, day_option, start_date, end_date, next_date)
+VALUES (
+    gen_random_uuid(),
+    DEFAULT,
+    DEFAULT,
+    @budget_id,
+    @logger_id,
+    @account_id,
+    @transfer_account_id,
+    @transaction_type,
+    @payee_id,
+    @notes,
+    @cleared,
+    @amounts,
+    @frequency,
+    @frequency_interval,
+    @day_option,
+    @start_date,
+    @end_date,
+    @next_date
+)
+RETURNING *;
+
+-- name: GetRecurringTransactionsByBudgetID :many
+SELECT *
+FROM recurring_transactions
+WHERE budget_id = $1
+ORDER BY next_date NULLS LAST, created_at;
+
+-- name: GetRecurringTransactionByID :one
+SELECT *
+FROM recurring_transactions
+WHERE id = $1;
+
+-- name: GetDueRecurringTransactionIDs :many
+SELECT id
+FROM recurring_transactions
+WHERE next_date IS NOT NULL
+  AND next_date <= @as_of::date
+  AND disabled_at IS NULL
+  AND (retry_after IS NULL OR retry_after <= @now::timestamp);
+
+-- NOTE:
+-- Rows already locked by another scheduler are skipped,
+-- so that no occurrence is ever posted twice.
+
+-- name: GetRecurringTransactionForUpdate :one
+SELECT *
+FROM recurring_transactions
+WHERE id = $1
+FOR UPDATE SKIP LOCKED;
+
+-- NOTE:
+-- Posting successfully clears any failures recorded before.
+
+-- name: SetRecurringTransactionNextDate :exec
+UPDATE recurring_transactions
+SET updated_at = NOW(),
+    next_date = @next_date,
+    failure_count = 0,
+    last_error = '',
+    retry_after = NULL
+WHERE id = @id;
+
+-- name: SetRecurringTransactionFailure :exec
+UPDATE recurring_transactions
+SET updated_at = NOW(),
+    failure_count = @failure_count,
+    last_error = @last_error,
+    retry_after = @retry_after,
+    disabled_at = @disabled_at
+WHERE id = @id;
+
+-- NOTE:
+-- Updating a recurring transaction enables it again,
+-- clearing any failures recorded before.
+
+-- name: UpdateRecurringTransaction :one
+UPDATE recurring_transactions
+SET updated_at = NOW(),
+    account_id = @account_id,
+    transfer_account_id = @transfer_account_id,
+    transaction_type = @transaction_type,
+    payee_id = @payee_id,
+    notes = @notes,
+    cleared = @cleared,
+    amounts = @amounts,
+    frequency = @frequency,
+    frequency_interval = @frequency_interval,
+    day_option = @day_option,
+    start_date = @start_date,
+    end_date = @end_date,
+    next_date = @next_date,
+    failure_count = 0,
+    last_error = '',
+    retry_after = NULL,
+    disabled_at = NULL
+WHERE id = @id
+RETURNING *;
+
+-- name: DeleteRecurringTransaction :exec
+DELETE
+FROM recurring_transactions
+WHERE id = $1;
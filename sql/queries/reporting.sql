@@ -10,38 +10,77 @@ FROM rep.get_category_reports_gate(
 )
 GROUP BY month;
 
+-- name: GetReadyToAssign :one
+SELECT rep.get_ready_to_assign(
+  @budget_id::uuid,
+  @month_id::date
+)::bigint AS ready_to_assign;
+
+-- name: GetCategoryReportsBetween :many
+SELECT
+  month::date AS month,
+  category_id::uuid AS category_id,
+  category_name::text AS category_name,
+  assigned::bigint AS assigned,
+  activity::bigint AS activity,
+  balance::bigint AS balance
+FROM rep.get_category_reports(
+  @budget_id::uuid,
+  @start_date::date,
+  @end_date::date
+)
+ORDER BY month, category_name;
+
 -- name: GetMonthCategoryReports :many
 SELECT 
   @month_id::date AS month,
-  category_id::uuid AS category_id,
+  r.category_id::uuid AS category_id,
   COALESCE(g.id, '00000000-0000-0000-0000-000000000000')::uuid AS group_id,
   category_name::text AS category_name,
   COALESCE(assigned, 0)::bigint AS assigned,
   COALESCE(activity, 0)::bigint AS activity,
-  COALESCE(balance, 0)::bigint AS balance
+  COALESCE(balance, 0)::bigint AS balance,
+  COALESCE(needed, 0)::bigint AS needed,
+  COALESCE(underfunded, 0)::bigint AS underfunded,
+  COALESCE(cg.goal_type, '')::text AS goal_type,
+  COALESCE(cg.target_amount, 0)::bigint AS goal_target_amount,
+  cg.target_date AS goal_target_date,
+  COALESCE(r.overspending_rule, '')::text AS overspending_rule,
+  COALESCE(r.overspent, 0)::bigint AS overspent,
+  COALESCE(r.credit_overspent, 0)::bigint AS credit_overspent
 FROM rep.get_category_reports_gate(
   @budget_id::uuid, 
   @month_id::date
 ) AS r
 LEFT JOIN categories c ON r.category_id = c.id
 LEFT JOIN groups g ON c.group_id = g.id
+LEFT JOIN category_goals cg ON r.category_id = cg.category_id
 ORDER BY category_name;
 
 -- name: GetMonthCategoryReport :one
 SELECT 
   @month_id::date AS month,
-  category_id::uuid AS category_id,
+  r.category_id::uuid AS category_id,
   COALESCE(g.id, '00000000-0000-0000-0000-000000000000')::uuid AS group_id,
   category_name::text AS category_name,
   COALESCE(assigned, 0)::bigint AS assigned,
   COALESCE(activity, 0)::bigint AS activity,
-  COALESCE(balance, 0)::bigint AS balance
+  COALESCE(balance, 0)::bigint AS balance,
+  COALESCE(needed, 0)::bigint AS needed,
+  COALESCE(underfunded, 0)::bigint AS underfunded,
+  COALESCE(cg.goal_type, '')::text AS goal_type,
+  COALESCE(cg.target_amount, 0)::bigint AS goal_target_amount,
+  cg.target_date AS goal_target_date,
+  COALESCE(r.overspending_rule, '')::text AS overspending_rule,
+  COALESCE(r.overspent, 0)::bigint AS overspent,
+  COALESCE(r.credit_overspent, 0)::bigint AS credit_overspent
 FROM rep.get_category_reports_gate(
   @budget_id::uuid, 
   @month_id::date
 ) AS r
 LEFT JOIN categories c ON r.category_id = c.id
 LEFT JOIN groups g ON c.group_id = g.id
+LEFT JOIN category_goals cg ON r.category_id = cg.category_id
 WHERE r.category_id = @category_id::uuid
 LIMIT 1;
 
@@ -0,0 +1,28 @@
+-- name: UpsertMemberRestriction :one
+INSERT INTO member_restrictions (budget_id, user_id, created_at, updated_at, allowed_account_ids, allowed_category_ids, max_transaction_amount)
+VALUES (
+    $1,
+    $2,
+    DEFAULT,
+    DEFAULT,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (budget_id, user_id) DO UPDATE
+SET updated_at = NOW(),
+    allowed_account_ids = EXCLUDED.allowed_account_ids,
+    allowed_category_ids = EXCLUDED.allowed_category_ids,
+    max_transaction_amount = EXCLUDED.max_transaction_amount
+RETURNING *;
+
+-- name: GetMemberRestriction :one
+SELECT *
+FROM member_restrictions
+WHERE budget_id = $1
+  AND user_id = $2;
+
+-- name: DeleteMemberRestriction :execrows
+DELETE FROM member_restrictions
+WHERE budget_id = $1
+  AND user_id = $2;
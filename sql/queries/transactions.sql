@@ -75,6 +75,10 @@ WHERE
     (@start_date::date = '0001-01-01' AND @end_date::date = '0001-01-01')
     OR (t.transaction_date BETWEEN @start_date::date AND @end_date::date)
   )
+  AND (
+    sqlc.narg('visible_account_ids')::uuid[] IS NULL
+    OR t.account_id = ANY(sqlc.narg('visible_account_ids')::uuid[])
+  )
 ORDER BY t.transaction_date DESC;
 
 -- name: GetTransactions :many
@@ -102,6 +106,10 @@ WHERE
     (@start_date::date = '0001-01-01' AND @end_date::date = '0001-01-01')
     OR (t.transaction_date BETWEEN @start_date::date AND @end_date::date)
   )
+  AND (
+    sqlc.narg('visible_account_ids')::uuid[] IS NULL
+    OR t.account_id = ANY(sqlc.narg('visible_account_ids')::uuid[])
+  )
 ORDER BY t.transaction_date DESC;
 
 
@@ -0,0 +1,90 @@
+-- name: TrashTransaction :one
+INSERT INTO trashed_transactions (
+    id, created_at, updated_at, budget_id, logger_id,
+    account_id, transaction_type, transaction_date,
+    payee_id, notes, cleared, deleted_at, deleted_by,
+    linked_transaction_id)
+SELECT
+    t.id,
+    t.created_at,
+    t.updated_at,
+    t.budget_id,
+    t.logger_id,
+    t.account_id,
+    t.transaction_type,
+    t.transaction_date,
+    t.payee_id,
+    t.notes,
+    t.cleared,
+    NOW() AT TIME ZONE 'utc',
+    @deleted_by::uuid,
+    sqlc.narg('linked_transaction_id')::uuid
+FROM transactions t
+WHERE t.id = @transaction_id
+RETURNING *;
+
+-- name: TrashTransactionSplits :exec
+INSERT INTO trashed_transaction_splits (id, transaction_id, category_id, amount)
+SELECT id, transaction_id, category_id, amount
+FROM transaction_splits
+WHERE transaction_id = $1;
+
+-- name: GetTrashedTransactionByID :one
+SELECT *
+FROM trashed_transactions
+WHERE id = $1;
+
+-- name: GetTrashedTransactions :many
+SELECT
+  tt.*,
+  COALESCE((
+    SELECT SUM(tts.amount)
+    FROM trashed_transaction_splits tts
+    WHERE tts.transaction_id = tt.id
+  ), 0)::bigint AS total_amount
+FROM trashed_transactions tt
+WHERE
+  tt.budget_id = @budget_id::uuid
+  AND (
+    sqlc.narg('visible_account_ids')::uuid[] IS NULL
+    OR tt.account_id = ANY(sqlc.narg('visible_account_ids')::uuid[])
+  )
+ORDER BY tt.deleted_at DESC;
+
+-- name: RestoreTransaction :one
+INSERT INTO transactions (
+    id, created_at, updated_at, budget_id, logger_id,
+    account_id, transaction_type, transaction_date,
+    payee_id, notes, cleared)
+SELECT
+    id,
+    created_at,
+    updated_at,
+    budget_id,
+    logger_id,
+    account_id,
+    transaction_type,
+    transaction_date,
+    payee_id,
+    notes,
+    cleared
+FROM trashed_transactions
+WHERE id = $1
+RETURNING *;
+
+-- name: RestoreTransactionSplits :exec
+INSERT INTO transaction_splits (id, transaction_id, category_id, amount)
+SELECT id, transaction_id, category_id, amount
+FROM trashed_transaction_splits
+WHERE transaction_id = $1;
+
+-- name: DeleteTrashedTransaction :exec
+DELETE
+FROM trashed_transactions
+WHERE id = $1;
+
+-- name: PurgeTrashedTransactions :many
+DELETE
+FROM trashed_transactions
+WHERE deleted_at < @cutoff
+RETURNING *;
@@ -0,0 +1,58 @@
+-- name: UpsertUserTwoFactor :one
+INSERT INTO user_two_factor (user_id, created_at, updated_at, totp_secret, enabled_at, last_used_step)
+VALUES (
+    $1,
+    DEFAULT,
+    DEFAULT,
+    $2,
+    NULL,
+    0
+)
+ON CONFLICT (user_id) DO UPDATE
+SET totp_secret = EXCLUDED.totp_secret,
+    enabled_at = NULL,
+    last_used_step = 0,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetUserTwoFactor :one
+SELECT *
+FROM user_two_factor
+WHERE user_id = $1;
+
+-- name: EnableUserTwoFactor :exec
+UPDATE user_two_factor
+SET enabled_at = NOW(), updated_at = NOW()
+WHERE user_id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE user_two_factor
+SET last_used_step = @step, updated_at = NOW()
+WHERE user_id = @user_id
+AND last_used_step < @step;
+
+-- name: DeleteUserTwoFactor :exec
+DELETE FROM user_two_factor
+WHERE user_id = $1;
+
+/* RECOVERY CODES */
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (id, created_at, user_id, hashed_code)
+VALUES (
+    gen_random_uuid(),
+    DEFAULT,
+    $1,
+    $2
+);
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1
+AND hashed_code = $2
+AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
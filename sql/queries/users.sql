@@ -11,6 +11,10 @@ VALUES (
 )
 RETURNING *;
 
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
 -- name: GetUserByUsername :one
 SELECT * FROM users
 WHERE username = $1;
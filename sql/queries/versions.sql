@@ -0,0 +1,72 @@
+-- name: CreateTransactionVersion :one
+INSERT INTO transaction_versions (
+    id, created_at, transaction_id, editor_id, account_id,
+    transaction_type, transaction_date, payee_id, notes, cleared)
+SELECT
+    gen_random_uuid(),
+    clock_timestamp() AT TIME ZONE 'utc',
+    t.id,
+    @editor_id::uuid,
+    t.account_id,
+    t.transaction_type,
+    t.transaction_date,
+    t.payee_id,
+    t.notes,
+    t.cleared
+FROM transactions t
+WHERE t.id = @transaction_id
+RETURNING *;
+
+-- name: CreateTransactionVersionSplits :exec
+INSERT INTO transaction_version_splits (version_id, category_id, amount)
+SELECT @version_id::uuid, category_id, amount
+FROM transaction_splits
+WHERE transaction_id = @transaction_id;
+
+-- name: GetLatestTransactionVersion :one
+SELECT *
+FROM transaction_versions
+WHERE transaction_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetTransactionVersionSplits :many
+SELECT *
+FROM transaction_version_splits
+WHERE version_id = $1;
+
+-- name: RevertTransaction :one
+UPDATE transactions t
+SET
+  updated_at = NOW(),
+  account_id = v.account_id,
+  transaction_type = v.transaction_type,
+  transaction_date = v.transaction_date,
+  payee_id = v.payee_id,
+  notes = v.notes,
+  cleared = v.cleared
+FROM transaction_versions v
+WHERE v.id = @version_id
+  AND t.id = v.transaction_id
+RETURNING t.*;
+
+-- name: RevertTransactionSplits :exec
+INSERT INTO transaction_splits (id, transaction_id, category_id, amount)
+SELECT gen_random_uuid(), v.transaction_id, vs.category_id, vs.amount
+FROM transaction_version_splits vs
+JOIN transaction_versions v ON v.id = vs.version_id
+WHERE vs.version_id = $1;
+
+-- name: DeleteTransactionVersion :exec
+DELETE
+FROM transaction_versions
+WHERE id = $1;
+
+-- name: PurgeTransactionVersions :execrows
+DELETE
+FROM transaction_versions v
+WHERE v.created_at < @cutoff
+  OR (
+    NOT EXISTS (SELECT 1 FROM transactions t WHERE t.id = v.transaction_id)
+    AND NOT EXISTS (SELECT 1 FROM trashed_transactions tt WHERE tt.id = v.transaction_id)
+  );
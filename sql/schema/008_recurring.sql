@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE recurring_transactions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    budget_id UUID NOT NULL,
+    logger_id UUID NOT NULL,
+    account_id UUID NOT NULL,
+    transfer_account_id UUID,
+    transaction_type VARCHAR(15) NOT NULL,
+    payee_id UUID NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    cleared BOOLEAN NOT NULL DEFAULT FALSE,
+    amounts JSONB NOT NULL,
+    frequency VARCHAR(10) NOT NULL,
+    frequency_interval INTEGER NOT NULL DEFAULT 1,
+    day_option VARCHAR(20) NOT NULL DEFAULT 'SAME_DAY',
+    start_date DATE NOT NULL,
+    end_date DATE,
+    next_date DATE,
+    FOREIGN KEY (budget_id) REFERENCES budgets(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (logger_id) REFERENCES users(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (account_id) REFERENCES accounts(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (transfer_account_id) REFERENCES accounts(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX recurring_transactions_next_date_idx
+    ON recurring_transactions (next_date)
+    WHERE next_date IS NOT NULL;
+
+-- +goose Down
+DROP TABLE recurring_transactions;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE import_profiles (
+    account_id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    has_header BOOLEAN NOT NULL DEFAULT TRUE,
+    delimiter VARCHAR(1) NOT NULL DEFAULT ',',
+    skip_rows INTEGER NOT NULL DEFAULT 0,
+    date_column TEXT NOT NULL,
+    date_format VARCHAR(20) NOT NULL DEFAULT 'YYYY-MM-DD',
+    amount_column TEXT NOT NULL DEFAULT '',
+    debit_column TEXT NOT NULL DEFAULT '',
+    credit_column TEXT NOT NULL DEFAULT '',
+    payee_column TEXT NOT NULL,
+    memo_column TEXT NOT NULL DEFAULT '',
+    category_column TEXT NOT NULL DEFAULT '',
+    default_category VARCHAR(50) NOT NULL DEFAULT '',
+    sign_convention VARCHAR(25) NOT NULL DEFAULT 'DEPOSITS_POSITIVE',
+    FOREIGN KEY (account_id) REFERENCES accounts(id)
+        ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE import_profiles;
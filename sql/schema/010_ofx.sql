@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE imported_transactions (
+    account_id UUID NOT NULL,
+    fitid TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    transaction_id UUID,
+    PRIMARY KEY (account_id, fitid),
+    FOREIGN KEY (account_id) REFERENCES accounts(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id)
+        ON DELETE SET NULL
+);
+
+-- +goose Down
+DROP TABLE imported_transactions;
@@ -0,0 +1,96 @@
+-- +goose Up
+ALTER TABLE transactions
+ADD COLUMN reconciled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE reconciliations (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    account_id UUID NOT NULL,
+    reconciler_id UUID NOT NULL,
+    statement_date DATE NOT NULL,
+    statement_balance BIGINT NOT NULL,
+    adjustment_transaction_id UUID,
+    FOREIGN KEY (account_id) REFERENCES accounts(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (reconciler_id) REFERENCES users(id)
+        ON DELETE CASCADE,
+    FOREIGN KEY (adjustment_transaction_id) REFERENCES transactions(id)
+        ON DELETE SET NULL
+);
+
+CREATE OR REPLACE VIEW transaction_details AS
+SELECT 
+  t.id,
+  t.transaction_date,
+  t.transaction_type,
+  t.notes,
+  COALESCE(
+    p.name,
+    'Transfer'
+  ) AS payee_name,
+  b.name AS budget_name,
+  a.name AS account_name,
+  u.username AS logger_name,
+  SUM(ts.amount)::bigint AS total_amount,
+  jsonb_object_agg(COALESCE(c.name, 'Uncategorized'), ts.amount) AS splits,
+  t.cleared,
+  t.reconciled
+FROM transactions t
+JOIN transaction_splits ts ON t.id = ts.transaction_id
+LEFT JOIN categories c ON ts.category_id = c.id
+LEFT JOIN payees p ON t.payee_id = p.id
+LEFT JOIN accounts a ON t.account_id = a.id
+LEFT JOIN users u ON t.logger_id = u.id
+LEFT JOIN budgets b ON t.budget_id = b.id
+GROUP BY
+    t.id,
+    t.transaction_date,
+    t.transaction_type,
+    t.notes,
+    p.name,
+    a.name,
+    u.username,
+    b.name
+ORDER BY
+    t.transaction_date DESC;
+
+-- +goose Down
+DROP VIEW transaction_details;
+CREATE VIEW transaction_details AS
+SELECT 
+  t.id,
+  t.transaction_date,
+  t.transaction_type,
+  t.notes,
+  COALESCE(
+    p.name,
+    'Transfer'
+  ) AS payee_name,
+  b.name AS budget_name,
+  a.name AS account_name,
+  u.username AS logger_name,
+  SUM(ts.amount)::bigint AS total_amount,
+  jsonb_object_agg(COALESCE(c.name, 'Uncategorized'), ts.amount) AS splits,
+  t.cleared
+FROM transactions t
+JOIN transaction_splits ts ON t.id = ts.transaction_id
+LEFT JOIN categories c ON ts.category_id = c.id
+LEFT JOIN payees p ON t.payee_id = p.id
+LEFT JOIN accounts a ON t.account_id = a.id
+LEFT JOIN users u ON t.logger_id = u.id
+LEFT JOIN budgets b ON t.budget_id = b.id
+GROUP BY
+    t.id,
+    t.transaction_date,
+    t.transaction_type,
+    t.notes,
+    p.name,
+    a.name,
+    u.username,
+    b.name
+ORDER BY
+    t.transaction_date DESC;
+
+DROP TABLE reconciliations;
+ALTER TABLE transactions
+DROP COLUMN reconciled;
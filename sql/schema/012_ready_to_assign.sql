@@ -0,0 +1,37 @@
+-- +goose Up
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION rep.get_ready_to_assign(
+  b_id UUID,
+  m_id DATE
+)
+RETURNS BIGINT AS $$
+DECLARE
+  v_next_month DATE := (date_trunc('month', m_id) + interval '1 month')::date;
+  v_income BIGINT;
+  v_assigned BIGINT;
+BEGIN
+  -- income is any uncategorized deposit to an on-budget account
+  SELECT COALESCE(SUM(ts.amount), 0)::bigint INTO v_income
+  FROM transaction_splits ts
+  JOIN transactions t ON t.id = ts.transaction_id
+  JOIN accounts a ON t.account_id = a.id
+  WHERE t.budget_id = b_id
+    AND t.transaction_type = 'DEPOSIT'
+    AND a.account_type = 'ON_BUDGET'
+    AND ts.category_id IS NULL
+    AND t.transaction_date < v_next_month;
+
+  SELECT COALESCE(SUM(asg.assigned), 0)::bigint INTO v_assigned
+  FROM assignments asg
+  JOIN categories c ON asg.category_id = c.id
+  WHERE c.budget_id = b_id
+    AND asg.month < v_next_month;
+
+  RETURN v_income - v_assigned;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION IF EXISTS rep.get_ready_to_assign(UUID, DATE);
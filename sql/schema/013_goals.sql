@@ -0,0 +1,373 @@
+-- +goose Up
+CREATE TABLE category_goals (
+  category_id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  goal_type VARCHAR(25) NOT NULL,
+  target_amount BIGINT NOT NULL,
+  target_date DATE,
+  FOREIGN KEY (category_id) REFERENCES categories(id)
+    ON DELETE CASCADE
+);
+
+-- the columns returned by the report functions change, so they are replaced
+DROP FUNCTION rep.get_category_reports_gate(UUID, DATE);
+DROP FUNCTION rep.get_category_reports(UUID, DATE, DATE);
+
+-- +goose StatementBegin
+CREATE FUNCTION rep.get_goal_needed(
+  goal_type TEXT,
+  target_amount BIGINT,
+  target_date DATE,
+  m_id DATE,
+  prev_balance BIGINT
+)
+RETURNS BIGINT AS $$
+DECLARE
+  v_remaining BIGINT := GREATEST(target_amount - prev_balance, 0);
+  v_months_left BIGINT;
+BEGIN
+  CASE goal_type
+    WHEN 'MONTHLY_FUNDING' THEN
+      RETURN target_amount;
+    WHEN 'TARGET_BALANCE', 'MONTHLY_SPENDING' THEN
+      RETURN v_remaining;
+    WHEN 'TARGET_BALANCE_BY_DATE' THEN
+      -- spread what remains evenly across the months left, this one included
+      v_months_left := (
+        (EXTRACT(YEAR FROM target_date) - EXTRACT(YEAR FROM m_id)) * 12
+        + EXTRACT(MONTH FROM target_date) - EXTRACT(MONTH FROM m_id) + 1
+      )::bigint;
+      IF v_months_left <= 1 THEN
+        RETURN v_remaining;
+      END IF;
+      RETURN (v_remaining + v_months_left - 1) / v_months_left;
+    ELSE
+      RETURN 0;
+  END CASE;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION rep.get_goal_underfunded(
+  goal_type TEXT,
+  target_amount BIGINT,
+  needed BIGINT,
+  assigned BIGINT,
+  balance BIGINT
+)
+RETURNS BIGINT AS $$
+BEGIN
+  -- spending counts against a target balance, but not against funding
+  -- set aside to be spent
+  IF goal_type = 'TARGET_BALANCE' THEN
+    RETURN GREATEST(target_amount - balance, 0);
+  ELSIF goal_type IS NULL THEN
+    RETURN 0;
+  END IF;
+  RETURN GREATEST(needed - assigned, 0);
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION rep.get_category_reports(
+  b_id UUID,
+  start_date DATE,
+  end_date DATE
+)
+RETURNS TABLE (
+  month DATE,
+  budget_id UUID,
+  category_id UUID,
+  category_name TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT,
+  needed BIGINT,
+  underfunded BIGINT
+) AS $$
+BEGIN
+  RETURN QUERY
+  WITH budget_categories AS (
+    SELECT c.id, c.name, g.goal_type, g.target_amount, g.target_date
+    FROM categories c
+    LEFT JOIN category_goals g ON g.category_id = c.id
+    WHERE c.budget_id = b_id
+  ),
+  totals AS (
+    SELECT
+      date_trunc('month', agg.dt)::date AS month_id,
+      agg.cat_id,
+      SUM(agg.val_assigned)::bigint AS val_assigned,
+      SUM(agg.val_activity)::bigint AS val_activity
+    FROM (
+      SELECT a.month AS dt, a.category_id AS cat_id, a.assigned AS val_assigned, 0 AS val_activity
+      FROM assignments a
+      JOIN categories c ON a.category_id = c.id
+      WHERE c.budget_id = b_id
+      UNION ALL
+      SELECT t.transaction_date, ts.category_id, 0, ts.amount
+      FROM transaction_splits ts
+      JOIN transactions t ON t.id = ts.transaction_id
+      WHERE t.budget_id = b_id
+    ) agg
+    GROUP BY 1, 2
+  ),
+  calculated_report AS (
+    SELECT
+      m.month_id,
+      c.id AS cat_id,
+      c.name AS cat_name,
+      c.goal_type,
+      c.target_amount,
+      c.target_date,
+      COALESCE(t.val_assigned, 0)::bigint AS assigned,
+      COALESCE(t.val_activity, 0)::bigint AS activity,
+      (SUM(COALESCE(t.val_assigned, 0) + COALESCE(t.val_activity, 0)) 
+          OVER (PARTITION BY c.id ORDER BY m.month_id))::bigint AS balance
+    FROM (
+      SELECT generate_series(
+        (SELECT first_month FROM rep.get_budget_bounds(b_id)),
+        date_trunc('month', end_date),
+        interval '1 month'
+      )::date AS month_id
+    ) m
+    CROSS JOIN budget_categories c
+    LEFT JOIN totals t ON m.month_id = t.month_id AND c.id = t.cat_id
+  ),
+  goal_report AS (
+    SELECT
+      cr.*,
+      rep.get_goal_needed(
+        cr.goal_type, cr.target_amount, cr.target_date, cr.month_id,
+        cr.balance - cr.assigned - cr.activity
+      )::bigint AS needed
+    FROM calculated_report cr
+  )
+  SELECT 
+    gr.month_id::date,
+    b_id::uuid,
+    gr.cat_id::uuid,
+    gr.cat_name::text,
+    gr.assigned::bigint,
+    gr.activity::bigint,
+    gr.balance::bigint,
+    gr.needed::bigint,
+    rep.get_goal_underfunded(
+      gr.goal_type, gr.target_amount, gr.needed, gr.assigned, gr.balance
+    )::bigint
+  FROM goal_report gr
+  WHERE gr.month_id >= date_trunc('month', start_date)::date
+  ORDER BY gr.month_id, gr.cat_name;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION rep.get_category_reports_gate(
+  b_id UUID,
+  m_id DATE
+)
+RETURNS TABLE (
+  month DATE,
+  budget_id UUID,
+  category_id UUID,
+  category_name TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT,
+  needed BIGINT,
+  underfunded BIGINT
+) AS $$
+DECLARE
+  v_bounds RECORD;
+  v_target DATE := date_trunc('month', m_id)::date;
+BEGIN
+  SELECT * INTO v_bounds FROM rep.get_budget_bounds(b_id);
+
+  IF v_bounds.first_month IS NULL OR v_target < v_bounds.first_month THEN
+    RETURN QUERY
+    SELECT
+      v_target::date,
+      b_id::uuid,
+      c.id::uuid,
+      c.name::text,
+      0::bigint,
+      0::bigint,
+      0::bigint,
+      n.needed::bigint,
+      rep.get_goal_underfunded(g.goal_type, g.target_amount, n.needed, 0, 0)::bigint
+    FROM categories c
+    LEFT JOIN category_goals g ON g.category_id = c.id
+    CROSS JOIN LATERAL (
+      SELECT rep.get_goal_needed(g.goal_type, g.target_amount, g.target_date, v_target, 0) AS needed
+    ) n
+    WHERE c.budget_id = b_id;
+    RETURN;
+  END IF;
+
+  -- months past the last month of activity simply carry balances forward
+  RETURN QUERY
+  SELECT
+    r.month::date,
+    b_id::uuid,
+    r.category_id::uuid,
+    r.category_name::text,
+    r.assigned::bigint,
+    r.activity::bigint,
+    r.balance::bigint,
+    r.needed::bigint,
+    r.underfunded::bigint
+  FROM rep.get_category_reports(b_id, v_target, v_target) r
+  WHERE r.month = v_target;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION rep.get_category_reports_gate(UUID, DATE);
+DROP FUNCTION rep.get_category_reports(UUID, DATE, DATE);
+DROP FUNCTION rep.get_goal_underfunded(TEXT, BIGINT, BIGINT, BIGINT, BIGINT);
+DROP FUNCTION rep.get_goal_needed(TEXT, BIGINT, DATE, DATE, BIGINT);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION rep.get_category_reports(
+  b_id UUID,
+  start_date DATE,
+  end_date DATE
+)
+RETURNS TABLE (
+  month DATE,
+  budget_id UUID,
+  category_id UUID,
+  category_name TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT
+) AS $$
+BEGIN
+  RETURN QUERY
+  WITH budget_categories AS (
+    SELECT id, name
+    FROM categories c
+    WHERE c.budget_id = b_id
+  ),
+  totals AS (
+    SELECT
+      date_trunc('month', agg.dt)::date AS month_id,
+      agg.cat_id,
+      SUM(agg.val_assigned)::bigint AS val_assigned,
+      SUM(agg.val_activity)::bigint AS val_activity
+    FROM (
+      SELECT a.month AS dt, a.category_id AS cat_id, a.assigned AS val_assigned, 0 AS val_activity
+      FROM assignments a
+      JOIN categories c ON a.category_id = c.id
+      WHERE c.budget_id = b_id
+      UNION ALL
+      SELECT t.transaction_date, ts.category_id, 0, ts.amount
+      FROM transaction_splits ts
+      JOIN transactions t ON t.id = ts.transaction_id
+      WHERE t.budget_id = b_id
+    ) agg
+    GROUP BY 1, 2
+  ),
+  calculated_report AS (
+    SELECT
+      m.month_id,
+      c.id AS cat_id,
+      c.name AS cat_name,
+      COALESCE(t.val_assigned, 0)::bigint AS assigned,
+      COALESCE(t.val_activity, 0)::bigint AS activity,
+      (SUM(COALESCE(t.val_assigned, 0) + COALESCE(t.val_activity, 0)) 
+          OVER (PARTITION BY c.id ORDER BY m.month_id))::bigint AS balance
+    FROM (
+      SELECT generate_series(
+        (SELECT first_month FROM rep.get_budget_bounds(b_id)),
+        date_trunc('month', end_date),
+        interval '1 month'
+      )::date AS month_id
+    ) m
+    CROSS JOIN budget_categories c
+    LEFT JOIN totals t ON m.month_id = t.month_id AND c.id = t.cat_id
+  )
+  SELECT 
+    cr.month_id::date,
+    b_id::uuid,
+    cr.cat_id::uuid,
+    cr.cat_name::text,
+    cr.assigned::bigint,
+    cr.activity::bigint,
+    cr.balance::bigint
+  FROM calculated_report cr
+  WHERE cr.month_id >= date_trunc('month', start_date)::date
+  ORDER BY cr.month_id, cr.cat_name;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION rep.get_category_reports_gate(
+  b_id UUID,
+  m_id DATE
+)
+RETURNS TABLE (
+  month DATE,
+  budget_id UUID,
+  category_id UUID,
+  category_name TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT
+) AS $$
+DECLARE
+  v_bounds RECORD;
+  v_target DATE := date_trunc('month', m_id)::date;
+BEGIN
+  SELECT * INTO v_bounds FROM rep.get_budget_bounds(b_id);
+
+  IF v_bounds.first_month IS NULL OR v_target < v_bounds.first_month THEN
+    RETURN QUERY
+    SELECT
+      v_target::date,
+      b_id::uuid,
+      c.id::uuid,
+      c.name::text,
+      0::bigint,
+      0::bigint,
+      0::bigint
+    FROM categories c
+    WHERE c.budget_id = b_id;
+    RETURN;
+  ELSIF v_target > v_bounds.last_month THEN
+    RETURN QUERY 
+    SELECT 
+      v_target::date,
+      b_id::uuid,
+      r.category_id::uuid,
+      r.category_name::text,
+      0::bigint,
+      0::bigint,
+      r.balance::bigint
+    FROM rep.get_category_reports(b_id, v_bounds.first_month, v_bounds.last_month) r
+    WHERE r.month = v_bounds.last_month;
+    RETURN;
+  END IF;
+
+  RETURN QUERY
+  SELECT
+    r.month::date,
+    b_id::uuid,
+    r.category_id::uuid,
+    r.category_name::text,
+    r.assigned::bigint,
+    r.activity::bigint,
+    r.balance::bigint
+  FROM rep.get_category_reports(b_id, v_bounds.first_month, v_bounds.last_month) r
+  WHERE r.month = v_target;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+DROP TABLE category_goals;
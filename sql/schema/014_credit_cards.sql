@@ -0,0 +1,273 @@
+-- +goose Up
+ALTER TABLE accounts
+ADD COLUMN payment_category_id UUID REFERENCES categories(id)
+  ON DELETE SET NULL;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION rep.get_category_reports(
+  b_id UUID,
+  start_date DATE,
+  end_date DATE
+)
+RETURNS TABLE (
+  month DATE,
+  budget_id UUID,
+  category_id UUID,
+  category_name TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT,
+  needed BIGINT,
+  underfunded BIGINT
+) AS $$
+BEGIN
+  RETURN QUERY
+  WITH budget_categories AS (
+    SELECT c.id, c.name, g.goal_type, g.target_amount, g.target_date
+    FROM categories c
+    LEFT JOIN category_goals g ON g.category_id = c.id
+    WHERE c.budget_id = b_id
+  ),
+  totals AS (
+    SELECT
+      date_trunc('month', agg.dt)::date AS month_id,
+      agg.cat_id,
+      SUM(agg.val_assigned)::bigint AS val_assigned,
+      SUM(agg.val_activity)::bigint AS val_activity
+    FROM (
+      SELECT a.month AS dt, a.category_id AS cat_id, a.assigned AS val_assigned, 0 AS val_activity
+      FROM assignments a
+      JOIN categories c ON a.category_id = c.id
+      WHERE c.budget_id = b_id
+      UNION ALL
+      SELECT t.transaction_date, ts.category_id, 0, ts.amount
+      FROM transaction_splits ts
+      JOIN transactions t ON t.id = ts.transaction_id
+      WHERE t.budget_id = b_id
+      UNION ALL
+      -- spending on a credit card moves money from the spent category into
+      -- the payment category of the card; payments made to the card draw it down
+      SELECT t.transaction_date, acc.payment_category_id, 0, -ts.amount
+      FROM transaction_splits ts
+      JOIN transactions t ON t.id = ts.transaction_id
+      JOIN accounts acc ON t.account_id = acc.id
+      WHERE t.budget_id = b_id
+        AND acc.account_type = 'CREDIT_CARD'
+        AND acc.payment_category_id IS NOT NULL
+        AND (ts.category_id IS NOT NULL OR t.transaction_type = 'TRANSFER_TO')
+    ) agg
+    GROUP BY 1, 2
+  ),
+  calculated_report AS (
+    SELECT
+      m.month_id,
+      c.id AS cat_id,
+      c.name AS cat_name,
+      c.goal_type,
+      c.target_amount,
+      c.target_date,
+      COALESCE(t.val_assigned, 0)::bigint AS assigned,
+      COALESCE(t.val_activity, 0)::bigint AS activity,
+      (SUM(COALESCE(t.val_assigned, 0) + COALESCE(t.val_activity, 0)) 
+          OVER (PARTITION BY c.id ORDER BY m.month_id))::bigint AS balance
+    FROM (
+      SELECT generate_series(
+        (SELECT first_month FROM rep.get_budget_bounds(b_id)),
+        date_trunc('month', end_date),
+        interval '1 month'
+      )::date AS month_id
+    ) m
+    CROSS JOIN budget_categories c
+    LEFT JOIN totals t ON m.month_id = t.month_id AND c.id = t.cat_id
+  ),
+  goal_report AS (
+    SELECT
+      cr.*,
+      rep.get_goal_needed(
+        cr.goal_type, cr.target_amount, cr.target_date, cr.month_id,
+        cr.balance - cr.assigned - cr.activity
+      )::bigint AS needed
+    FROM calculated_report cr
+  )
+  SELECT 
+    gr.month_id::date,
+    b_id::uuid,
+    gr.cat_id::uuid,
+    gr.cat_name::text,
+    gr.assigned::bigint,
+    gr.activity::bigint,
+    gr.balance::bigint,
+    gr.needed::bigint,
+    rep.get_goal_underfunded(
+      gr.goal_type, gr.target_amount, gr.needed, gr.assigned, gr.balance
+    )::bigint
+  FROM goal_report gr
+  WHERE gr.month_id >= date_trunc('month', start_date)::date
+  ORDER BY gr.month_id, gr.cat_name;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION rep.get_ready_to_assign(
+  b_id UUID,
+  m_id DATE
+)
+RETURNS BIGINT AS $$
+DECLARE
+  v_next_month DATE := (date_trunc('month', m_id) + interval '1 month')::date;
+  v_income BIGINT;
+  v_assigned BIGINT;
+BEGIN
+  -- income is any uncategorized deposit to an on-budget or credit card account
+  SELECT COALESCE(SUM(ts.amount), 0)::bigint INTO v_income
+  FROM transaction_splits ts
+  JOIN transactions t ON t.id = ts.transaction_id
+  JOIN accounts a ON t.account_id = a.id
+  WHERE t.budget_id = b_id
+    AND t.transaction_type = 'DEPOSIT'
+    AND a.account_type IN ('ON_BUDGET', 'CREDIT_CARD')
+    AND ts.category_id IS NULL
+    AND t.transaction_date < v_next_month;
+
+  SELECT COALESCE(SUM(asg.assigned), 0)::bigint INTO v_assigned
+  FROM assignments asg
+  JOIN categories c ON asg.category_id = c.id
+  WHERE c.budget_id = b_id
+    AND asg.month < v_next_month;
+
+  RETURN v_income - v_assigned;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION rep.get_category_reports(
+  b_id UUID,
+  start_date DATE,
+  end_date DATE
+)
+RETURNS TABLE (
+  month DATE,
+  budget_id UUID,
+  category_id UUID,
+  category_name TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT,
+  needed BIGINT,
+  underfunded BIGINT
+) AS $$
+BEGIN
+  RETURN QUERY
+  WITH budget_categories AS (
+    SELECT c.id, c.name, g.goal_type, g.target_amount, g.target_date
+    FROM categories c
+    LEFT JOIN category_goals g ON g.category_id = c.id
+    WHERE c.budget_id = b_id
+  ),
+  totals AS (
+    SELECT
+      date_trunc('month', agg.dt)::date AS month_id,
+      agg.cat_id,
+      SUM(agg.val_assigned)::bigint AS val_assigned,
+      SUM(agg.val_activity)::bigint AS val_activity
+    FROM (
+      SELECT a.month AS dt, a.category_id AS cat_id, a.assigned AS val_assigned, 0 AS val_activity
+      FROM assignments a
+      JOIN categories c ON a.category_id = c.id
+      WHERE c.budget_id = b_id
+      UNION ALL
+      SELECT t.transaction_date, ts.category_id, 0, ts.amount
+      FROM transaction_splits ts
+      JOIN transactions t ON t.id = ts.transaction_id
+      WHERE t.budget_id = b_id
+    ) agg
+    GROUP BY 1, 2
+  ),
+  calculated_report AS (
+    SELECT
+      m.month_id,
+      c.id AS cat_id,
+      c.name AS cat_name,
+      c.goal_type,
+      c.target_amount,
+      c.target_date,
+      COALESCE(t.val_assigned, 0)::bigint AS assigned,
+      COALESCE(t.val_activity, 0)::bigint AS activity,
+      (SUM(COALESCE(t.val_assigned, 0) + COALESCE(t.val_activity, 0)) 
+          OVER (PARTITION BY c.id ORDER BY m.month_id))::bigint AS balance
+    FROM (
+      SELECT generate_series(
+        (SELECT first_month FROM rep.get_budget_bounds(b_id)),
+        date_trunc('month', end_date),
+        interval '1 month'
+      )::date AS month_id
+    ) m
+    CROSS JOIN budget_categories c
+    LEFT JOIN totals t ON m.month_id = t.month_id AND c.id = t.cat_id
+  ),
+  goal_report AS (
+    SELECT
+      cr.*,
+      rep.get_goal_needed(
+        cr.goal_type, cr.target_amount, cr.target_date, cr.month_id,
+        cr.balance - cr.assigned - cr.activity
+      )::bigint AS needed
+    FROM calculated_report cr
+  )
+  SELECT 
+    gr.month_id::date,
+    b_id::uuid,
+    gr.cat_id::uuid,
+    gr.cat_name::text,
+    gr.assigned::bigint,
+    gr.activity::bigint,
+    gr.balance::bigint,
+    gr.needed::bigint,
+    rep.get_goal_underfunded(
+      gr.goal_type, gr.target_amount, gr.needed, gr.assigned, gr.balance
+    )::bigint
+  FROM goal_report gr
+  WHERE gr.month_id >= date_trunc('month', start_date)::date
+  ORDER BY gr.month_id, gr.cat_name;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION rep.get_ready_to_assign(
+  b_id UUID,
+  m_id DATE
+)
+RETURNS BIGINT AS $$
+DECLARE
+  v_next_month DATE := (date_trunc('month', m_id) + interval '1 month')::date;
+  v_income BIGINT;
+  v_assigned BIGINT;
+BEGIN
+  -- income is any uncategorized deposit to an on-budget account
+  SELECT COALESCE(SUM(ts.amount), 0)::bigint INTO v_income
+  FROM transaction_splits ts
+  JOIN transactions t ON t.id = ts.transaction_id
+  JOIN accounts a ON t.account_id = a.id
+  WHERE t.budget_id = b_id
+    AND t.transaction_type = 'DEPOSIT'
+    AND a.account_type = 'ON_BUDGET'
+    AND ts.category_id IS NULL
+    AND t.transaction_date < v_next_month;
+
+  SELECT COALESCE(SUM(asg.assigned), 0)::bigint INTO v_assigned
+  FROM assignments asg
+  JOIN categories c ON asg.category_id = c.id
+  WHERE c.budget_id = b_id
+    AND asg.month < v_next_month;
+
+  RETURN v_income - v_assigned;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+ALTER TABLE accounts
+DROP COLUMN payment_category_id;
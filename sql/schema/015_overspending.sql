@@ -0,0 +1,629 @@
+-- +goose Up
+-- RESET: overspending is cleared at the end of each month; cash overspending
+-- is taken from the money ready to assign the month after, while overspending
+-- on credit cards becomes debt on the card.
+-- CARRY: overspending is carried forward as a negative balance.
+ALTER TABLE budgets
+ADD COLUMN overspending_rule VARCHAR(10) NOT NULL DEFAULT 'CARRY';
+
+-- BUDGET: the rule of the budget is followed.
+ALTER TABLE categories
+ADD COLUMN overspending_rule VARCHAR(10) NOT NULL DEFAULT 'BUDGET';
+
+-- +goose StatementBegin
+-- the rule a category follows, where it may defer to its budget
+CREATE FUNCTION rep.get_overspending_rule(
+  category_rule TEXT,
+  budget_rule TEXT
+)
+RETURNS TEXT AS $$
+  SELECT CASE WHEN category_rule = 'BUDGET' THEN budget_rule ELSE category_rule END;
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- what was assigned to, and spent from, a category in a month
+CREATE TYPE rep.category_month AS (
+  month_id DATE,
+  cat_id UUID,
+  rule TEXT,
+  assigned BIGINT,
+  activity BIGINT
+);
+
+-- +goose StatementBegin
+-- the balance of each category in each month is the running total of what
+-- was assigned to and spent from it; a RESET balance is the running total
+-- less its lowest point before the month, so that each month starts no
+-- lower than zero
+CREATE FUNCTION rep.get_category_balances(
+  category_months rep.category_month[]
+)
+RETURNS TABLE (
+  month_id DATE,
+  cat_id UUID,
+  rule TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT
+) AS $$
+  WITH running AS (
+    SELECT
+      cm.*,
+      (SUM(cm.assigned + cm.activity)
+          OVER (PARTITION BY cm.cat_id ORDER BY cm.month_id))::bigint AS running
+    FROM unnest(category_months) cm
+  )
+  SELECT
+    r.month_id,
+    r.cat_id,
+    r.rule,
+    r.assigned,
+    r.activity,
+    (CASE
+      WHEN r.rule = 'RESET' THEN r.running - LEAST(COALESCE(
+        MIN(r.running) OVER (
+          PARTITION BY r.cat_id ORDER BY r.month_id
+          ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+        ), 0), 0)
+      ELSE r.running
+    END)::bigint
+  FROM running r;
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- the columns returned by the report functions change, so they are replaced
+DROP FUNCTION rep.get_category_reports_gate(UUID, DATE);
+DROP FUNCTION rep.get_category_reports(UUID, DATE, DATE);
+
+-- +goose StatementBegin
+CREATE FUNCTION rep.get_category_reports(
+  b_id UUID,
+  start_date DATE,
+  end_date DATE
+)
+RETURNS TABLE (
+  month DATE,
+  budget_id UUID,
+  category_id UUID,
+  category_name TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT,
+  needed BIGINT,
+  underfunded BIGINT,
+  overspending_rule TEXT,
+  overspent BIGINT,
+  credit_overspent BIGINT
+) AS $$
+BEGIN
+  RETURN QUERY
+  WITH budget_categories AS (
+    SELECT
+      c.id,
+      c.name,
+      g.goal_type,
+      g.target_amount,
+      g.target_date,
+      rep.get_overspending_rule(c.overspending_rule, b.overspending_rule) AS rule,
+      EXISTS (
+        SELECT 1 FROM accounts acc WHERE acc.payment_category_id = c.id
+      ) AS is_payment
+    FROM categories c
+    JOIN budgets b ON b.id = c.budget_id
+    LEFT JOIN category_goals g ON g.category_id = c.id
+    WHERE c.budget_id = b_id
+  ),
+  months AS (
+    SELECT generate_series(
+      (SELECT first_month FROM rep.get_budget_bounds(b_id)),
+      date_trunc('month', end_date),
+      interval '1 month'
+    )::date AS month_id
+  ),
+  totals AS (
+    SELECT
+      date_trunc('month', agg.dt)::date AS month_id,
+      agg.cat_id,
+      SUM(agg.val_assigned)::bigint AS val_assigned,
+      SUM(agg.val_activity)::bigint AS val_activity
+    FROM (
+      SELECT a.month AS dt, a.category_id AS cat_id, a.assigned AS val_assigned, 0 AS val_activity
+      FROM assignments a
+      JOIN categories c ON a.category_id = c.id
+      WHERE c.budget_id = b_id
+      UNION ALL
+      SELECT t.transaction_date, ts.category_id, 0, ts.amount
+      FROM transaction_splits ts
+      JOIN transactions t ON t.id = ts.transaction_id
+      WHERE t.budget_id = b_id
+    ) agg
+    GROUP BY 1, 2
+  ),
+  -- balances of all but the payment categories of credit cards,
+  -- which depend upon them
+  spending_report AS (
+    SELECT *
+    FROM rep.get_category_balances(ARRAY(
+      SELECT ROW(
+        m.month_id,
+        c.id,
+        c.rule,
+        COALESCE(t.val_assigned, 0),
+        COALESCE(t.val_activity, 0)
+      )::rep.category_month
+      FROM months m
+      CROSS JOIN budget_categories c
+      LEFT JOIN totals t ON m.month_id = t.month_id AND c.id = t.cat_id
+      WHERE NOT c.is_payment
+    ))
+  ),
+  -- net spending from each category on each credit card
+  card_spending AS (
+    SELECT
+      date_trunc('month', t.transaction_date)::date AS month_id,
+      ts.category_id AS cat_id,
+      acc.id AS account_id,
+      acc.payment_category_id AS payment_cat_id,
+      (-SUM(ts.amount))::bigint AS spent
+    FROM transaction_splits ts
+    JOIN transactions t ON t.id = ts.transaction_id
+    JOIN accounts acc ON t.account_id = acc.id
+    WHERE t.budget_id = b_id
+      AND acc.account_type = 'CREDIT_CARD'
+      AND acc.payment_category_id IS NOT NULL
+      AND ts.category_id IS NOT NULL
+    GROUP BY 1, 2, 3, 4
+  ),
+  -- overspending which became debt on a card is whatever the card spending
+  -- of a RESET category left uncovered
+  credit_overspending AS (
+    SELECT
+      sp.month_id,
+      sp.cat_id,
+      LEAST(
+        GREATEST(-sp.balance, 0),
+        COALESCE((
+          SELECT SUM(GREATEST(cs.spent, 0))
+          FROM card_spending cs
+          WHERE cs.month_id = sp.month_id AND cs.cat_id = sp.cat_id
+        ), 0)
+      )::bigint AS credit_overspent
+    FROM spending_report sp
+    WHERE sp.rule = 'RESET'
+  ),
+  -- debt is taken on by each card in turn, up to what was spent on it
+  card_moves AS (
+    SELECT
+      cs.month_id,
+      cs.payment_cat_id,
+      cs.spent,
+      LEAST(
+        GREATEST(cs.spent, 0),
+        GREATEST(COALESCE(co.credit_overspent, 0) - COALESCE(
+          SUM(GREATEST(cs.spent, 0)) OVER (
+            PARTITION BY cs.month_id, cs.cat_id ORDER BY cs.account_id
+            ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+          ), 0), 0)
+      )::bigint AS debt
+    FROM card_spending cs
+    LEFT JOIN credit_overspending co
+      ON co.month_id = cs.month_id AND co.cat_id = cs.cat_id
+  ),
+  -- spending on a credit card moves money from the spent category into the
+  -- payment category of the card, except where it became debt; payments made
+  -- to the card draw it down
+  payment_totals AS (
+    SELECT agg.month_id, agg.cat_id, SUM(agg.val_activity)::bigint AS val_activity
+    FROM (
+      SELECT cm.month_id, cm.payment_cat_id AS cat_id, cm.spent - cm.debt AS val_activity
+      FROM card_moves cm
+      UNION ALL
+      SELECT date_trunc('month', t.transaction_date)::date, acc.payment_category_id, -ts.amount
+      FROM transaction_splits ts
+      JOIN transactions t ON t.id = ts.transaction_id
+      JOIN accounts acc ON t.account_id = acc.id
+      WHERE t.budget_id = b_id
+        AND acc.account_type = 'CREDIT_CARD'
+        AND acc.payment_category_id IS NOT NULL
+        AND t.transaction_type = 'TRANSFER_TO'
+    ) agg
+    GROUP BY 1, 2
+  ),
+  payment_report AS (
+    SELECT *
+    FROM rep.get_category_balances(ARRAY(
+      SELECT ROW(
+        m.month_id,
+        c.id,
+        c.rule,
+        COALESCE(t.val_assigned, 0),
+        COALESCE(t.val_activity, 0) + COALESCE(pt.val_activity, 0)
+      )::rep.category_month
+      FROM months m
+      CROSS JOIN budget_categories c
+      LEFT JOIN totals t ON m.month_id = t.month_id AND c.id = t.cat_id
+      LEFT JOIN payment_totals pt ON m.month_id = pt.month_id AND c.id = pt.cat_id
+      WHERE c.is_payment
+    ))
+  ),
+  calculated_report AS (
+    SELECT
+      r.month_id,
+      c.id AS cat_id,
+      c.name AS cat_name,
+      c.goal_type,
+      c.target_amount,
+      c.target_date,
+      r.rule,
+      r.assigned,
+      r.activity,
+      r.balance,
+      COALESCE(co.credit_overspent, 0)::bigint AS credit_overspent
+    FROM (
+      SELECT * FROM spending_report
+      UNION ALL
+      SELECT * FROM payment_report
+    ) r
+    JOIN budget_categories c ON c.id = r.cat_id
+    LEFT JOIN credit_overspending co
+      ON co.month_id = r.month_id AND co.cat_id = r.cat_id
+  ),
+  goal_report AS (
+    SELECT
+      cr.*,
+      rep.get_goal_needed(
+        cr.goal_type, cr.target_amount, cr.target_date, cr.month_id,
+        cr.balance - cr.assigned - cr.activity
+      )::bigint AS needed
+    FROM calculated_report cr
+  )
+  SELECT 
+    gr.month_id::date,
+    b_id::uuid,
+    gr.cat_id::uuid,
+    gr.cat_name::text,
+    gr.assigned::bigint,
+    gr.activity::bigint,
+    gr.balance::bigint,
+    gr.needed::bigint,
+    rep.get_goal_underfunded(
+      gr.goal_type, gr.target_amount, gr.needed, gr.assigned, gr.balance
+    )::bigint,
+    gr.rule::text,
+    GREATEST(-gr.balance, 0)::bigint,
+    gr.credit_overspent::bigint
+  FROM goal_report gr
+  WHERE gr.month_id >= date_trunc('month', start_date)::date
+  ORDER BY gr.month_id, gr.cat_name;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION rep.get_category_reports_gate(
+  b_id UUID,
+  m_id DATE
+)
+RETURNS TABLE (
+  month DATE,
+  budget_id UUID,
+  category_id UUID,
+  category_name TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT,
+  needed BIGINT,
+  underfunded BIGINT,
+  overspending_rule TEXT,
+  overspent BIGINT,
+  credit_overspent BIGINT
+) AS $$
+DECLARE
+  v_bounds RECORD;
+  v_target DATE := date_trunc('month', m_id)::date;
+BEGIN
+  SELECT * INTO v_bounds FROM rep.get_budget_bounds(b_id);
+
+  IF v_bounds.first_month IS NULL OR v_target < v_bounds.first_month THEN
+    RETURN QUERY
+    SELECT
+      v_target::date,
+      b_id::uuid,
+      c.id::uuid,
+      c.name::text,
+      0::bigint,
+      0::bigint,
+      0::bigint,
+      n.needed::bigint,
+      rep.get_goal_underfunded(g.goal_type, g.target_amount, n.needed, 0, 0)::bigint,
+      rep.get_overspending_rule(c.overspending_rule, b.overspending_rule),
+      0::bigint,
+      0::bigint
+    FROM categories c
+    JOIN budgets b ON b.id = c.budget_id
+    LEFT JOIN category_goals g ON g.category_id = c.id
+    CROSS JOIN LATERAL (
+      SELECT rep.get_goal_needed(g.goal_type, g.target_amount, g.target_date, v_target, 0) AS needed
+    ) n
+    WHERE c.budget_id = b_id;
+    RETURN;
+  END IF;
+
+  -- months past the last month of activity simply carry balances forward
+  RETURN QUERY
+  SELECT
+    r.month::date,
+    b_id::uuid,
+    r.category_id::uuid,
+    r.category_name::text,
+    r.assigned::bigint,
+    r.activity::bigint,
+    r.balance::bigint,
+    r.needed::bigint,
+    r.underfunded::bigint,
+    r.overspending_rule::text,
+    r.overspent::bigint,
+    r.credit_overspent::bigint
+  FROM rep.get_category_reports(b_id, v_target, v_target) r
+  WHERE r.month = v_target;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION rep.get_ready_to_assign(
+  b_id UUID,
+  m_id DATE
+)
+RETURNS BIGINT AS $$
+DECLARE
+  v_month DATE := date_trunc('month', m_id)::date;
+  v_next_month DATE := (date_trunc('month', m_id) + interval '1 month')::date;
+  v_income BIGINT;
+  v_assigned BIGINT;
+  v_overspent BIGINT;
+BEGIN
+  -- income is any uncategorized deposit to an on-budget or credit card account
+  SELECT COALESCE(SUM(ts.amount), 0)::bigint INTO v_income
+  FROM transaction_splits ts
+  JOIN transactions t ON t.id = ts.transaction_id
+  JOIN accounts a ON t.account_id = a.id
+  WHERE t.budget_id = b_id
+    AND t.transaction_type = 'DEPOSIT'
+    AND a.account_type IN ('ON_BUDGET', 'CREDIT_CARD')
+    AND ts.category_id IS NULL
+    AND t.transaction_date < v_next_month;
+
+  SELECT COALESCE(SUM(asg.assigned), 0)::bigint INTO v_assigned
+  FROM assignments asg
+  JOIN categories c ON asg.category_id = c.id
+  WHERE c.budget_id = b_id
+    AND asg.month < v_next_month;
+
+  -- cash overspending cleared from a category is taken the month after
+  SELECT COALESCE(SUM(r.overspent - r.credit_overspent), 0)::bigint INTO v_overspent
+  FROM rep.get_category_reports(
+    b_id,
+    (SELECT first_month FROM rep.get_budget_bounds(b_id)),
+    (v_month - interval '1 month')::date
+  ) r
+  WHERE r.overspending_rule = 'RESET'
+    AND r.month < v_month;
+
+  RETURN v_income - v_assigned - v_overspent;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION rep.get_category_reports_gate(UUID, DATE);
+DROP FUNCTION rep.get_category_reports(UUID, DATE, DATE);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION rep.get_category_reports(
+  b_id UUID,
+  start_date DATE,
+  end_date DATE
+)
+RETURNS TABLE (
+  month DATE,
+  budget_id UUID,
+  category_id UUID,
+  category_name TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT,
+  needed BIGINT,
+  underfunded BIGINT
+) AS $$
+BEGIN
+  RETURN QUERY
+  WITH budget_categories AS (
+    SELECT c.id, c.name, g.goal_type, g.target_amount, g.target_date
+    FROM categories c
+    LEFT JOIN category_goals g ON g.category_id = c.id
+    WHERE c.budget_id = b_id
+  ),
+  totals AS (
+    SELECT
+      date_trunc('month', agg.dt)::date AS month_id,
+      agg.cat_id,
+      SUM(agg.val_assigned)::bigint AS val_assigned,
+      SUM(agg.val_activity)::bigint AS val_activity
+    FROM (
+      SELECT a.month AS dt, a.category_id AS cat_id, a.assigned AS val_assigned, 0 AS val_activity
+      FROM assignments a
+      JOIN categories c ON a.category_id = c.id
+      WHERE c.budget_id = b_id
+      UNION ALL
+      SELECT t.transaction_date, ts.category_id, 0, ts.amount
+      FROM transaction_splits ts
+      JOIN transactions t ON t.id = ts.transaction_id
+      WHERE t.budget_id = b_id
+      UNION ALL
+      -- spending on a credit card moves money from the spent category into
+      -- the payment category of the card; payments made to the card draw it down
+      SELECT t.transaction_date, acc.payment_category_id, 0, -ts.amount
+      FROM transaction_splits ts
+      JOIN transactions t ON t.id = ts.transaction_id
+      JOIN accounts acc ON t.account_id = acc.id
+      WHERE t.budget_id = b_id
+        AND acc.account_type = 'CREDIT_CARD'
+        AND acc.payment_category_id IS NOT NULL
+        AND (ts.category_id IS NOT NULL OR t.transaction_type = 'TRANSFER_TO')
+    ) agg
+    GROUP BY 1, 2
+  ),
+  calculated_report AS (
+    SELECT
+      m.month_id,
+      c.id AS cat_id,
+      c.name AS cat_name,
+      c.goal_type,
+      c.target_amount,
+      c.target_date,
+      COALESCE(t.val_assigned, 0)::bigint AS assigned,
+      COALESCE(t.val_activity, 0)::bigint AS activity,
+      (SUM(COALESCE(t.val_assigned, 0) + COALESCE(t.val_activity, 0)) 
+          OVER (PARTITION BY c.id ORDER BY m.month_id))::bigint AS balance
+    FROM (
+      SELECT generate_series(
+        (SELECT first_month FROM rep.get_budget_bounds(b_id)),
+        date_trunc('month', end_date),
+        interval '1 month'
+      )::date AS month_id
+    ) m
+    CROSS JOIN budget_categories c
+    LEFT JOIN totals t ON m.month_id = t.month_id AND c.id = t.cat_id
+  ),
+  goal_report AS (
+    SELECT
+      cr.*,
+      rep.get_goal_needed(
+        cr.goal_type, cr.target_amount, cr.target_date, cr.month_id,
+        cr.balance - cr.assigned - cr.activity
+      )::bigint AS needed
+    FROM calculated_report cr
+  )
+  SELECT 
+    gr.month_id::date,
+    b_id::uuid,
+    gr.cat_id::uuid,
+    gr.cat_name::text,
+    gr.assigned::bigint,
+    gr.activity::bigint,
+    gr.balance::bigint,
+    gr.needed::bigint,
+    rep.get_goal_underfunded(
+      gr.goal_type, gr.target_amount, gr.needed, gr.assigned, gr.balance
+    )::bigint
+  FROM goal_report gr
+  WHERE gr.month_id >= date_trunc('month', start_date)::date
+  ORDER BY gr.month_id, gr.cat_name;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION rep.get_category_reports_gate(
+  b_id UUID,
+  m_id DATE
+)
+RETURNS TABLE (
+  month DATE,
+  budget_id UUID,
+  category_id UUID,
+  category_name TEXT,
+  assigned BIGINT,
+  activity BIGINT,
+  balance BIGINT,
+  needed BIGINT,
+  underfunded BIGINT
+) AS $$
+DECLARE
+  v_bounds RECORD;
+  v_target DATE := date_trunc('month', m_id)::date;
+BEGIN
+  SELECT * INTO v_bounds FROM rep.get_budget_bounds(b_id);
+
+  IF v_bounds.first_month IS NULL OR v_target < v_bounds.first_month THEN
+    RETURN QUERY
+    SELECT
+      v_target::date,
+      b_id::uuid,
+      c.id::uuid,
+      c.name::text,
+      0::bigint,
+      0::bigint,
+      0::bigint,
+      n.needed::bigint,
+      rep.get_goal_underfunded(g.goal_type, g.target_amount, n.needed, 0, 0)::bigint
+    FROM categories c
+    LEFT JOIN category_goals g ON g.category_id = c.id
+    CROSS JOIN LATERAL (
+      SELECT rep.get_goal_needed(g.goal_type, g.target_amount, g.target_date, v_target, 0) AS needed
+    ) n
+    WHERE c.budget_id = b_id;
+    RETURN;
+  END IF;
+
+  -- months past the last month of activity simply carry balances forward
+  RETURN QUERY
+  SELECT
+    r.month::date,
+    b_id::uuid,
+    r.category_id::uuid,
+    r.category_name::text,
+    r.assigned::bigint,
+    r.activity::bigint,
+    r.balance::bigint,
+    r.needed::bigint,
+    r.underfunded::bigint
+  FROM rep.get_category_reports(b_id, v_target, v_target) r
+  WHERE r.month = v_target;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION rep.get_ready_to_assign(
+  b_id UUID,
+  m_id DATE
+)
+RETURNS BIGINT AS $$
+DECLARE
+  v_next_month DATE := (date_trunc('month', m_id) + interval '1 month')::date;
+  v_income BIGINT;
+  v_assigned BIGINT;
+BEGIN
+  -- income is any uncategorized deposit to an on-budget or credit card account
+  SELECT COALESCE(SUM(ts.amount), 0)::bigint INTO v_income
+  FROM transaction_splits ts
+  JOIN transactions t ON t.id = ts.transaction_id
+  JOIN accounts a ON t.account_id = a.id
+  WHERE t.budget_id = b_id
+    AND t.transaction_type = 'DEPOSIT'
+    AND a.account_type IN ('ON_BUDGET', 'CREDIT_CARD')
+    AND ts.category_id IS NULL
+    AND t.transaction_date < v_next_month;
+
+  SELECT COALESCE(SUM(asg.assigned), 0)::bigint INTO v_assigned
+  FROM assignments asg
+  JOIN categories c ON asg.category_id = c.id
+  WHERE c.budget_id = b_id
+    AND asg.month < v_next_month;
+
+  RETURN v_income - v_assigned;
+END;
+$$ LANGUAGE plpgsql STABLE;
+-- +goose StatementEnd
+
+DROP FUNCTION rep.get_category_balances(rep.category_month[]);
+DROP TYPE rep.category_month;
+DROP FUNCTION rep.get_overspending_rule(TEXT, TEXT);
+
+ALTER TABLE categories
+DROP COLUMN overspending_rule;
+
+ALTER TABLE budgets
+DROP COLUMN overspending_rule;
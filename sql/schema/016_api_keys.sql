@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE api_keys (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  user_id UUID NOT NULL,
+  name VARCHAR(50) NOT NULL,
+  key_prefix VARCHAR(16) NOT NULL,
+  hashed_key CHAR(64) NOT NULL UNIQUE,
+  max_role VARCHAR(15) NOT NULL,
+  expires_at TIMESTAMP,
+  last_used_at TIMESTAMP,
+  revoked_at TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE api_key_budgets (
+  api_key_id UUID NOT NULL,
+  budget_id UUID NOT NULL,
+  PRIMARY KEY (api_key_id, budget_id),
+  FOREIGN KEY (api_key_id) REFERENCES api_keys(id)
+    ON DELETE CASCADE,
+  FOREIGN KEY (budget_id) REFERENCES budgets(id)
+    ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE api_key_budgets;
+DROP TABLE api_keys;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- refresh tokens issued by rotation share the family of the token they
+-- replaced, so that a whole family may be revoked should one be reused
+ALTER TABLE refresh_tokens
+ADD COLUMN family_id UUID NOT NULL DEFAULT gen_random_uuid();
+
+CREATE INDEX refresh_tokens_family_id_idx
+  ON refresh_tokens (family_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_family_id_idx;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN family_id;
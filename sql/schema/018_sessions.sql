@@ -0,0 +1,18 @@
+-- +goose Up
+-- every refresh token records the client it was issued to, and when it was
+-- redeemed; a family of refresh tokens is presented to users as a session
+ALTER TABLE refresh_tokens
+ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+ADD COLUMN ip_address TEXT NOT NULL DEFAULT '',
+ADD COLUMN last_used_at TIMESTAMP;
+
+CREATE INDEX refresh_tokens_user_id_idx
+  ON refresh_tokens (user_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_user_id_idx;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN last_used_at,
+DROP COLUMN ip_address,
+DROP COLUMN user_agent;
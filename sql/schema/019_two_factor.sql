@@ -0,0 +1,28 @@
+-- +goose Up
+-- a user's TOTP secret is kept from enrollment on, but
+-- only takes effect once confirmed with a valid code
+CREATE TABLE user_two_factor (
+  user_id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  totp_secret VARCHAR(64) NOT NULL,
+  enabled_at TIMESTAMP,
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+  FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE recovery_codes (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  user_id UUID NOT NULL,
+  hashed_code CHAR(64) NOT NULL,
+  used_at TIMESTAMP,
+  UNIQUE (user_id, hashed_code),
+  FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE recovery_codes;
+DROP TABLE user_two_factor;
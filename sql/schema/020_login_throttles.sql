@@ -0,0 +1,16 @@
+-- +goose Up
+-- failed attempts are counted per scope and subject, such as
+-- a username or a client address, so that lockouts survive restarts
+CREATE TABLE login_throttles (
+  scope VARCHAR(10) NOT NULL,
+  subject TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  failed_attempts INTEGER NOT NULL DEFAULT 0,
+  last_failed_at TIMESTAMP NOT NULL,
+  blocked_until TIMESTAMP,
+  PRIMARY KEY (scope, subject)
+);
+
+-- +goose Down
+DROP TABLE login_throttles;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- a user is linked to their account with an OpenID Connect
+-- provider by the subject under which the provider knows them
+CREATE TABLE user_identities (
+  issuer TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  user_id UUID NOT NULL,
+  PRIMARY KEY (issuer, subject),
+  FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX user_identities_user_id_idx ON user_identities (user_id);
+
+-- a login is kept from the time a user is sent to the provider
+-- until they return; logins which link an identity to an
+-- existing user name that user
+CREATE TABLE oidc_logins (
+  state VARCHAR(64) PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  expires_at TIMESTAMP NOT NULL,
+  nonce VARCHAR(64) NOT NULL,
+  code_verifier VARCHAR(64) NOT NULL,
+  user_id UUID,
+  FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE oidc_logins;
+DROP TABLE user_identities;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- an invitation is addressed either to one user, or to whoever
+-- holds its link token, of which only the hash is kept; it is
+-- deleted once accepted, declined, or revoked
+CREATE TABLE budget_invitations (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  budget_id UUID NOT NULL,
+  inviter_id UUID,
+  invitee_id UUID,
+  hashed_token CHAR(64) UNIQUE,
+  member_role VARCHAR(12) NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  CHECK ((invitee_id IS NULL) <> (hashed_token IS NULL)),
+  UNIQUE (budget_id, invitee_id),
+  FOREIGN KEY (budget_id) REFERENCES budgets(id)
+    ON DELETE CASCADE,
+  FOREIGN KEY (inviter_id) REFERENCES users(id)
+    ON DELETE SET NULL,
+  FOREIGN KEY (invitee_id) REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX budget_invitations_invitee_id_idx ON budget_invitations (invitee_id);
+
+-- +goose Down
+DROP TABLE budget_invitations;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- a budget's admin may nominate one other member at a time to take
+-- over the budget, which happens only once the nominee accepts
+CREATE TABLE budget_ownership_transfers (
+  budget_id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  from_user_id UUID NOT NULL,
+  to_user_id UUID NOT NULL,
+  FOREIGN KEY (budget_id) REFERENCES budgets(id)
+    ON DELETE CASCADE,
+  FOREIGN KEY (from_user_id) REFERENCES users(id)
+    ON DELETE CASCADE,
+  FOREIGN KEY (to_user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX budget_ownership_transfers_to_user_id_idx ON budget_ownership_transfers (to_user_id);
+
+-- +goose Down
+DROP TABLE budget_ownership_transfers;
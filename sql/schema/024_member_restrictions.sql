@@ -0,0 +1,19 @@
+-- +goose Up
+-- a member may be restricted, beyond their role, to some accounts and
+-- categories of a budget, and to transactions of some size; where a
+-- column is null, the member is not restricted by it
+CREATE TABLE member_restrictions (
+  budget_id UUID NOT NULL,
+  user_id UUID NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  updated_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  allowed_account_ids UUID[],
+  allowed_category_ids UUID[],
+  max_transaction_amount BIGINT CHECK (max_transaction_amount >= 0),
+  PRIMARY KEY (budget_id, user_id),
+  FOREIGN KEY (budget_id, user_id) REFERENCES memberships(budget_id, user_id)
+    ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE member_restrictions;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- every change made to a budget is recorded here, by whom, and from what
+-- to what; entries are only ever added, and outlive the users who made
+-- them and the budgets they were made to
+CREATE TABLE audit_log (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  budget_id UUID NOT NULL,
+  actor_id UUID,
+  resource_type VARCHAR(32) NOT NULL,
+  resource_id TEXT NOT NULL,
+  action VARCHAR(12) NOT NULL,
+  before JSONB,
+  after JSONB
+);
+
+CREATE INDEX audit_log_budget_id_created_at_idx ON audit_log (budget_id, created_at);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_append_only;
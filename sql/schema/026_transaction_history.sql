@@ -0,0 +1,71 @@
+-- +goose Up
+-- deleted transactions are moved here, with their splits, where they may
+-- be restored from until purged; both legs of a transfer are trashed
+-- together, each naming the other
+CREATE TABLE trashed_transactions (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  budget_id UUID NOT NULL,
+  logger_id UUID NOT NULL,
+  account_id UUID NOT NULL,
+  transaction_type VARCHAR(15) NOT NULL,
+  transaction_date DATE NOT NULL,
+  payee_id UUID NOT NULL,
+  notes TEXT NOT NULL,
+  cleared BOOLEAN NOT NULL,
+  deleted_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  deleted_by UUID NOT NULL,
+  linked_transaction_id UUID,
+  FOREIGN KEY (budget_id) REFERENCES budgets(id)
+    ON DELETE CASCADE,
+  FOREIGN KEY (account_id) REFERENCES accounts(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX trashed_transactions_budget_id_idx ON trashed_transactions (budget_id);
+CREATE INDEX trashed_transactions_deleted_at_idx ON trashed_transactions (deleted_at);
+
+CREATE TABLE trashed_transaction_splits (
+  id UUID PRIMARY KEY,
+  transaction_id UUID NOT NULL,
+  category_id UUID,
+  amount BIGINT NOT NULL,
+  FOREIGN KEY (transaction_id) REFERENCES trashed_transactions(id)
+    ON DELETE CASCADE
+);
+
+-- the state of a transaction before each update made to it,
+-- so that updates may be undone, latest first
+CREATE TABLE transaction_versions (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+  transaction_id UUID NOT NULL,
+  editor_id UUID NOT NULL,
+  account_id UUID NOT NULL,
+  transaction_type VARCHAR(15) NOT NULL,
+  transaction_date DATE NOT NULL,
+  payee_id UUID NOT NULL,
+  notes TEXT NOT NULL,
+  cleared BOOLEAN NOT NULL,
+  FOREIGN KEY (transaction_id) REFERENCES transactions(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX transaction_versions_transaction_id_created_at_idx ON transaction_versions (transaction_id, created_at);
+
+CREATE TABLE transaction_version_splits (
+  version_id UUID NOT NULL,
+  category_id UUID,
+  amount BIGINT NOT NULL,
+  FOREIGN KEY (version_id) REFERENCES transaction_versions(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX transaction_version_splits_version_id_idx ON transaction_version_splits (version_id);
+
+-- +goose Down
+DROP TABLE transaction_version_splits;
+DROP TABLE transaction_versions;
+DROP TABLE trashed_transaction_splits;
+DROP TABLE trashed_transactions;
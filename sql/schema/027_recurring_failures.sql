@@ -0,0 +1,15 @@
+-- +goose Up
+-- recurring transactions that fail to post are retried with increasing
+-- delay, and disabled once they have failed too many times in a row
+ALTER TABLE recurring_transactions
+    ADD COLUMN failure_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT NOT NULL DEFAULT '',
+    ADD COLUMN retry_after TIMESTAMP,
+    ADD COLUMN disabled_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE recurring_transactions
+    DROP COLUMN disabled_at,
+    DROP COLUMN retry_after,
+    DROP COLUMN last_error,
+    DROP COLUMN failure_count;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- a login may only be completed by the browser that began it, which
+-- holds the secret whose hash is kept here; logins begun without
+-- one are abandoned
+DELETE FROM oidc_logins;
+ALTER TABLE oidc_logins
+    ADD COLUMN binding_hash VARCHAR(64) NOT NULL;
+
+-- +goose Down
+ALTER TABLE oidc_logins
+    DROP COLUMN binding_hash;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- prior versions of a transaction are kept while it is in the trash, so
+-- that it may still be undone once restored; those of transactions no
+-- longer live or trashed are purged along with the trash
+ALTER TABLE transaction_versions
+    DROP CONSTRAINT transaction_versions_transaction_id_fkey;
+
+-- +goose Down
+DELETE FROM transaction_versions v
+WHERE NOT EXISTS (SELECT 1 FROM transactions t WHERE t.id = v.transaction_id);
+ALTER TABLE transaction_versions
+    ADD CONSTRAINT transaction_versions_transaction_id_fkey
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id)
+    ON DELETE CASCADE;
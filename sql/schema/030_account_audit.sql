@@ -0,0 +1,12 @@
+-- +goose Up
+-- changes made to a user's own account, rather than to any budget, are
+-- recorded without one
+ALTER TABLE audit_log
+    ALTER COLUMN budget_id DROP NOT NULL;
+
+-- +goose Down
+ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only;
+DELETE FROM audit_log WHERE budget_id IS NULL;
+ALTER TABLE audit_log ENABLE TRIGGER audit_log_append_only;
+ALTER TABLE audit_log
+    ALTER COLUMN budget_id SET NOT NULL;
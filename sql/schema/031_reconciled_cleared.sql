@@ -0,0 +1,14 @@
+-- +goose Up
+-- a transaction may only be reconciled once cleared, and stays cleared
+-- for as long as it is reconciled
+UPDATE transactions
+SET cleared = TRUE
+WHERE reconciled AND NOT cleared;
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_reconciled_cleared
+    CHECK (cleared OR NOT reconciled);
+
+-- +goose Down
+ALTER TABLE transactions
+    DROP CONSTRAINT transactions_reconciled_cleared;
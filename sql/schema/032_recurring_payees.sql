@@ -0,0 +1,36 @@
+-- +goose Up
+-- transfers are scheduled without a payee; payees may not be deleted while
+-- transactions are scheduled to them, but are replaced in those schedules
+-- as they are in the transactions logged
+ALTER TABLE recurring_transactions
+    ALTER COLUMN payee_id DROP NOT NULL;
+
+UPDATE recurring_transactions
+SET payee_id = NULL
+WHERE transfer_account_id IS NOT NULL;
+
+-- those scheduled to payees deleted already may not be posted,
+-- until updated with another
+UPDATE recurring_transactions r
+SET payee_id = NULL,
+    last_error = 'payee no longer exists',
+    retry_after = NULL,
+    disabled_at = NOW() AT TIME ZONE 'utc'
+WHERE r.payee_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM payees p WHERE p.id = r.payee_id);
+
+ALTER TABLE recurring_transactions
+    ADD CONSTRAINT recurring_transactions_payee_id_fkey
+    FOREIGN KEY (payee_id) REFERENCES payees(id)
+    ON DELETE NO ACTION;
+
+-- +goose Down
+ALTER TABLE recurring_transactions
+    DROP CONSTRAINT recurring_transactions_payee_id_fkey;
+
+UPDATE recurring_transactions
+SET payee_id = '00000000-0000-0000-0000-000000000000'
+WHERE payee_id IS NULL;
+
+ALTER TABLE recurring_transactions
+    ALTER COLUMN payee_id SET NOT NULL;